@@ -0,0 +1,33 @@
+// Package distribution runs the Alloy command line. It is used by the main
+// package of Alloy and by the main package of the custom distributions
+// generated by the alloy build command.
+package distribution
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/alloycli"
+	"github.com/grafana/alloy/internal/build"
+
+	// Register Prometheus SD components
+	_ "github.com/grafana/loki/v3/clients/pkg/promtail/discovery/consulagent"
+	_ "github.com/prometheus/prometheus/discovery/install"
+
+	// Register integrations
+	_ "github.com/grafana/alloy/internal/static/integrations/install"
+
+	// Embed a set of fallback X.509 trusted roots
+	// Allows the app to work correctly even when the OS does not provide a verifier or systems roots pool
+	_ "golang.org/x/crypto/x509roots/fallback"
+
+	// Embed application manifest for Windows builds
+	_ "github.com/grafana/alloy/internal/winmanifest"
+)
+
+// Run runs the Alloy command line with the components of Alloy and the
+// extension components imported by the main package. It is expected to be
+// called directly from the main function.
+func Run() {
+	prometheus.MustRegister(build.NewCollector("alloy"))
+	alloycli.Run()
+}
@@ -0,0 +1,129 @@
+// Package extension is the API used to write components outside of the Alloy
+// repository.
+//
+// Extension components live in their own Go modules. Their packages call
+// [Register] from an init function, and are compiled into a custom
+// distribution of Alloy with the alloy build command, which generates a main
+// package importing them.
+//
+// The types of this package are aliases of the types used by the components
+// of Alloy. Changes to them which require extension components to be updated
+// increase [APIVersion].
+package extension
+
+import (
+	"fmt"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+// APIVersion is the version of the extension API. Components set the
+// APIVersion field of their [Registration] to the value of APIVersion of the
+// Alloy release they are written against.
+const APIVersion = 1
+
+type (
+	// Component is the interface implemented by components.
+	Component = component.Component
+
+	// Options are the options given to components when they are built.
+	Options = component.Options
+
+	// Arguments holds the arguments of a component, decoded from its block.
+	Arguments = component.Arguments
+
+	// Exports holds the exports of a component.
+	Exports = component.Exports
+
+	// HealthComponent is implemented by components which report their health.
+	HealthComponent = component.HealthComponent
+
+	// Health is the health reported by a HealthComponent.
+	Health = component.Health
+
+	// DebugComponent is implemented by components which expose debug
+	// information.
+	DebugComponent = component.DebugComponent
+
+	// Stability is the stability level of a component.
+	Stability = featuregate.Stability
+)
+
+// Stability levels of components. Components with a stability level lower
+// than the one set with the --stability.level flag of alloy run can't be
+// used.
+const (
+	StabilityExperimental       = featuregate.StabilityExperimental
+	StabilityPublicPreview      = featuregate.StabilityPublicPreview
+	StabilityGenerallyAvailable = featuregate.StabilityGenerallyAvailable
+)
+
+// Registration describes an extension component.
+type Registration struct {
+	// APIVersion is the version of the extension API the component is written
+	// against. It must be set to APIVersion.
+	APIVersion int
+
+	// Name of the component, such as "acme.queue". Names follow the same
+	// rules as the names of the components of Alloy, and must not be in use
+	// by another component.
+	Name string
+
+	// Stability is the stability level of the component. It must be set.
+	Stability Stability
+
+	// Args is the zero value of the Arguments of the component.
+	Args Arguments
+
+	// Exports is the zero value of the Exports of the component, or nil if the
+	// component has no exports.
+	Exports Exports
+
+	// Build builds a new component from its initial arguments.
+	Build func(opts Options, args Arguments) (Component, error)
+}
+
+// Register registers an extension component. Register panics if r isn't
+// compatible with this release of Alloy or if it can't be registered, so that
+// a distribution built with incompatible components fails on startup.
+func Register(r Registration) {
+	if err := r.validate(); err != nil {
+		panic(fmt.Sprintf("extension component %q: %s", r.Name, err))
+	}
+
+	component.Register(component.Registration{
+		Name:      r.Name,
+		Stability: r.Stability,
+		Args:      r.Args,
+		Exports:   r.Exports,
+		Build:     r.Build,
+	})
+}
+
+func (r Registration) validate() error {
+	switch {
+	case r.APIVersion == 0:
+		return fmt.Errorf("the registration has no API version, set APIVersion to extension.APIVersion")
+	case r.APIVersion < APIVersion:
+		return fmt.Errorf("written against extension API version %d, which is older than the version of this release (%d); update the component to a newer release of Alloy", r.APIVersion, APIVersion)
+	case r.APIVersion > APIVersion:
+		return fmt.Errorf("written against extension API version %d, which is newer than the version of this release (%d); build the component with a newer release of Alloy", r.APIVersion, APIVersion)
+	}
+
+	switch r.Stability {
+	case StabilityExperimental, StabilityPublicPreview, StabilityGenerallyAvailable:
+	case featuregate.StabilityUndefined:
+		return fmt.Errorf("the stability level is undefined")
+	default:
+		return fmt.Errorf("unknown stability level %d", int(r.Stability))
+	}
+
+	if r.Args == nil {
+		return fmt.Errorf("the registration has no Args")
+	}
+	if r.Build == nil {
+		return fmt.Errorf("the registration has no Build function")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package extension
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+type testArguments struct{}
+
+type testComponent struct{}
+
+func (testComponent) Run(ctx context.Context) error { <-ctx.Done(); return nil }
+func (testComponent) Update(Arguments) error        { return nil }
+
+func validRegistration(name string) Registration {
+	return Registration{
+		APIVersion: APIVersion,
+		Name:       name,
+		Stability:  StabilityExperimental,
+		Args:       testArguments{},
+		Build: func(Options, Arguments) (Component, error) {
+			return testComponent{}, nil
+		},
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(validRegistration("extensiontest.valid"))
+
+	reg, ok := component.Get("extensiontest.valid")
+	require.True(t, ok)
+	require.Equal(t, StabilityExperimental, reg.Stability)
+	require.False(t, reg.Community)
+}
+
+func TestRegister_Invalid(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(r *Registration)
+		err    string
+	}{
+		{
+			name:   "NoAPIVersion",
+			modify: func(r *Registration) { r.APIVersion = 0 },
+			err:    `extension component "extensiontest.invalid": the registration has no API version, set APIVersion to extension.APIVersion`,
+		},
+		{
+			name:   "NewerAPIVersion",
+			modify: func(r *Registration) { r.APIVersion = APIVersion + 1 },
+			err:    "which is newer than the version of this release",
+		},
+		{
+			name:   "UndefinedStability",
+			modify: func(r *Registration) { r.Stability = 0 },
+			err:    "the stability level is undefined",
+		},
+		{
+			name:   "UnknownStability",
+			modify: func(r *Registration) { r.Stability = StabilityGenerallyAvailable + 1 },
+			err:    "unknown stability level",
+		},
+		{
+			name:   "NoArgs",
+			modify: func(r *Registration) { r.Args = nil },
+			err:    "the registration has no Args",
+		},
+		{
+			name:   "NoBuild",
+			modify: func(r *Registration) { r.Build = nil },
+			err:    "the registration has no Build function",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := validRegistration("extensiontest.invalid")
+			tc.modify(&r)
+
+			defer func() {
+				msg := recover()
+				require.NotNil(t, msg, "expected Register to panic")
+				require.Contains(t, msg, tc.err)
+
+				_, ok := component.Get("extensiontest.invalid")
+				require.False(t, ok)
+			}()
+			Register(r)
+		})
+	}
+}
@@ -24,9 +24,14 @@ func Run() {
 	cmd.SetVersionTemplate("{{ .Version }}\n")
 
 	cmd.AddCommand(
+		buildCommand(),
 		convertCommand(),
 		fmtCommand(),
+		lspCommand(),
+		modCommand(),
+		planCommand(),
 		runCommand(),
+		testCommand(),
 		toolsCommand(),
 		validateCommand(),
 	)
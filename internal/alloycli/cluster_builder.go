@@ -25,6 +25,7 @@ type ClusterOptions struct {
 	EnableClustering       bool
 	MinimumClusterSize     int
 	MinimumSizeWaitTimeout time.Duration
+	WaitForConfigMatch     bool
 	NodeName               string
 	AdvertiseAddress       string
 	ListenAddress          string
@@ -61,6 +62,7 @@ func NewClusterService(
 		EnableClustering:       opts.EnableClustering,
 		MinimumClusterSize:     opts.MinimumClusterSize,
 		MinimumSizeWaitTimeout: opts.MinimumSizeWaitTimeout,
+		WaitForConfigMatch:     opts.WaitForConfigMatch,
 		NodeName:               opts.NodeName,
 		RejoinInterval:         opts.RejoinInterval,
 		ClusterMaxJoinPeers:    opts.ClusterMaxJoinPeers,
@@ -0,0 +1,181 @@
+package alloycli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/alloytest"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/syntax/diag"
+)
+
+func testCommand() *cobra.Command {
+	t := &alloyTest{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "test [flags] path [spec...]",
+		Short: "Run tests of the pipelines of a configuration",
+		Long: `The test subcommand runs the tests described by test specification
+files against the configuration at path, which can be a file or a directory.
+
+Tests inject log lines, Prometheus samples, OTLP payloads and discovery
+targets into components and assert on the data which reaches loki.write,
+prometheus.remote_write, prometheus.write.queue and otelcol.exporter
+components. These components are replaced by in-memory stubs, so no data is
+sent to the configured backends.
+
+If no spec arguments are given, the *.alloytest files of the configuration
+directory are used.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return t.Run(cmd.Context(), args[0], args[1:])
+		},
+	}
+
+	cmd.Flags().StringVar(&t.run, "run", t.run, "Only run the tests whose name matches the regular expression.")
+	cmd.Flags().BoolVarP(&t.verbose, "verbose", "v", t.verbose, "Write the logs of the components under test to stderr.")
+	cmd.Flags().Var(&t.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&t.enableCommunityComps, "feature.community-components.enabled", t.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyTest struct {
+	run     string
+	verbose bool
+
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (t *alloyTest) Run(ctx context.Context, configPath string, specPaths []string) error {
+	filter, err := regexp.Compile(t.run)
+	if err != nil {
+		return fmt.Errorf("invalid --run expression: %w", err)
+	}
+
+	sources, err := loadSourceFiles(configPath, "alloy", false, "")
+	if err != nil {
+		return fmt.Errorf("reading config path %q: %w", configPath, err)
+	}
+
+	if len(specPaths) == 0 {
+		specPaths, err = findSpecFiles(configPath)
+		if err != nil {
+			return err
+		}
+		if len(specPaths) == 0 {
+			return fmt.Errorf("no %s files found for %q", alloytest.FileExtension, configPath)
+		}
+	}
+
+	opts := alloytest.Options{
+		Sources:              sources,
+		ConfigPath:           configPath,
+		MinStability:         t.minStability,
+		EnableCommunityComps: t.enableCommunityComps,
+	}
+	if t.verbose {
+		opts.LogWriter = os.Stderr
+	}
+
+	var total, failed int
+	for _, path := range specPaths {
+		bb, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := alloytest.ParseFile(path, bb)
+		if err != nil {
+			printDiags(os.Stderr, map[string][]byte{path: bb}, err)
+			return fmt.Errorf("could not parse test specification %q", path)
+		}
+
+		for _, test := range f.Tests {
+			if !filter.MatchString(test.Name) {
+				continue
+			}
+			total++
+
+			start := time.Now()
+			err := alloytest.Run(ctx, opts, test)
+			elapsed := time.Since(start).Seconds()
+
+			if err == nil {
+				fmt.Printf("--- PASS: %s (%.2fs)\n", test.Name, elapsed)
+				continue
+			}
+
+			failed++
+			fmt.Printf("--- FAIL: %s (%.2fs)\n", test.Name, elapsed)
+			var diags diag.Diagnostics
+			if errors.As(err, &diags) {
+				printDiags(os.Stdout, sources, err)
+				continue
+			}
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	if failed > 0 {
+		fmt.Println("FAIL")
+		return fmt.Errorf("%d of %d tests failed", failed, total)
+	}
+	fmt.Println("PASS")
+	return nil
+}
+
+// findSpecFiles returns the test specification files of the directory of a
+// configuration path.
+func findSpecFiles(configPath string) ([]string, error) {
+	fi, err := os.Stat(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := configPath
+	if !fi.IsDir() {
+		dir = filepath.Dir(configPath)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+alloytest.FileExtension))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// printDiags prints err with the source lines it refers to if it holds
+// diagnostics, or prints err otherwise.
+func printDiags(w io.Writer, sources map[string][]byte, err error) {
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		fmt.Fprintln(w, err)
+		return
+	}
+
+	p := diag.NewPrinter(diag.PrinterConfig{
+		Color:              !color.NoColor,
+		ContextLinesBefore: 1,
+		ContextLinesAfter:  1,
+	})
+	_ = p.Fprint(w, sources, diags)
+	fmt.Fprintln(w)
+}
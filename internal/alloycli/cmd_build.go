@@ -0,0 +1,67 @@
+package alloycli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/builder"
+)
+
+func buildCommand() *cobra.Command {
+	b := &alloyBuild{goCmd: "go"}
+
+	cmd := &cobra.Command{
+		Use:   "build [flags] manifest",
+		Short: "Build a distribution of Alloy with extension components",
+		Long: `The build subcommand builds a custom distribution of Alloy which includes
+extension components from the Go modules listed in the manifest.
+
+The main package and go.mod file of the distribution are generated in the
+output path of the manifest, and compiled with the go command. The build fails
+if the component modules require a newer version of Alloy than the one the
+distribution is built with, or if the components aren't compatible with it.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return b.Run(cmd, args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&b.outputPath, "output-path", b.outputPath, "Directory to write the distribution to. Overrides dist.output_path of the manifest.")
+	cmd.Flags().BoolVar(&b.skipCompilation, "skip-compilation", b.skipCompilation, "Generate the sources of the distribution without compiling them.")
+	cmd.Flags().StringVar(&b.goCmd, "go", b.goCmd, "The go command to build the distribution with.")
+	return cmd
+}
+
+type alloyBuild struct {
+	outputPath      string
+	skipCompilation bool
+	goCmd           string
+}
+
+func (b *alloyBuild) Run(cmd *cobra.Command, manifestPath string) error {
+	m, err := builder.LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	if b.outputPath != "" {
+		m.Dist.OutputPath = b.outputPath
+	}
+	// Build with the version of Alloy running the command by default.
+	if m.AlloyVersion == "" && m.AlloyPath == "" && build.Version != "v0.0.0" {
+		m.AlloyVersion = build.Version
+	}
+
+	bld := &builder.Builder{GoCmd: b.goCmd, Log: os.Stderr}
+	binary, err := bld.Build(cmd.Context(), m, b.skipCompilation)
+	if err != nil {
+		return err
+	}
+	if binary != "" {
+		fmt.Fprintf(os.Stderr, "Distribution written to %s\n", binary)
+	}
+	return nil
+}
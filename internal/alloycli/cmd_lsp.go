@@ -0,0 +1,68 @@
+package alloycli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/lsp"
+	"github.com/grafana/alloy/internal/service/cluster"
+	"github.com/grafana/alloy/internal/service/http"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/otel"
+	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/resourceusage"
+	"github.com/grafana/alloy/internal/service/ui"
+)
+
+func lspCommand() *cobra.Command {
+	l := &alloyLSP{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lsp [flags]",
+		Short: "Run a language server for configuration files",
+		Long: `The lsp subcommand runs a Language Server Protocol server for
+configuration files over standard input and output.
+
+Editors can use the language server to report diagnostics and to provide
+completion, go to definition, hover documentation and formatting.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return l.Run(cmd)
+		},
+	}
+
+	cmd.Flags().Var(&l.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&l.enableCommunityComps, "feature.community-components.enabled", l.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyLSP struct {
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (l *alloyLSP) Run(cmd *cobra.Command) error {
+	server := lsp.NewServer(lsp.Options{
+		ComponentRegistry: component.NewDefaultRegistry(l.minStability, l.enableCommunityComps),
+		ServiceDefinitions: getServiceDefinitions(
+			&cluster.Service{},
+			&http.Service{},
+			&labelstore.Service{},
+			&otel.Service{},
+			&remotecfg.Service{},
+			&resourceusage.Service{},
+			&ui.Service{},
+		),
+		MinStability: l.minStability,
+	})
+	return server.Serve(cmd.Context(), os.Stdin, os.Stdout)
+}
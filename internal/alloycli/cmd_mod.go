@@ -0,0 +1,146 @@
+package alloycli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+)
+
+func modCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mod",
+		Short: "Manage the versions of imported modules",
+		Long: `The mod command manages the lock file which records the version of
+every module imported by a configuration with import.git, import.http and
+import.oci blocks, including the modules imported by other modules.
+
+alloy run loads the versions recorded in the lock file instead of the latest
+versions of the modules, so that every deployment of a configuration runs the
+same modules.`,
+	}
+
+	cmd.AddCommand(
+		modLockCommand(),
+		modUpdateCommand(),
+	)
+	return cmd
+}
+
+func modLockCommand() *cobra.Command {
+	m := newAlloyMod(false)
+
+	cmd := &cobra.Command{
+		Use:   "lock [flags] path",
+		Short: "Record the versions of the imported modules",
+		Long: `The lock subcommand writes the lock file of the configuration at path,
+which can be a file or a directory.
+
+The versions already recorded in the lock file are kept. The latest version
+of the modules which aren't recorded yet is added, and the modules which are no
+longer imported are removed.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return m.Run(args[0])
+		},
+	}
+	m.addFlags(cmd)
+	return cmd
+}
+
+func modUpdateCommand() *cobra.Command {
+	m := newAlloyMod(true)
+
+	cmd := &cobra.Command{
+		Use:   "update [flags] path",
+		Short: "Update the imported modules to their latest versions",
+		Long: `The update subcommand writes the lock file of the configuration at
+path, which can be a file or a directory, with the latest version of every
+imported module.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return m.Run(args[0])
+		},
+	}
+	m.addFlags(cmd)
+	return cmd
+}
+
+type alloyMod struct {
+	update       bool
+	lockPath     string
+	minStability featuregate.Stability
+}
+
+func newAlloyMod(update bool) *alloyMod {
+	return &alloyMod{
+		update:       update,
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+}
+
+func (m *alloyMod) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&m.lockPath, "lock.path", m.lockPath, fmt.Sprintf("Path of the lock file. Defaults to %s in the directory of the configuration.", modlock.FileName))
+	cmd.Flags().Var(&m.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+}
+
+func (m *alloyMod) Run(configPath string) error {
+	lockPath := m.lockPath
+	if lockPath == "" {
+		lockPath = modlock.DefaultPath(configPath)
+	}
+
+	sources, err := loadSourceFiles(configPath, "alloy", false, "")
+	if err != nil {
+		return fmt.Errorf("reading config path %q: %w", configPath, err)
+	}
+	source, err := alloy_runtime.ParseSources(sources)
+	if err != nil {
+		printDiags(os.Stderr, sources, err)
+		return fmt.Errorf("could not parse config path %q", configPath)
+	}
+
+	var current *modlock.Lock
+	if !m.update {
+		if current, err = modlock.Load(lockPath); err != nil {
+			return err
+		}
+	}
+
+	// The modules are fetched in a temporary directory, so that the data of a
+	// running Alloy isn't changed.
+	dataPath, err := os.MkdirTemp("", "alloy-mod-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataPath)
+
+	logger, err := logging.New(os.Stderr, logging.Options{Level: logging.LevelWarn, Format: logging.FormatDefault})
+	if err != nil {
+		return fmt.Errorf("building logger: %w", err)
+	}
+
+	lock, err := alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{
+		Logger:       logger,
+		DataPath:     dataPath,
+		MinStability: m.minStability,
+		Current:      current,
+	})
+	if err != nil {
+		return err
+	}
+	if err := lock.Write(lockPath); err != nil {
+		return fmt.Errorf("writing lock file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Locked %d modules in %s\n", len(lock.Modules), lockPath)
+	return nil
+}
@@ -0,0 +1,92 @@
+package alloycli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/plan"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/syntax/ast"
+)
+
+func planCommand() *cobra.Command {
+	p := &alloyPlan{
+		format:       "text",
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "plan [flags] old new",
+		Short: "Show the changes between two configurations",
+		Long: `The plan subcommand reports the changes loading the configuration at new
+would make to the configuration at old, without running either of them. Each
+path can be a file or a directory.
+
+The plan lists the components which would be added, removed or changed, the
+references between components which would be added or removed, and the
+modules affected by the change. The values of sensitive arguments are masked.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return p.Run(args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVar(&p.format, "format", p.format, "The format of the plan. Supported formats: text, json.")
+	cmd.Flags().Var(&p.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&p.enableCommunityComps, "feature.community-components.enabled", p.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyPlan struct {
+	format string
+
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (p *alloyPlan) Run(oldPath, newPath string) error {
+	if p.format != "text" && p.format != "json" {
+		return fmt.Errorf("unsupported format %q", p.format)
+	}
+
+	oldFiles, err := p.parse(oldPath)
+	if err != nil {
+		return err
+	}
+	newFiles, err := p.parse(newPath)
+	if err != nil {
+		return err
+	}
+
+	reg := component.NewDefaultRegistry(p.minStability, p.enableCommunityComps)
+	result := plan.Compute(oldFiles, newFiles, reg)
+
+	if p.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return result.WriteText(os.Stdout)
+}
+
+func (p *alloyPlan) parse(path string) (map[string]*ast.File, error) {
+	sources, err := loadSourceFiles(path, "alloy", false, "")
+	if err != nil {
+		return nil, fmt.Errorf("reading config path %q: %w", path, err)
+	}
+
+	src, err := alloy_runtime.ParseSources(sources)
+	if err != nil {
+		printDiags(os.Stderr, sources, err)
+		return nil, fmt.Errorf("could not parse config path %q", path)
+	}
+	return src.SourceFiles(), nil
+}
@@ -3,6 +3,7 @@ package alloycli
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -14,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -37,6 +39,7 @@ import (
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/internal/service"
 	httpservice "github.com/grafana/alloy/internal/service/http"
@@ -44,6 +47,8 @@ import (
 	"github.com/grafana/alloy/internal/service/livedebugging"
 	otel_service "github.com/grafana/alloy/internal/service/otel"
 	remotecfgservice "github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/resourceusage"
+	"github.com/grafana/alloy/internal/service/statestore"
 	uiservice "github.com/grafana/alloy/internal/service/ui"
 	"github.com/grafana/alloy/internal/static/config/instrumentation"
 	"github.com/grafana/alloy/internal/usagestats"
@@ -69,6 +74,7 @@ func runCommand() *cobra.Command {
 		disableReporting:      false,
 		enablePprof:           true,
 		configFormat:          "alloy",
+		configLockEnabled:     true,
 		clusterAdvInterfaces:  advertise.DefaultInterfaces,
 		clusterMaxJoinPeers:   5,
 		clusterRejoinInterval: 60 * time.Second,
@@ -160,11 +166,16 @@ depending on the nature of the reload error.
 		IntVar(&r.clusterWaitForSize, "cluster.wait-for-size", r.clusterWaitForSize, "Wait for the cluster to reach the specified number of instances before allowing components that use clustering to begin processing. Zero means disabled")
 	cmd.Flags().
 		DurationVar(&r.clusterWaitTimeout, "cluster.wait-timeout", 0, "Maximum duration to wait for minimum cluster size before proceeding with available nodes. Zero means wait forever, no timeout")
+	cmd.Flags().
+		BoolVar(&r.clusterWaitForConfigMatch, "cluster.wait-for-config-match", r.clusterWaitForConfigMatch, "Stop components that use clustering from processing while the configuration of the node differs from the configuration of the majority of the cluster")
 
 	// Config flags
 	cmd.Flags().StringVar(&r.configFormat, "config.format", r.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
 	cmd.Flags().BoolVar(&r.configBypassConversionErrors, "config.bypass-conversion-errors", r.configBypassConversionErrors, "Enable bypassing errors when converting")
 	cmd.Flags().StringVar(&r.configExtraArgs, "config.extra-args", r.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+	cmd.Flags().DurationVar(&r.configRollbackSettlePeriod, "config.rollback.settle-period", r.configRollbackSettlePeriod, "How long to wait after a reload before reverting to the last known-good configuration if components became unhealthy. Zero disables automatic rollback")
+	cmd.Flags().BoolVar(&r.configLockEnabled, "config.lock.enabled", r.configLockEnabled, fmt.Sprintf("Load the versions of the imported modules recorded in the %s lock file", modlock.FileName))
+	cmd.Flags().StringVar(&r.configLockPath, "config.lock.path", r.configLockPath, fmt.Sprintf("Path of the lock file. Defaults to %s in the directory of the configuration", modlock.FileName))
 
 	// Misc flags
 	cmd.Flags().
@@ -205,9 +216,13 @@ type alloyRun struct {
 	clusterTLSServerName                 string
 	clusterWaitForSize                   int
 	clusterWaitTimeout                   time.Duration
+	clusterWaitForConfigMatch            bool
 	configFormat                         string
 	configBypassConversionErrors         bool
 	configExtraArgs                      string
+	configRollbackSettlePeriod           time.Duration
+	configLockEnabled                    bool
+	configLockPath                       string
 	enableCommunityComps                 bool
 	disableSupportBundle                 bool
 	prometheusMetricNameValidationScheme string
@@ -324,6 +339,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		TLSServerName:          fr.clusterTLSServerName,
 		MinimumClusterSize:     fr.clusterWaitForSize,
 		MinimumSizeWaitTimeout: fr.clusterWaitTimeout,
+		WaitForConfigMatch:     fr.clusterWaitForConfigMatch,
 	})
 	if err != nil {
 		return err
@@ -355,6 +371,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 			RuntimeFlags:         runtimeFlags,
 			DisableSupportBundle: fr.disableSupportBundle,
 		},
+		ComponentRegistry: component.NewDefaultRegistry(fr.minStability, fr.enableCommunityComps),
 	})
 
 	remoteCfgService, err := remotecfgservice.New(remotecfgservice.Options{
@@ -381,8 +398,18 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 	}
 
 	labelService := labelstore.New(l, reg)
+	resourceUsageService := resourceusage.New(log.With(l, "service", "resourceusage"), reg)
+	stateStoreService := statestore.New(log.With(l, "service", "statestore"), fr.storagePath)
 	alloyseed.Init(fr.storagePath, l)
 
+	// The module lock is loaded again on every reload, so that updating it
+	// doesn't require a restart.
+	var moduleLock atomic.Pointer[modlock.Lock]
+	lockPath := fr.configLockPath
+	if lockPath == "" {
+		lockPath = modlock.DefaultPath(configPath)
+	}
+
 	f := alloy_runtime.New(alloy_runtime.Options{
 		Logger:               l,
 		Tracer:               t,
@@ -390,6 +417,21 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		Reg:                  reg,
 		MinStability:         fr.minStability,
 		EnableCommunityComps: fr.enableCommunityComps,
+		RollbackSettlePeriod: fr.configRollbackSettlePeriod,
+		ModuleLock:           moduleLock.Load,
+		// A reload may be reverted after it returned, once its components
+		// settled, so the state derived from the reverted source is restored
+		// here rather than by reload.
+		OnRevert: func(source *alloy_runtime.Source, lock *modlock.Lock) {
+			if fr.configLockEnabled {
+				moduleLock.Store(lock)
+			}
+			httpService.SetSources(source.SourceFiles())
+			// Peers compare the hash of the configuration which is running,
+			// not the one which was rejected.
+			sourcesHash := hashSourceFiles(source.RawConfigs())
+			clusterService.SetConfigHash(hex.EncodeToString(sourcesHash[:]))
+		},
 		Services: []service.Service{
 			clusterService,
 			httpService,
@@ -397,6 +439,8 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 			liveDebuggingService,
 			otelService,
 			remoteCfgService,
+			resourceUsageService,
+			stateStoreService,
 			uiService,
 		},
 	})
@@ -410,15 +454,32 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		}
 
 		alloySource, err := alloy_runtime.ParseSources(sources)
-		defer instrumentation.InstrumentConfig(err == nil, hashSourceFiles(sources), fr.clusterName)
+		sourcesHash := hashSourceFiles(sources)
+		defer instrumentation.InstrumentConfig(err == nil, sourcesHash, fr.clusterName)
 		if err != nil {
 			return sources, fmt.Errorf("reading config path %q: %w", configPath, err)
 		}
 
+		// The lock is read by the modules while the source is loaded, so it's
+		// stored before loading it, and the previous lock is restored if the
+		// load fails.
+		var prevLock *modlock.Lock
+		if fr.configLockEnabled {
+			lock, err := modlock.Load(lockPath)
+			if err != nil {
+				return sources, fmt.Errorf("reading lock file: %w", err)
+			}
+			prevLock = moduleLock.Swap(lock)
+		}
+
 		httpService.SetSources(alloySource.SourceFiles())
 		if err := f.LoadSource(alloySource, nil, configPath); err != nil {
+			if fr.configLockEnabled {
+				moduleLock.Store(prevLock)
+			}
 			return sources, fmt.Errorf("error during the initial load: %w", err)
 		}
+		clusterService.SetConfigHash(hex.EncodeToString(sourcesHash[:]))
 
 		return sources, nil
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/grafana/alloy/internal/component/prometheus/remotewrite"
+	"github.com/grafana/alloy/internal/service/statestore"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +17,7 @@ func toolsCommand() *cobra.Command {
 
 	cmd.AddCommand(
 		getTools("prometheus.remote_write", remotewrite.InstallTools),
+		stateTools(),
 	)
 
 	return cmd
@@ -29,3 +31,12 @@ func getTools(name string, installFunc func(*cobra.Command)) *cobra.Command {
 	installFunc(groupCommand)
 	return groupCommand
 }
+
+func stateTools() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Tools for the persisted state of components",
+	}
+	statestore.InstallTools(cmd)
+	return cmd
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/alloy/internal/service/labelstore"
 	"github.com/grafana/alloy/internal/service/otel"
 	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/resourceusage"
 	"github.com/grafana/alloy/internal/service/ui"
 	"github.com/grafana/alloy/internal/validator"
 	"github.com/spf13/cobra"
@@ -72,6 +73,7 @@ func (v *alloyValidate) Run(configFile string) error {
 				&labelstore.Service{},
 				&otel.Service{},
 				&remotecfg.Service{},
+				&resourceusage.Service{},
 				&ui.Service{},
 			),
 			ComponentRegistry: component.NewDefaultRegistry(v.minStability, v.enableCommunityComps),
@@ -0,0 +1,285 @@
+package alloytest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/discovery"
+	"github.com/grafana/alloy/internal/component/otelcol"
+)
+
+var (
+	logsReceiverType = reflect.TypeOf((*loki.LogsReceiver)(nil)).Elem()
+	appendableType   = reflect.TypeOf((*storage.Appendable)(nil)).Elem()
+	consumerType     = reflect.TypeOf((*otelcol.Consumer)(nil)).Elem()
+)
+
+// isSink returns true if the component with the given name sends data out of
+// Alloy and is replaced by a capture stub when running tests. The otelcol
+// exporters which convert data for other Alloy components aren't sinks.
+func isSink(name string) bool {
+	switch name {
+	case "loki.write", "prometheus.remote_write", "prometheus.write.queue":
+		return true
+	case "otelcol.exporter.loki", "otelcol.exporter.prometheus":
+		return false
+	}
+	return strings.HasPrefix(name, "otelcol.exporter.")
+}
+
+// registry wraps a component.Registry to replace sinks with capture stubs and
+// components with injected discovery targets with static stubs.
+type registry struct {
+	inner   component.Registry
+	sinks   *sinks
+	targets map[string][]discovery.Target // Injected targets by component ID.
+}
+
+var _ component.Registry = (*registry)(nil)
+
+// Get implements component.Registry.
+func (r *registry) Get(name string) (component.Registration, error) {
+	reg, err := r.inner.Get(name)
+	if err != nil {
+		return reg, err
+	}
+
+	build := reg.Build
+	if isSink(name) {
+		build = func(opts component.Options, _ component.Arguments) (component.Component, error) {
+			return r.sinks.build(opts, reg.Exports)
+		}
+	}
+
+	reg.Build = func(opts component.Options, args component.Arguments) (component.Component, error) {
+		if targets, ok := r.targets[opts.ID]; ok {
+			return buildTargetsStub(opts, reg.Exports, targets)
+		}
+		return build(opts, args)
+	}
+	return reg, nil
+}
+
+// setExport returns a copy of exports where the first field which can hold v
+// is set to v. ok is false if exports has no such field.
+func setExport(exports component.Exports, v any) (res component.Exports, ok bool) {
+	rv := reflect.New(reflect.TypeOf(exports)).Elem()
+	rv.Set(reflect.ValueOf(exports))
+	if rv.Kind() != reflect.Struct {
+		return exports, false
+	}
+
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Field(i)
+		if f.CanSet() && reflect.TypeOf(v).AssignableTo(f.Type()) {
+			f.Set(reflect.ValueOf(v))
+			return rv.Interface(), true
+		}
+	}
+	return exports, false
+}
+
+// exportOf returns the first field of exports with type t.
+func exportOf(exports component.Exports, t reflect.Type) (any, bool) {
+	rv := reflect.ValueOf(exports)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Field(i)
+		if f.Type() == t && f.CanInterface() && !f.IsZero() {
+			return f.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// stub is a component which does nothing besides exporting a fixed value.
+type stub struct {
+	run func(ctx context.Context)
+}
+
+func (s stub) Run(ctx context.Context) error {
+	if s.run != nil {
+		s.run(ctx)
+		return nil
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (stub) Update(component.Arguments) error { return nil }
+
+func buildTargetsStub(opts component.Options, exports component.Exports, targets []discovery.Target) (component.Component, error) {
+	e, ok := setExport(exports, targets)
+	if !ok {
+		return nil, fmt.Errorf("component %s doesn't export targets", opts.ID)
+	}
+	opts.OnStateChange(e)
+	return stub{}, nil
+}
+
+// sinks holds the data captured by the sink stubs of a test.
+type sinks struct {
+	mut  sync.Mutex
+	byID map[string]*sink
+}
+
+func newSinks() *sinks {
+	return &sinks{byID: make(map[string]*sink)}
+}
+
+// Get returns the sink of the component with the given ID.
+func (s *sinks) Get(id string) (*sink, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	sk, ok := s.byID[id]
+	return sk, ok
+}
+
+// build creates a stub which captures the data sent to the sink with the
+// given exports.
+func (s *sinks) build(opts component.Options, exports component.Exports) (component.Component, error) {
+	s.mut.Lock()
+	sk, ok := s.byID[opts.ID]
+	if !ok {
+		sk = &sink{}
+		s.byID[opts.ID] = sk
+	}
+	s.mut.Unlock()
+
+	var (
+		entries = make(chan loki.Entry)
+		e       = exports
+	)
+	for _, v := range []any{loki.NewLogsReceiverWithChannel(entries), appendable{sk}, consumer{sk}} {
+		if res, ok := setExport(e, v); ok {
+			e = res
+		}
+	}
+	opts.OnStateChange(e)
+
+	return stub{run: func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry := <-entries:
+				sk.appendLog(entry)
+			}
+		}
+	}}, nil
+}
+
+// sink holds the data received by a sink stub.
+type sink struct {
+	mut        sync.Mutex
+	logs       []loki.Entry
+	samples    []Sample
+	logRecords int
+	dataPoints int
+	spans      int
+}
+
+func (s *sink) appendLog(e loki.Entry) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.logs = append(s.logs, e)
+}
+
+// appendable captures the samples committed to a sink.
+type appendable struct{ sink *sink }
+
+func (a appendable) Appender(context.Context) storage.Appender {
+	return &appender{sink: a.sink}
+}
+
+type appender struct {
+	sink    *sink
+	pending []Sample
+}
+
+var _ storage.Appender = (*appender)(nil)
+
+func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	a.pending = append(a.pending, newSample(l, t, v))
+	return ref, nil
+}
+
+func (a *appender) AppendExemplar(ref storage.SeriesRef, _ labels.Labels, _ exemplar.Exemplar) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *appender) AppendHistogram(ref storage.SeriesRef, _ labels.Labels, _ int64, _ *histogram.Histogram, _ *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *appender) UpdateMetadata(ref storage.SeriesRef, _ labels.Labels, _ metadata.Metadata) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *appender) AppendCTZeroSample(ref storage.SeriesRef, _ labels.Labels, _ int64, _ int64) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *appender) Commit() error {
+	a.sink.mut.Lock()
+	defer a.sink.mut.Unlock()
+	a.sink.samples = append(a.sink.samples, a.pending...)
+	a.pending = nil
+	return nil
+}
+
+func (a *appender) Rollback() error {
+	a.pending = nil
+	return nil
+}
+
+// consumer captures the OTLP data sent to a sink.
+type consumer struct{ sink *sink }
+
+var _ otelcol.Consumer = consumer{}
+
+func (consumer) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+func (c consumer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
+	c.sink.mut.Lock()
+	defer c.sink.mut.Unlock()
+	c.sink.logRecords += ld.LogRecordCount()
+	return nil
+}
+
+func (c consumer) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
+	c.sink.mut.Lock()
+	defer c.sink.mut.Unlock()
+	c.sink.dataPoints += md.DataPointCount()
+	return nil
+}
+
+func (c consumer) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	c.sink.mut.Lock()
+	defer c.sink.mut.Unlock()
+	c.sink.spans += td.SpanCount()
+	return nil
+}
@@ -0,0 +1,386 @@
+package alloytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/discovery"
+	"github.com/grafana/alloy/internal/component/otelcol"
+	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+	cluster_service "github.com/grafana/alloy/internal/service/cluster"
+	http_service "github.com/grafana/alloy/internal/service/http"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	otel_service "github.com/grafana/alloy/internal/service/otel"
+	remotecfg_service "github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/resourceusage"
+)
+
+// settleDuration is how long a test keeps checking its expectations after
+// they were met, to catch data which wasn't expected but arrives late.
+const settleDuration = 200 * time.Millisecond
+
+// pollInterval is how often the expectations of a test are checked.
+const pollInterval = 10 * time.Millisecond
+
+// Options configures how tests are run.
+type Options struct {
+	// Sources are the configuration files of the pipeline under test.
+	Sources map[string][]byte
+	// ConfigPath is the path the sources were loaded from. It's used to
+	// resolve the paths of imported modules.
+	ConfigPath string
+
+	// MinStability is the minimum stability level of features which may be
+	// used by the configuration.
+	MinStability featuregate.Stability
+	// EnableCommunityComps enables the use of community components.
+	EnableCommunityComps bool
+
+	// LogWriter receives the logs of the components under test. Logs are
+	// discarded if LogWriter is nil.
+	LogWriter io.Writer
+}
+
+// Run runs a single test against a new instance of the pipeline. An error
+// describing the differences between the expected and received data is
+// returned if the test fails.
+func Run(ctx context.Context, opts Options, test Test) error {
+	if opts.LogWriter == nil {
+		opts.LogWriter = io.Discard
+	}
+
+	logger, err := logging.New(opts.LogWriter, logging.DefaultOptions)
+	if err != nil {
+		return fmt.Errorf("building logger: %w", err)
+	}
+
+	dataPath, err := os.MkdirTemp("", "alloytest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataPath)
+
+	services, err := newServices(logger, dataPath)
+	if err != nil {
+		return err
+	}
+
+	reg := &registry{
+		inner:   component.NewDefaultRegistry(opts.MinStability, opts.EnableCommunityComps),
+		sinks:   newSinks(),
+		targets: make(map[string][]discovery.Target, len(test.Targets)),
+	}
+	for _, t := range test.Targets {
+		reg.targets[t.Component] = t.Targets
+	}
+
+	source, err := alloy_runtime.ParseSources(opts.Sources)
+	if err != nil {
+		return err
+	}
+
+	f := alloy_runtime.New(alloy_runtime.Options{
+		Logger:               logger,
+		DataPath:             dataPath,
+		Reg:                  prometheus.NewRegistry(),
+		MinStability:         opts.MinStability,
+		EnableCommunityComps: opts.EnableCommunityComps,
+		Services:             services,
+		ComponentRegistry:    reg,
+	})
+
+	// The runtime is run before the source is loaded so that it's shut down on
+	// every return path.
+	ctx, cancel := context.WithTimeout(ctx, test.Timeout)
+	done := make(chan struct{})
+	defer func() { <-done }()
+	defer cancel()
+	go func() {
+		defer close(done)
+		f.Run(ctx)
+	}()
+
+	if err := f.LoadSource(source, nil, opts.ConfigPath); err != nil {
+		return err
+	}
+
+	sinks := make([]*sink, len(test.Expects))
+	for i, e := range test.Expects {
+		sk, ok := reg.sinks.Get(e.Component)
+		if !ok {
+			return fmt.Errorf("component %s isn't a sink which can be tested; expect blocks must refer to loki.write, prometheus.remote_write, prometheus.write.queue or otelcol.exporter components", e.Component)
+		}
+		sinks[i] = sk
+	}
+
+	injectErr := make(chan error, 1)
+	go func() {
+		injectErr <- inject(ctx, f, test.Inputs)
+	}()
+
+	var (
+		ticker   = time.NewTicker(pollInterval)
+		settleAt time.Time
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-injectErr:
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			if settleAt.IsZero() {
+				return report(test, sinks)
+			}
+			return nil
+		case <-ticker.C:
+		}
+
+		// Data is never removed from sinks, so the test fails as soon as data
+		// which isn't expected is received.
+		missing, unexpected := checkAll(test, sinks)
+		switch {
+		case unexpected:
+			return report(test, sinks)
+		case !missing && settleAt.IsZero():
+			settleAt = time.Now().Add(settleDuration)
+		case !missing && time.Now().After(settleAt):
+			return nil
+		case missing:
+			settleAt = time.Time{}
+		}
+	}
+}
+
+func newServices(logger *logging.Logger, dataPath string) ([]service.Service, error) {
+	clusterService, err := cluster_service.New(cluster_service.Options{
+		Log:              logger,
+		EnableClustering: false,
+		NodeName:         "alloytest",
+		AdvertiseAddress: "127.0.0.1:80",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating the cluster service: %w", err)
+	}
+
+	otelService := otel_service.New(logger)
+	if otelService == nil {
+		return nil, fmt.Errorf("failed to create otel service")
+	}
+
+	// The HTTP service listens on a random local port so that components which
+	// expose HTTP endpoints can be tested.
+	httpService := http_service.New(http_service.Options{
+		Logger:           logger,
+		Gatherer:         prometheus.NewRegistry(),
+		ReadyFunc:        func() bool { return true },
+		ReloadFunc:       func() error { return nil },
+		HTTPListenAddr:   "127.0.0.1:0",
+		MemoryListenAddr: "alloytest.internal:12345",
+	})
+
+	// The remotecfg service is required by the HTTP service but isn't
+	// configured, so it never fetches configuration.
+	remotecfgService, err := remotecfg_service.New(remotecfg_service.Options{
+		Logger:      logger,
+		StoragePath: dataPath,
+		Metrics:     prometheus.NewRegistry(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating the remotecfg service: %w", err)
+	}
+
+	return []service.Service{
+		clusterService,
+		httpService,
+		remotecfgService,
+		labelstore.New(logger, prometheus.NewRegistry()),
+		livedebugging.New(),
+		otelService,
+		resourceusage.New(logger, nil),
+	}, nil
+}
+
+// checkAll returns whether any of the expected data is missing from the
+// sinks and whether any data which isn't expected was received.
+func checkAll(test Test, sinks []*sink) (missing, unexpected bool) {
+	for i, e := range test.Expects {
+		m, u := e.check(sinks[i])
+		missing = missing || len(m) > 0
+		unexpected = unexpected || len(u) > 0
+	}
+	return missing, unexpected
+}
+
+// report returns an error describing the expected data which is missing from
+// the sinks and the received data which wasn't expected.
+func report(test Test, sinks []*sink) error {
+	var sb strings.Builder
+	for i, e := range test.Expects {
+		missing, unexpected := e.check(sinks[i])
+		if len(missing) == 0 && len(unexpected) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%s:\n", e.Component)
+		for _, m := range missing {
+			fmt.Fprintf(&sb, "  missing %s\n", m)
+		}
+		for _, u := range unexpected {
+			fmt.Fprintf(&sb, "  unexpected %s\n", u)
+		}
+	}
+
+	if sb.Len() == 0 {
+		return nil
+	}
+	return errors.New(strings.TrimSuffix(sb.String(), "\n"))
+}
+
+// inject sends the data of the inputs to the receivers exported by their
+// components.
+func inject(ctx context.Context, f *alloy_runtime.Runtime, inputs []Input) error {
+	for _, in := range inputs {
+		info, err := f.GetComponent(component.ParseID(in.Component), component.InfoOptions{GetExports: true})
+		if err != nil {
+			return fmt.Errorf("input: %w", err)
+		}
+
+		if err := injectLogs(ctx, info, in.Logs); err != nil {
+			return err
+		}
+		if err := injectSamples(ctx, info, in.Samples); err != nil {
+			return err
+		}
+		if err := injectOTLP(ctx, info, in); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receiver returns the receiver of type t exported by a component.
+func receiver(info *component.Info, t reflect.Type, kind string) (any, error) {
+	v, ok := exportOf(info.Exports, t)
+	if !ok {
+		return nil, fmt.Errorf("component %s doesn't export a receiver for %s", info.ID, kind)
+	}
+	return v, nil
+}
+
+func injectLogs(ctx context.Context, info *component.Info, entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	v, err := receiver(info, logsReceiverType, "logs")
+	if err != nil {
+		return err
+	}
+
+	ch := v.(loki.LogsReceiver).Chan()
+	for _, e := range entries {
+		ts := e.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		entry := loki.Entry{
+			Labels: toLabelSet(e.Labels),
+			Entry:  logproto.Entry{Timestamp: ts, Line: e.Line},
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out sending logs to %s", info.ID)
+		case ch <- entry:
+		}
+	}
+	return nil
+}
+
+func injectSamples(ctx context.Context, info *component.Info, samples []Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	v, err := receiver(info, appendableType, "metrics")
+	if err != nil {
+		return err
+	}
+
+	app := v.(storage.Appendable).Appender(ctx)
+	for _, s := range samples {
+		ts := s.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		if _, err := app.Append(0, labels.FromMap(s.Labels), ts.UnixMilli(), s.Value); err != nil {
+			_ = app.Rollback()
+			return fmt.Errorf("sending samples to %s: %w", info.ID, err)
+		}
+	}
+	if err := app.Commit(); err != nil {
+		return fmt.Errorf("sending samples to %s: %w", info.ID, err)
+	}
+	return nil
+}
+
+func injectOTLP(ctx context.Context, info *component.Info, in Input) error {
+	if in.OTLPLogs == "" && in.OTLPMetrics == "" && in.OTLPTraces == "" {
+		return nil
+	}
+	v, err := receiver(info, consumerType, "OTLP data")
+	if err != nil {
+		return err
+	}
+	c := v.(otelcol.Consumer)
+
+	if in.OTLPLogs != "" {
+		ld, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(in.OTLPLogs))
+		if err != nil {
+			return fmt.Errorf("decoding otlp_logs: %w", err)
+		}
+		if err := c.ConsumeLogs(ctx, ld); err != nil {
+			return fmt.Errorf("sending logs to %s: %w", info.ID, err)
+		}
+	}
+	if in.OTLPMetrics != "" {
+		md, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(in.OTLPMetrics))
+		if err != nil {
+			return fmt.Errorf("decoding otlp_metrics: %w", err)
+		}
+		if err := c.ConsumeMetrics(ctx, md); err != nil {
+			return fmt.Errorf("sending metrics to %s: %w", info.ID, err)
+		}
+	}
+	if in.OTLPTraces != "" {
+		td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(in.OTLPTraces))
+		if err != nil {
+			return fmt.Errorf("decoding otlp_traces: %w", err)
+		}
+		if err := c.ConsumeTraces(ctx, td); err != nil {
+			return fmt.Errorf("sending traces to %s: %w", info.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package alloytest_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/alloytest"
+	_ "github.com/grafana/alloy/internal/component/all"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+func runTests(t *testing.T, config string, spec string) map[string]error {
+	t.Helper()
+
+	f, err := alloytest.ParseFile("test.alloytest", []byte(spec))
+	require.NoError(t, err)
+
+	opts := alloytest.Options{
+		Sources:      map[string][]byte{"config.alloy": []byte(config)},
+		MinStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	results := make(map[string]error, len(f.Tests))
+	for _, test := range f.Tests {
+		results[test.Name] = alloytest.Run(context.Background(), opts, test)
+	}
+	return results
+}
+
+const logsConfig = `
+	loki.process "default" {
+		forward_to = [loki.write.default.receiver]
+
+		stage.drop {
+			expression = ".*level=debug.*"
+		}
+
+		stage.static_labels {
+			values = { env = "prod" }
+		}
+	}
+
+	loki.write "default" {
+		endpoint {
+			url = "http://localhost:3100/loki/api/v1/push"
+		}
+	}
+`
+
+func TestRunLogs(t *testing.T) {
+	results := runTests(t, logsConfig, `
+		test "drops_debug_lines" {
+			input {
+				component = "loki.process.default"
+
+				log {
+					line   = "level=debug msg=hello"
+					labels = { job = "app" }
+				}
+				log {
+					line   = "level=info msg=hello"
+					labels = { job = "app" }
+				}
+			}
+
+			expect {
+				component = "loki.write.default"
+
+				log {
+					line   = "level=info msg=hello"
+					labels = { job = "app", env = "prod" }
+				}
+			}
+		}
+
+		test "regression" {
+			timeout = "500ms"
+
+			input {
+				component = "loki.process.default"
+
+				log {
+					line   = "level=info msg=hello"
+					labels = { job = "app" }
+				}
+			}
+
+			expect {
+				component = "loki.write.default"
+
+				log {
+					line   = "level=info msg=hello"
+					labels = { job = "app" }
+				}
+			}
+		}
+	`)
+
+	require.NoError(t, results["drops_debug_lines"])
+	require.EqualError(t, results["regression"], `loki.write.default:
+  missing log "level=info msg=hello" {job="app"}
+  unexpected log "level=info msg=hello" {env="prod", job="app"}`)
+}
+
+func TestRunMetrics(t *testing.T) {
+	config := `
+		prometheus.relabel "default" {
+			forward_to = [prometheus.remote_write.default.receiver]
+
+			rule {
+				source_labels = ["env"]
+				regex         = "dev"
+				action        = "drop"
+			}
+		}
+
+		prometheus.remote_write "default" {
+			endpoint {
+				url = "http://localhost:9009/api/v1/push"
+			}
+		}
+	`
+
+	results := runTests(t, config, `
+		test "drops_dev_samples" {
+			input {
+				component = "prometheus.relabel.default"
+
+				sample {
+					labels    = { __name__ = "up", env = "dev" }
+					value     = 1
+				}
+				sample {
+					labels    = { __name__ = "up", env = "prod" }
+					value     = 1
+					timestamp = "2024-01-01T00:00:00Z"
+				}
+			}
+
+			expect {
+				component = "prometheus.remote_write.default"
+
+				sample {
+					labels    = { __name__ = "up", env = "prod" }
+					value     = 1
+					timestamp = "2024-01-01T00:00:00Z"
+				}
+			}
+		}
+
+		test "missing_sample" {
+			timeout = "500ms"
+
+			input {
+				component = "prometheus.relabel.default"
+
+				sample {
+					labels = { __name__ = "up", env = "dev" }
+					value  = 1
+				}
+			}
+
+			expect {
+				component = "prometheus.remote_write.default"
+
+				sample {
+					labels = { __name__ = "up", env = "dev" }
+					value  = 1
+				}
+			}
+		}
+	`)
+
+	require.NoError(t, results["drops_dev_samples"])
+	require.EqualError(t, results["missing_sample"], `prometheus.remote_write.default:
+  missing sample {__name__="up", env="dev"} 1`)
+}
+
+func TestRunOTLP(t *testing.T) {
+	config := `
+		otelcol.processor.batch "default" {
+			timeout = "10ms"
+
+			output {
+				traces = [otelcol.exporter.otlp.default.input]
+			}
+		}
+
+		otelcol.exporter.otlp "default" {
+			client {
+				endpoint = "localhost:4317"
+			}
+		}
+	`
+
+	results := runTests(t, config, `
+		test "forwards_spans" {
+			input {
+				component   = "otelcol.processor.batch.default"
+				otlp_traces = `+"`"+`{"resourceSpans": [{"scopeSpans": [{"spans": [
+					{"traceId": "5b8efff798038103d269b633813fc60c", "spanId": "eee19b7ec3c1b174", "name": "a"},
+					{"traceId": "5b8efff798038103d269b633813fc60c", "spanId": "eee19b7ec3c1b175", "name": "b"}
+				]}]}]}`+"`"+`
+			}
+
+			expect {
+				component = "otelcol.exporter.otlp.default"
+				spans     = 2
+			}
+		}
+	`)
+
+	require.NoError(t, results["forwards_spans"])
+}
+
+func TestRunTargets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+
+	config := `
+		discovery.kubernetes "pods" {
+			role = "pod"
+		}
+
+		loki.source.file "pods" {
+			targets    = discovery.kubernetes.pods.targets
+			forward_to = [loki.write.default.receiver]
+		}
+
+		loki.write "default" {
+			endpoint {
+				url = "http://localhost:3100/loki/api/v1/push"
+			}
+		}
+	`
+
+	results := runTests(t, config, fmt.Sprintf(`
+		test "tails_discovered_files" {
+			targets {
+				component = "discovery.kubernetes.pods"
+				targets   = [{ __path__ = %q, job = "app" }]
+			}
+
+			expect {
+				component = "loki.write.default"
+
+				log {
+					line   = "hello"
+					labels = { job = "app", filename = %[1]q }
+				}
+			}
+		}
+	`, path))
+
+	require.NoError(t, results["tails_discovered_files"])
+}
+
+func TestRunErrors(t *testing.T) {
+	results := runTests(t, logsConfig, `
+		test "not_a_sink" {
+			expect {
+				component = "loki.process.default"
+			}
+		}
+
+		test "no_receiver" {
+			input {
+				component = "loki.write.default"
+
+				sample {
+					labels = { __name__ = "up" }
+					value  = 1
+				}
+			}
+
+			expect {
+				component = "loki.write.default"
+			}
+		}
+	`)
+
+	require.ErrorContains(t, results["not_a_sink"], "component loki.process.default isn't a sink which can be tested")
+	require.EqualError(t, results["no_receiver"], "component loki.write.default doesn't export a receiver for metrics")
+}
+
+func TestParseFile(t *testing.T) {
+	_, err := alloytest.ParseFile("test.alloytest", []byte(`
+		test "a" {
+			expect {
+				component = "loki.write.default"
+			}
+		}
+
+		test "a" {
+			expect {
+				component = "loki.write.default"
+			}
+		}
+	`))
+	require.ErrorContains(t, err, `test "a" is defined more than once`)
+
+	_, err = alloytest.ParseFile("test.alloytest", []byte(`test "a" {}`))
+	require.ErrorContains(t, err, `test "a" has no expect blocks`)
+
+	f, err := alloytest.ParseFile("test.alloytest", []byte(strings.TrimSpace(`
+test "a" {
+	expect {
+		component = "loki.write.default"
+	}
+}
+`)))
+	require.NoError(t, err)
+	require.Equal(t, alloytest.DefaultTimeout, f.Tests[0].Timeout)
+}
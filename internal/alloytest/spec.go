@@ -0,0 +1,261 @@
+// Package alloytest runs tests of pipelines defined in Alloy configuration
+// files. Tests are described by test specification files, which inject data
+// into components and assert on the data which reaches sinks such as
+// loki.write, prometheus.remote_write and otelcol.exporter.otlp.
+package alloytest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/discovery"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// FileExtension is the extension of test specification files.
+const FileExtension = ".alloytest"
+
+// DefaultTimeout is the default time a test waits for its expectations to be
+// met.
+const DefaultTimeout = 5 * time.Second
+
+// File is the content of a test specification file.
+type File struct {
+	Tests []Test `alloy:"test,block,optional"`
+}
+
+// ParseFile parses and decodes a test specification file.
+func ParseFile(filename string, bb []byte) (*File, error) {
+	node, err := parser.ParseFile(filename, bb)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := vm.New(node).Evaluate(nil, &f); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(f.Tests))
+	for i, test := range f.Tests {
+		if _, ok := names[test.Name]; ok {
+			block := node.Body[i]
+			return nil, diag.Diagnostics{{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(block).Position(),
+				EndPos:   ast.EndPos(block).Position(),
+				Message:  fmt.Sprintf("test %q is defined more than once", test.Name),
+			}}
+		}
+		names[test.Name] = struct{}{}
+	}
+	return &f, nil
+}
+
+// Test injects data into the components of a pipeline and describes the
+// data which is expected to reach its sinks.
+type Test struct {
+	Name string `alloy:",label"`
+
+	// Timeout is how long to wait for the expectations to be met.
+	Timeout time.Duration `alloy:"timeout,attr,optional"`
+
+	Targets []Targets `alloy:"targets,block,optional"`
+	Inputs  []Input   `alloy:"input,block,optional"`
+	Expects []Expect  `alloy:"expect,block,optional"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (t *Test) SetToDefault() {
+	*t = Test{Timeout: DefaultTimeout}
+}
+
+// Validate implements syntax.Validator.
+func (t *Test) Validate() error {
+	if t.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if len(t.Expects) == 0 {
+		return fmt.Errorf("test %q has no expect blocks", t.Name)
+	}
+	return nil
+}
+
+// Targets replaces the exports of a discovery component with static targets.
+type Targets struct {
+	Component string             `alloy:"component,attr"`
+	Targets   []discovery.Target `alloy:"targets,attr"`
+}
+
+// Input is data sent to the receivers exported by a component.
+type Input struct {
+	Component string `alloy:"component,attr"`
+
+	Logs    []LogEntry `alloy:"log,block,optional"`
+	Samples []Sample   `alloy:"sample,block,optional"`
+
+	// OTLP payloads encoded as JSON.
+	OTLPLogs    string `alloy:"otlp_logs,attr,optional"`
+	OTLPMetrics string `alloy:"otlp_metrics,attr,optional"`
+	OTLPTraces  string `alloy:"otlp_traces,attr,optional"`
+}
+
+// Expect describes all the data which is expected to reach a sink.
+type Expect struct {
+	Component string `alloy:"component,attr"`
+
+	Logs    []LogEntry `alloy:"log,block,optional"`
+	Samples []Sample   `alloy:"sample,block,optional"`
+
+	// Number of OTLP log records, metric data points and spans.
+	LogRecords int `alloy:"log_records,attr,optional"`
+	DataPoints int `alloy:"data_points,attr,optional"`
+	Spans      int `alloy:"spans,attr,optional"`
+}
+
+// LogEntry is a log line and its labels. The labels and timestamp of an
+// expected log entry are only compared if they are set.
+type LogEntry struct {
+	Line      string            `alloy:"line,attr"`
+	Labels    map[string]string `alloy:"labels,attr,optional"`
+	Timestamp time.Time         `alloy:"timestamp,attr,optional"`
+}
+
+func (e LogEntry) matches(entry loki.Entry) bool {
+	if e.Line != entry.Line {
+		return false
+	}
+	if e.Labels != nil && !toLabelSet(e.Labels).Equal(entry.Labels) {
+		return false
+	}
+	return e.Timestamp.IsZero() || e.Timestamp.Equal(entry.Timestamp)
+}
+
+func (e LogEntry) String() string {
+	s := fmt.Sprintf("log %q", e.Line)
+	if e.Labels != nil {
+		s += " " + toLabelSet(e.Labels).String()
+	}
+	if !e.Timestamp.IsZero() {
+		s += " at " + e.Timestamp.Format(time.RFC3339Nano)
+	}
+	return s
+}
+
+func toLabelSet(m map[string]string) model.LabelSet {
+	ls := make(model.LabelSet, len(m))
+	for k, v := range m {
+		ls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return ls
+}
+
+func labelsOf(ls model.LabelSet) map[string]string {
+	m := make(map[string]string, len(ls))
+	for k, v := range ls {
+		m[string(k)] = string(v)
+	}
+	return m
+}
+
+// Sample is a Prometheus sample. The timestamp of an expected sample is only
+// compared if it's set.
+type Sample struct {
+	Labels    map[string]string `alloy:"labels,attr"`
+	Value     float64           `alloy:"value,attr"`
+	Timestamp time.Time         `alloy:"timestamp,attr,optional"`
+}
+
+func newSample(l labels.Labels, t int64, v float64) Sample {
+	return Sample{
+		Labels:    l.Map(),
+		Value:     v,
+		Timestamp: time.UnixMilli(t),
+	}
+}
+
+func (s Sample) matches(other Sample) bool {
+	if s.Value != other.Value || !labels.Equal(labels.FromMap(s.Labels), labels.FromMap(other.Labels)) {
+		return false
+	}
+	return s.Timestamp.IsZero() || s.Timestamp.UnixMilli() == other.Timestamp.UnixMilli()
+}
+
+func (s Sample) String() string {
+	str := fmt.Sprintf("sample %s %v", labels.FromMap(s.Labels), s.Value)
+	if !s.Timestamp.IsZero() {
+		str += " at " + s.Timestamp.Format(time.RFC3339Nano)
+	}
+	return str
+}
+
+// check compares the data received by a sink with the expectation. It
+// returns the expected data which is missing and the received data which
+// wasn't expected.
+func (e Expect) check(sk *sink) (missing, unexpected []string) {
+	sk.mut.Lock()
+	defer sk.mut.Unlock()
+
+	// Received data is described without timestamps, which are usually set
+	// when the data is sent.
+	missing, unexpected = match(e.Logs, sk.logs, LogEntry.matches, func(e loki.Entry) string {
+		return LogEntry{Line: e.Line, Labels: labelsOf(e.Labels)}.String()
+	})
+	m, u := match(e.Samples, sk.samples, Sample.matches, func(s Sample) string {
+		return Sample{Labels: s.Labels, Value: s.Value}.String()
+	})
+	missing, unexpected = append(missing, m...), append(unexpected, u...)
+
+	for _, c := range []struct {
+		name          string
+		expected, got int
+	}{
+		{"OTLP log records", e.LogRecords, sk.logRecords},
+		{"OTLP metric data points", e.DataPoints, sk.dataPoints},
+		{"OTLP spans", e.Spans, sk.spans},
+	} {
+		switch {
+		case c.got < c.expected:
+			missing = append(missing, fmt.Sprintf("%d %s (got %d)", c.expected-c.got, c.name, c.got))
+		case c.got > c.expected:
+			unexpected = append(unexpected, fmt.Sprintf("%d %s (expected %d)", c.got-c.expected, c.name, c.expected))
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	return missing, unexpected
+}
+
+// match pairs each of the received values with an expected value. Values are
+// matched in order, so the first expected value which matches a received
+// value is used.
+func match[E, R any](expected []E, received []R, matches func(E, R) bool, describe func(R) string) (missing, unexpected []string) {
+	used := make([]bool, len(expected))
+
+Received:
+	for _, r := range received {
+		for i, e := range expected {
+			if !used[i] && matches(e, r) {
+				used[i] = true
+				continue Received
+			}
+		}
+		unexpected = append(unexpected, describe(r))
+	}
+
+	for i, e := range expected {
+		if !used[i] {
+			missing = append(missing, fmt.Sprint(e))
+		}
+	}
+	return missing, unexpected
+}
@@ -0,0 +1,169 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Builder generates and compiles distributions.
+type Builder struct {
+	// GoCmd is the go command used to build distributions.
+	GoCmd string
+
+	// Log receives the progress of builds and the output of the go command.
+	Log io.Writer
+}
+
+// Build generates the distribution described by m and compiles it, unless
+// skipCompilation is true. It returns the path of the compiled binary.
+func (b *Builder) Build(ctx context.Context, m *Manifest, skipCompilation bool) (string, error) {
+	if err := m.Validate(); err != nil {
+		return "", fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	alloyGoMod, alloyGoSum, err := b.alloyModule(ctx, m)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(b.Log, "Generating distribution %s in %s\n", m.Dist.Name, m.Dist.OutputPath)
+	if err := Generate(m, alloyGoMod, alloyGoSum); err != nil {
+		return "", fmt.Errorf("generating distribution: %w", err)
+	}
+
+	fmt.Fprintln(b.Log, "Resolving dependencies")
+	if _, err := b.goCmd(ctx, m.Dist.OutputPath, "mod", "tidy"); err != nil {
+		return "", err
+	}
+	if err := b.checkAlloyVersion(ctx, m); err != nil {
+		return "", err
+	}
+	if skipCompilation {
+		return "", nil
+	}
+
+	binary := m.Dist.Name
+	if targetOS() == "windows" {
+		binary += ".exe"
+	}
+	version := m.Dist.Version
+	if version == "" {
+		version = m.AlloyVersion
+	}
+
+	fmt.Fprintf(b.Log, "Compiling %s\n", binary)
+	_, err = b.goCmd(ctx, m.Dist.OutputPath, "build", "-trimpath",
+		"-ldflags", "-X "+alloyModule+"/internal/build.Version="+version,
+		"-o", binary, ".")
+	if err != nil {
+		return "", err
+	}
+	binary = filepath.Join(m.Dist.OutputPath, binary)
+
+	if err := checkBinary(ctx, binary); err != nil {
+		return "", err
+	}
+	return binary, nil
+}
+
+// alloyModule returns the go.mod and go.sum files of the version of Alloy m
+// is built with. The version of m is replaced with the version it resolves to.
+func (b *Builder) alloyModule(ctx context.Context, m *Manifest) (goMod, goSum []byte, err error) {
+	dir := m.AlloyPath
+	if dir == "" {
+		fmt.Fprintf(b.Log, "Downloading %s@%s\n", alloyModule, m.AlloyVersion)
+		out, err := b.goCmd(ctx, "", "mod", "download", "-json", alloyModule+"@"+m.AlloyVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		var download struct {
+			Version string
+			Dir     string
+		}
+		if err := json.Unmarshal(out, &download); err != nil {
+			return nil, nil, fmt.Errorf("decoding output of go mod download: %w", err)
+		}
+		m.AlloyVersion = download.Version
+		dir = download.Dir
+	}
+
+	if goMod, err = os.ReadFile(filepath.Join(dir, "go.mod")); err != nil {
+		return nil, nil, err
+	}
+	if goSum, err = os.ReadFile(filepath.Join(dir, "go.sum")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	return goMod, goSum, nil
+}
+
+// checkAlloyVersion checks that the component modules don't require a newer
+// version of Alloy than the one the distribution is built with. The go
+// command would silently upgrade Alloy otherwise.
+func (b *Builder) checkAlloyVersion(ctx context.Context, m *Manifest) error {
+	if m.AlloyPath != "" {
+		return nil
+	}
+	out, err := b.goCmd(ctx, m.Dist.OutputPath, "list", "-m", "-f", "{{.Version}}", alloyModule)
+	if err != nil {
+		return err
+	}
+	return compareAlloyVersion(m.AlloyVersion, strings.TrimSpace(string(out)))
+}
+
+func compareAlloyVersion(expected, selected string) error {
+	if selected != expected {
+		return fmt.Errorf("the component modules require %s %s, which is newer than the version of the distribution (%s): "+
+			"build the distribution with a newer version of Alloy or use older versions of the components", alloyModule, selected, expected)
+	}
+	return nil
+}
+
+// checkBinary runs the compiled binary, which panics on startup if an
+// extension component isn't compatible with the version of Alloy. Binaries
+// compiled for another platform aren't checked.
+func checkBinary(ctx context.Context, binary string) error {
+	if targetOS() != runtime.GOOS || targetArch() != runtime.GOARCH {
+		return nil
+	}
+	out, err := exec.CommandContext(ctx, binary, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("the distribution failed to start, check that the components are compatible with this version of Alloy: %w\n%s", err, out)
+	}
+	return nil
+}
+
+// goCmd runs the go command in dir and returns its output.
+func (b *Builder) goCmd(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, b.GoCmd, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = b.Log
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running go %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func targetOS() string {
+	if goos := os.Getenv("GOOS"); goos != "" {
+		return goos
+	}
+	return runtime.GOOS
+}
+
+func targetArch() string {
+	if goarch := os.Getenv("GOARCH"); goarch != "" {
+		return goarch
+	}
+	return runtime.GOARCH
+}
@@ -0,0 +1,172 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"golang.org/x/mod/modfile"
+)
+
+// alloyModule is the module path of Alloy.
+const alloyModule = "github.com/grafana/alloy"
+
+var mainTemplate = template.Must(template.New("main.go").Parse(`// Code generated by alloy build. DO NOT EDIT.
+
+// Command {{ .Name }} is a distribution of Grafana Alloy with extension
+// components.
+package main
+
+import (
+	"github.com/grafana/alloy/extension/distribution"
+
+	// Extension components.
+{{- range .Imports }}
+	_ "{{ . }}"
+{{- end }}
+)
+
+func main() {
+	distribution.Run()
+}
+`))
+
+// Generate writes the main package of the distribution described by m to its
+// output path. alloyGoMod and alloyGoSum are the go.mod and go.sum files of
+// the version of Alloy the distribution is built with. The replace directives
+// of alloyGoMod are copied to the go.mod file of the distribution, since the
+// go command ignores the replace directives of dependencies. The go.sum file
+// of the distribution starts with the checksums of alloyGoSum, so that the
+// dependencies shared with Alloy are verified against the same checksums.
+func Generate(m *Manifest, alloyGoMod, alloyGoSum []byte) error {
+	goMod, err := generateGoMod(m, alloyGoMod)
+	if err != nil {
+		return err
+	}
+	main, err := generateMain(m)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dist.OutputPath, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(m.Dist.OutputPath, "go.mod"), goMod, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(m.Dist.OutputPath, "go.sum"), alloyGoSum, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.Dist.OutputPath, "main.go"), main, 0o644)
+}
+
+func generateGoMod(m *Manifest, alloyGoMod []byte) ([]byte, error) {
+	alloy, err := modfile.Parse("go.mod", alloyGoMod, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing go.mod file of Alloy: %w", err)
+	}
+
+	modulePath := m.Dist.Module
+	if modulePath == "" {
+		modulePath = m.Dist.Name
+	}
+
+	f := new(modfile.File)
+	if err := f.AddModuleStmt(modulePath); err != nil {
+		return nil, err
+	}
+	if alloy.Go != nil {
+		if err := f.AddGoStmt(alloy.Go.Version); err != nil {
+			return nil, err
+		}
+	}
+	if alloy.Toolchain != nil {
+		if err := f.AddToolchainStmt(alloy.Toolchain.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.AlloyPath == "" {
+		if err := f.AddRequire(alloyModule, m.AlloyVersion); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range m.Components {
+		if path, version, _ := c.module(); version != "" {
+			if err := f.AddRequire(path, version); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if m.AlloyPath != "" {
+		if err := f.AddReplace(alloyModule, "", m.AlloyPath, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range alloy.Replace {
+		newPath := r.New.Path
+		if modfile.IsDirectoryPath(newPath) {
+			// Directories are only available in a local checkout of Alloy. The
+			// modules of a release are used otherwise.
+			if m.AlloyPath == "" {
+				continue
+			}
+			newPath = resolvePath(m.AlloyPath, newPath)
+		}
+		if err := f.AddReplace(r.Old.Path, r.Old.Version, newPath, r.New.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range m.Components {
+		if c.Path != "" {
+			path, _, _ := c.module()
+			if err := f.AddReplace(path, "", c.Path, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Replaces of the manifest take precedence over the ones of Alloy.
+	for _, r := range m.Replaces {
+		parsed, err := parseReplace(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.AddReplace(parsed.oldPath, parsed.oldVersion, parsed.newPath, parsed.newVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	f.SortBlocks()
+	f.Cleanup()
+	return f.Format()
+}
+
+func generateMain(m *Manifest) ([]byte, error) {
+	var imports []string
+	seen := make(map[string]struct{})
+	for _, c := range m.Components {
+		path := c.importPath()
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		imports = append(imports, path)
+	}
+
+	var buf bytes.Buffer
+	err := mainTemplate.Execute(&buf, struct {
+		Name    string
+		Imports []string
+	}{m.Dist.Name, imports})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
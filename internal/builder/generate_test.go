@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testAlloyGoMod = `module github.com/grafana/alloy
+
+go 1.24.3
+
+toolchain go1.24.4
+
+require github.com/grafana/alloy/syntax v0.1.0
+
+replace github.com/grafana/alloy/syntax => ./syntax
+
+replace gopkg.in/yaml.v2 => github.com/rfratto/go-yaml v0.0.0-20211119180816-77389c3526dc
+
+replace github.com/acme/dep => github.com/acme/dep v1.0.0
+`
+
+func TestGenerate(t *testing.T) {
+	m := &Manifest{
+		Dist:         Dist{Name: "alloy-acme", Module: "example.com/alloy-acme", OutputPath: t.TempDir()},
+		AlloyVersion: "v1.10.0",
+		Components: []Module{
+			{GoMod: "github.com/acme/alloy-components v0.3.0", Import: "github.com/acme/alloy-components/queue"},
+			{GoMod: "github.com/acme/alloy-components v0.3.0", Import: "github.com/acme/alloy-components/queue"},
+			{GoMod: "github.com/acme/local", Path: "/src/local"},
+		},
+		Replaces: []string{"github.com/acme/dep => /src/dep"},
+	}
+	require.NoError(t, m.Validate())
+	require.NoError(t, Generate(m, []byte(testAlloyGoMod), []byte("github.com/acme/dep v1.0.0 h1:abc=\n")))
+
+	goMod, err := os.ReadFile(filepath.Join(m.Dist.OutputPath, "go.mod"))
+	require.NoError(t, err)
+	require.Equal(t, `module example.com/alloy-acme
+
+go 1.24.3
+
+toolchain go1.24.4
+
+require (
+	github.com/acme/alloy-components v0.3.0
+	github.com/grafana/alloy v1.10.0
+)
+
+replace gopkg.in/yaml.v2 => github.com/rfratto/go-yaml v0.0.0-20211119180816-77389c3526dc
+
+replace github.com/acme/dep => /src/dep
+
+replace github.com/acme/local => /src/local
+`, string(goMod))
+
+	goSum, err := os.ReadFile(filepath.Join(m.Dist.OutputPath, "go.sum"))
+	require.NoError(t, err)
+	require.Equal(t, "github.com/acme/dep v1.0.0 h1:abc=\n", string(goSum))
+
+	main, err := os.ReadFile(filepath.Join(m.Dist.OutputPath, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by alloy build. DO NOT EDIT.
+
+// Command alloy-acme is a distribution of Grafana Alloy with extension
+// components.
+package main
+
+import (
+	"github.com/grafana/alloy/extension/distribution"
+
+	// Extension components.
+	_ "github.com/acme/alloy-components/queue"
+	_ "github.com/acme/local"
+)
+
+func main() {
+	distribution.Run()
+}
+`, string(main))
+}
+
+func TestGenerate_AlloyPath(t *testing.T) {
+	m := &Manifest{
+		Dist:       Dist{Name: "alloy-acme", OutputPath: t.TempDir()},
+		AlloyPath:  "/src/alloy",
+		Components: []Module{{GoMod: "github.com/acme/alloy-components v0.3.0"}},
+	}
+	require.NoError(t, m.Validate())
+
+	goMod, err := generateGoMod(m, []byte(testAlloyGoMod))
+	require.NoError(t, err)
+	require.Equal(t, `module alloy-acme
+
+go 1.24.3
+
+toolchain go1.24.4
+
+require github.com/acme/alloy-components v0.3.0
+
+replace github.com/grafana/alloy => /src/alloy
+
+replace github.com/grafana/alloy/syntax => /src/alloy/syntax
+
+replace gopkg.in/yaml.v2 => github.com/rfratto/go-yaml v0.0.0-20211119180816-77389c3526dc
+
+replace github.com/acme/dep => github.com/acme/dep v1.0.0
+`, string(goMod))
+}
+
+func TestCompareAlloyVersion(t *testing.T) {
+	require.NoError(t, compareAlloyVersion("v1.10.0", "v1.10.0"))
+	require.ErrorContains(t, compareAlloyVersion("v1.10.0", "v1.11.0"),
+		"the component modules require github.com/grafana/alloy v1.11.0, which is newer than the version of the distribution (v1.10.0)")
+}
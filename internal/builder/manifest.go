@@ -0,0 +1,215 @@
+// Package builder generates and compiles custom distributions of Alloy which
+// include extension components from other Go modules.
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes a custom distribution of Alloy.
+type Manifest struct {
+	Dist Dist `yaml:"dist"`
+
+	// AlloyVersion is the version of Alloy to build the distribution with. It
+	// can be any version query supported by go get, such as v1.10.0 or a
+	// commit hash.
+	AlloyVersion string `yaml:"alloy_version"`
+
+	// AlloyPath is the path to a local checkout of Alloy to build the
+	// distribution with instead of AlloyVersion.
+	AlloyPath string `yaml:"alloy_path"`
+
+	// Components are the modules providing extension components.
+	Components []Module `yaml:"components"`
+
+	// Replaces are replace directives added to the go.mod file of the
+	// distribution, such as "example.com/foo => ../foo".
+	Replaces []string `yaml:"replaces"`
+}
+
+// Dist holds the settings of the generated distribution.
+type Dist struct {
+	// Name is the name of the binary.
+	Name string `yaml:"name"`
+
+	// Module is the module path of the generated main package. Defaults to
+	// Name.
+	Module string `yaml:"module"`
+
+	// Version is the version reported by the binary. Defaults to the version
+	// of Alloy.
+	Version string `yaml:"version"`
+
+	// OutputPath is the directory the sources and the binary are written to.
+	// Defaults to the _build directory next to the manifest.
+	OutputPath string `yaml:"output_path"`
+}
+
+// Module is a Go module providing extension components.
+type Module struct {
+	// GoMod is the module path and version, such as
+	// "github.com/acme/alloy-components v0.3.0". The version may be omitted
+	// when Path is set.
+	GoMod string `yaml:"gomod"`
+
+	// Import is the package which registers the components. Defaults to the
+	// module path.
+	Import string `yaml:"import"`
+
+	// Path is the path to a local copy of the module to build the
+	// distribution with.
+	Path string `yaml:"path"`
+}
+
+// LoadManifest reads the manifest at path. Relative paths in the manifest are
+// resolved against the directory of the manifest. The manifest isn't
+// validated, so that callers can set defaults first.
+func LoadManifest(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	if m.Dist.OutputPath == "" {
+		m.Dist.OutputPath = "_build"
+	}
+	m.Dist.OutputPath = resolvePath(dir, m.Dist.OutputPath)
+	m.AlloyPath = resolvePath(dir, m.AlloyPath)
+	for i := range m.Components {
+		m.Components[i].Path = resolvePath(dir, m.Components[i].Path)
+	}
+	for i, r := range m.Replaces {
+		if old, replacement, ok := strings.Cut(r, "=>"); ok && modfile.IsDirectoryPath(strings.TrimSpace(replacement)) {
+			m.Replaces[i] = strings.TrimSpace(old) + " => " + resolvePath(dir, strings.TrimSpace(replacement))
+		}
+	}
+	return &m, nil
+}
+
+// Validate checks that m describes a distribution which can be built.
+func (m *Manifest) Validate() error {
+	if m.Dist.Name == "" {
+		return fmt.Errorf("dist.name must be set")
+	}
+	if strings.ContainsAny(m.Dist.Name, `/\`) {
+		return fmt.Errorf("dist.name %q must not contain path separators", m.Dist.Name)
+	}
+	if m.AlloyVersion == "" && m.AlloyPath == "" {
+		return fmt.Errorf("one of alloy_version or alloy_path must be set")
+	}
+	if m.Dist.Module != "" {
+		if err := module.CheckImportPath(m.Dist.Module); err != nil {
+			return fmt.Errorf("dist.module: %w", err)
+		}
+	}
+	if len(m.Components) == 0 {
+		return fmt.Errorf("at least one component module must be listed")
+	}
+
+	for i, c := range m.Components {
+		path, version, err := c.module()
+		if err != nil {
+			return fmt.Errorf("components[%d]: %w", i, err)
+		}
+		if version == "" && c.Path == "" {
+			return fmt.Errorf("components[%d]: module %s must have a version or a path", i, path)
+		}
+		if c.Import != "" && c.Import != path && !strings.HasPrefix(c.Import, path+"/") {
+			return fmt.Errorf("components[%d]: package %s isn't in module %s", i, c.Import, path)
+		}
+	}
+	for i, r := range m.Replaces {
+		if _, err := parseReplace(r); err != nil {
+			return fmt.Errorf("replaces[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// module returns the path and the version of the module.
+func (c Module) module() (path, version string, err error) {
+	fields := strings.Fields(c.GoMod)
+	switch len(fields) {
+	case 1:
+		path = fields[0]
+	case 2:
+		path, version = fields[0], fields[1]
+	default:
+		return "", "", fmt.Errorf("gomod %q must be a module path optionally followed by a version", c.GoMod)
+	}
+	if err := module.CheckPath(path); err != nil {
+		return "", "", err
+	}
+	return path, version, nil
+}
+
+// importPath returns the package which registers the components.
+func (c Module) importPath() string {
+	if c.Import != "" {
+		return c.Import
+	}
+	path, _, _ := c.module()
+	return path
+}
+
+type replace struct {
+	oldPath, oldVersion string
+	newPath, newVersion string
+}
+
+// parseReplace parses a replace directive of the form
+// "old[ version] => new[ version]".
+func parseReplace(r string) (replace, error) {
+	old, replacement, ok := strings.Cut(r, "=>")
+	if !ok {
+		return replace{}, fmt.Errorf("replace %q must be of the form \"old => new\"", r)
+	}
+
+	var res replace
+	switch fields := strings.Fields(old); len(fields) {
+	case 1:
+		res.oldPath = fields[0]
+	case 2:
+		res.oldPath, res.oldVersion = fields[0], fields[1]
+	default:
+		return replace{}, fmt.Errorf("replace %q has an invalid module", r)
+	}
+	switch fields := strings.Fields(replacement); len(fields) {
+	case 1:
+		res.newPath = fields[0]
+	case 2:
+		res.newPath, res.newVersion = fields[0], fields[1]
+	default:
+		return replace{}, fmt.Errorf("replace %q has an invalid replacement", r)
+	}
+	if res.newVersion == "" && !modfile.IsDirectoryPath(res.newPath) {
+		return replace{}, fmt.Errorf("replace %q must have a version or replace the module with a directory", r)
+	}
+	return res, nil
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
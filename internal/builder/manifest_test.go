@@ -0,0 +1,110 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "builder.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+dist:
+  name: alloy-acme
+  version: v1.0.0-acme
+alloy_version: v1.10.0
+components:
+  - gomod: github.com/acme/alloy-components v0.3.0
+    import: github.com/acme/alloy-components/queue
+  - gomod: github.com/acme/local
+    path: ./local
+replaces:
+  - github.com/acme/dep => ../dep
+  - github.com/acme/other v1.0.0 => github.com/acme/fork v1.0.1
+`), 0o644))
+
+	m, err := LoadManifest(path)
+	require.NoError(t, err)
+	require.NoError(t, m.Validate())
+
+	require.Equal(t, filepath.Join(dir, "_build"), m.Dist.OutputPath)
+	require.Equal(t, filepath.Join(dir, "local"), m.Components[1].Path)
+	require.Equal(t, []string{
+		"github.com/acme/dep => " + filepath.Join(filepath.Dir(dir), "dep"),
+		"github.com/acme/other v1.0.0 => github.com/acme/fork v1.0.1",
+	}, m.Replaces)
+	require.Equal(t, "github.com/acme/alloy-components/queue", m.Components[0].importPath())
+	require.Equal(t, "github.com/acme/local", m.Components[1].importPath())
+}
+
+func TestLoadManifest_UnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "builder.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("dist:\n  nam: alloy-acme\n"), 0o644))
+
+	_, err := LoadManifest(path)
+	require.ErrorContains(t, err, "field nam not found")
+}
+
+func TestManifestValidate(t *testing.T) {
+	valid := func() *Manifest {
+		return &Manifest{
+			Dist:         Dist{Name: "alloy-acme"},
+			AlloyVersion: "v1.10.0",
+			Components:   []Module{{GoMod: "github.com/acme/alloy-components v0.3.0"}},
+		}
+	}
+
+	tt := []struct {
+		name   string
+		modify func(m *Manifest)
+		err    string
+	}{
+		{"Valid", func(*Manifest) {}, ""},
+		{"NoName", func(m *Manifest) { m.Dist.Name = "" }, "dist.name must be set"},
+		{"NameWithSlash", func(m *Manifest) { m.Dist.Name = "bin/alloy" }, "must not contain path separators"},
+		{"InvalidModule", func(m *Manifest) { m.Dist.Module = "example.com/alloy acme" }, "dist.module"},
+		{"NoAlloy", func(m *Manifest) { m.AlloyVersion = "" }, "one of alloy_version or alloy_path must be set"},
+		{"NoComponents", func(m *Manifest) { m.Components = nil }, "at least one component module must be listed"},
+		{
+			"InvalidGoMod",
+			func(m *Manifest) { m.Components[0].GoMod = "github.com/acme/alloy-components v0.3.0 extra" },
+			"components[0]: gomod",
+		},
+		{
+			"NoVersion",
+			func(m *Manifest) { m.Components[0].GoMod = "github.com/acme/alloy-components" },
+			"components[0]: module github.com/acme/alloy-components must have a version or a path",
+		},
+		{
+			"ImportOutsideModule",
+			func(m *Manifest) { m.Components[0].Import = "github.com/acme/alloy-components-other" },
+			"components[0]: package github.com/acme/alloy-components-other isn't in module github.com/acme/alloy-components",
+		},
+		{
+			"InvalidReplace",
+			func(m *Manifest) { m.Replaces = []string{"github.com/acme/dep"} },
+			"replaces[0]: replace \"github.com/acme/dep\" must be of the form",
+		},
+		{
+			"ReplaceWithoutVersion",
+			func(m *Manifest) { m.Replaces = []string{"github.com/acme/dep => github.com/acme/fork"} },
+			"must have a version or replace the module with a directory",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := valid()
+			tc.modify(m)
+			err := m.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -125,6 +125,7 @@ type Info struct {
 
 	ComponentName string // Name of the component.
 	Health        Health // Current component health.
+	Restarts      int    // Number of times the component was restarted after exiting.
 
 	Arguments            Arguments   // Current arguments value of the component.
 	Exports              Exports     // Current exports value of the component.
@@ -152,6 +153,7 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			ReferencedBy         []string             `json:"referencedBy"`
 			DataFlowEdgesTo      []string             `json:"dataFlowEdgesTo"`
 			Health               *componentHealthJSON `json:"health"`
+			Restarts             int                  `json:"restarts"`
 			Original             string               `json:"original"`
 			Arguments            json.RawMessage      `json:"arguments,omitempty"`
 			Exports              json.RawMessage      `json:"exports,omitempty"`
@@ -207,6 +209,7 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			Message:     info.Health.Message,
 			UpdatedTime: info.Health.UpdateTime,
 		},
+		Restarts:             info.Restarts,
 		Arguments:            arguments,
 		Exports:              exports,
 		DebugInfo:            debugInfo,
@@ -0,0 +1,321 @@
+package lsp
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
+	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/importoci"
+	"github.com/grafana/alloy/internal/nodeconf/restartpolicy"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/scanner"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// configBlocks are the names of the blocks which can be defined in a
+// configuration file besides components and services.
+var configBlocks = []string{
+	"argument", "declare", "export", foreach.Name, "function", conditional.Name,
+	"import.file", "import.git", "import.http", importoci.Name, "import.string",
+	"logging", restartpolicy.Name, "tracing",
+}
+
+// completionContext describes where completion was requested.
+type completionContext struct {
+	// blocks are the names of the blocks enclosing the position, starting
+	// with the outermost block.
+	blocks []string
+	// expression is true if the position is within the value of an
+	// attribute.
+	expression bool
+	// headers are all the blocks which were opened before the position.
+	headers []scannedBlock
+}
+
+// scannedBlock is a block header found while scanning a document.
+type scannedBlock struct {
+	name    string
+	label   string
+	parents []string // Names of the enclosing blocks.
+}
+
+// id returns the parts of the ID used to reference the block.
+func (b scannedBlock) id() []string {
+	id := strings.Split(b.name, ".")
+	if b.label != "" {
+		id = append(id, b.label)
+	}
+	return id
+}
+
+// scanContext determines the completion context at the end of text. The
+// document is tokenized instead of parsed since it's likely incomplete while
+// it's being edited.
+func scanContext(text []byte) completionContext {
+	var (
+		ctx completionContext
+
+		// Statement holds the tokens of the current statement, which are used to
+		// detect block headers.
+		stmt []token.Token
+		lits []string
+
+		// Nesting tracks the brackets, parentheses and object literals opened in
+		// the current expression.
+		nesting []token.Token
+	)
+
+	s := scanner.New(token.NewFile(""), text, nil, 0)
+	for {
+		_, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			ctx.expression = len(nesting) > 0 || slices.Contains(stmt, token.ASSIGN)
+			return ctx
+
+		case token.LCURLY:
+			if name, label, ok := blockHeader(stmt, lits); ok && len(nesting) == 0 {
+				ctx.headers = append(ctx.headers, scannedBlock{name: name, label: label, parents: slices.Clone(ctx.blocks)})
+				ctx.blocks = append(ctx.blocks, name)
+				stmt, lits = nil, nil
+				continue
+			}
+			nesting = append(nesting, tok)
+
+		case token.LBRACK, token.LPAREN:
+			nesting = append(nesting, tok)
+
+		case token.RCURLY:
+			if len(nesting) == 0 {
+				if len(ctx.blocks) > 0 {
+					ctx.blocks = ctx.blocks[:len(ctx.blocks)-1]
+				}
+				stmt, lits = nil, nil
+				continue
+			}
+			nesting = nesting[:len(nesting)-1]
+
+		case token.RBRACK, token.RPAREN:
+			if len(nesting) > 0 {
+				nesting = nesting[:len(nesting)-1]
+			}
+
+		case token.TERMINATOR:
+			if len(nesting) == 0 {
+				stmt, lits = nil, nil
+				continue
+			}
+		}
+
+		stmt = append(stmt, tok)
+		lits = append(lits, lit)
+	}
+}
+
+// blockHeader returns the name and label of a block if the tokens are a
+// block header, such as prometheus.scrape "default".
+func blockHeader(stmt []token.Token, lits []string) (name string, label string, ok bool) {
+	if len(stmt) > 0 && stmt[len(stmt)-1] == token.STRING {
+		label, _ = strconv.Unquote(lits[len(lits)-1])
+		stmt = stmt[:len(stmt)-1]
+	}
+	if len(stmt)%2 == 0 {
+		return "", "", false
+	}
+
+	var sb strings.Builder
+	for i, tok := range stmt {
+		switch {
+		case i%2 == 0 && tok == token.IDENT:
+			sb.WriteString(lits[i])
+		case i%2 == 1 && tok == token.DOT:
+			sb.WriteByte('.')
+		default:
+			return "", "", false
+		}
+	}
+	return sb.String(), label, true
+}
+
+// referenceableBlocks returns the blocks of a document which can be
+// referenced by ID, which are the top-level blocks and the blocks of declare
+// bodies. The document is scanned so blocks are found even if the document
+// can't be parsed.
+func referenceableBlocks(d *document) []scannedBlock {
+	var blocks []scannedBlock
+	for _, b := range scanContext(d.text).headers {
+		if len(b.parents) == 0 || (len(b.parents) == 1 && b.parents[0] == "declare") {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+// complete returns the completion items at offset of d.
+func (s *Server) complete(d *document, offset int) CompletionList {
+	prefix := d.prefixAt(offset)
+	ctx := scanContext(d.text[:offset-len(prefix)])
+
+	var items []CompletionItem
+	switch {
+	case ctx.expression:
+		items = s.completeReference(d, prefix)
+	case len(ctx.blocks) == 0 || (len(ctx.blocks) == 1 && ctx.blocks[0] == "declare") ||
+		(len(ctx.blocks) == 2 && ctx.blocks[0] == foreach.Name && ctx.blocks[1] == foreach.TypeTemplate) ||
+		(len(ctx.blocks) == 2 && ctx.blocks[0] == conditional.Name && ctx.blocks[1] == conditional.TypeElse):
+		items = s.completeBlockNames(d)
+	case len(ctx.blocks) == 1 && ctx.blocks[0] == conditional.Name:
+		// The body of an if block holds both its arguments and the blocks of
+		// its first branch.
+		items = append(s.completeFields(ctx.blocks), s.completeBlockNames(d)...)
+	default:
+		items = s.completeFields(ctx.blocks)
+	}
+
+	if items == nil {
+		items = []CompletionItem{}
+	}
+	return CompletionList{Items: items}
+}
+
+// completeBlockNames completes the names of components, custom components,
+// services and configuration blocks.
+func (s *Server) completeBlockNames(d *document) []CompletionItem {
+	var items []CompletionItem
+
+	for _, name := range s.opts.ComponentNames {
+		reg, err := s.opts.ComponentRegistry.Get(name)
+		if err != nil {
+			continue
+		}
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   CompletionItemKindModule,
+			Detail: stabilityOf(reg),
+		})
+	}
+
+	for _, doc := range s.workspace(d) {
+		for _, b := range referenceableBlocks(doc) {
+			switch {
+			case b.name == "declare" && b.label != "":
+				items = append(items, CompletionItem{Label: b.label, Kind: CompletionItemKindModule, Detail: "custom component"})
+			case strings.HasPrefix(b.name, "import.") && b.label != "":
+				items = append(items, CompletionItem{Label: b.label, Kind: CompletionItemKindModule, Detail: "imported module"})
+			}
+		}
+	}
+
+	for name := range s.services {
+		items = append(items, CompletionItem{Label: name, Kind: CompletionItemKindStruct, Detail: "service"})
+	}
+	for _, name := range configBlocks {
+		items = append(items, CompletionItem{Label: name, Kind: CompletionItemKindStruct, Detail: "configuration block"})
+	}
+
+	slices.SortFunc(items, func(a, b CompletionItem) int { return strings.Compare(a.Label, b.Label) })
+	return slices.CompactFunc(items, func(a, b CompletionItem) bool { return a.Label == b.Label })
+}
+
+// completeFields completes the attributes and blocks of the block at path.
+func (s *Server) completeFields(path []string) []CompletionItem {
+	var items []CompletionItem
+	for _, f := range fieldsOf(s.schema(path)) {
+		items = append(items, fieldItem(f))
+	}
+	return items
+}
+
+// completeReference completes references to blocks and their exports. The
+// prefix holds the part of the reference which was already typed, such as
+// prometheus.remote_write.
+func (s *Server) completeReference(d *document, prefix string) []CompletionItem {
+	parts := strings.Split(prefix, ".")
+	path := parts[:len(parts)-1]
+
+	var items []CompletionItem
+	for _, doc := range s.workspace(d) {
+		for _, b := range referenceableBlocks(doc) {
+			id := b.id()
+			switch {
+			case len(path) < len(id) && slices.Equal(id[:len(path)], path):
+				items = append(items, CompletionItem{Label: id[len(path)], Kind: CompletionItemKindVariable, Detail: strings.Join(id, ".")})
+			case len(path) >= len(id) && slices.Equal(path[:len(id)], id):
+				items = append(items, s.completeExports(b.name, path[len(id):])...)
+			}
+		}
+	}
+
+	slices.SortFunc(items, func(a, b CompletionItem) int { return strings.Compare(a.Label, b.Label) })
+	return slices.CompactFunc(items, func(a, b CompletionItem) bool { return a.Label == b.Label })
+}
+
+// completeExports completes the exports of a block, or the fields of
+// nested exports at path.
+func (s *Server) completeExports(name string, path []string) []CompletionItem {
+	if name == "argument" {
+		if len(path) == 0 {
+			return []CompletionItem{{Label: "value", Kind: CompletionItemKindField, Detail: "argument value"}}
+		}
+		return nil
+	}
+
+	var t reflect.Type
+	if name == foreach.Name {
+		t = reflect.TypeOf(foreach.Exports{})
+	} else {
+		reg, err := s.opts.ComponentRegistry.Get(name)
+		if err != nil || reg.Exports == nil {
+			return nil
+		}
+		t = reflect.TypeOf(reg.Exports)
+	}
+
+	for _, name := range path {
+		f, ok := lookupField(t, name)
+		if !ok {
+			return nil
+		}
+		t = f.typ
+	}
+
+	var items []CompletionItem
+	for _, f := range fieldsOf(t) {
+		items = append(items, fieldItem(f))
+	}
+	return items
+}
+
+func fieldItem(f field) CompletionItem {
+	item := CompletionItem{Label: f.name, Kind: CompletionItemKindProperty}
+	if f.block {
+		item.Kind = CompletionItemKindStruct
+		item.Detail = "block"
+	} else {
+		item.Detail = describeType(f.typ)
+	}
+	if !f.optional {
+		item.Detail += " (required)"
+	}
+	return item
+}
+
+// topLevelBlocks returns the blocks of body and the blocks of the bodies of
+// declare blocks, which are the blocks that can be referenced by ID.
+func topLevelBlocks(body ast.Body) []*ast.BlockStmt {
+	var blocks []*ast.BlockStmt
+	for _, stmt := range body {
+		b, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+		blocks = append(blocks, b)
+		if b.GetBlockName() == "declare" {
+			blocks = append(blocks, topLevelBlocks(b.Body)...)
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,132 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+)
+
+// document is an Alloy configuration file known to the server, either
+// opened by the client or read from disk.
+type document struct {
+	uri  string
+	path string
+	text []byte
+
+	lines []int // Byte offset of the start of each line.
+
+	file     *ast.File // nil if the document failed to parse.
+	parseErr error
+}
+
+func newDocument(uri string, text []byte) *document {
+	d := &document{
+		uri:   uri,
+		path:  uriToPath(uri),
+		text:  text,
+		lines: []int{0},
+	}
+	for i, b := range text {
+		if b == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	d.file, d.parseErr = parser.ParseFile(d.path, text)
+	return d
+}
+
+// offset converts an LSP position into a byte offset of the document.
+// Positions past the end of a line are clamped to the end of the line.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	} else if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	off, end := d.lines[pos.Line], d.lineEnd(pos.Line)
+	for units := 0; off < end && units < pos.Character; {
+		r, size := utf8.DecodeRune(d.text[off:end])
+		units += utf16.RuneLen(r)
+		off += size
+	}
+	return off
+}
+
+// position converts a byte offset of the document into an LSP position.
+func (d *document) position(offset int) Position {
+	offset = min(max(offset, 0), len(d.text))
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+
+	var units int
+	for off := d.lines[line]; off < offset; {
+		r, size := utf8.DecodeRune(d.text[off:offset])
+		units += utf16.RuneLen(r)
+		off += size
+	}
+	return Position{Line: line, Character: units}
+}
+
+// rangeOf returns the range of a node. The end position of nodes is
+// inclusive, so the range ends one byte after it.
+func (d *document) rangeOf(n ast.Node) Range {
+	return Range{
+		Start: d.position(ast.StartPos(n).Offset()),
+		End:   d.position(ast.EndPos(n).Offset() + 1),
+	}
+}
+
+// fullRange returns the range of the whole document.
+func (d *document) fullRange() Range {
+	return Range{Start: Position{}, End: d.position(len(d.text))}
+}
+
+func (d *document) lineEnd(line int) int {
+	if line+1 < len(d.lines) {
+		return d.lines[line+1] - 1
+	}
+	return len(d.text)
+}
+
+// wordAt returns the identifier, including any dots of a traversal, which
+// contains offset, along with its start offset.
+func (d *document) wordAt(offset int) (string, int) {
+	start, end := offset, offset
+	for start > 0 && isWordChar(d.text[start-1]) {
+		start--
+	}
+	for end < len(d.text) && isWordChar(d.text[end]) {
+		end++
+	}
+	return string(d.text[start:end]), start
+}
+
+// prefixAt returns the part of the identifier before offset.
+func (d *document) prefixAt(offset int) string {
+	start := offset
+	for start > 0 && isWordChar(d.text[start-1]) {
+		start--
+	}
+	return string(d.text[start:offset])
+}
+
+func isWordChar(b byte) bool {
+	return b == '_' || b == '.' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
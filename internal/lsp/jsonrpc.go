@@ -0,0 +1,126 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// JSON-RPC error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+	codeInternalError  = -32603
+)
+
+// request is an incoming JSON-RPC request or notification. Notifications
+// don't have an ID.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+func (r *request) isNotification() bool { return len(r.ID) == 0 }
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string { return e.Message }
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// conn reads and writes JSON-RPC messages framed with the base protocol of
+// the Language Server Protocol, where each message is preceded by a
+// Content-Length header.
+type conn struct {
+	r *textproto.Reader
+
+	mut sync.Mutex
+	w   io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		r: textproto.NewReader(bufio.NewReader(r)),
+		w: w,
+	}
+}
+
+// read reads the next message. io.EOF is returned once the input is closed.
+func (c *conn) read() (*request, error) {
+	header, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r.R, body); err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, &responseError{Code: codeParseError, Message: err.Error()}
+	}
+	return &req, nil
+}
+
+func (c *conn) reply(id json.RawMessage, result any, rerr *responseError) error {
+	resp := response{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		bb, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = bb
+	}
+	return c.write(resp)
+}
+
+func (c *conn) notify(method string, params any) error {
+	return c.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *conn) write(msg any) error {
+	bb, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(bb)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(bb)
+	return err
+}
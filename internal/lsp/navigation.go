@@ -0,0 +1,381 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
+	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/syntax/ast"
+)
+
+// nodeAt returns the blocks enclosing offset, starting with the outermost
+// block, and the attribute at offset if any. onName reports whether offset
+// is on the name of the innermost block or of the attribute.
+func nodeAt(body ast.Body, offset int) (blocks []*ast.BlockStmt, attr *ast.AttributeStmt, onName bool) {
+	for _, stmt := range body {
+		if offset < ast.StartPos(stmt).Offset() || offset > ast.EndPos(stmt).Offset() {
+			continue
+		}
+
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			start := stmt.Name.NamePos.Offset()
+			return nil, stmt, offset >= start && offset < start+len(stmt.Name.Name)
+
+		case *ast.BlockStmt:
+			start := stmt.NamePos.Offset()
+			if offset >= start && offset < start+len(stmt.GetBlockName()) {
+				return []*ast.BlockStmt{stmt}, nil, true
+			}
+			inner, attr, onName := nodeAt(stmt.Body, offset)
+			return append([]*ast.BlockStmt{stmt}, inner...), attr, onName
+		}
+	}
+	return nil, nil, false
+}
+
+// target is a block found in the workspace.
+type target struct {
+	doc   *document
+	block *ast.BlockStmt
+}
+
+// resolve finds the block referenced by the traversal parts, such as
+// ["prometheus", "remote_write", "default", "receiver"]. The remaining parts
+// of the traversal, which reference the exports of the block, are returned
+// as well. Blocks of the enclosing declare block take precedence over the
+// blocks of the workspace.
+func (s *Server) resolve(d *document, enclosing []*ast.BlockStmt, parts []string) (target, []string, bool) {
+	var candidates []target
+	if len(enclosing) > 0 && enclosing[0].GetBlockName() == "declare" {
+		for _, b := range topLevelBlocks(enclosing[0].Body) {
+			candidates = append(candidates, target{doc: d, block: b})
+		}
+	}
+	for _, doc := range s.workspace(d) {
+		if doc.file == nil {
+			continue
+		}
+		for _, stmt := range doc.file.Body {
+			if b, ok := stmt.(*ast.BlockStmt); ok {
+				candidates = append(candidates, target{doc: doc, block: b})
+			}
+		}
+	}
+
+	var (
+		best  target
+		depth int
+	)
+	for _, c := range candidates {
+		id := blockID(c.block)
+		if len(id) > depth && len(id) <= len(parts) && slices.Equal(parts[:len(id)], id) {
+			best, depth = c, len(id)
+		}
+	}
+	if depth > 0 {
+		return best, parts[depth:], true
+	}
+
+	// Functions of imported modules are referenced through the label of the
+	// import block.
+	for _, c := range candidates {
+		if c.block.Name[0] == "import" && c.block.Label == parts[0] {
+			return c, parts[1:], true
+		}
+	}
+	return target{}, nil, false
+}
+
+// resolveCustomComponent finds the declare block of a custom component named
+// name. Custom components of imported modules are looked up in the imported
+// file when it's available locally; otherwise the import block is returned.
+func (s *Server) resolveCustomComponent(d *document, enclosing []*ast.BlockStmt, name string) (target, bool) {
+	namespace, declare, imported := strings.Cut(name, ".")
+
+	var candidates []target
+	if len(enclosing) > 0 && enclosing[0].GetBlockName() == "declare" {
+		for _, b := range topLevelBlocks(enclosing[0].Body) {
+			candidates = append(candidates, target{doc: d, block: b})
+		}
+	}
+	for _, doc := range s.workspace(d) {
+		if doc.file == nil {
+			continue
+		}
+		for _, b := range topLevelBlocks(doc.file.Body) {
+			candidates = append(candidates, target{doc: doc, block: b})
+		}
+	}
+
+	for _, c := range candidates {
+		switch {
+		case !imported && c.block.GetBlockName() == "declare" && c.block.Label == name:
+			return c, true
+		case imported && c.block.Name[0] == "import" && c.block.Label == namespace:
+			if t, ok := importedDeclare(c, declare); ok {
+				return t, true
+			}
+			return c, true
+		}
+	}
+	return target{}, false
+}
+
+// importedDeclare looks up the declare block called name in the file
+// imported by an import.file block with a literal filename.
+func importedDeclare(imp target, name string) (target, bool) {
+	if imp.block.GetBlockName() != "import.file" {
+		return target{}, false
+	}
+
+	var filename string
+	for _, stmt := range imp.block.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok || attr.Name.Name != "filename" {
+			continue
+		}
+		lit, ok := attr.Value.(*ast.LiteralExpr)
+		if !ok {
+			return target{}, false
+		}
+		filename, _ = strconv.Unquote(lit.Value)
+	}
+	if filename == "" {
+		return target{}, false
+	}
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(filepath.Dir(imp.doc.path), filename)
+	}
+
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		return target{}, false
+	}
+	doc := newDocument(pathToURI(filename), text)
+	if doc.file == nil {
+		return target{}, false
+	}
+	for _, b := range topLevelBlocks(doc.file.Body) {
+		if b.GetBlockName() == "declare" && b.Label == name {
+			return target{doc: doc, block: b}, true
+		}
+	}
+	return target{}, false
+}
+
+// definition returns the location of the block referenced at offset of d.
+func (s *Server) definition(d *document, offset int) *Location {
+	if d.file == nil {
+		return nil
+	}
+
+	blocks, attr, onName := nodeAt(d.file.Body, offset)
+	if onName && attr == nil {
+		// Only custom components have a definition in the configuration, and
+		// they can't be nested in the blocks of other components.
+		if len(blocks) > 1 {
+			switch blocks[len(blocks)-2].GetBlockName() {
+			case "declare", foreach.TypeTemplate, conditional.Name, conditional.TypeElse:
+			default:
+				return nil
+			}
+		}
+		if t, ok := s.resolveCustomComponent(d, blocks[:len(blocks)-1], blocks[len(blocks)-1].GetBlockName()); ok {
+			return &Location{URI: t.doc.uri, Range: t.doc.rangeOf(t.block)}
+		}
+		return nil
+	} else if onName {
+		return nil
+	}
+
+	word, _ := d.wordAt(offset)
+	if word == "" {
+		return nil
+	}
+	t, _, ok := s.resolve(d, blocks, strings.Split(word, "."))
+	if !ok {
+		return nil
+	}
+	return &Location{URI: t.doc.uri, Range: t.doc.rangeOf(t.block)}
+}
+
+// hover returns documentation for the block, attribute or reference at
+// offset of d.
+func (s *Server) hover(d *document, offset int) *Hover {
+	if d.file == nil {
+		return nil
+	}
+
+	var contents string
+	blocks, attr, onName := nodeAt(d.file.Body, offset)
+	switch {
+	case onName && attr == nil:
+		// Blocks which aren't nested in other components, such as the blocks of
+		// declare bodies, are documented like top-level blocks.
+		parent := blockPath(blocks[:len(blocks)-1])
+		if s.schema(parent) == nil {
+			contents = s.describeBlock(d, blocks[len(blocks)-1])
+		} else {
+			contents = s.describeField(parent, blocks[len(blocks)-1].GetBlockName())
+		}
+
+	case onName:
+		contents = s.describeField(blockPath(blocks), attr.Name.Name)
+
+	default:
+		word, start := d.wordAt(offset)
+		if word == "" {
+			return nil
+		}
+		t, rest, ok := s.resolve(d, blocks, strings.Split(word, "."))
+		if !ok {
+			return nil
+		}
+		contents = s.describeReference(t.block, rest)
+		if contents == "" {
+			return nil
+		}
+		rng := Range{Start: d.position(start), End: d.position(start + len(word))}
+		return &Hover{Contents: MarkupContent{Kind: "markdown", Value: contents}, Range: &rng}
+	}
+
+	if contents == "" {
+		return nil
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: contents}}
+}
+
+// describeBlock documents a top-level block.
+func (s *Server) describeBlock(d *document, b *ast.BlockStmt) string {
+	name := b.GetBlockName()
+	if reg, err := s.opts.ComponentRegistry.Get(name); err == nil {
+		return describeComponent(reg)
+	}
+	if t, ok := s.resolveCustomComponent(d, nil, name); ok && t.block.GetBlockName() == "declare" {
+		return describeDeclare(t.block)
+	}
+	if t := s.schema([]string{name}); t != nil {
+		return fmt.Sprintf("**%s**\n\n%s", name, describeFields(fieldsOf(t)))
+	}
+	return ""
+}
+
+// describeField documents the attribute or block called name within the
+// block at path.
+func (s *Server) describeField(path []string, name string) string {
+	f, ok := lookupField(s.schema(path), name)
+	if !ok {
+		return ""
+	}
+	if f.block {
+		return fmt.Sprintf("**%s** block%s\n\n%s", f.name, requiredSuffix(f), describeFields(fieldsOf(f.typ)))
+	}
+	return fmt.Sprintf("**%s** attribute%s\n\nType: `%s`", f.name, requiredSuffix(f), describeType(f.typ))
+}
+
+// describeReference documents a reference to block b, where rest is the
+// path of the referenced export.
+func (s *Server) describeReference(b *ast.BlockStmt, rest []string) string {
+	id := strings.Join(blockID(b), ".")
+
+	reg, err := s.opts.ComponentRegistry.Get(b.GetBlockName())
+	if err != nil {
+		if b.GetBlockName() == "declare" || b.Name[0] == "import" || len(rest) == 0 {
+			return fmt.Sprintf("**%s**", id)
+		}
+		return ""
+	}
+	if len(rest) == 0 {
+		return fmt.Sprintf("**%s**\n\n%s", id, describeComponent(reg))
+	}
+
+	t := reflect.TypeOf(reg.Exports)
+	for _, name := range rest {
+		f, ok := lookupField(t, name)
+		if !ok {
+			return ""
+		}
+		t = f.typ
+	}
+	return fmt.Sprintf("**%s** exported by `%s`\n\nType: `%s`", strings.Join(rest, "."), id, describeType(t))
+}
+
+// describeComponent documents the arguments and exports of a component.
+func describeComponent(reg component.Registration) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** (%s)\n\n", reg.Name, stabilityOf(reg))
+	fmt.Fprintf(&sb, "[Documentation](%s)\n", componentDocsURL(reg.Name))
+
+	if reg.Args != nil {
+		if fields := fieldsOf(reflect.TypeOf(reg.Args)); len(fields) > 0 {
+			fmt.Fprintf(&sb, "\nArguments:\n\n%s", describeFields(fields))
+		}
+	}
+	if reg.Exports != nil {
+		if fields := fieldsOf(reflect.TypeOf(reg.Exports)); len(fields) > 0 {
+			fmt.Fprintf(&sb, "\nExports:\n\n%s", describeFields(fields))
+		}
+	}
+	return sb.String()
+}
+
+// describeDeclare documents the arguments and exports of a custom
+// component.
+func describeDeclare(b *ast.BlockStmt) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** (custom component)\n", b.Label)
+
+	for _, kind := range []string{"argument", "export"} {
+		var labels []string
+		for _, stmt := range b.Body {
+			if inner, ok := stmt.(*ast.BlockStmt); ok && inner.GetBlockName() == kind {
+				labels = append(labels, fmt.Sprintf("* `%s`\n", inner.Label))
+			}
+		}
+		if len(labels) > 0 {
+			fmt.Fprintf(&sb, "\n%ss:\n\n%s", strings.ToUpper(kind[:1])+kind[1:], strings.Join(labels, ""))
+		}
+	}
+	return sb.String()
+}
+
+func describeFields(fields []field) string {
+	var sb strings.Builder
+	for _, f := range fields {
+		if f.block {
+			fmt.Fprintf(&sb, "* `%s` block%s\n", f.name, requiredSuffix(f))
+		} else {
+			fmt.Fprintf(&sb, "* `%s` `%s`%s\n", f.name, describeType(f.typ), requiredSuffix(f))
+		}
+	}
+	return sb.String()
+}
+
+func requiredSuffix(f field) string {
+	if f.optional {
+		return ""
+	}
+	return " (required)"
+}
+
+func stabilityOf(reg component.Registration) string {
+	if reg.Community {
+		return "community"
+	}
+	return strings.Trim(reg.Stability.String(), `"`)
+}
+
+// componentDocsURL returns the URL of the reference documentation of a
+// component, which is grouped by the namespace of the component.
+func componentDocsURL(name string) string {
+	namespace, _, _ := strings.Cut(name, ".")
+	return fmt.Sprintf("https://grafana.com/docs/alloy/latest/reference/components/%s/%s/", namespace, name)
+}
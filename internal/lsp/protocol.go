@@ -0,0 +1,144 @@
+package lsp
+
+// This file holds the subset of the Language Server Protocol types used by
+// the server. Refer to the specification for the meaning of each field:
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/
+
+// Position is a zero-based line and UTF-16 character offset in a document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a document. End is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   TextDocumentIdentifier           `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// TextDocumentContentChangeEvent holds the full content of a document, as
+// the server only supports full document synchronization.
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   ServerInfo         `json:"serverInfo"`
+}
+
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type ServerCapabilities struct {
+	TextDocumentSync           int                `json:"textDocumentSync"`
+	CompletionProvider         *CompletionOptions `json:"completionProvider,omitempty"`
+	DefinitionProvider         bool               `json:"definitionProvider"`
+	HoverProvider              bool               `json:"hoverProvider"`
+	DocumentFormattingProvider bool               `json:"documentFormattingProvider"`
+}
+
+// textDocumentSyncFull makes clients send the full content of a document
+// on every change.
+const textDocumentSyncFull = 1
+
+type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+type CompletionItemKind int
+
+// Completion item kinds used by the server.
+const (
+	CompletionItemKindField    CompletionItemKind = 5
+	CompletionItemKindVariable CompletionItemKind = 6
+	CompletionItemKindModule   CompletionItemKind = 9
+	CompletionItemKindProperty CompletionItemKind = 10
+	CompletionItemKindStruct   CompletionItemKind = 22
+)
+
+type CompletionItem struct {
+	Label  string             `json:"label"`
+	Kind   CompletionItemKind `json:"kind,omitempty"`
+	Detail string             `json:"detail,omitempty"`
+}
+
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type DiagnosticSeverity int
+
+// Diagnostic severities.
+const (
+	DiagnosticSeverityError   DiagnosticSeverity = 1
+	DiagnosticSeverityWarning DiagnosticSeverity = 2
+)
+
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Source   string             `json:"source"`
+	Message  string             `json:"message"`
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
@@ -0,0 +1,147 @@
+package lsp
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/grafana/alloy/syntax"
+)
+
+// field is an attribute or block of an Arguments or Exports struct, derived
+// from its alloy struct tags.
+type field struct {
+	name     string
+	block    bool
+	optional bool
+	typ      reflect.Type
+}
+
+// fieldsOf returns the attributes and blocks of the struct type t. Squashed
+// fields are flattened and enum blocks are returned with their full name, for
+// example stage.logfmt. nil is returned if t isn't a struct.
+func fieldsOf(t reflect.Type) []field {
+	t = derefType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("alloy")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		f := field{
+			name:     name,
+			optional: hasFlag(flags, "optional"),
+			typ:      sf.Type,
+		}
+
+		switch {
+		case hasFlag(flags, "squash"):
+			fields = append(fields, fieldsOf(sf.Type)...)
+		case hasFlag(flags, "attr"):
+			fields = append(fields, f)
+		case hasFlag(flags, "block"):
+			f.block = true
+			f.typ = blockType(sf.Type)
+			fields = append(fields, f)
+		case hasFlag(flags, "enum"):
+			for _, inner := range fieldsOf(blockType(sf.Type)) {
+				inner.name = name + "." + inner.name
+				inner.optional = true
+				fields = append(fields, inner)
+			}
+		}
+	}
+	return fields
+}
+
+// lookupField returns the field called name of the struct type t.
+func lookupField(t reflect.Type, name string) (field, bool) {
+	for _, f := range fieldsOf(t) {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// blockType returns the struct type of a block field, which may be a
+// pointer, slice or array of structs.
+func blockType(t reflect.Type) reflect.Type {
+	t = derefType(t)
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = derefType(t.Elem())
+	}
+	return t
+}
+
+func hasFlag(flags, flag string) bool {
+	for _, f := range strings.Split(flags, ",") {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+var (
+	goCapsule       = reflect.TypeOf((*syntax.Capsule)(nil)).Elem()
+	goTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	goDuration      = reflect.TypeOf(time.Duration(0))
+	goAny           = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// describeType describes the type of values accepted by a Go type in terms
+// of the Alloy syntax, for example list(string) or capsule(storage.Appendable).
+func describeType(t reflect.Type) string {
+	t = derefType(t)
+	switch {
+	case t == nil:
+		return "null"
+	case t == goAny:
+		return "any"
+	case t.Implements(goCapsule), reflect.PointerTo(t).Implements(goCapsule):
+		return fmt.Sprintf("capsule(%s)", t)
+	case t == goDuration, t.Implements(goTextMarshaler):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("list(%s)", describeType(t.Elem()))
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return fmt.Sprintf("map(%s)", describeType(t.Elem()))
+		}
+	case reflect.Struct:
+		if len(fieldsOf(t)) > 0 {
+			return "object"
+		}
+	case reflect.Func:
+		return "function"
+	}
+	return fmt.Sprintf("capsule(%s)", t)
+}
@@ -0,0 +1,427 @@
+// Package lsp implements a Language Server Protocol server for Alloy
+// configuration files.
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
+	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/importoci"
+	"github.com/grafana/alloy/internal/nodeconf/restartpolicy"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/tracing"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/validator"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/printer"
+)
+
+// Options configures the language server.
+type Options struct {
+	// ComponentRegistry is used to look up the arguments and exports of
+	// components.
+	ComponentRegistry component.Registry
+	// ComponentNames are the names of the components which can be completed.
+	// component.AllNames is used if nil.
+	ComponentNames []string
+	// ServiceDefinitions is used to complete and validate service blocks.
+	ServiceDefinitions []service.Definition
+	// MinStability is the minimum stability level of features which may be
+	// used by configuration files.
+	MinStability featuregate.Stability
+}
+
+// Server is a Language Server Protocol server for Alloy configuration
+// files. Documents are synchronized in full and files which aren't opened by
+// the client are read from disk when resolving references across the files
+// of a directory.
+type Server struct {
+	opts Options
+	conn *conn
+
+	docs     map[string]*document // Open documents by URI.
+	services map[string]service.Definition
+	shutdown bool
+}
+
+// NewServer creates a new Server.
+func NewServer(opts Options) *Server {
+	if opts.ComponentNames == nil {
+		opts.ComponentNames = component.AllNames()
+	}
+
+	services := make(map[string]service.Definition, len(opts.ServiceDefinitions))
+	for _, def := range opts.ServiceDefinitions {
+		services[def.Name] = def
+	}
+
+	return &Server{
+		opts:     opts,
+		docs:     make(map[string]*document),
+		services: services,
+	}
+}
+
+// Serve handles messages read from r and writes responses to w until the
+// client sends the exit notification, r is closed or ctx is canceled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	for ctx.Err() == nil {
+		req, err := s.conn.read()
+		var rerr *responseError
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.As(err, &rerr):
+			if err := s.conn.reply(json.RawMessage("null"), nil, rerr); err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit received before shutdown")
+			}
+			return nil
+		}
+
+		result, err := s.handle(req)
+		if req.isNotification() {
+			continue
+		}
+
+		if err != nil {
+			if !errors.As(err, &rerr) {
+				rerr = &responseError{Code: codeInternalError, Message: err.Error()}
+			}
+			err = s.conn.reply(req.ID, nil, rerr)
+		} else {
+			err = s.conn.reply(req.ID, result, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *Server) handle(req *request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:           textDocumentSyncFull,
+				CompletionProvider:         &CompletionOptions{TriggerCharacters: []string{"."}},
+				DefinitionProvider:         true,
+				HoverProvider:              true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "alloy", Version: build.Version},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		d, err := s.documentParams(req, &params)
+		if err != nil {
+			return nil, err
+		}
+		return s.complete(d, d.offset(params.Position)), nil
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		d, err := s.documentParams(req, &params)
+		if err != nil {
+			return nil, err
+		}
+		return s.definition(d, d.offset(params.Position)), nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		d, err := s.documentParams(req, &params)
+		if err != nil {
+			return nil, err
+		}
+		return s.hover(d, d.offset(params.Position)), nil
+
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		d, err := s.documentParams(req, &params)
+		if err != nil {
+			return nil, err
+		}
+		return format(d)
+
+	case "initialized", "textDocument/didSave", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", req.Method)}
+}
+
+func decodeParams(req *request, params any) error {
+	if err := json.Unmarshal(req.Params, params); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// documentParams decodes params of requests which target a text document
+// and returns the document.
+func (s *Server) documentParams(req *request, params any) (*document, error) {
+	if err := decodeParams(req, params); err != nil {
+		return nil, err
+	}
+
+	var target struct {
+		TextDocument TextDocumentIdentifier `json:"textDocument"`
+	}
+	_ = json.Unmarshal(req.Params, &target)
+
+	d, ok := s.docs[target.TextDocument.URI]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document %q is not open", target.TextDocument.URI)}
+	}
+	return d, nil
+}
+
+// update stores the new content of a document and publishes its
+// diagnostics.
+func (s *Server) update(uri, text string) error {
+	d := newDocument(uri, []byte(text))
+	s.docs[uri] = d
+
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: s.diagnostics(d),
+	})
+}
+
+// diagnostics returns the syntax errors of a document, or the result of
+// validating it if it could be parsed.
+func (s *Server) diagnostics(d *document) []Diagnostic {
+	err := d.parseErr
+	if err == nil {
+		err = validator.Validate(validator.Options{
+			Sources:            map[string][]byte{d.path: d.text},
+			ServiceDefinitions: s.opts.ServiceDefinitions,
+			ComponentRegistry:  s.opts.ComponentRegistry,
+			MinStability:       s.opts.MinStability,
+		})
+	}
+
+	res := []Diagnostic{}
+	if err == nil {
+		return res
+	}
+
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		return append(res, Diagnostic{
+			Severity: DiagnosticSeverityError,
+			Source:   "alloy",
+			Message:  err.Error(),
+		})
+	}
+
+	for _, dg := range diags {
+		if dg.StartPos.Filename != "" && dg.StartPos.Filename != d.path {
+			continue
+		}
+
+		var rng Range
+		if dg.StartPos.Valid() {
+			rng.Start = d.position(dg.StartPos.Offset)
+			rng.End = rng.Start
+		}
+		if dg.EndPos.Valid() {
+			rng.End = d.position(dg.EndPos.Offset + 1)
+		}
+
+		severity := DiagnosticSeverityError
+		if dg.Severity == diag.SeverityLevelWarn {
+			severity = DiagnosticSeverityWarning
+		}
+
+		res = append(res, Diagnostic{
+			Range:    rng,
+			Severity: severity,
+			Source:   "alloy",
+			Message:  dg.Message,
+		})
+	}
+	return res
+}
+
+// format returns an edit replacing the document with its formatted content.
+// No edits are returned if the document can't be parsed.
+func format(d *document) ([]TextEdit, error) {
+	if d.file == nil {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, d.file); err != nil {
+		return nil, err
+	}
+	// Add a newline at the end of the file like alloy fmt does.
+	_, _ = buf.Write([]byte{'\n'})
+
+	if bytes.Equal(buf.Bytes(), d.text) {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{Range: d.fullRange(), NewText: buf.String()}}, nil
+}
+
+// workspace returns the documents of the directory of d. Alloy loads all
+// files of a directory as a single configuration, so blocks can reference
+// blocks of other files in the same directory. Open documents take
+// precedence over the files on disk.
+func (s *Server) workspace(d *document) []*document {
+	docs := []*document{d}
+	seen := map[string]struct{}{d.path: {}}
+
+	dir := filepath.Dir(d.path)
+	for _, other := range s.docs {
+		if _, ok := seen[other.path]; !ok && filepath.Dir(other.path) == dir {
+			docs = append(docs, other)
+			seen[other.path] = struct{}{}
+		}
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.alloy"))
+	for _, path := range paths {
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		if text, err := os.ReadFile(path); err == nil {
+			docs = append(docs, newDocument(pathToURI(path), text))
+		}
+	}
+
+	// Keep the result stable for documents which aren't d.
+	slices.SortFunc(docs[1:], func(a, b *document) int { return strings.Compare(a.path, b.path) })
+	return docs
+}
+
+// schema returns the Go type which the block at path decodes into, where
+// path holds the names of the blocks from the outermost one. nil is
+// returned if the type isn't known, for example for custom components.
+func (s *Server) schema(path []string) reflect.Type {
+	if len(path) == 0 {
+		return nil
+	}
+
+	var t reflect.Type
+	switch name := path[0]; name {
+	case "declare":
+		return s.schema(path[1:])
+	case foreach.Name:
+		if len(path) > 1 && path[1] == foreach.TypeTemplate {
+			return s.schema(path[2:])
+		}
+		t = reflect.TypeOf(foreach.Arguments{})
+	case conditional.Name:
+		// The if block has no nested blocks of its own: they belong to its
+		// branches.
+		switch {
+		case len(path) > 2 && path[1] == conditional.TypeElse:
+			return s.schema(path[2:])
+		case len(path) > 1 && path[1] != conditional.TypeElse:
+			return s.schema(path[1:])
+		}
+		t = reflect.TypeOf(conditional.Arguments{})
+	case importoci.Name:
+		t = reflect.TypeOf(importoci.Arguments{})
+	case "logging":
+		t = reflect.TypeOf(logging.Options{})
+	case "tracing":
+		t = reflect.TypeOf(tracing.Options{})
+	case restartpolicy.Name:
+		t = reflect.TypeOf(restartpolicy.Arguments{})
+	default:
+		if def, ok := s.services[name]; ok && def.ConfigType != nil {
+			t = reflect.TypeOf(def.ConfigType)
+		} else if reg, err := s.opts.ComponentRegistry.Get(name); err == nil && reg.Args != nil {
+			t = reflect.TypeOf(reg.Args)
+		}
+	}
+
+	for _, name := range path[1:] {
+		f, ok := lookupField(t, name)
+		if !ok || !f.block {
+			return nil
+		}
+		t = f.typ
+	}
+	return t
+}
+
+// blockPath returns the names of blocks.
+func blockPath(blocks []*ast.BlockStmt) []string {
+	path := make([]string, len(blocks))
+	for i, b := range blocks {
+		path[i] = b.GetBlockName()
+	}
+	return path
+}
+
+// blockID returns the parts of the ID of a block which are used to
+// reference it from expressions, for example ["local", "file", "name"].
+func blockID(b *ast.BlockStmt) []string {
+	id := slices.Clone(b.Name)
+	if b.Label != "" {
+		id = append(id, b.Label)
+	}
+	return id
+}
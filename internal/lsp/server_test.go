@@ -0,0 +1,423 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+type testReceiver interface{ Receive() }
+
+type testAppender interface{ Append() }
+
+type scrapeArguments struct {
+	ForwardTo []testAppender `alloy:"forward_to,attr"`
+	Interval  time.Duration  `alloy:"interval,attr,optional"`
+	Auth      *authBlock     `alloy:"auth,block,optional"`
+}
+
+type authBlock struct {
+	Username string `alloy:"username,attr"`
+}
+
+type writeArguments struct {
+	URL string `alloy:"url,attr"`
+}
+
+type writeExports struct {
+	Receiver testReceiver `alloy:"receiver,attr"`
+}
+
+type remoteWriteExports struct {
+	Receiver testAppender `alloy:"receiver,attr"`
+}
+
+func newTestServer() *Server {
+	registrations := map[string]component.Registration{
+		"test.scrape": {
+			Name:      "test.scrape",
+			Stability: featuregate.StabilityGenerallyAvailable,
+			Args:      scrapeArguments{},
+		},
+		"test.write": {
+			Name:      "test.write",
+			Stability: featuregate.StabilityExperimental,
+			Args:      writeArguments{},
+			Exports:   writeExports{},
+		},
+		"test.remote_write": {
+			Name:      "test.remote_write",
+			Stability: featuregate.StabilityGenerallyAvailable,
+			Args:      writeArguments{},
+			Exports:   remoteWriteExports{},
+		},
+	}
+	names := make([]string, 0, len(registrations))
+	for name := range registrations {
+		names = append(names, name)
+	}
+
+	return NewServer(Options{
+		ComponentRegistry: component.NewRegistryMap(featuregate.StabilityExperimental, false, registrations),
+		ComponentNames:    names,
+		MinStability:      featuregate.StabilityExperimental,
+	})
+}
+
+// testClient talks to a Server over in-memory pipes.
+type testClient struct {
+	t *testing.T
+
+	w      io.Writer
+	r      *textproto.Reader
+	nextID int
+	done   chan error
+
+	notifications []json.RawMessage
+}
+
+func newTestClient(t *testing.T, s *Server) *testClient {
+	serverR, clientW := io.Pipe()
+	clientR, serverW := io.Pipe()
+
+	c := &testClient{
+		t:    t,
+		w:    clientW,
+		r:    textproto.NewReader(bufio.NewReader(clientR)),
+		done: make(chan error, 1),
+	}
+	go func() {
+		c.done <- s.Serve(context.Background(), serverR, serverW)
+		serverW.Close()
+	}()
+	t.Cleanup(func() { clientW.Close() })
+
+	c.call("initialize", map[string]any{}, nil)
+	c.notify("initialized", map[string]any{})
+	return c
+}
+
+func (c *testClient) write(msg map[string]any) {
+	bb, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(bb), bb)
+	require.NoError(c.t, err)
+}
+
+func (c *testClient) read() map[string]json.RawMessage {
+	header, err := c.r.ReadMIMEHeader()
+	require.NoError(c.t, err)
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	require.NoError(c.t, err)
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(c.r.R, body)
+	require.NoError(c.t, err)
+
+	var msg map[string]json.RawMessage
+	require.NoError(c.t, json.Unmarshal(body, &msg))
+	return msg
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// call sends a request and decodes its result into result. Notifications
+// received while waiting for the response are stored.
+func (c *testClient) call(method string, params any, result any) *responseError {
+	c.nextID++
+	c.write(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+
+	for {
+		msg := c.read()
+		if _, ok := msg["id"]; !ok {
+			c.notifications = append(c.notifications, msg["params"])
+			continue
+		}
+		require.Equal(c.t, strconv.Itoa(c.nextID), string(msg["id"]))
+
+		if rerr, ok := msg["error"]; ok {
+			var res responseError
+			require.NoError(c.t, json.Unmarshal(rerr, &res))
+			return &res
+		}
+		if result != nil {
+			require.NoError(c.t, json.Unmarshal(msg["result"], result))
+		}
+		return nil
+	}
+}
+
+// open opens a document and returns its published diagnostics.
+func (c *testClient) open(uri, text string) []Diagnostic {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "alloy", Text: text},
+	})
+
+	var params PublishDiagnosticsParams
+	require.NoError(c.t, json.Unmarshal(c.read()["params"], &params))
+	require.Equal(c.t, uri, params.URI)
+	return params.Diagnostics
+}
+
+func position(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+const testConfig = `test.write "default" {
+	url = "http://localhost"
+}
+
+test.remote_write "default" {
+	url = "http://localhost"
+}
+
+test.scrape "default" {
+	forward_to = [test.remote_write.default.receiver]
+}
+`
+
+func TestDiagnostics(t *testing.T) {
+	c := newTestClient(t, newTestServer())
+	uri := pathToURI(filepath.Join(t.TempDir(), "config.alloy"))
+
+	require.Empty(t, c.open(uri, testConfig))
+
+	t.Run("syntax error", func(t *testing.T) {
+		c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+			TextDocument:   TextDocumentIdentifier{URI: uri},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: "test.write \"default\" {\n\turl = \n}\n"}},
+		})
+
+		var params PublishDiagnosticsParams
+		require.NoError(t, json.Unmarshal(c.read()["params"], &params))
+		require.Equal(t, []Diagnostic{{
+			Range:    Range{Start: Position{Line: 2, Character: 0}, End: Position{Line: 2, Character: 0}},
+			Severity: DiagnosticSeverityError,
+			Source:   "alloy",
+			Message:  "expected expression, got }",
+		}}, params.Diagnostics)
+	})
+
+	t.Run("type error", func(t *testing.T) {
+		c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: `test.write "default" {
+	url = "http://localhost"
+}
+
+test.scrape "default" {
+	forward_to = [test.write.default.receiver]
+}
+`}},
+		})
+
+		var params PublishDiagnosticsParams
+		require.NoError(t, json.Unmarshal(c.read()["params"], &params))
+		require.Equal(t, []Diagnostic{{
+			Range:    Range{Start: Position{Line: 5, Character: 15}, End: Position{Line: 5, Character: 42}},
+			Severity: DiagnosticSeverityError,
+			Source:   "alloy",
+			Message:  `expected capsule("lsp.testAppender"), got capsule("lsp.testReceiver")`,
+		}}, params.Diagnostics)
+	})
+}
+
+func TestCompletion(t *testing.T) {
+	c := newTestClient(t, newTestServer())
+	uri := pathToURI(filepath.Join(t.TempDir(), "config.alloy"))
+
+	labels := func(text string, line, character int) []string {
+		c.open(uri, text)
+		var list CompletionList
+		require.Nil(t, c.call("textDocument/completion", position(uri, line, character), &list))
+
+		var res []string
+		for _, item := range list.Items {
+			res = append(res, item.Label)
+		}
+		return res
+	}
+
+	t.Run("component names", func(t *testing.T) {
+		res := labels("declare \"custom\" {}\n\nte", 2, 2)
+		require.Contains(t, res, "test.scrape")
+		require.Contains(t, res, "test.write")
+		require.Contains(t, res, "custom")
+		require.Contains(t, res, "logging")
+	})
+
+	t.Run("arguments", func(t *testing.T) {
+		res := labels("test.scrape \"default\" {\n\t\n}\n", 1, 1)
+		require.Equal(t, []string{"forward_to", "interval", "auth"}, res)
+	})
+
+	t.Run("nested block arguments", func(t *testing.T) {
+		res := labels("test.scrape \"default\" {\n\tauth {\n\t\t\n\t}\n}\n", 2, 2)
+		require.Equal(t, []string{"username"}, res)
+	})
+
+	t.Run("if block", func(t *testing.T) {
+		res := labels("if \"a\" {\n\t\n}\n", 1, 1)
+		require.Contains(t, res, "condition")
+		require.Contains(t, res, "test.scrape")
+
+		res = labels("if \"a\" {\n\telse {\n\t\t\n\t}\n}\n", 2, 2)
+		require.NotContains(t, res, "condition")
+		require.Contains(t, res, "test.scrape")
+
+		res = labels("if \"a\" {\n\telse {\n\t\ttest.scrape \"default\" {\n\t\t\t\n\t\t}\n\t}\n}\n", 3, 3)
+		require.Equal(t, []string{"forward_to", "interval", "auth"}, res)
+	})
+
+	t.Run("references", func(t *testing.T) {
+		res := labels("test.write \"a\" {}\ntest.remote_write \"b\" {}\ntest.scrape \"c\" {\n\tforward_to = [test.\n}\n", 3, 20)
+		require.Equal(t, []string{"remote_write", "scrape", "write"}, res)
+	})
+
+	t.Run("exports", func(t *testing.T) {
+		res := labels("test.remote_write \"b\" {}\ntest.scrape \"c\" {\n\tforward_to = [test.remote_write.b.\n}\n", 2, 35)
+		require.Equal(t, []string{"receiver"}, res)
+
+		res = labels("foreach \"f\" {}\ntest.scrape \"c\" {\n\tforward_to = [foreach.f.\n}\n", 2, 26)
+		require.Equal(t, []string{"instances"}, res)
+	})
+}
+
+func TestDefinition(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "module.alloy"), []byte("declare \"imported\" {}\n"), 0o644))
+
+	c := newTestClient(t, newTestServer())
+	uri := pathToURI(filepath.Join(dir, "config.alloy"))
+	c.open(uri, testConfig)
+
+	otherURI := pathToURI(filepath.Join(dir, "custom.alloy"))
+	c.open(otherURI, `declare "custom" {}
+
+import.file "mod" {
+	filename = "module.alloy"
+}
+
+custom "a" {}
+
+mod.imported "b" {}
+`)
+
+	definition := func(uri string, line, character int) *Location {
+		var loc *Location
+		require.Nil(t, c.call("textDocument/definition", position(uri, line, character), &loc))
+		return loc
+	}
+
+	t.Run("component reference", func(t *testing.T) {
+		require.Equal(t, &Location{
+			URI:   uri,
+			Range: Range{Start: Position{Line: 4, Character: 0}, End: Position{Line: 6, Character: 1}},
+		}, definition(uri, 9, 30))
+	})
+
+	t.Run("custom component", func(t *testing.T) {
+		require.Equal(t, &Location{
+			URI:   otherURI,
+			Range: Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 19}},
+		}, definition(otherURI, 6, 2))
+	})
+
+	t.Run("imported custom component", func(t *testing.T) {
+		require.Equal(t, &Location{
+			URI:   pathToURI(filepath.Join(dir, "module.alloy")),
+			Range: Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 21}},
+		}, definition(otherURI, 8, 5))
+	})
+
+	t.Run("builtin component", func(t *testing.T) {
+		require.Nil(t, definition(uri, 0, 2))
+	})
+}
+
+func TestHover(t *testing.T) {
+	c := newTestClient(t, newTestServer())
+	uri := pathToURI(filepath.Join(t.TempDir(), "config.alloy"))
+	c.open(uri, testConfig)
+
+	hover := func(line, character int) string {
+		var h *Hover
+		require.Nil(t, c.call("textDocument/hover", position(uri, line, character), &h))
+		if h == nil {
+			return ""
+		}
+		return h.Contents.Value
+	}
+
+	require.Equal(t, "**test.write** (experimental)\n\n"+
+		"[Documentation](https://grafana.com/docs/alloy/latest/reference/components/test/test.write/)\n\n"+
+		"Arguments:\n\n* `url` `string` (required)\n\n"+
+		"Exports:\n\n* `receiver` `capsule(lsp.testReceiver)` (required)\n", hover(0, 3))
+
+	require.Equal(t, "**forward_to** attribute (required)\n\nType: `list(capsule(lsp.testAppender))`", hover(9, 3))
+	require.Equal(t, "**receiver** exported by `test.remote_write.default`\n\nType: `capsule(lsp.testAppender)`", hover(9, 20))
+	require.Equal(t, "", hover(3, 0))
+}
+
+func TestFormatting(t *testing.T) {
+	c := newTestClient(t, newTestServer())
+	uri := pathToURI(filepath.Join(t.TempDir(), "config.alloy"))
+	c.open(uri, "test.write \"default\" {\nurl=\"http://localhost\"\n}")
+
+	var edits []TextEdit
+	require.Nil(t, c.call("textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &edits))
+	require.Equal(t, []TextEdit{{
+		Range:   Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 2, Character: 1}},
+		NewText: "test.write \"default\" {\n\turl = \"http://localhost\"\n}\n",
+	}}, edits)
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newTestClient(t, newTestServer())
+
+	rerr := c.call("workspace/symbol", map[string]any{}, nil)
+	require.Equal(t, codeMethodNotFound, rerr.Code)
+
+	rerr = c.call("textDocument/hover", position("file:///missing.alloy", 0, 0), nil)
+	require.Equal(t, codeInvalidParams, rerr.Code)
+
+	require.Nil(t, c.call("shutdown", nil, nil))
+	c.notify("exit", nil)
+	require.NoError(t, <-c.done)
+}
+
+func TestDocumentPositions(t *testing.T) {
+	d := newDocument("file:///test.alloy", []byte("a = \"héllo 😀\"\nb = 1\n"))
+
+	for _, tc := range []struct {
+		offset int
+		pos    Position
+	}{
+		{0, Position{Line: 0, Character: 0}},
+		{6, Position{Line: 0, Character: 6}},
+		{12, Position{Line: 0, Character: 11}},
+		{16, Position{Line: 0, Character: 13}},
+		{18, Position{Line: 1, Character: 0}},
+	} {
+		require.Equal(t, tc.pos, d.position(tc.offset), "offset %d", tc.offset)
+		require.Equal(t, tc.offset, d.offset(tc.pos), "position %v", tc.pos)
+	}
+}
@@ -0,0 +1,16 @@
+package conditional
+
+import "github.com/grafana/alloy/internal/featuregate"
+
+const (
+	// Name is the block name for if blocks.
+	Name = "if"
+	// StabilityLevel for if blocks.
+	StabilityLevel = featuregate.StabilityExperimental
+	// TypeElse is the block name for the else branch of an if block.
+	TypeElse = "else"
+)
+
+type Arguments struct {
+	Condition bool `alloy:"condition,attr"`
+}
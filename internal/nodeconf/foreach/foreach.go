@@ -9,15 +9,31 @@ const (
 	StabilityLevel = featuregate.StabilityExperimental
 	// TypeTemplate is the block name for template property
 	TypeTemplate = "template"
+	// AttrKey is the name of the attribute defining the identity of the
+	// instances. It is evaluated once per item of the collection.
+	AttrKey = "key"
 )
 
 type Arguments struct {
-	Collection []any  `alloy:"collection,attr"`
+	// Collection is either an array or an object. When it is an object, var
+	// holds an object with the "key" and "value" fields of each entry.
+	Collection any    `alloy:"collection,attr"`
 	Var        string `alloy:"var,attr"`
 	Id         string `alloy:"id,attr,optional"`
 
+	// Key is evaluated separately for each item of the collection, with var
+	// set to the item. It is declared here for validation and completion but
+	// it is never decoded with the other arguments.
+	Key any `alloy:"key,attr,optional"`
+
 	// EnableMetrics should be false by default.
 	// That way users are protected from an explosion of debug metrics
 	// if there are many items inside "collection".
 	EnableMetrics bool `alloy:"enable_metrics,attr,optional"`
 }
+
+// Exports exposes the exports of the foreach instances to the rest of the
+// config, keyed by the identity of each instance.
+type Exports struct {
+	Instances map[string]map[string]any `alloy:"instances,attr"`
+}
@@ -0,0 +1,84 @@
+package importoci
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+const (
+	// Name is the block name for import.oci blocks.
+	Name = "import.oci"
+	// StabilityLevel for import.oci blocks.
+	StabilityLevel = featuregate.StabilityExperimental
+)
+
+// Arguments holds the values used to pull a module bundle from an OCI registry.
+type Arguments struct {
+	Reference     string        `alloy:"reference,attr"`
+	Path          string        `alloy:"path,attr,optional"`
+	Digest        string        `alloy:"digest,attr,optional"`
+	PublicKey     string        `alloy:"public_key,attr,optional"`
+	PollFrequency time.Duration `alloy:"poll_frequency,attr,optional"`
+	PollTimeout   time.Duration `alloy:"poll_timeout,attr,optional"`
+	Insecure      bool          `alloy:"insecure,attr,optional"`
+	BasicAuth     *BasicAuth    `alloy:"basic_auth,block,optional"`
+}
+
+// BasicAuth holds the credentials used to authenticate to the registry.
+type BasicAuth struct {
+	Username string            `alloy:"username,attr"`
+	Password alloytypes.Secret `alloy:"password,attr"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Path:          ".",
+	PollFrequency: time.Minute,
+	PollTimeout:   30 * time.Second,
+}
+
+var (
+	_ syntax.Validator = (*Arguments)(nil)
+	_ syntax.Defaulter = (*Arguments)(nil)
+)
+
+// SetToDefault implements syntax.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	ref, err := oci.ParseReference(args.Reference)
+	if err != nil {
+		return err
+	}
+	if args.Digest != "" {
+		d, err := digest.Parse(args.Digest)
+		if err != nil {
+			return fmt.Errorf("invalid digest %q: %w", args.Digest, err)
+		}
+		if ref.Digest != "" && ref.Digest != d {
+			return fmt.Errorf("the digest %s doesn't match the digest of the reference %s", d, ref.Digest)
+		}
+	}
+	if args.PublicKey != "" {
+		if _, err := oci.ParsePublicKey(args.PublicKey); err != nil {
+			return err
+		}
+	}
+	if args.PollFrequency < 0 {
+		return fmt.Errorf("poll_frequency must not be negative")
+	}
+	if args.PollTimeout <= 0 {
+		return fmt.Errorf("poll_timeout must be greater than 0")
+	}
+	return nil
+}
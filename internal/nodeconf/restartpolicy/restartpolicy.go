@@ -0,0 +1,93 @@
+package restartpolicy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+const (
+	// Name is the block name for restart_policy blocks.
+	Name = "restart_policy"
+	// StabilityLevel for restart_policy blocks.
+	StabilityLevel = featuregate.StabilityExperimental
+)
+
+// Modes of restart policies.
+const (
+	// ModeNever never restarts components.
+	ModeNever = "never"
+	// ModeOnFailure restarts components which exit with an error or panic.
+	ModeOnFailure = "on_failure"
+	// ModeAlways restarts components which exit for any reason.
+	ModeAlways = "always"
+)
+
+// Arguments configures how components which exit while Alloy is running are
+// restarted.
+type Arguments struct {
+	Mode           string        `alloy:"mode,attr,optional"`
+	InitialBackoff time.Duration `alloy:"initial_backoff,attr,optional"`
+	MaxBackoff     time.Duration `alloy:"max_backoff,attr,optional"`
+	MaxRestarts    int           `alloy:"max_restarts,attr,optional"`
+}
+
+// DefaultArguments holds the default arguments of restart_policy blocks.
+var DefaultArguments = Arguments{
+	Mode:           ModeOnFailure,
+	InitialBackoff: time.Second,
+	MaxBackoff:     5 * time.Minute,
+	MaxRestarts:    0,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	switch args.Mode {
+	case ModeNever, ModeOnFailure, ModeAlways:
+	default:
+		return fmt.Errorf("mode must be one of %q, %q or %q, got %q", ModeNever, ModeOnFailure, ModeAlways, args.Mode)
+	}
+	if args.InitialBackoff <= 0 {
+		return fmt.Errorf("initial_backoff must be greater than 0")
+	}
+	if args.MaxBackoff < args.InitialBackoff {
+		return fmt.Errorf("max_backoff must not be less than initial_backoff")
+	}
+	if args.MaxRestarts < 0 {
+		return fmt.Errorf("max_restarts must not be negative")
+	}
+	return nil
+}
+
+// ShouldRestart returns whether a component which exited with err is
+// restarted. err is nil if the component exited cleanly.
+func (args *Arguments) ShouldRestart(err error) bool {
+	switch args.Mode {
+	case ModeAlways:
+		return true
+	case ModeOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// Backoff returns how long to wait before restarting a component which was
+// already restarted the given number of times in a row. The backoff doubles
+// with every restart, up to MaxBackoff.
+func (args *Arguments) Backoff(restarts int) time.Duration {
+	backoff := args.InitialBackoff
+	for range restarts {
+		if backoff >= args.MaxBackoff/2 {
+			return args.MaxBackoff
+		}
+		backoff *= 2
+	}
+	return min(backoff, args.MaxBackoff)
+}
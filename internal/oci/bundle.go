@@ -0,0 +1,77 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// ArtifactTypeModule is the artifact type of module bundles.
+	ArtifactTypeModule = "application/vnd.grafana.alloy.module.v1"
+	// MediaTypeModuleLayer is the media type of the layer holding the files of
+	// a module bundle.
+	MediaTypeModuleLayer = "application/vnd.grafana.alloy.module.layer.v1.tar+gzip"
+)
+
+// moduleLayerMediaTypes are the media types accepted for the layer of a module
+// bundle, so that bundles can also be pushed as plain OCI layers.
+var moduleLayerMediaTypes = []string{MediaTypeModuleLayer, ocispec.MediaTypeImageLayerGzip, ocispec.MediaTypeImageLayer}
+
+// ModuleLayer returns the layer of manifest which holds the module bundle.
+func ModuleLayer(manifest ocispec.Manifest) (ocispec.Descriptor, error) {
+	for _, mediaType := range moduleLayerMediaTypes {
+		for _, layer := range manifest.Layers {
+			if layer.MediaType == mediaType {
+				return layer, nil
+			}
+		}
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("the manifest has no layer of type %q", MediaTypeModuleLayer)
+}
+
+// ReadBundle returns the regular files of a module bundle, which is a tarball
+// optionally compressed with gzip. The files are keyed by their cleaned path
+// in the tarball. Paths escaping the root of the tarball are rejected.
+func ReadBundle(data []byte) (map[string][]byte, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading bundle: %w", err)
+		}
+		defer gz.Close()
+		r = io.LimitReader(gz, MaxBlobSize)
+	}
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("reading bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("invalid path %q in bundle", hdr.Name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading %q from bundle: %w", hdr.Name, err)
+		}
+		files[name] = content
+	}
+}
@@ -0,0 +1,364 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// maxManifestSize is the maximum size of the manifests fetched from registries.
+	maxManifestSize = 4 << 20
+	// MaxBlobSize is the maximum size of the blobs fetched from registries.
+	MaxBlobSize = 64 << 20
+
+	// mediaTypeDockerManifest is the media type of Docker image manifests, which
+	// have the same layout as OCI image manifests.
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// manifestMediaTypes are the manifest media types accepted from registries.
+var manifestMediaTypes = []string{ocispec.MediaTypeImageManifest, mediaTypeDockerManifest}
+
+// ErrNotFound is returned when the registry doesn't know a manifest or a blob.
+var ErrNotFound = errors.New("not found")
+
+// ResponseError is returned when the registry answers with an unexpected status.
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+// Error returns the error string, denoting the failed request.
+func (err ResponseError) Error() string {
+	msg := fmt.Sprintf("%s %s: unexpected status %d", err.Method, err.URL, err.StatusCode)
+	if err.Body != "" {
+		msg += ": " + err.Body
+	}
+	return msg
+}
+
+// Unwrap returns ErrNotFound for 404 responses.
+func (err ResponseError) Unwrap() error {
+	if err.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Options configure a Client.
+type Options struct {
+	// Username and Password are used to authenticate to the registry, either
+	// directly or to get a token from its authorization server. Requests are
+	// anonymous when Username is empty.
+	Username string
+	Password string
+	// Insecure uses plain HTTP to connect to the registry.
+	Insecure bool
+	// HTTPClient sends the requests. http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+}
+
+// Client pulls manifests and blobs from OCI registries with the distribution
+// API. It answers the authentication challenges of the registries.
+type Client struct {
+	opts Options
+
+	mut    sync.Mutex
+	basic  bool              // the registry asked for basic authentication
+	tokens map[string]string // scope -> bearer token
+}
+
+// NewClient creates a new Client.
+func NewClient(opts Options) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &Client{
+		opts:   opts,
+		tokens: make(map[string]string),
+	}
+}
+
+// Resolve returns the digest of the manifest that ref points to.
+func (c *Client) Resolve(ctx context.Context, ref Reference) (digest.Digest, error) {
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	resp, err := c.do(ctx, http.MethodHead, ref, "manifests/"+ref.version(), manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if d, err := digest.Parse(resp.Header.Get("Docker-Content-Digest")); err == nil {
+		return d, nil
+	}
+
+	// The digest header is optional: compute the digest from the content instead.
+	_, raw, err := c.fetchManifest(ctx, ref, ref.version())
+	if err != nil {
+		return "", err
+	}
+	return digest.FromBytes(raw), nil
+}
+
+// FetchManifest fetches the image manifest with digest d from the repository of
+// ref. It returns the decoded manifest and its raw content, which matches d.
+func (c *Client) FetchManifest(ctx context.Context, ref Reference, d digest.Digest) (ocispec.Manifest, []byte, error) {
+	if err := d.Validate(); err != nil {
+		return ocispec.Manifest{}, nil, fmt.Errorf("invalid manifest digest %q: %w", d, err)
+	}
+	manifest, raw, err := c.fetchManifest(ctx, ref, d.String())
+	if err != nil {
+		return ocispec.Manifest{}, nil, err
+	}
+	if actual := d.Algorithm().FromBytes(raw); actual != d {
+		return ocispec.Manifest{}, nil, fmt.Errorf("manifest digest mismatch: expected %s, got %s", d, actual)
+	}
+	return manifest, raw, nil
+}
+
+func (c *Client) fetchManifest(ctx context.Context, ref Reference, version string) (ocispec.Manifest, []byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref, "manifests/"+version, manifestMediaTypes)
+	if err != nil {
+		return ocispec.Manifest{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := readLimited(resp.Body, maxManifestSize)
+	if err != nil {
+		return ocispec.Manifest{}, nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	manifest, err := ParseManifest(raw)
+	if err != nil {
+		return ocispec.Manifest{}, nil, err
+	}
+	return manifest, raw, nil
+}
+
+// ParseManifest decodes an image manifest. Image indexes are not supported.
+func ParseManifest(raw []byte) (ocispec.Manifest, error) {
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("decoding manifest: %w", err)
+	}
+	switch manifest.MediaType {
+	case "", ocispec.MediaTypeImageManifest, mediaTypeDockerManifest:
+		return manifest, nil
+	default:
+		return ocispec.Manifest{}, fmt.Errorf("unsupported manifest media type %q", manifest.MediaType)
+	}
+}
+
+// FetchBlob fetches the blob described by desc from the repository of ref.
+// The size and the digest of the blob are checked against desc.
+func (c *Client) FetchBlob(ctx context.Context, ref Reference, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Size > MaxBlobSize {
+		return nil, fmt.Errorf("blob %s is too large: %d bytes, the maximum is %d", desc.Digest, desc.Size, MaxBlobSize)
+	}
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid blob digest %q: %w", desc.Digest, err)
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, ref, "blobs/"+desc.Digest.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := readLimited(resp.Body, desc.Size)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob %s: %w", desc.Digest, err)
+	}
+	if err := VerifyBlob(desc, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// VerifyBlob checks that data matches the size and the digest of desc.
+func VerifyBlob(desc ocispec.Descriptor, data []byte) error {
+	if int64(len(data)) != desc.Size {
+		return fmt.Errorf("blob %s size mismatch: expected %d bytes, got %d", desc.Digest, desc.Size, len(data))
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(data); actual != desc.Digest {
+		return fmt.Errorf("blob digest mismatch: expected %s, got %s", desc.Digest, actual)
+	}
+	return nil
+}
+
+// do sends a request to the repository of ref. When the registry asks for
+// authentication, the request is sent again with credentials.
+func (c *Client) do(ctx context.Context, method string, ref Reference, path string, accept []string) (*http.Response, error) {
+	scheme := "https"
+	if c.opts.Insecure {
+		scheme = "http"
+	}
+	u := fmt.Sprintf("%s://%s/v2/%s/%s", scheme, ref.Registry, ref.Repository, path)
+	scope := fmt.Sprintf("repository:%s:pull", ref.Repository)
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		c.authorize(req, scope)
+
+		resp, err := c.opts.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if err := c.authenticate(ctx, challenge, scope); err != nil {
+				return nil, fmt.Errorf("authenticating to %s: %w", ref.Registry, err)
+			}
+		default:
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return nil, ResponseError{Method: method, URL: u, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+		}
+	}
+}
+
+// authorize adds the credentials known for scope to req.
+func (c *Client) authorize(req *http.Request, scope string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if token, ok := c.tokens[scope]; ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.basic && c.opts.Username != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+}
+
+// authenticate answers the challenge of a registry so that the next requests
+// for scope are authorized.
+func (c *Client) authenticate(ctx context.Context, challenge string, scope string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.opts.Username == "" {
+			return errors.New("the registry requires credentials")
+		}
+		c.mut.Lock()
+		c.basic = true
+		c.mut.Unlock()
+		return nil
+	case "bearer":
+		token, err := c.fetchToken(ctx, params, scope)
+		if err != nil {
+			return err
+		}
+		c.mut.Lock()
+		c.tokens[scope] = token
+		c.mut.Unlock()
+		return nil
+	default:
+		return fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+// fetchToken gets a bearer token for scope from the authorization server of a
+// registry.
+func (c *Client) fetchToken(ctx context.Context, params map[string]string, scope string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if s := params["scope"]; s != "" {
+		scope = s
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.opts.Username != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %s failed with status %d", realm.Host, resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", errors.New("the token response doesn't contain a token")
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, ", "), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			params[key] = value
+		}
+	}
+	return scheme, params
+}
+
+// readLimited reads r, failing if it holds more than limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("content is larger than %d bytes", limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,159 @@
+package oci_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+)
+
+func TestParseReference(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect oci.Reference
+	}{
+		{
+			input:  "registry.example.com:5000/modules/platform:v1.0.0",
+			expect: oci.Reference{Registry: "registry.example.com:5000", Repository: "modules/platform", Tag: "v1.0.0"},
+		},
+		{
+			input:  "registry.example.com/modules",
+			expect: oci.Reference{Registry: "registry.example.com", Repository: "modules", Tag: "latest"},
+		},
+		{
+			input: "registry.example.com/modules:v1@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			expect: oci.Reference{
+				Registry:   "registry.example.com",
+				Repository: "modules",
+				Digest:     "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			},
+		},
+		{
+			input:  "grafana/modules:v1",
+			expect: oci.Reference{Registry: "registry-1.docker.io", Repository: "grafana/modules", Tag: "v1"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			ref, err := oci.ParseReference(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, ref)
+		})
+	}
+
+	_, err := oci.ParseReference("Invalid Reference")
+	require.Error(t, err)
+}
+
+func TestClientPull(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.RequireAuth("user", "pass")
+	pushed := registry.PushModule("modules", "v1", map[string]string{"math.alloy": `declare "add" {}`})
+
+	ref, err := oci.ParseReference(registry.Host() + "/modules:v1")
+	require.NoError(t, err)
+
+	client := oci.NewClient(oci.Options{Username: "user", Password: "pass", Insecure: true})
+	d, err := client.Resolve(t.Context(), ref)
+	require.NoError(t, err)
+	require.Equal(t, pushed, d)
+
+	manifest, raw, err := client.FetchManifest(t.Context(), ref, d)
+	require.NoError(t, err)
+	require.Equal(t, d, digest.FromBytes(raw))
+
+	layer, err := oci.ModuleLayer(manifest)
+	require.NoError(t, err)
+	data, err := client.FetchBlob(t.Context(), ref, layer)
+	require.NoError(t, err)
+
+	files, err := oci.ReadBundle(data)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"math.alloy": []byte(`declare "add" {}`)}, files)
+}
+
+func TestClientErrors(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.RequireAuth("user", "pass")
+	pushed := registry.PushModule("modules", "v1", map[string]string{"math.alloy": `declare "add" {}`})
+
+	ref, err := oci.ParseReference(registry.Host() + "/modules:v1")
+	require.NoError(t, err)
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		client := oci.NewClient(oci.Options{Username: "user", Password: "wrong", Insecure: true})
+		_, err := client.Resolve(t.Context(), ref)
+		require.ErrorContains(t, err, "token request")
+	})
+
+	client := oci.NewClient(oci.Options{Username: "user", Password: "pass", Insecure: true})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		missing := ref
+		missing.Tag = "v2"
+		_, err := client.Resolve(t.Context(), missing)
+		require.ErrorIs(t, err, oci.ErrNotFound)
+	})
+
+	t.Run("blob digest mismatch", func(t *testing.T) {
+		manifest, _, err := client.FetchManifest(t.Context(), ref, pushed)
+		require.NoError(t, err)
+		layer := manifest.Layers[0]
+		require.ErrorContains(t, oci.VerifyBlob(layer, make([]byte, layer.Size)), "blob digest mismatch")
+		require.ErrorContains(t, oci.VerifyBlob(layer, nil), "size mismatch")
+	})
+}
+
+func TestVerifySignature(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	signed := registry.PushModule("modules", "v1", map[string]string{"math.alloy": `declare "add" {}`})
+	unsigned := registry.PushModule("modules", "v2", map[string]string{"math.alloy": `declare "sub" {}`})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	registry.Sign("modules", signed, key)
+
+	ref, err := oci.ParseReference(registry.Host() + "/modules:v1")
+	require.NoError(t, err)
+	client := oci.NewClient(oci.Options{Insecure: true})
+
+	publicKey, err := oci.ParsePublicKey(publicKeyPEM(t, &key.PublicKey))
+	require.NoError(t, err)
+	require.NoError(t, client.VerifySignature(t.Context(), ref, signed, publicKey))
+
+	err = client.VerifySignature(t.Context(), ref, unsigned, publicKey)
+	require.ErrorContains(t, err, "no signature found")
+
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	err = client.VerifySignature(t.Context(), ref, signed, otherPublicKey)
+	require.ErrorContains(t, err, "invalid signature")
+
+	_, err = oci.ParsePublicKey("not a key")
+	require.ErrorContains(t, err, "no PEM block found")
+}
+
+func TestReadBundleRejectsEscapingPaths(t *testing.T) {
+	_, err := oci.ReadBundle(ocitest.Bundle(t, map[string]string{"../main.alloy": ""}))
+	require.ErrorContains(t, err, `invalid path "../main.alloy"`)
+
+	files, err := oci.ReadBundle(ocitest.Bundle(t, map[string]string{"./lib/../main.alloy": "a"}))
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"main.alloy": []byte("a")}, files)
+}
+
+func publicKeyPEM(t *testing.T, key any) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
@@ -0,0 +1,250 @@
+// Package ocitest provides an in-memory OCI registry for tests.
+package ocitest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/oci"
+)
+
+var routeRegexp = regexp.MustCompile(`^/v2/(.+)/(manifests|blobs)/([^/]+)$`)
+
+// Registry is an in-memory registry serving the pull endpoints of the
+// distribution API. It can require clients to get a bearer token with basic
+// credentials first.
+type Registry struct {
+	t   testing.TB
+	srv *httptest.Server
+
+	mut         sync.Mutex
+	blobs       map[digest.Digest][]byte
+	manifests   map[string][]byte        // repository@digest -> manifest
+	tags        map[string]digest.Digest // repository:tag -> manifest digest
+	username    string
+	password    string
+	unavailable bool
+	requests    int
+}
+
+// NewRegistry starts a new Registry, which is stopped when the test ends.
+func NewRegistry(t testing.TB) *Registry {
+	r := &Registry{
+		t:         t,
+		blobs:     make(map[digest.Digest][]byte),
+		manifests: make(map[string][]byte),
+		tags:      make(map[string]digest.Digest),
+	}
+	r.srv = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+// Host returns the host and port of the registry, to use in references.
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.srv.URL, "http://")
+}
+
+// RequireAuth makes the registry reject the requests without a bearer token.
+// Tokens are given for the provided credentials.
+func (r *Registry) RequireAuth(username, password string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.username, r.password = username, password
+}
+
+// SetUnavailable makes the registry answer all the requests with an error.
+func (r *Registry) SetUnavailable(unavailable bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.unavailable = unavailable
+}
+
+// Requests returns the number of requests served by the registry.
+func (r *Registry) Requests() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.requests
+}
+
+// PushBlob stores data and returns its descriptor.
+func (r *Registry) PushBlob(mediaType string, data []byte) ocispec.Descriptor {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	d := digest.FromBytes(data)
+	r.blobs[d] = data
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data))}
+}
+
+// PushManifest stores manifest in repository and tags it with tag if it's not
+// empty. It returns the digest of the manifest.
+func (r *Registry) PushManifest(repository string, tag string, manifest ocispec.Manifest) digest.Digest {
+	raw, err := json.Marshal(manifest)
+	require.NoError(r.t, err)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	d := digest.FromBytes(raw)
+	r.manifests[repository+"@"+d.String()] = raw
+	if tag != "" {
+		r.tags[repository+":"+tag] = d
+	}
+	return d
+}
+
+// PushModule pushes a module bundle holding files to repository with tag. It
+// returns the digest of the manifest.
+func (r *Registry) PushModule(repository string, tag string, files map[string]string) digest.Digest {
+	config := r.PushBlob(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layer := r.PushBlob(oci.MediaTypeModuleLayer, Bundle(r.t, files))
+	return r.PushManifest(repository, tag, ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: oci.ArtifactTypeModule,
+		Config:       config,
+		Layers:       []ocispec.Descriptor{layer},
+	})
+}
+
+// Sign pushes a signature of the manifest with digest d made with key, in the
+// same format as `cosign sign --key`.
+func (r *Registry) Sign(repository string, d digest.Digest, key crypto.Signer) {
+	var payload oci.SignaturePayload
+	payload.Critical.Identity.DockerReference = r.Host() + "/" + repository
+	payload.Critical.Image.DockerManifestDigest = d.String()
+	payload.Critical.Type = "cosign container image signature"
+	rawPayload, err := json.Marshal(payload)
+	require.NoError(r.t, err)
+
+	var sig []byte
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		sig, err = key.Sign(rand.Reader, rawPayload, crypto.Hash(0))
+	} else {
+		hash := sha256.Sum256(rawPayload)
+		sig, err = key.Sign(rand.Reader, hash[:], crypto.SHA256)
+	}
+	require.NoError(r.t, err)
+
+	config := r.PushBlob(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	layer := r.PushBlob(oci.MediaTypeSignaturePayload, rawPayload)
+	layer.Annotations = map[string]string{oci.SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)}
+	r.PushManifest(repository, oci.SignatureTag(d), ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+}
+
+// Bundle returns a gzip compressed tarball holding files.
+func Bundle(t testing.TB, files map[string]string) []byte {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(files[name])),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.requests++
+
+	if r.unavailable {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if req.URL.Path == "/token" {
+		if user, pass, ok := req.BasicAuth(); !ok || user != r.username || pass != r.password {
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": r.token()})
+		return
+	}
+
+	match := routeRegexp.FindStringSubmatch(req.URL.Path)
+	if match == nil {
+		http.NotFound(w, req)
+		return
+	}
+	repository, kind, version := match[1], match[2], match[3]
+
+	if r.username != "" && req.Header.Get("Authorization") != "Bearer "+r.token() {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+			`Bearer realm="%s/token",service="ocitest",scope="repository:%s:pull"`, r.srv.URL, repository,
+		))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var (
+		content   []byte
+		mediaType = "application/octet-stream"
+	)
+	switch kind {
+	case "manifests":
+		d, err := digest.Parse(version)
+		if err != nil {
+			d = r.tags[repository+":"+version]
+		}
+		content = r.manifests[repository+"@"+d.String()]
+		mediaType = ocispec.MediaTypeImageManifest
+	case "blobs":
+		content = r.blobs[digest.Digest(version)]
+	}
+	if content == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", digest.FromBytes(content).String())
+	if req.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+// token returns the token given for the credentials of the registry.
+func (r *Registry) token() string {
+	return base64.StdEncoding.EncodeToString([]byte(r.username + ":" + r.password + ":token"))
+}
@@ -0,0 +1,72 @@
+// Package oci implements a minimal client for OCI registries, used to pull
+// module bundles.
+package oci
+
+import (
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// dockerHubDomain is the domain of Docker Hub in normalized references.
+	dockerHubDomain = "docker.io"
+	// dockerHubRegistry is the host serving the registry API of Docker Hub.
+	dockerHubRegistry = "registry-1.docker.io"
+	// defaultTag is the tag used for references without a tag or a digest.
+	defaultTag = "latest"
+)
+
+// Reference points to an artifact in an OCI registry.
+type Reference struct {
+	Registry   string        // Host of the registry, with an optional port.
+	Repository string        // Name of the repository in the registry.
+	Tag        string        // Tag of the artifact, empty if the reference is pinned by digest.
+	Digest     digest.Digest // Digest of the manifest of the artifact, empty if the reference uses a tag.
+}
+
+// ParseReference parses a reference such as registry.example.com/modules:v1.0.0
+// or registry.example.com/modules@sha256:<digest>. The tag defaults to "latest"
+// when the reference has neither a tag nor a digest.
+func ParseReference(s string) (Reference, error) {
+	named, err := reference.ParseNormalizedNamed(s)
+	if err != nil {
+		return Reference{}, fmt.Errorf("invalid reference %q: %w", s, err)
+	}
+
+	ref := Reference{
+		Registry:   reference.Domain(named),
+		Repository: reference.Path(named),
+	}
+	if ref.Registry == dockerHubDomain {
+		ref.Registry = dockerHubRegistry
+	}
+
+	if digested, ok := named.(reference.Digested); ok {
+		ref.Digest = digested.Digest()
+	}
+	if tagged, ok := named.(reference.Tagged); ok && ref.Digest == "" {
+		ref.Tag = tagged.Tag()
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// String returns the reference in its canonical form.
+func (r Reference) String() string {
+	if r.Digest != "" {
+		return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, r.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// version returns the tag or the digest to use in the API paths.
+func (r Reference) version() string {
+	if r.Digest != "" {
+		return r.Digest.String()
+	}
+	return r.Tag
+}
@@ -0,0 +1,140 @@
+package oci
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// SignatureAnnotation is the annotation of the signature layers which holds
+	// the base64 encoded signature of the layer content.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// MediaTypeSignaturePayload is the media type of the signed payloads.
+	MediaTypeSignaturePayload = "application/vnd.dev.cosign.simplesigning.v1+json"
+)
+
+// SignaturePayload is the content signed for a manifest, in the simple signing
+// format used by cosign.
+type SignaturePayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// SignatureTag returns the tag which holds the signatures of the manifest with
+// digest d, following the cosign convention.
+func SignatureTag(d digest.Digest) string {
+	return fmt.Sprintf("%s-%s.sig", d.Algorithm(), d.Encoded())
+}
+
+// ParsePublicKey parses a PEM encoded ECDSA, Ed25519 or RSA public key.
+func ParsePublicKey(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("no PEM block found in the public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// VerifySignature checks that the repository of ref holds a signature of the
+// manifest with digest d made with key. The signatures are looked up in the
+// tag returned by SignatureTag, as pushed by `cosign sign --key`. The
+// transparency log isn't checked.
+func (c *Client) VerifySignature(ctx context.Context, ref Reference, d digest.Digest, key crypto.PublicKey) error {
+	sigRef := Reference{Registry: ref.Registry, Repository: ref.Repository, Tag: SignatureTag(d)}
+	sigDigest, err := c.Resolve(ctx, sigRef)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("no signature found for %s", d)
+	} else if err != nil {
+		return fmt.Errorf("resolving signatures: %w", err)
+	}
+	manifest, _, err := c.FetchManifest(ctx, sigRef, sigDigest)
+	if err != nil {
+		return fmt.Errorf("fetching signatures: %w", err)
+	}
+
+	var errs []error
+	for _, layer := range manifest.Layers {
+		sig, ok := layer.Annotations[SignatureAnnotation]
+		if !ok || layer.MediaType != MediaTypeSignaturePayload {
+			continue
+		}
+		payload, err := c.FetchBlob(ctx, sigRef, layer)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := VerifyPayload(key, payload, sig, d); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no signature found for %s", d)
+	}
+	return fmt.Errorf("no valid signature found for %s: %w", d, errors.Join(errs...))
+}
+
+// VerifyPayload checks that sig is a base64 encoded signature of payload made
+// with key, and that payload refers to the manifest with digest d.
+func VerifyPayload(key crypto.PublicKey, payload []byte, sig string, d digest.Digest) error {
+	rawSig, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	hash := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, hash[:], rawSig) {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, payload, rawSig) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], rawSig); err != nil {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	var p SignaturePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("decoding signature payload: %w", err)
+	}
+	if p.Critical.Image.DockerManifestDigest != d.String() {
+		return fmt.Errorf("the signature is for the manifest %s", p.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+// Package plan computes the changes a new configuration would make to the
+// components of a running configuration, without evaluating or applying it.
+package plan
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/tracing"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/printer"
+)
+
+// Plan describes the differences between two configurations.
+type Plan struct {
+	// Added and Removed are the IDs of the components which only exist in
+	// the new or the old configuration.
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	// Changed are the components whose arguments differ.
+	Changed []ComponentDiff `json:"changed"`
+
+	// AddedEdges and RemovedEdges are the references between components
+	// which only exist in the new or the old configuration.
+	AddedEdges   []Edge `json:"added_edges"`
+	RemovedEdges []Edge `json:"removed_edges"`
+
+	// Modules are the declare and import blocks which were added, removed or
+	// changed.
+	Modules []ModuleDiff `json:"modules"`
+}
+
+// Empty returns true if the plan has no changes.
+func (p *Plan) Empty() bool {
+	return len(p.Added) == 0 && len(p.Removed) == 0 && len(p.Changed) == 0 &&
+		len(p.AddedEdges) == 0 && len(p.RemovedEdges) == 0 && len(p.Modules) == 0
+}
+
+// ComponentDiff holds the changed arguments of a component.
+type ComponentDiff struct {
+	ID      string   `json:"id"`
+	Changes []Change `json:"changes"`
+}
+
+// Change is an argument which was added, removed or changed. Old is empty if
+// the argument was added and New is empty if the argument was removed. The
+// values of sensitive arguments are masked.
+type Change struct {
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// Edge is a reference from the arguments of the component From to the
+// exports of the component To.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Module change types.
+const (
+	ModuleAdded   = "added"
+	ModuleRemoved = "removed"
+	ModuleChanged = "changed"
+)
+
+// ModuleDiff describes a change to a declare or import block.
+type ModuleDiff struct {
+	ID     string `json:"id"`
+	Change string `json:"change"`
+	// Instances are the IDs of the components which use the module.
+	Instances []string `json:"instances"`
+	// Plan holds the changes to the body of a declare block.
+	Plan *Plan `json:"plan,omitempty"`
+}
+
+// secretMask replaces the values of sensitive arguments.
+const secretMask = "(secret)"
+
+// configTypes are the types of the config blocks which are built into the
+// runtime. The types of the config blocks of services are looked up in the
+// registry passed to Compute.
+var configTypes = map[string]any{
+	"logging": logging.Options{},
+	"tracing": tracing.Options{},
+}
+
+// Compute returns the changes between the old and new configuration files.
+// The files of each configuration are merged like they are when Alloy loads
+// a directory. reg is used to find which arguments are sensitive.
+func Compute(oldFiles, newFiles map[string]*ast.File, reg component.Registry) *Plan {
+	return computeBody(mergeFiles(oldFiles), mergeFiles(newFiles), reg)
+}
+
+// mergeFiles concatenates the bodies of files in the order of their names.
+func mergeFiles(files map[string]*ast.File) ast.Body {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var body ast.Body
+	for _, name := range names {
+		if f := files[name]; f != nil {
+			body = append(body, f.Body...)
+		}
+	}
+	return body
+}
+
+func computeBody(oldBody, newBody ast.Body, reg component.Registry) *Plan {
+	var (
+		p = &Plan{
+			Added:        []string{},
+			Removed:      []string{},
+			Changed:      []ComponentDiff{},
+			AddedEdges:   []Edge{},
+			RemovedEdges: []Edge{},
+			Modules:      []ModuleDiff{},
+		}
+
+		oldBlocks = blocksByID(oldBody)
+		newBlocks = blocksByID(newBody)
+	)
+
+	for _, id := range sortedKeys(newBlocks) {
+		nb := newBlocks[id]
+		ob, ok := oldBlocks[id]
+		switch {
+		case isModule(nb):
+			continue
+		case !ok:
+			p.Added = append(p.Added, id)
+		default:
+			if changes := diffBlock(ob, nb, reg); len(changes) > 0 {
+				p.Changed = append(p.Changed, ComponentDiff{ID: id, Changes: changes})
+			}
+		}
+	}
+	for _, id := range sortedKeys(oldBlocks) {
+		if _, ok := newBlocks[id]; !ok && !isModule(oldBlocks[id]) {
+			p.Removed = append(p.Removed, id)
+		}
+	}
+
+	oldEdges, newEdges := edges(oldBlocks), edges(newBlocks)
+	p.AddedEdges = subtractEdges(newEdges, oldEdges)
+	p.RemovedEdges = subtractEdges(oldEdges, newEdges)
+
+	p.Modules = diffModules(oldBlocks, newBlocks, reg)
+	return p
+}
+
+// blockID returns the ID of a block, such as prometheus.scrape.default.
+func blockID(b *ast.BlockStmt) string {
+	id := b.GetBlockName()
+	if b.Label != "" {
+		id += "." + b.Label
+	}
+	return id
+}
+
+func blocksByID(body ast.Body) map[string]*ast.BlockStmt {
+	blocks := make(map[string]*ast.BlockStmt)
+	for _, stmt := range body {
+		if b, ok := stmt.(*ast.BlockStmt); ok {
+			blocks[blockID(b)] = b
+		}
+	}
+	return blocks
+}
+
+// isModule returns true if b defines a module rather than a component.
+func isModule(b *ast.BlockStmt) bool {
+	name := b.GetBlockName()
+	return name == "declare" || strings.HasPrefix(name, "import.")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffBlock returns the changed arguments of a block.
+func diffBlock(ob, nb *ast.BlockStmt, reg component.Registry) []Change {
+	var t reflect.Type
+	if reg != nil {
+		if r, err := reg.Get(nb.GetBlockName()); err == nil && r.Args != nil {
+			t = reflect.TypeOf(r.Args)
+		}
+	}
+	if args, ok := configTypes[nb.GetBlockName()]; ok && t == nil {
+		t = reflect.TypeOf(args)
+	}
+
+	oldValues, newValues := map[string]argValue{}, map[string]argValue{}
+	flatten(oldValues, "", ob.Body, t)
+	flatten(newValues, "", nb.Body, t)
+
+	changes := []Change{}
+	for _, path := range sortedKeys(newValues) {
+		nv := newValues[path]
+		ov, ok := oldValues[path]
+		if ok && ov.raw == nv.raw {
+			continue
+		}
+		// An argument which held a secret in the running configuration is
+		// masked in the new one too, as the new one hasn't been evaluated.
+		if ov.secret {
+			nv.secret = true
+		}
+		changes = append(changes, Change{Path: path, Old: ov.String(), New: nv.String()})
+	}
+	for _, path := range sortedKeys(oldValues) {
+		if _, ok := newValues[path]; !ok {
+			changes = append(changes, Change{Path: path, Old: oldValues[path].String()})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b Change) int { return strings.Compare(a.Path, b.Path) })
+	return changes
+}
+
+// argValue is the printed value of an argument. raw is compared between
+// configurations, while redacted has the secrets marked by the evaluation of
+// a running configuration redacted.
+type argValue struct {
+	raw      string
+	redacted string
+	secret   bool
+}
+
+func (v argValue) String() string {
+	if v.secret && v.raw != "" {
+		return secretMask
+	}
+	return v.redacted
+}
+
+// flatten stores the printed values of the attributes of body by their path,
+// such as endpoint[0].url. Empty blocks are stored as {}. t is the Go type
+// body decodes into, if known.
+//
+// Attributes are masked if their type is sensitive, if their value was marked
+// as a secret when it was evaluated, or if their type is unknown and their
+// value holds literals. The type of config blocks, import blocks and custom
+// components is unknown.
+func flatten(values map[string]argValue, prefix string, body ast.Body, t reflect.Type) {
+	counts := make(map[string]int)
+
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			name := stmt.Name.Name
+			v := argValue{
+				raw:      printExpr(stmt.Value, false),
+				redacted: printExpr(stmt.Value, true),
+			}
+			if ft, ok := fieldType(t, name); ok {
+				v.secret = isSecretType(ft)
+			} else {
+				v.secret = hasLiterals(stmt.Value)
+			}
+			if v.redacted != v.raw {
+				v.secret = true
+			}
+			values[prefix+name] = v
+
+		case *ast.BlockStmt:
+			name := stmt.GetBlockName()
+			if stmt.Label != "" {
+				name += "." + stmt.Label
+			}
+			path := prefix + name + "[" + strconv.Itoa(counts[name]) + "]"
+			counts[name]++
+
+			if len(stmt.Body) == 0 {
+				values[path] = argValue{raw: "{}", redacted: "{}"}
+				continue
+			}
+			ft, _ := fieldType(t, stmt.GetBlockName())
+			flatten(values, path+".", stmt.Body, ft)
+		}
+	}
+}
+
+func printExpr(e ast.Expr, redactSecrets bool) string {
+	var buf bytes.Buffer
+	cfg := printer.Config{RedactSecrets: redactSecrets}
+	if err := cfg.Fprint(&buf, e); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+// hasLiterals returns true if e holds a literal value. Expressions which only
+// refer to other values print none of the configuration's data.
+func hasLiterals(e ast.Expr) bool {
+	var found bool
+	ast.Walk(visitFunc(func(n ast.Node) bool {
+		if _, ok := n.(*ast.LiteralExpr); ok {
+			found = true
+		}
+		return !found
+	}), e)
+	return found
+}
+
+// edges returns the references between blocks. A reference is an expression
+// such as prometheus.remote_write.default.receiver, which refers to the
+// block with the longest matching ID.
+func edges(blocks map[string]*ast.BlockStmt) []Edge {
+	var res []Edge
+	for _, from := range sortedKeys(blocks) {
+		seen := make(map[string]struct{})
+		for _, ref := range references(blocks[from].Body) {
+			to, ok := resolve(ref, blocks)
+			if !ok || to == from {
+				continue
+			}
+			if _, ok := seen[to]; ok {
+				continue
+			}
+			seen[to] = struct{}{}
+			res = append(res, Edge{From: from, To: to})
+		}
+	}
+	slices.SortFunc(res, compareEdges)
+	return res
+}
+
+func compareEdges(a, b Edge) int {
+	if c := strings.Compare(a.From, b.From); c != 0 {
+		return c
+	}
+	return strings.Compare(a.To, b.To)
+}
+
+// resolve returns the ID of the block referenced by the parts of ref.
+func resolve(ref []string, blocks map[string]*ast.BlockStmt) (string, bool) {
+	for i := len(ref); i > 0; i-- {
+		id := strings.Join(ref[:i], ".")
+		if _, ok := blocks[id]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// subtractEdges returns the edges of a which aren't in b.
+func subtractEdges(a, b []Edge) []Edge {
+	res := []Edge{}
+	for _, e := range a {
+		if !slices.Contains(b, e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+type visitFunc func(ast.Node) bool
+
+func (f visitFunc) Visit(n ast.Node) ast.Visitor {
+	if n != nil && f(n) {
+		return f
+	}
+	return nil
+}
+
+// references returns the names of the identifiers and field accesses of
+// body, such as [prometheus remote_write default receiver].
+func references(body ast.Body) [][]string {
+	var refs [][]string
+	ast.Walk(visitFunc(func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IdentifierExpr:
+			refs = append(refs, []string{n.Ident.Name})
+			return false
+		case *ast.AccessExpr:
+			if ref, ok := accessPath(n); ok {
+				refs = append(refs, ref)
+				return false
+			}
+		}
+		return true
+	}), body)
+	return refs
+}
+
+func accessPath(e ast.Expr) ([]string, bool) {
+	switch e := e.(type) {
+	case *ast.IdentifierExpr:
+		return []string{e.Ident.Name}, true
+	case *ast.AccessExpr:
+		path, ok := accessPath(e.Value)
+		if !ok {
+			return nil, false
+		}
+		return append(path, e.Name.Name), true
+	}
+	return nil, false
+}
+
+// diffModules returns the declare and import blocks which were added, removed
+// or changed, along with the components which use them.
+func diffModules(oldBlocks, newBlocks map[string]*ast.BlockStmt, reg component.Registry) []ModuleDiff {
+	res := []ModuleDiff{}
+
+	for _, id := range sortedKeys(newBlocks) {
+		nb := newBlocks[id]
+		if !isModule(nb) {
+			continue
+		}
+
+		ob, ok := oldBlocks[id]
+		switch {
+		case !ok:
+			res = append(res, ModuleDiff{ID: id, Change: ModuleAdded, Instances: instances(nb, newBlocks)})
+		case nb.GetBlockName() == "declare":
+			if p := computeBody(ob.Body, nb.Body, reg); !p.Empty() {
+				res = append(res, ModuleDiff{ID: id, Change: ModuleChanged, Instances: instances(nb, newBlocks), Plan: p})
+			}
+		default:
+			if len(diffBlock(ob, nb, reg)) > 0 {
+				res = append(res, ModuleDiff{ID: id, Change: ModuleChanged, Instances: instances(nb, newBlocks)})
+			}
+		}
+	}
+
+	for _, id := range sortedKeys(oldBlocks) {
+		ob := oldBlocks[id]
+		if _, ok := newBlocks[id]; !ok && isModule(ob) {
+			res = append(res, ModuleDiff{ID: id, Change: ModuleRemoved, Instances: instances(ob, oldBlocks)})
+		}
+	}
+
+	slices.SortStableFunc(res, func(a, b ModuleDiff) int { return strings.Compare(a.ID, b.ID) })
+	return res
+}
+
+// instances returns the IDs of the blocks which are instances of the custom
+// components defined by the module block m.
+func instances(m *ast.BlockStmt, blocks map[string]*ast.BlockStmt) []string {
+	res := []string{}
+	for _, id := range sortedKeys(blocks) {
+		b := blocks[id]
+		if isModule(b) {
+			continue
+		}
+
+		name := b.GetBlockName()
+		switch {
+		case m.GetBlockName() == "declare" && name == m.Label:
+			res = append(res, id)
+		case m.GetBlockName() != "declare" && strings.HasPrefix(name, m.Label+"."):
+			res = append(res, id)
+		}
+	}
+	return res
+}
@@ -0,0 +1,295 @@
+package plan_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/plan"
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+)
+
+type writerArgs struct {
+	URL      string            `alloy:"url,attr"`
+	Password alloytypes.Secret `alloy:"password,attr,optional"`
+	Auth     *authBlock        `alloy:"auth,block,optional"`
+}
+
+type authBlock struct {
+	Token alloytypes.Secret `alloy:"token,attr"`
+}
+
+var registry = component.NewRegistryMap(featuregate.StabilityGenerallyAvailable, false, map[string]component.Registration{
+	"test.writer": {
+		Name:      "test.writer",
+		Stability: featuregate.StabilityGenerallyAvailable,
+		Args:      writerArgs{},
+	},
+})
+
+func parse(t *testing.T, name string, src string) map[string]*ast.File {
+	t.Helper()
+	f, err := parser.ParseFile(name, []byte(src))
+	require.NoError(t, err)
+	return map[string]*ast.File{name: f}
+}
+
+func TestCompute(t *testing.T) {
+	oldFiles := parse(t, "old.alloy", `
+		test.source "a" {
+			forward_to = [test.writer.a.receiver]
+		}
+
+		test.source "removed" {
+			forward_to = []
+		}
+
+		test.writer "a" {
+			url      = "http://old"
+			password = "old-password"
+
+			auth {
+				token = "old-token"
+			}
+		}
+
+		declare "pipeline" {
+			test.writer "inner" {
+				url = "http://old"
+			}
+		}
+
+		pipeline "default" { }
+
+		import.file "lib" {
+			filename = "lib.alloy"
+		}
+	`)
+
+	newFiles := parse(t, "new.alloy", `
+		test.source "a" {
+			forward_to = [test.writer.b.receiver]
+		}
+
+		test.source "added" {
+			forward_to = [test.writer.a.receiver]
+		}
+
+		test.writer "a" {
+			url      = "http://new"
+			password = "new-password"
+
+			auth {
+				token = "old-token"
+			}
+		}
+
+		test.writer "b" {
+			url = "http://b"
+		}
+
+		declare "pipeline" {
+			test.writer "inner" {
+				url = "http://new"
+			}
+		}
+
+		pipeline "default" { }
+
+		import.file "lib" {
+			filename = "lib.alloy"
+		}
+	`)
+
+	p := plan.Compute(oldFiles, newFiles, registry)
+
+	require.Equal(t, []string{"test.source.added", "test.writer.b"}, p.Added)
+	require.Equal(t, []string{"test.source.removed"}, p.Removed)
+	require.Equal(t, []plan.ComponentDiff{
+		{ID: "test.source.a", Changes: []plan.Change{
+			{Path: "forward_to", Old: "[test.writer.a.receiver]", New: "[test.writer.b.receiver]"},
+		}},
+		{ID: "test.writer.a", Changes: []plan.Change{
+			{Path: "password", Old: "(secret)", New: "(secret)"},
+			{Path: "url", Old: `"http://old"`, New: `"http://new"`},
+		}},
+	}, p.Changed)
+	require.Equal(t, []plan.Edge{
+		{From: "test.source.a", To: "test.writer.b"},
+		{From: "test.source.added", To: "test.writer.a"},
+	}, p.AddedEdges)
+	require.Equal(t, []plan.Edge{{From: "test.source.a", To: "test.writer.a"}}, p.RemovedEdges)
+
+	require.Len(t, p.Modules, 1)
+	require.Equal(t, "declare.pipeline", p.Modules[0].ID)
+	require.Equal(t, plan.ModuleChanged, p.Modules[0].Change)
+	require.Equal(t, []string{"pipeline.default"}, p.Modules[0].Instances)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteText(&buf))
+	require.Equal(t, `Components:
+  + test.source.added
+  + test.writer.b
+  - test.source.removed
+  ~ test.source.a
+      ~ forward_to = [test.writer.a.receiver] -> [test.writer.b.receiver]
+  ~ test.writer.a
+      ~ password = (secret) -> (secret)
+      ~ url = "http://old" -> "http://new"
+References:
+  + test.source.a -> test.writer.b
+  + test.source.added -> test.writer.a
+  - test.source.a -> test.writer.a
+Modules:
+  ~ declare.pipeline (used by pipeline.default)
+      Components:
+        ~ test.writer.inner
+            ~ url = "http://old" -> "http://new"
+
+2 to add, 2 to change, 1 to remove.
+`, buf.String())
+}
+
+func TestComputeModules(t *testing.T) {
+	oldFiles := parse(t, "old.alloy", `
+		import.git "lib" {
+			repository = "https://example.com/lib.git"
+			revision   = "v1"
+			path       = "lib.alloy"
+		}
+
+		import.file "removed" {
+			filename = "removed.alloy"
+		}
+
+		lib.pipeline "default" { }
+	`)
+	newFiles := parse(t, "new.alloy", `
+		import.git "lib" {
+			repository = "https://example.com/lib.git"
+			revision   = "v2"
+			path       = "lib.alloy"
+		}
+
+		declare "added" { }
+
+		lib.pipeline "default" { }
+		added "default" { }
+	`)
+
+	p := plan.Compute(oldFiles, newFiles, registry)
+	require.Equal(t, []plan.ModuleDiff{
+		{ID: "declare.added", Change: plan.ModuleAdded, Instances: []string{"added.default"}},
+		{ID: "import.file.removed", Change: plan.ModuleRemoved, Instances: []string{}},
+		{ID: "import.git.lib", Change: plan.ModuleChanged, Instances: []string{"lib.pipeline.default"}},
+	}, p.Modules)
+	require.Equal(t, []string{"added.default"}, p.Added)
+}
+
+func TestComputeSecretsInBlocks(t *testing.T) {
+	oldFiles := parse(t, "old.alloy", `
+		test.writer "a" {
+			url = "http://a"
+
+			auth {
+				token = "old-token"
+			}
+		}
+	`)
+	newFiles := parse(t, "new.alloy", `
+		test.writer "a" {
+			url = "http://a"
+
+			auth {
+				token = "new-token"
+			}
+			auth { }
+		}
+	`)
+
+	p := plan.Compute(oldFiles, newFiles, registry)
+	require.Equal(t, []plan.ComponentDiff{
+		{ID: "test.writer.a", Changes: []plan.Change{
+			{Path: "auth[0].token", Old: "(secret)", New: "(secret)"},
+			{Path: "auth[1]", New: "{}"},
+		}},
+	}, p.Changed)
+}
+
+func TestComputeNoChanges(t *testing.T) {
+	src := `
+		test.writer "a" {
+			url = "http://a"
+		}
+	`
+	p := plan.Compute(parse(t, "old.alloy", src), parse(t, "new.alloy", src), registry)
+	require.True(t, p.Empty())
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteText(&buf))
+	require.Equal(t, "No changes.\n", buf.String())
+}
+
+func TestComputeSecretsOfUnknownTypes(t *testing.T) {
+	oldFiles := parse(t, "old.alloy", `
+		remotecfg {
+			url = "http://remotecfg"
+
+			basic_auth {
+				username = "user"
+				password = "oldpass"
+			}
+		}
+
+		pipeline "default" {
+			token      = "old-token"
+			forward_to = [test.writer.a.receiver]
+		}
+
+		test.writer "a" {
+			url = "http://old"
+		}
+	`)
+	newFiles := parse(t, "new.alloy", `
+		remotecfg {
+			url = "http://remotecfg"
+
+			basic_auth {
+				username = "user"
+				password = "newpass"
+			}
+		}
+
+		pipeline "default" {
+			token      = "new-token"
+			forward_to = [test.writer.b.receiver]
+		}
+
+		test.writer "a" {
+			url = "http://new"
+		}
+	`)
+
+	// The running configuration marks the values it decoded into secrets.
+	url := oldFiles["old.alloy"].Body[2].(*ast.BlockStmt).Body[0].(*ast.AttributeStmt)
+	url.Value.(*ast.LiteralExpr).Secret = true
+
+	p := plan.Compute(oldFiles, newFiles, registry)
+	require.Equal(t, []plan.ComponentDiff{
+		{ID: "pipeline.default", Changes: []plan.Change{
+			{Path: "forward_to", Old: "[test.writer.a.receiver]", New: "[test.writer.b.receiver]"},
+			{Path: "token", Old: "(secret)", New: "(secret)"},
+		}},
+		{ID: "remotecfg", Changes: []plan.Change{
+			{Path: "basic_auth[0].password", Old: "(secret)", New: "(secret)"},
+		}},
+		{ID: "test.writer.a", Changes: []plan.Change{
+			{Path: "url", Old: "(secret)", New: "(secret)"},
+		}},
+	}, p.Changed)
+}
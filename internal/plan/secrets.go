@@ -0,0 +1,96 @@
+package plan
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+var (
+	secretType         = reflect.TypeOf(alloytypes.Secret(""))
+	optionalSecretType = reflect.TypeOf(alloytypes.OptionalSecret{})
+)
+
+// isSecretType returns true if values of t hold sensitive strings.
+func isSecretType(t reflect.Type) bool {
+	t = derefType(t)
+	switch {
+	case t == nil:
+		return false
+	case t == secretType, t == optionalSecretType:
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return isSecretType(t.Elem())
+	}
+	return false
+}
+
+// fieldType returns the type of the attribute or block called name of the
+// struct type t, using its alloy struct tags. Squashed fields are searched
+// and enum blocks are found by their full name, for example stage.logfmt.
+func fieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	t = derefType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("alloy")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		tagName, flags, _ := strings.Cut(tag, ",")
+		flagSet := strings.Split(flags, ",")
+		switch {
+		case contains(flagSet, "squash"):
+			if ft, ok := fieldType(sf.Type, name); ok {
+				return ft, true
+			}
+		case contains(flagSet, "enum"):
+			if inner, ok := strings.CutPrefix(name, tagName+"."); ok {
+				if ft, ok := fieldType(elemType(sf.Type), inner); ok {
+					return ft, true
+				}
+			}
+		case contains(flagSet, "block"):
+			if tagName == name {
+				return elemType(sf.Type), true
+			}
+		case tagName == name:
+			return sf.Type, true
+		}
+	}
+	return nil, false
+}
+
+// elemType returns the element type of a block field, which may be a
+// pointer, slice or array of structs.
+func elemType(t reflect.Type) reflect.Type {
+	t = derefType(t)
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = derefType(t.Elem())
+	}
+	return t
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func contains(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText writes a human-readable description of the plan to w. Added
+// items are prefixed with +, removed items with - and changed items with ~.
+func (p *Plan) WriteText(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+
+	tw := &textWriter{w: w}
+	p.write(tw, "")
+	if tw.err != nil {
+		return tw.err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d to add, %d to change, %d to remove.\n", len(p.Added), len(p.Changed), len(p.Removed))
+	return err
+}
+
+type textWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (tw *textWriter) printf(format string, args ...any) {
+	if tw.err == nil {
+		_, tw.err = fmt.Fprintf(tw.w, format, args...)
+	}
+}
+
+func (p *Plan) write(tw *textWriter, indent string) {
+	if len(p.Added)+len(p.Removed)+len(p.Changed) > 0 {
+		tw.printf("%sComponents:\n", indent)
+		for _, id := range p.Added {
+			tw.printf("%s  + %s\n", indent, id)
+		}
+		for _, id := range p.Removed {
+			tw.printf("%s  - %s\n", indent, id)
+		}
+		for _, c := range p.Changed {
+			tw.printf("%s  ~ %s\n", indent, c.ID)
+			for _, ch := range c.Changes {
+				switch {
+				case ch.Old == "":
+					tw.printf("%s      + %s = %s\n", indent, ch.Path, indentValue(ch.New, indent))
+				case ch.New == "":
+					tw.printf("%s      - %s = %s\n", indent, ch.Path, indentValue(ch.Old, indent))
+				default:
+					tw.printf("%s      ~ %s = %s -> %s\n", indent, ch.Path, indentValue(ch.Old, indent), indentValue(ch.New, indent))
+				}
+			}
+		}
+	}
+
+	if len(p.AddedEdges)+len(p.RemovedEdges) > 0 {
+		tw.printf("%sReferences:\n", indent)
+		for _, e := range p.AddedEdges {
+			tw.printf("%s  + %s -> %s\n", indent, e.From, e.To)
+		}
+		for _, e := range p.RemovedEdges {
+			tw.printf("%s  - %s -> %s\n", indent, e.From, e.To)
+		}
+	}
+
+	if len(p.Modules) > 0 {
+		tw.printf("%sModules:\n", indent)
+		for _, m := range p.Modules {
+			var prefix string
+			switch m.Change {
+			case ModuleAdded:
+				prefix = "+"
+			case ModuleRemoved:
+				prefix = "-"
+			default:
+				prefix = "~"
+			}
+
+			tw.printf("%s  %s %s", indent, prefix, m.ID)
+			if len(m.Instances) > 0 {
+				tw.printf(" (used by %s)", strings.Join(m.Instances, ", "))
+			}
+			tw.printf("\n")
+
+			if m.Plan != nil {
+				m.Plan.write(tw, indent+"      ")
+			}
+		}
+	}
+}
+
+// indentValue indents the lines of multiline values.
+func indentValue(v string, indent string) string {
+	return strings.ReplaceAll(v, "\n", "\n"+indent+"        ")
+}
@@ -61,6 +61,7 @@ import (
 	"github.com/grafana/alloy/internal/runtime/internal/worker"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/internal/util"
@@ -114,6 +115,29 @@ type Options struct {
 
 	// EnableCommunityComps enables the use of community components.
 	EnableCommunityComps bool
+
+	// ComponentRegistry is where the controller looks up components. A
+	// default registry which respects MinStability and EnableCommunityComps is
+	// used if this is nil.
+	ComponentRegistry component.Registry
+
+	// RollbackSettlePeriod enables automatic rollback of reloads when
+	// non-zero. LoadSource reverts to the last known-good source if evaluation
+	// failed, or if components became unhealthy RollbackSettlePeriod after the
+	// load. It isn't used by the isolated controllers of services.
+	RollbackSettlePeriod time.Duration
+
+	// ModuleLock returns the lock pinning the versions of the modules imported
+	// by import.git, import.http and import.oci blocks. It's called whenever an
+	// import block is evaluated, so that a reloaded lock applies to the next
+	// evaluation. Modules aren't pinned if ModuleLock is nil or returns nil.
+	ModuleLock func() *modlock.Lock
+
+	// OnRevert is called when a reload is reverted to the last known-good
+	// source, before that source is loaded again. moduleLock is the lock
+	// ModuleLock returned when the source was first loaded, so that the caller
+	// can restore it along with any other state it derived from the source.
+	OnRevert func(source *Source, moduleLock *modlock.Lock)
 }
 
 // Runtime is the Alloy system.
@@ -131,6 +155,8 @@ type Runtime struct {
 
 	loadMut    sync.RWMutex
 	loadedOnce atomic.Bool
+
+	rollback *rollbackState // Nil if automatic rollback is disabled.
 }
 
 // New creates a new, unstarted Alloy controller. Call Run to run the controller.
@@ -148,11 +174,13 @@ func New(o Options) *Runtime {
 type controllerOptions struct {
 	Options
 
-	ComponentRegistry component.Registry // Custom component registry used in tests.
-	ModuleRegistry    *moduleRegistry    // Where to register created modules.
-	IsModule          bool               // Whether this controller is for a module.
+	ModuleRegistry *moduleRegistry // Where to register created modules.
+	IsModule       bool            // Whether this controller is for a module.
 	// A worker pool to evaluate components asynchronously. A default one will be created if this is nil.
 	WorkerPool worker.Pool
+	// The restart policy of components, shared by the root controller with its
+	// modules. A new one is created if this is nil.
+	RestartPolicy *controller.RestartPolicy
 }
 
 // newController creates a new, unstarted Alloy controller with a specific
@@ -160,9 +188,10 @@ type controllerOptions struct {
 // given modReg.
 func newController(o controllerOptions) *Runtime {
 	var (
-		log        = o.Logger
-		tracer     = o.Tracer
-		workerPool = o.WorkerPool
+		log           = o.Logger
+		tracer        = o.Tracer
+		workerPool    = o.WorkerPool
+		restartPolicy = o.RestartPolicy
 	)
 
 	if tracer == nil {
@@ -179,6 +208,10 @@ func newController(o controllerOptions) *Runtime {
 		workerPool = worker.NewDefaultWorkerPool()
 	}
 
+	if restartPolicy == nil {
+		restartPolicy = controller.NewRestartPolicy()
+	}
+
 	f := &Runtime{
 		log:    log,
 		tracer: tracer,
@@ -191,6 +224,9 @@ func newController(o controllerOptions) *Runtime {
 
 		loadFinished: make(chan struct{}, 1),
 	}
+	if o.RollbackSettlePeriod > 0 {
+		f.rollback = newRollbackState(o.ControllerID, o.RollbackSettlePeriod, o.Reg)
+	}
 
 	serviceMap := controller.NewServiceMap(o.Services)
 
@@ -201,10 +237,15 @@ func newController(o controllerOptions) *Runtime {
 			DataPath:             o.DataPath,
 			MinStability:         o.MinStability,
 			EnableCommunityComps: o.EnableCommunityComps,
+			ModuleLock:           o.ModuleLock,
+			RestartPolicy:        restartPolicy,
 			OnBlockNodeUpdate: func(cn controller.BlockNode) {
 				// Changed node should be queued for reevaluation.
 				f.updateQueue.Enqueue(&controller.QueuedNode{Node: cn, LastUpdatedTime: time.Now()})
 			},
+			OnBlockNodeReevaluate: func(cn controller.BlockNode) {
+				f.updateQueue.Enqueue(&controller.QueuedNode{Node: cn, LastUpdatedTime: time.Now(), Reevaluate: true})
+			},
 			OnExportsChange: o.OnExportsChange,
 			Registerer:      o.Reg,
 			ControllerID:    o.ControllerID,
@@ -225,9 +266,11 @@ func newController(o controllerOptions) *Runtime {
 					DataPath:             o.DataPath,
 					MinStability:         o.MinStability,
 					EnableCommunityComps: o.EnableCommunityComps,
+					ModuleLock:           o.ModuleLock,
 					ID:                   opts.Id,
 					ServiceMap:           serviceMap,
 					WorkerPool:           workerPool,
+					RestartPolicy:        restartPolicy,
 				})
 			},
 			GetServiceData: func(name string) (interface{}, error) {
@@ -254,6 +297,9 @@ func (f *Runtime) Run(ctx context.Context) {
 	defer func() { _ = f.sched.Close() }()
 	defer f.loader.Cleanup(!f.opts.IsModule)
 	defer level.Debug(f.log).Log("msg", "Alloy controller exiting")
+	if f.rollback != nil {
+		defer f.rollback.stop()
+	}
 
 	for {
 		select {
@@ -274,6 +320,7 @@ func (f *Runtime) Run(ctx context.Context) {
 				services   = f.loader.Services()
 				imports    = f.loader.Imports()
 				forEachs   = f.loader.ForEachs()
+				ifs        = f.loader.Ifs()
 
 				runnables = make([]controller.RunnableNode, 0, len(components)+len(services)+len(imports))
 			)
@@ -289,6 +336,10 @@ func (f *Runtime) Run(ctx context.Context) {
 				runnables = append(runnables, fe)
 			}
 
+			for _, i := range ifs {
+				runnables = append(runnables, i)
+			}
+
 			// Only the root controller should run services, since modules share the
 			// same service instance as the root.
 			if !f.opts.IsModule {
@@ -312,7 +363,19 @@ func (f *Runtime) Run(ctx context.Context) {
 // The controller will only start running components after Load is called once
 // without any configuration errors.
 // LoadSource uses default loader configuration.
+//
+// If RollbackSettlePeriod is set, LoadSource returns an error wrapping
+// ErrRolledBack if the source failed to evaluate and was reverted. The health
+// of the components is checked in the background once the settle period
+// elapsed.
 func (f *Runtime) LoadSource(source *Source, args map[string]any, configPath string) error {
+	if f.rollback != nil {
+		return f.loadSourceWithRollback(source, args, configPath)
+	}
+	return f.applySource(source, args, configPath)
+}
+
+func (f *Runtime) applySource(source *Source, args map[string]any, configPath string) error {
 	modulePath, err := util.ExtractDirPath(configPath)
 	if err != nil {
 		level.Warn(f.log).Log("msg", "failed to extract directory path from configPath", "configPath", configPath, "err", err)
@@ -246,11 +246,11 @@ func TestComponents_Using_Services(t *testing.T) {
 
 	opts := testOptions(t)
 	opts.Services = append(opts.Services, existsSvc)
+	opts.ComponentRegistry = registry
 
 	ctrl := newController(controllerOptions{
-		Options:           opts,
-		ComponentRegistry: registry,
-		ModuleRegistry:    newModuleRegistry(),
+		Options:        opts,
+		ModuleRegistry: newModuleRegistry(),
 	})
 	require.NoError(t, ctrl.LoadSource(f, nil, ""))
 	go ctrl.Run(ctx)
@@ -325,11 +325,11 @@ func TestComponents_Using_Services_In_Modules(t *testing.T) {
 
 	opts := testOptions(t)
 	opts.Services = append(opts.Services, existsSvc)
+	opts.ComponentRegistry = registry
 
 	ctrl := newController(controllerOptions{
-		Options:           opts,
-		ComponentRegistry: registry,
-		ModuleRegistry:    newModuleRegistry(),
+		Options:        opts,
+		ModuleRegistry: newModuleRegistry(),
 	})
 	require.NoError(t, ctrl.LoadSource(f, nil, ""))
 	go ctrl.Run(ctx)
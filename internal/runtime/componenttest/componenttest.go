@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/internal/service/labelstore"
 	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/statestore"
 
 	"github.com/go-kit/log"
 	"go.opentelemetry.io/otel/trace/noop"
@@ -168,6 +169,8 @@ func (c *Controller) buildComponent(dataPath string, args component.Arguments) (
 				return labelstore.New(nil, prometheus.DefaultRegisterer), nil
 			case livedebugging.ServiceName:
 				return livedebugging.NewLiveDebugging(), nil
+			case statestore.ServiceName:
+				return statestore.New(nil, dataPath), nil
 			default:
 				return nil, fmt.Errorf("no service named %s defined", name)
 			}
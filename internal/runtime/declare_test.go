@@ -55,6 +55,37 @@ func TestDeclare(t *testing.T) {
 			`,
 			expected: 10,
 		},
+		{
+			name: "DeclareWithFunction",
+			config: `
+			declare "test" {
+				argument "input" {
+					optional = false
+				}
+
+				function "negate" {
+					value = (x) => -x
+				}
+
+				export "output" {
+					value = function.negate(argument.input.value)
+				}
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: -10,
+		},
 		{
 			name: "NestedDeclares",
 			config: `
@@ -327,6 +358,38 @@ func TestDeclare(t *testing.T) {
 			`,
 			expected: 10,
 		},
+		{
+			name: "DeclareWithTypedArgument",
+			config: `
+			declare "test" {
+				argument "input" {
+					type = "number"
+
+					validation {
+						min = 0
+						max = 10
+					}
+				}
+
+				export "output" {
+					value = argument.input.value
+				}
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: 10,
+		},
 	}
 
 	for _, tc := range tt {
@@ -482,6 +545,70 @@ func TestDeclareError(t *testing.T) {
 			`,
 			expectedError: regexp.MustCompile(`'declare' is not a valid label for a declare block`),
 		},
+		{
+			name: "ArgumentTypeMismatch",
+			config: `
+			declare "a" {
+				argument "targets" {
+					type = "list(object({__address__ = string}))"
+				}
+			}
+			a "example" {
+				targets = [{__address__ = 8080}]
+			}
+			`,
+			expectedError: regexp.MustCompile(`:7:4: Failed to evaluate node for config block: .*invalid value for argument "targets" to module: index 0: field "__address__": expected string, got number`),
+		},
+		{
+			name: "ArgumentValidation",
+			config: `
+			declare "a" {
+				argument "level" {
+					type = "string"
+
+					validation {
+						regex         = "debug|info|warn|error"
+						error_message = "level must be one of debug, info, warn or error"
+					}
+				}
+			}
+			a "example" {
+				level = "verbose"
+			}
+			`,
+			expectedError: regexp.MustCompile(`:12:4: .*invalid value for argument "level" to module: level must be one of debug, info, warn or error`),
+		},
+		{
+			name: "ArgumentInvalidDefault",
+			config: `
+			declare "a" {
+				argument "names" {
+					optional = true
+					default  = []
+
+					validation {
+						non_empty = true
+					}
+				}
+			}
+			a "example" {}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "names" to module: value must not be empty`),
+		},
+		{
+			name: "ArgumentUnknownType",
+			config: `
+			declare "a" {
+				argument "input" {
+					type = "strin"
+				}
+			}
+			a "example" {
+				input = "foo"
+			}
+			`,
+			expectedError: regexp.MustCompile(`:3:5: Failed to evaluate node for config block: invalid type: unknown type "strin"`),
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
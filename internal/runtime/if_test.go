@@ -0,0 +1,29 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIf(t *testing.T) {
+	directory := "./testdata/if"
+	for _, file := range getTestFiles(directory, t) {
+		tc := buildTestForEach(t, filepath.Join(directory, file.Name()))
+		t.Run(file.Name(), func(t *testing.T) {
+			if tc.module != "" {
+				defer os.Remove("module.alloy")
+				require.NoError(t, os.WriteFile("module.alloy", []byte(tc.module), 0664))
+			}
+			if tc.update != nil {
+				testConfigForEach(t, tc.main, tc.reloadConfig, func() {
+					require.NoError(t, os.WriteFile(tc.update.name, []byte(tc.update.updateConfig), 0664))
+				}, nil, nil, nil)
+			} else {
+				testConfigForEach(t, tc.main, tc.reloadConfig, nil, nil, nil, nil)
+			}
+		})
+	}
+}
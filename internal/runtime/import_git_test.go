@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/modlock"
 	"github.com/grafana/alloy/internal/vcs"
 	"github.com/stretchr/testify/require"
 )
@@ -381,3 +383,154 @@ testImport.add "cc" {
 		ctrl.Run(ctx)
 	}()
 }
+
+func TestImportGitOfflineStartup(t *testing.T) {
+	testRepo := t.TempDir()
+	initializeRepo(t, testRepo)
+	runGit(t, testRepo, "checkout", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(testRepo, "math.alloy"), []byte(contents), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test\"")
+
+	main := `
+import.git "testImport" {
+	repository = "` + testRepo + `"
+	path       = "math.alloy"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	dataPath := t.TempDir()
+	ctx, cancel := context.WithCancel(t.Context())
+	ctrl, err := loadWithDataPath(t, ctx, main, dataPath, nil, featuregate.StabilityPublicPreview)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+	cancel()
+
+	// The module is loaded from the cache when the repository is unreachable.
+	require.NoError(t, os.RemoveAll(testRepo))
+	ctrl = runWithDataPath(t, main, dataPath)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// The cache isn't used for another path.
+	_, err = loadWithDataPath(t, t.Context(), `import.git "testImport" {
+	repository = "`+testRepo+`"
+	path       = "other.alloy"
+}`, dataPath, nil, featuregate.StabilityPublicPreview)
+	require.ErrorContains(t, err, "failed to update repository")
+}
+
+func TestImportGitPinnedCommit(t *testing.T) {
+	testRepo := t.TempDir()
+	initializeRepo(t, testRepo)
+	runGit(t, testRepo, "checkout", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(testRepo, "math.alloy"), []byte(contents), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test\"")
+
+	out, err := exec.Command("git", "-C", testRepo, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+	commit := strings.TrimSpace(string(out))
+
+	config := func(commit string) string {
+		return `
+import.git "testImport" {
+	repository = "` + testRepo + `"
+	path       = "math.alloy"
+	commit     = "` + commit + `"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	}
+
+	ctrl := runWithDataPath(t, config(commit), t.TempDir())
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	_, err = loadWithDataPath(t, t.Context(), config(strings.Repeat("0", 40)), t.TempDir(), nil, featuregate.StabilityPublicPreview)
+	require.ErrorContains(t, err, "doesn't match the pinned commit")
+
+	_, err = loadWithDataPath(t, t.Context(), config("main"), t.TempDir(), nil, featuregate.StabilityPublicPreview)
+	require.ErrorContains(t, err, "commit must be a full commit SHA")
+}
+
+func TestImportGitModuleLock(t *testing.T) {
+	testRepo := t.TempDir()
+	initializeRepo(t, testRepo)
+	runGit(t, testRepo, "checkout", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(testRepo, "math.alloy"), []byte(contents), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test\"")
+
+	out, err := exec.Command("git", "-C", testRepo, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+	locked := strings.TrimSpace(string(out))
+
+	configPath := filepath.Join(t.TempDir(), "config.alloy")
+	config := `
+import.git "testImport" {
+	repository = "` + testRepo + `"
+	path       = "math.alloy"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+	source, err := alloy_runtime.ParseSource(configPath, []byte(config))
+	require.NoError(t, err)
+
+	lock, err := alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{DataPath: t.TempDir()})
+	require.NoError(t, err)
+	require.Equal(t, []modlock.Entry{
+		{Source: modlock.SourceGit, Repository: testRepo, Revision: "main", Commit: locked},
+	}, lock.Modules)
+
+	// The branch moves on but the locked commit is loaded.
+	require.NoError(t, os.WriteFile(filepath.Join(testRepo, "math.alloy"), []byte(contentsMore), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test2\"")
+
+	ctrl, err := loadWithModuleLock(t, configPath, lock)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Locking again keeps the locked commit.
+	relocked, err := alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{DataPath: t.TempDir(), Current: lock})
+	require.NoError(t, err)
+	require.Equal(t, lock.Modules, relocked.Modules)
+
+	// Updating the lock records the new commit of the branch.
+	updated, err := alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{DataPath: t.TempDir()})
+	require.NoError(t, err)
+	entry, ok := updated.Git(testRepo, "main")
+	require.True(t, ok)
+	require.NotEqual(t, locked, entry.Commit)
+
+	ctrl, err = loadWithModuleLock(t, configPath, updated)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 100*time.Millisecond)
+}
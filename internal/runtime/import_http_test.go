@@ -0,0 +1,124 @@
+package runtime_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+func TestImportHTTPOfflineStartup(t *testing.T) {
+	var available atomic.Bool
+	available.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(addModule))
+	}))
+	defer srv.Close()
+
+	main := `
+import.http "testImport" {
+	url            = "` + srv.URL + `/math.alloy"
+	poll_frequency = "100ms"
+	poll_timeout   = "50ms"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	dataPath := t.TempDir()
+	ctx, cancel := context.WithCancel(t.Context())
+	ctrl, err := loadWithDataPath(t, ctx, main, dataPath, nil, featuregate.StabilityPublicPreview)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	cancel()
+
+	// The module is loaded from the cache when the server is unreachable.
+	available.Store(false)
+	reg := prometheus.NewRegistry()
+	ctrl, err = loadWithDataPath(t, t.Context(), main, dataPath, reg, featuregate.StabilityPublicPreview)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Equal(t, 1.0, servedFromCache(t, reg))
+
+	// The module is fetched again once the server is back.
+	available.Store(true)
+	require.Eventually(t, func() bool {
+		return servedFromCache(t, reg) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The cache isn't used for another URL.
+	available.Store(false)
+	_, err = loadWithDataPath(t, t.Context(), `import.http "testImport" {
+	url = "`+srv.URL+`/other.alloy"
+}`, dataPath, nil, featuregate.StabilityPublicPreview)
+	require.ErrorContains(t, err, "unexpected status code 503")
+}
+
+func TestImportHTTPPinnedDigest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(addModule))
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256([]byte(addModule))
+	config := func(digest string) string {
+		return `
+import.http "testImport" {
+	url    = "` + srv.URL + `/math.alloy"
+	sha256 = "` + digest + `"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	}
+
+	ctrl := runWithDataPath(t, config(hex.EncodeToString(sum[:])), t.TempDir())
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	_, err := loadWithDataPath(t, t.Context(), config(strings.Repeat("0", 64)), t.TempDir(), nil, featuregate.StabilityPublicPreview)
+	require.ErrorContains(t, err, "doesn't match the pinned digest")
+
+	_, err = loadWithDataPath(t, t.Context(), config("abc"), t.TempDir(), nil, featuregate.StabilityPublicPreview)
+	require.ErrorContains(t, err, "sha256 must be a hex encoded SHA-256 digest")
+}
+
+// servedFromCache returns the value of the alloy_import_served_from_cache
+// metric of the only import block of a controller.
+func servedFromCache(t *testing.T, reg *prometheus.Registry) float64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() == "alloy_import_served_from_cache" {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return -1
+}
@@ -0,0 +1,156 @@
+package runtime_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+)
+
+const addModule = `declare "add" {
+    argument "a" {}
+    argument "b" {}
+
+    export "sum" {
+        value = argument.a.value + argument.b.value
+    }
+}`
+
+const addModuleMore = `declare "add" {
+    argument "a" {}
+    argument "b" {}
+
+    export "sum" {
+        value = argument.a.value + argument.b.value + 1
+    }
+}`
+
+func TestImportOCIUpdatingTag(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.RequireAuth("user", "pass")
+	registry.PushModule("modules", "v1", map[string]string{"math.alloy": addModule, "README.md": "math"})
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + registry.Host() + `/modules:v1"
+	insecure       = true
+	poll_frequency = "100ms"
+
+	basic_auth {
+		username = "user"
+		password = "pass"
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	ctrl := runWithDataPath(t, main, t.TempDir())
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Move the tag to a new bundle.
+	registry.PushModule("modules", "v1", map[string]string{"math.alloy": addModuleMore})
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestImportOCIOfflineStartup(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	d := registry.PushModule("modules", "v1", map[string]string{"lib/math.alloy": addModule})
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + registry.Host() + `/modules:v1"
+	path           = "lib"
+	digest         = "` + d.String() + `"
+	insecure       = true
+	poll_frequency = "0s"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	dataPath := t.TempDir()
+	ctx, cancel := context.WithCancel(t.Context())
+	ctrl, err := loadWithDataPath(t, ctx, main, dataPath, nil, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	cancel()
+
+	// The module is loaded from the cache when the registry is unreachable.
+	registry.SetUnavailable(true)
+	ctrl = runWithDataPath(t, main, dataPath)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The cache isn't used for another reference.
+	_, err = loadWithDataPath(t, t.Context(), `import.oci "testImport" {
+	reference = "`+registry.Host()+`/modules:v2"
+	insecure  = true
+}`, dataPath, nil, featuregate.StabilityExperimental)
+	require.ErrorContains(t, err, "the cached module was pulled from")
+}
+
+func TestImportOCISignature(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	signed := registry.PushModule("modules", "v1", map[string]string{"math.alloy": addModule})
+	registry.PushModule("modules", "v2", map[string]string{"math.alloy": addModuleMore})
+
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	registry.Sign("modules", signed, key)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	config := func(tag string) string {
+		return `
+import.oci "testImport" {
+	reference  = "` + registry.Host() + `/modules:` + tag + `"
+	insecure   = true
+	public_key = ` + "`" + publicKeyPEM + "`" + `
+}
+`
+	}
+
+	_, err = loadWithDataPath(t, t.Context(), config("v1"), t.TempDir(), nil, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+
+	_, err = loadWithDataPath(t, t.Context(), config("v2"), t.TempDir(), nil, featuregate.StabilityExperimental)
+	require.ErrorContains(t, err, "no signature found")
+}
+
+func TestImportOCIStability(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	main := `
+import.oci "testImport" {
+	reference = "` + registry.Host() + `/modules:v1"
+}
+`
+	_, err := loadWithDataPath(t, t.Context(), main, t.TempDir(), nil, featuregate.StabilityPublicPreview)
+	require.ErrorContains(t, err, `config block "import.oci" is at stability level "experimental"`)
+}
@@ -409,3 +409,37 @@ func filterFiles(files []fs.FileInfo, denylistedPrefix string) []fs.FileInfo {
 	}
 	return res
 }
+
+// runWithDataPath loads config and runs the controller until the test ends.
+func runWithDataPath(t *testing.T, config string, dataPath string) *alloy_runtime.Runtime {
+	ctrl, err := loadWithDataPath(t, t.Context(), config, dataPath, nil, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+	return ctrl
+}
+
+// loadWithDataPath loads config and runs the controller until ctx is canceled or
+// the test ends, even if config failed to load, so that its workers are
+// stopped.
+func loadWithDataPath(t *testing.T, ctx context.Context, config string, dataPath string, reg prometheus.Registerer, stability featuregate.Stability) (*alloy_runtime.Runtime, error) {
+	s, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+	ctrl := alloy_runtime.New(alloy_runtime.Options{
+		Logger:       s,
+		DataPath:     dataPath,
+		MinStability: stability,
+		Reg:          reg,
+		Services:     []service.Service{},
+	})
+	f, err := alloy_runtime.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	err = ctrl.LoadSource(f, nil, "")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+	t.Cleanup(wg.Wait)
+	return ctrl, err
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/internal/dag"
+	"github.com/grafana/alloy/internal/runtime/internal/importsource"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
@@ -60,6 +61,14 @@ func ComponentReferences(cn dag.Node, g *dag.Graph, l log.Logger, scope *vm.Scop
 		ref, resolveDiags := resolveTraversal(t, g)
 		componentRefMatch := !resolveDiags.HasErrors()
 
+		if !componentRefMatch {
+			// Imported functions are referenced through the label of their import
+			// block, which isn't part of the ID of the import node.
+			if importRef, ok := resolveImportedFunction(t, g); ok {
+				ref, componentRefMatch = importRef, true
+			}
+		}
+
 		// we look for a match in the provided scope and the stdlib
 		_, scopeMatch := scope.Lookup(t[0].Name)
 
@@ -71,7 +80,10 @@ func ComponentReferences(cn dag.Node, g *dag.Graph, l log.Logger, scope *vm.Scop
 			// Both cases should be ignored at the linking level, that's the diags are ignored here.
 			// This is not super clean, but it should not create any problem since that the errors will be caught either during evaluation or while linking components
 			// inside of the foreach.
-			if _, ok := cn.(*ForeachConfigNode); !ok {
+			// The same applies to the branches of the if node.
+			switch cn.(type) {
+			case *ForeachConfigNode, *IfConfigNode:
+			default:
 				diags = append(diags, resolveDiags...)
 			}
 			continue
@@ -151,6 +163,27 @@ func (tw *traversalWalker) Visit(node ast.Node) ast.Visitor {
 			ast.Walk(tw, arg)
 		}
 		return nil
+
+	case *ast.LambdaExpr:
+		// Lambda parameters are only visible inside the lambda body, so
+		// traversals starting with a parameter never reference a component.
+		tw.flush()
+
+		params := make(map[string]struct{}, len(n.Params))
+		for _, param := range n.Params {
+			params[param.Name] = struct{}{}
+		}
+
+		var inner traversalWalker
+		ast.Walk(&inner, n.Body)
+		inner.flush()
+
+		for _, t := range inner.traversals {
+			if _, isParam := params[t[0].Name]; !isParam {
+				tw.traversals = append(tw.traversals, t)
+			}
+		}
+		return nil
 	}
 
 	return tw
@@ -200,3 +233,21 @@ func resolveTraversal(t Traversal, g *dag.Graph) (Reference, diag.Diagnostics) {
 	})
 	return Reference{}, diags
 }
+
+// resolveImportedFunction resolves a traversal such as "LABEL.NAME" to the
+// import block with the given label, if there is one.
+func resolveImportedFunction(t Traversal, g *dag.Graph) (Reference, bool) {
+	if len(t) < 2 {
+		return Reference{}, false
+	}
+
+	for _, blockName := range []string{importsource.BlockImportFile, importsource.BlockImportString, importsource.BlockImportHTTP, importsource.BlockImportGit, importsource.BlockImportOCI} {
+		if n, ok := g.GetByID(blockName + "." + t[0].Name).(*ImportConfigNode); ok {
+			return Reference{
+				Target:    n,
+				Traversal: t[1:],
+			}, true
+		}
+	}
+	return Reference{}, false
+}
@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/filedetector"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// filePollFrequency is how often the files read by nodes are checked in case
+// filesystem events are missed.
+const filePollFrequency = time.Minute
+
+// fileNamespace is the stdlib namespace of the file functions.
+const fileNamespace = "file"
+
+// fileTracker tracks the files read by the file.read and file.glob stdlib
+// functions during the evaluation of nodes, and requests the nodes to be
+// evaluated again when the result of these functions changes.
+type fileTracker struct {
+	log      log.Logger
+	onChange func(BlockNode) // Nil if the files aren't watched.
+
+	mut       sync.Mutex
+	closed    bool
+	watches   map[fileKey]*fileWatch
+	nodeReads map[string]*fileReads // Files read since the last evaluation of nodes, by node ID.
+}
+
+// fileKey identifies the result of a file function.
+type fileKey struct {
+	function string // read or glob.
+	path     string // Path of the file or glob pattern.
+}
+
+// fileWatch watches the result of a file function for the nodes which
+// called it.
+type fileWatch struct {
+	detector    io.Closer
+	fingerprint string
+	nodes       map[string]BlockNode
+}
+
+// fileReads holds the files read during an evaluation of a node, and by the
+// functions which the evaluation created once it finished.
+type fileReads struct {
+	t        *fileTracker
+	node     BlockNode
+	keys     map[fileKey]struct{}
+	finished bool
+}
+
+func newFileTracker(logger log.Logger, onChange func(BlockNode)) *fileTracker {
+	return &fileTracker{
+		log:       logger,
+		onChange:  onChange,
+		watches:   make(map[fileKey]*fileWatch),
+		nodeReads: make(map[string]*fileReads),
+	}
+}
+
+// scope adds the file functions which track the files read by n to scope.
+// done must be called once n is evaluated to stop watching the files n no
+// longer reads.
+func (t *fileTracker) scope(n BlockNode, scope *vm.Scope) (done func()) {
+	if t.onChange == nil {
+		return func() {}
+	}
+	// Don't shadow a variable from the config, such as an import label.
+	if _, found := scope.Variables[fileNamespace]; found {
+		return func() {}
+	}
+
+	reads := &fileReads{t: t, node: n, keys: make(map[fileKey]struct{})}
+
+	stdlib, _ := vm.NewScope(nil).Lookup(fileNamespace)
+	functions := maps.Clone(stdlib.(map[string]any))
+	functions["read"] = reads.read
+	functions["glob"] = reads.glob
+	scope.Variables[fileNamespace] = functions
+
+	return func() { t.finish(reads) }
+}
+
+func (r *fileReads) read(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	r.t.record(r, fileKey{function: "read", path: path}, readFingerprint(content, err))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (r *fileReads) glob(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		// The pattern is malformed, there is nothing to watch.
+		return nil, err
+	}
+	r.t.record(r, fileKey{function: "glob", path: pattern}, globFingerprint(matches))
+	if matches == nil {
+		return []string{}, nil
+	}
+	return matches, nil
+}
+
+// record watches the result of the file function identified by key for the
+// node of r. fingerprint is the result observed by the node.
+//
+// Functions created by the evaluation of a node, such as the value of a
+// function block, call the file functions after the evaluation finished. Their
+// reads are recorded with the reads of the last evaluation of the node, and
+// ignored once the node was removed.
+func (t *fileTracker) record(r *fileReads, key fileKey, fingerprint string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if t.closed {
+		return
+	}
+	if r.finished {
+		last, ok := t.nodeReads[r.node.NodeID()]
+		if !ok {
+			return
+		}
+		r = last
+	}
+	r.keys[key] = struct{}{}
+
+	w, ok := t.watches[key]
+	if !ok {
+		w = &fileWatch{nodes: make(map[string]BlockNode)}
+		w.detector = t.newDetector(key)
+		t.watches[key] = w
+	}
+	w.fingerprint = fingerprint
+	w.nodes[r.node.NodeID()] = r.node
+}
+
+// finish stops watching the files which the node of r read during its
+// previous evaluation but not during this one.
+func (t *fileTracker) finish(r *fileReads) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	r.finished = true
+	if t.closed {
+		return
+	}
+
+	id := r.node.NodeID()
+	if prev, ok := t.nodeReads[id]; ok && prev != r {
+		for key := range prev.keys {
+			if _, ok := r.keys[key]; !ok {
+				t.unwatch(key, id)
+			}
+		}
+	}
+	// The node is tracked even if it read no files, as the functions it
+	// created may read files later.
+	t.nodeReads[id] = r
+}
+
+// retain stops watching files for the nodes for which keep returns false.
+func (t *fileTracker) retain(keep func(nodeID string) bool) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	for id, reads := range t.nodeReads {
+		if keep(id) {
+			continue
+		}
+		for key := range reads.keys {
+			t.unwatch(key, id)
+		}
+		delete(t.nodeReads, id)
+	}
+}
+
+// Close stops watching all files. Files read afterwards aren't watched.
+func (t *fileTracker) Close() {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.closed = true
+	for key, w := range t.watches {
+		if w.detector != nil {
+			_ = w.detector.Close()
+		}
+		delete(t.watches, key)
+	}
+	clear(t.nodeReads)
+}
+
+// unwatch removes the node from the watch of key. t.mut must be held.
+func (t *fileTracker) unwatch(key fileKey, nodeID string) {
+	w, ok := t.watches[key]
+	if !ok {
+		return
+	}
+	delete(w.nodes, nodeID)
+	if len(w.nodes) > 0 {
+		return
+	}
+	delete(t.watches, key)
+	if w.detector != nil {
+		_ = w.detector.Close()
+	}
+}
+
+func (t *fileTracker) newDetector(key fileKey) io.Closer {
+	// Glob patterns are watched through the directory which holds the
+	// matches, to detect files being created and deleted.
+	filename := key.path
+	if key.function == "glob" {
+		filename = globBase(key.path)
+	}
+
+	reload := func() { t.check(key) }
+	detector, err := filedetector.NewFSNotify(filedetector.FSNotifyOptions{
+		Logger:        t.log,
+		Filename:      filename,
+		ReloadFile:    reload,
+		PollFrequency: filePollFrequency,
+	})
+	if err != nil {
+		level.Warn(t.log).Log("msg", "failed to watch file, falling back to polling", "file", filename, "err", err)
+		return filedetector.NewPoller(filedetector.PollerOptions{
+			Filename:      filename,
+			ReloadFile:    reload,
+			PollFrequency: filePollFrequency,
+		})
+	}
+	return detector
+}
+
+// check requests the nodes which called the file function identified by key
+// to be evaluated again if its result changed.
+func (t *fileTracker) check(key fileKey) {
+	var fingerprint string
+	switch key.function {
+	case "read":
+		fingerprint = readFingerprint(os.ReadFile(key.path))
+	case "glob":
+		matches, _ := filepath.Glob(key.path)
+		fingerprint = globFingerprint(matches)
+	}
+
+	t.mut.Lock()
+	w, ok := t.watches[key]
+	if !ok || w.fingerprint == fingerprint {
+		t.mut.Unlock()
+		return
+	}
+	w.fingerprint = fingerprint
+	nodes := make([]BlockNode, 0, len(w.nodes))
+	for _, n := range w.nodes {
+		nodes = append(nodes, n)
+	}
+	t.mut.Unlock()
+
+	level.Debug(t.log).Log("msg", "file changed, evaluating nodes again", "file", key.path, "nodes", len(nodes))
+	for _, n := range nodes {
+		t.onChange(n)
+	}
+}
+
+func readFingerprint(content []byte, err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func globFingerprint(matches []string) string {
+	return strings.Join(matches, "\x00")
+}
+
+// globBase returns the longest directory of pattern without glob
+// metacharacters.
+func globBase(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
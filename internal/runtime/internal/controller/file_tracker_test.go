@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+func TestFileTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+
+	changed := make(chan BlockNode, 10)
+	tracker := newFileTracker(log.NewNopLogger(), func(n BlockNode) { changed <- n })
+	defer tracker.Close()
+
+	file, err := parser.ParseFile(t.Name(), []byte(`argument "reader" {}`))
+	require.NoError(t, err)
+	node := NewArgumentConfigNode(file.Body[0].(*ast.BlockStmt), ComponentGlobals{})
+
+	evaluate := func(expr string) string {
+		t.Helper()
+		e, err := parser.ParseExpression(expr)
+		require.NoError(t, err)
+
+		scope := vm.NewScope(map[string]any{"path": path})
+		done := tracker.scope(node, scope)
+		defer done()
+
+		var result string
+		require.NoError(t, vm.New(e).Evaluate(scope, &result))
+		return result
+	}
+
+	require.Equal(t, "first", evaluate(`file.read(path)`))
+	require.Len(t, tracker.watches, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	select {
+	case n := <-changed:
+		require.Equal(t, node, n)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "node wasn't evaluated again")
+	}
+	require.Equal(t, "second", evaluate(`file.read(path)`))
+
+	// The file is no longer watched once the node stops reading it.
+	require.Equal(t, "path", evaluate(`"path"`))
+	require.Empty(t, tracker.watches)
+	require.Empty(t, tracker.nodeReads[node.NodeID()].keys)
+}
+
+func TestFileTrackerFunction(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	path := filepath.Join(t.TempDir(), "input.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+
+	changed := make(chan BlockNode, 10)
+	tracker := newFileTracker(log.NewNopLogger(), func(n BlockNode) { changed <- n })
+
+	file, err := parser.ParseFile(t.Name(), []byte(`
+		function "read" {
+			value = (path) => file.read(path)
+		}
+	`))
+	require.NoError(t, err)
+	node := NewFunctionConfigNode(file.Body[0].(*ast.BlockStmt))
+
+	scope := vm.NewScope(map[string]any{})
+	done := tracker.scope(node, scope)
+	require.NoError(t, node.Evaluate(scope))
+	done()
+
+	// The file is read when the function is called, once the function block
+	// was evaluated.
+	call := func() string {
+		t.Helper()
+		e, err := parser.ParseExpression(`read(path)`)
+		require.NoError(t, err)
+
+		var result string
+		require.NoError(t, vm.New(e).Evaluate(vm.NewScope(map[string]any{"read": node.Value(), "path": path}), &result))
+		return result
+	}
+	require.Equal(t, "first", call())
+	require.Len(t, tracker.watches, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	select {
+	case n := <-changed:
+		require.Equal(t, node, n)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "node wasn't evaluated again")
+	}
+
+	tracker.Close()
+	require.Empty(t, tracker.watches)
+
+	// Functions can still be called once the files are no longer watched.
+	require.Equal(t, "second", call())
+	require.Empty(t, tracker.watches)
+}
+
+func TestFileTrackerRetain(t *testing.T) {
+	dir := t.TempDir()
+	tracker := newFileTracker(log.NewNopLogger(), func(BlockNode) {})
+	defer tracker.Close()
+
+	var nodes []*ArgumentConfigNode
+	for _, label := range []string{"a", "b"} {
+		file, err := parser.ParseFile(t.Name(), []byte(`argument "`+label+`" {}`))
+		require.NoError(t, err)
+		n := NewArgumentConfigNode(file.Body[0].(*ast.BlockStmt), ComponentGlobals{})
+		nodes = append(nodes, n)
+
+		e, err := parser.ParseExpression(`file.glob(pattern)`)
+		require.NoError(t, err)
+		scope := vm.NewScope(map[string]any{"pattern": filepath.Join(dir, "*.txt")})
+		done := tracker.scope(n, scope)
+		var matches []string
+		require.NoError(t, vm.New(e).Evaluate(scope, &matches))
+		done()
+	}
+	require.Len(t, tracker.watches, 1)
+	require.Len(t, tracker.watches[fileKey{function: "glob", path: filepath.Join(dir, "*.txt")}].nodes, 2)
+
+	tracker.retain(func(nodeID string) bool { return nodeID == nodes[0].NodeID() })
+	require.Len(t, tracker.watches, 1)
+	require.Len(t, tracker.nodeReads, 1)
+
+	tracker.retain(func(string) bool { return false })
+	require.Empty(t, tracker.watches)
+}
+
+func TestGlobBase(t *testing.T) {
+	require.Equal(t, "/etc/alloy", globBase("/etc/alloy/*.alloy"))
+	require.Equal(t, "/etc", globBase("/etc/*/config.alloy"))
+	require.Equal(t, ".", globBase("*.alloy"))
+}
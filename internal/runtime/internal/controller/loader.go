@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"path"
 	"reflect"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +24,7 @@ import (
 	"github.com/grafana/alloy/internal/component/common/loki"
 	"github.com/grafana/alloy/internal/component/otelcol"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
 	"github.com/grafana/alloy/internal/runtime/internal/dag"
 	"github.com/grafana/alloy/internal/runtime/internal/worker"
@@ -41,6 +44,8 @@ type Loader struct {
 	services   []service.Service
 	host       service.Host
 	workerPool worker.Pool
+	// applyConcurrency is the number of workers Apply uses to evaluate nodes.
+	applyConcurrency int
 	// backoffConfig is used to backoff when an updated component's dependencies cannot be submitted to worker
 	// pool for evaluation in EvaluateDependants, because the queue is full. This is an unlikely scenario, but when
 	// it happens we should avoid retrying too often to give other goroutines a chance to progress. Having a backoff
@@ -53,6 +58,7 @@ type Loader struct {
 	declareNodes         map[string]*DeclareNode
 	importConfigNodes    map[string]*ImportConfigNode
 	forEachNodes         map[string]*ForeachConfigNode
+	ifNodes              map[string]*IfConfigNode
 	serviceNodes         []*ServiceNode
 	cache                *valueCache
 	blocks               []*ast.BlockStmt // Most recently loaded blocks, used for writing
@@ -60,6 +66,7 @@ type Loader struct {
 	cc                   *controllerCollector
 	moduleExportIndex    int
 	componentNodeManager *ComponentNodeManager
+	files                *fileTracker // Files read by the nodes through the file stdlib functions.
 }
 
 // LoaderOptions holds options for creating a Loader.
@@ -71,6 +78,10 @@ type LoaderOptions struct {
 	Host              service.Host       // Service host (when running services).
 	ComponentRegistry component.Registry // Registry to search for components.
 	WorkerPool        worker.Pool        // Worker pool to use for async tasks.
+
+	// ApplyConcurrency is the maximum number of nodes Apply evaluates
+	// concurrently. GOMAXPROCS is used if ApplyConcurrency is zero.
+	ApplyConcurrency int
 }
 
 // NewLoader creates a new Loader. Components built by the Loader will be built
@@ -89,6 +100,11 @@ func NewLoader(opts LoaderOptions) *Loader {
 		reg = component.NewDefaultRegistry(opts.ComponentGlobals.MinStability, opts.ComponentGlobals.EnableCommunityComps)
 	}
 
+	applyConcurrency := opts.ApplyConcurrency
+	if applyConcurrency <= 0 {
+		applyConcurrency = runtime.GOMAXPROCS(0)
+	}
+
 	l := &Loader{
 		log:        log.With(globals.Logger, "controller_path", parent, "controller_id", id),
 		tracer:     tracing.WrapTracerForLoader(globals.TraceProvider, globals.ControllerID),
@@ -97,6 +113,8 @@ func NewLoader(opts LoaderOptions) *Loader {
 		host:       host,
 		workerPool: opts.WorkerPool,
 
+		applyConcurrency: applyConcurrency,
+
 		componentNodeManager: NewComponentNodeManager(globals, reg),
 
 		// This is a reasonable default which should work for most cases. If a component is completely stuck, we would
@@ -112,6 +130,7 @@ func NewLoader(opts LoaderOptions) *Loader {
 		cm:    newControllerMetrics(parent, id),
 	}
 	l.cc = newControllerCollector(l, parent, id)
+	l.files = newFileTracker(l.log, globals.OnBlockNodeReevaluate)
 
 	if globals.Registerer != nil {
 		globals.Registerer.MustRegister(l.cc)
@@ -194,9 +213,17 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 	}()
 
 	l.cache.ClearModuleExports()
+	l.cache.ClearFunctions()
 
-	// Evaluate all the components.
-	_ = dag.WalkTopological(&newGraph, newGraph.Leaves(), func(n dag.Node) error {
+	// Evaluate all the nodes. Nodes which don't depend on each other are
+	// evaluated concurrently, so the diagnostics of each node are collected
+	// separately and merged in a deterministic order once all nodes are done.
+	var (
+		resultsMut sync.Mutex
+		results    = make(map[dag.Node]diag.Diagnostics)
+		pool       = worker.NewFixedWorkerPool(l.applyConcurrency, max(1, len(newGraph.Nodes())))
+	)
+	_ = dag.WalkTopologicalParallel(&newGraph, newGraph.Leaves(), pool, func(n dag.Node) error {
 		_, span := tracer.Start(spanCtx, "EvaluateNode", trace.WithSpanKind(trace.SpanKindInternal))
 		span.SetAttributes(attribute.String("node_id", n.NodeID()))
 		defer span.End()
@@ -206,19 +233,19 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 			level.Info(logger).Log("msg", "finished node evaluation", "node_id", n.NodeID(), "duration", time.Since(start))
 		}()
 
-		var err error
+		var (
+			err       error
+			nodeDiags diag.Diagnostics
+		)
 
 		switch n := n.(type) {
 		case ComponentNode:
-			components = append(components, n)
-			componentIDs[n.ID().String()] = n.ID()
-
 			if err = l.evaluate(logger, n); err != nil {
 				var evalDiags diag.Diagnostics
 				if errors.As(err, &evalDiags) {
-					diags = append(diags, evalDiags...)
+					nodeDiags = append(nodeDiags, evalDiags...)
 				} else {
-					diags.Add(diag.Diagnostic{
+					nodeDiags.Add(diag.Diagnostic{
 						Severity: diag.SeverityLevelError,
 						Message:  fmt.Sprintf("Failed to build component: %s", err),
 						StartPos: ast.StartPos(n.Block()).Position(),
@@ -228,14 +255,12 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 			}
 
 		case *ServiceNode:
-			services = append(services, n)
-
 			if err = l.evaluate(logger, n); err != nil {
 				var evalDiags diag.Diagnostics
 				if errors.As(err, &evalDiags) {
-					diags = append(diags, evalDiags...)
+					nodeDiags = append(nodeDiags, evalDiags...)
 				} else {
-					diags.Add(diag.Diagnostic{
+					nodeDiags.Add(diag.Diagnostic{
 						Severity: diag.SeverityLevelError,
 						Message:  fmt.Sprintf("Failed to evaluate service: %s", err),
 						StartPos: ast.StartPos(n.Block()).Position(),
@@ -246,18 +271,28 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 
 		case BlockNode:
 			if err = l.evaluate(logger, n); err != nil {
-				diags.Add(diag.Diagnostic{
+				d := diag.Diagnostic{
 					Severity: diag.SeverityLevelError,
 					Message:  fmt.Sprintf("Failed to evaluate node for config block: %s", err),
-					StartPos: ast.StartPos(n.Block()).Position(),
-					EndPos:   ast.EndPos(n.Block()).Position(),
-				})
+				}
+				// Errors in the values given to the arguments of a module have no
+				// position: the custom component node reports them on the block of
+				// the caller.
+				if !errors.As(err, new(argumentValueError)) {
+					d.StartPos = ast.StartPos(n.Block()).Position()
+					d.EndPos = ast.EndPos(n.Block()).Position()
+				}
+				nodeDiags.Add(d)
 			}
 			if exp, ok := n.(*ExportConfigNode); ok {
 				l.cache.CacheModuleExportValue(exp.Label(), exp.Value())
 			}
 		}
 
+		resultsMut.Lock()
+		results[n] = nodeDiags
+		resultsMut.Unlock()
+
 		// We only use the error for updating the span status; we don't return the
 		// error because we want to evaluate as many nodes as we can.
 		if err != nil {
@@ -267,10 +302,23 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 		}
 		return nil
 	})
+	pool.Stop()
+
+	for _, n := range evaluationOrder(&newGraph, results) {
+		switch n := n.(type) {
+		case ComponentNode:
+			components = append(components, n)
+			componentIDs[n.ID().String()] = n.ID()
+		case *ServiceNode:
+			services = append(services, n)
+		}
+		diags = append(diags, results[n]...)
+	}
 
 	l.componentNodes = components
 	l.serviceNodes = services
 	l.graph = &newGraph
+	l.files.retain(func(nodeID string) bool { return newGraph.GetByID(nodeID) != nil })
 	err := l.cache.SyncIDs(componentIDs)
 	if err != nil {
 		diags.Add(diag.Diagnostic{
@@ -293,6 +341,7 @@ func (l *Loader) Cleanup(stopWorkerPool bool) {
 	if stopWorkerPool {
 		l.workerPool.Stop()
 	}
+	l.files.Close()
 	if l.globals.Registerer == nil {
 		return
 	}
@@ -553,8 +602,15 @@ func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, con
 		g.Add(c)
 	}
 
+	// If a restart_policy config block is not provided, components aren't
+	// restarted.
+	if nodeMap.restartPolicy == nil && l.isRootController() {
+		l.globals.RestartPolicy.set(nil)
+	}
+
 	l.importConfigNodes = nodeMap.importMap
 	l.forEachNodes = nodeMap.foreachMap
+	l.ifNodes = nodeMap.ifMap
 
 	return diags
 }
@@ -641,6 +697,8 @@ func (l *Loader) wireGraphEdges(g *dag.Graph) diag.Diagnostics {
 			l.wireCustomComponentNode(g, n)
 		case *ForeachConfigNode:
 			l.wireForEachNode(g, n)
+		case *IfConfigNode:
+			l.wireIfNode(g, n)
 		}
 
 		// Finally, wire component references.
@@ -681,6 +739,14 @@ func (l *Loader) wireForEachNode(g *dag.Graph, fn *ForeachConfigNode) {
 	}
 }
 
+// wireIfNode add edges between an if node and declare/import nodes that are used in its branches.
+func (l *Loader) wireIfNode(g *dag.Graph, fn *IfConfigNode) {
+	refs := l.findCustomComponentReferences(fn.Block())
+	for ref := range refs {
+		g.AddEdge(dag.Edge{From: fn, To: ref})
+	}
+}
+
 // Variables returns the Variables the Loader exposes for other components to
 // reference.
 func (l *Loader) Variables() map[string]interface{} {
@@ -715,6 +781,13 @@ func (l *Loader) ForEachs() map[string]*ForeachConfigNode {
 	return l.forEachNodes
 }
 
+// Ifs returns the current set of if nodes.
+func (l *Loader) Ifs() map[string]*IfConfigNode {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.ifNodes
+}
+
 // Graph returns a copy of the DAG managed by the Loader.
 func (l *Loader) Graph() *dag.Graph {
 	l.mut.RLock()
@@ -723,7 +796,8 @@ func (l *Loader) Graph() *dag.Graph {
 }
 
 // EvaluateDependants sends nodes which depend directly on nodes in updatedNodes for evaluation to the
-// workerPool. It should be called whenever nodes update their exports.
+// workerPool. It should be called whenever nodes update their exports. Nodes queued with Reevaluate set
+// are sent for evaluation themselves instead.
 // It is beneficial to call EvaluateDependants with a batch of nodes, as it will enqueue the entire batch before
 // the worker pool starts to evaluate them, resulting in smaller number of total evaluations when
 // node updates are frequent. If the worker pool's queue is full, EvaluateDependants will retry with a backoff until
@@ -756,6 +830,15 @@ func (l *Loader) EvaluateDependants(ctx context.Context, updatedNodes []*QueuedN
 		case *ImportConfigNode:
 			// Update the scope with the imported content.
 			l.componentNodeManager.customComponentReg.updateImportContent(parentNode)
+			l.cache.CacheImportedFunctions(parentNode.Label(), parentNode.ImportedFunctions())
+		}
+		if parent.Reevaluate {
+			// The node itself must be evaluated again, its dependants are
+			// evaluated if its exports change.
+			if n := l.graph.GetByID(parent.Node.NodeID()); n != nil {
+				dependenciesToParentsMap[n] = parent
+			}
+			continue
 		}
 		// We collect all nodes directly incoming to parent.
 		_ = dag.WalkIncomingNodes(l.graph, parent.Node, func(n dag.Node) error {
@@ -845,7 +928,9 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 		// RLock before evaluate to prevent Evaluating while the config is being reloaded
 		l.mut.RLock()
 		ectx := l.cache.GetContext()
+		filesRead := l.files.scope(n, ectx)
 		evalErr := n.Evaluate(ectx)
+		filesRead()
 
 		err = l.postEvaluate(l.log, n, evalErr)
 
@@ -853,6 +938,11 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 		if exp, ok := n.(*ExportConfigNode); ok {
 			l.cache.CacheModuleExportValue(exp.Label(), exp.Value())
 		}
+		// Functions capture the values of the components they reference, so the
+		// nodes calling them must be evaluated again with the new function.
+		if fn, ok := n.(*FunctionConfigNode); ok && evalErr == nil && l.globals.OnBlockNodeUpdate != nil {
+			l.globals.OnBlockNodeUpdate(fn)
+		}
 		if l.globals.OnExportsChange != nil && l.cache.ExportChangeIndex() != l.moduleExportIndex {
 			// Upgrade to write lock to update the module exports.
 			l.mut.RUnlock()
@@ -881,7 +971,9 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 // evaluates it. mut must be held when calling evaluate.
 func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
 	ectx := l.cache.GetContext()
+	filesRead := l.files.scope(bn, ectx)
 	err := bn.Evaluate(ectx)
+	filesRead()
 	return l.postEvaluate(logger, bn, err)
 }
 
@@ -906,17 +998,27 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 			}
 		}
 	case *ArgumentConfigNode:
-		if _, found := l.cache.GetModuleArgument(c.Label()); !found {
-			if c.Optional() {
-				l.cache.CacheModuleArgument(c.Label(), c.Default())
-			} else {
-				// NOTE: this masks the previous evaluation error, but we treat a missing module arguments as
-				// a more important error to address.
-				err = fmt.Errorf("missing required argument %q to module", c.Label())
+		var value any
+		if argument, found := l.cache.GetModuleArgument(c.Label()); found {
+			value = argument.(map[string]any)["value"]
+		} else if c.Optional() {
+			value = c.Default()
+			l.cache.CacheModuleArgument(c.Label(), value)
+		} else {
+			// NOTE: this masks the previous evaluation error, but we treat a missing module arguments as
+			// a more important error to address.
+			err = argumentValueError{fmt.Errorf("missing required argument %q to module", c.Label())}
+		}
+		if err == nil {
+			if err2 := c.Validate(value); err2 != nil {
+				err = argumentValueError{fmt.Errorf("invalid value for argument %q to module: %w", c.Label(), err2)}
 			}
 		}
+	case *FunctionConfigNode:
+		l.cache.CacheFunction(c.Label(), c.Value())
 	case *ImportConfigNode:
 		l.componentNodeManager.customComponentReg.updateImportContent(c)
+		l.cache.CacheImportedFunctions(c.Label(), c.ImportedFunctions())
 	}
 
 	if err != nil {
@@ -926,6 +1028,37 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 	return nil
 }
 
+// evaluationOrder returns the evaluated nodes in a deterministic order which
+// respects their dependencies: nodes are sorted by the length of their
+// longest dependency chain and then by ID.
+func evaluationOrder(g *dag.Graph, evaluated map[dag.Node]diag.Diagnostics) []dag.Node {
+	depths := make(map[dag.Node]int, len(evaluated))
+	var depth func(n dag.Node) int
+	depth = func(n dag.Node) int {
+		if d, ok := depths[n]; ok {
+			return d
+		}
+		d := 0
+		for _, dep := range g.Dependencies(n) {
+			d = max(d, depth(dep)+1)
+		}
+		depths[n] = d
+		return d
+	}
+
+	order := make([]dag.Node, 0, len(evaluated))
+	for n := range evaluated {
+		order = append(order, n)
+	}
+	slices.SortFunc(order, func(a, b dag.Node) int {
+		if da, db := depth(a), depth(b); da != db {
+			return da - db
+		}
+		return strings.Compare(a.NodeID(), b.NodeID())
+	})
+	return order
+}
+
 func multierrToDiags(errors error) diag.Diagnostics {
 	var diags diag.Diagnostics
 	for _, err := range errors.(*multierror.Error).Errors {
@@ -966,7 +1099,8 @@ func (l *Loader) collectCustomComponentReferences(stmts ast.Body, uniqueReferenc
 		)
 
 		switch {
-		case componentName == declareType || componentName == foreach.TypeTemplate:
+		case componentName == declareType || componentName == foreach.TypeTemplate ||
+			componentName == conditional.Name || componentName == conditional.TypeElse:
 			l.collectCustomComponentReferences(blockStmt.Body, uniqueReferences)
 		case foundDeclare:
 			uniqueReferences[declareNode] = struct{}{}
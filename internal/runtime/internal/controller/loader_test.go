@@ -3,14 +3,18 @@ package controller_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/internal/controller"
 	"github.com/grafana/alloy/internal/runtime/internal/dag"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/syntax/ast"
@@ -19,8 +23,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/trace/noop"
-
-	_ "github.com/grafana/alloy/internal/runtime/internal/testcomponents" // Include test components
 )
 
 func TestLoader(t *testing.T) {
@@ -221,6 +223,113 @@ func TestLoader(t *testing.T) {
 		require.NoError(t, diags.ErrorOrNil())
 	})
 
+	t.Run("Load component with lambda", func(t *testing.T) {
+		file := `
+			testcomponents.passthrough "static" {
+				input = "1s"
+			}
+
+			testcomponents.tick "default" {
+				frequency = array.reduce(["1", "s"], "", (acc, x) => acc + x)
+			}
+
+			testcomponents.passthrough "ticker" {
+				input = array.map([testcomponents.passthrough.static.output], (out) => out)[0]
+			}
+		`
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, []byte(file), nil, nil)
+		require.NoError(t, diags.ErrorOrNil())
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes: []string{
+				"testcomponents.passthrough.static",
+				"testcomponents.tick.default",
+				"testcomponents.passthrough.ticker",
+				"logging",
+				"tracing",
+			},
+			OutEdges: []edge{
+				{From: "testcomponents.passthrough.ticker", To: "testcomponents.passthrough.static"},
+			},
+		})
+	})
+
+	t.Run("Load component with function", func(t *testing.T) {
+		file := `
+			testcomponents.passthrough "static" {
+				input = "s"
+			}
+
+			testcomponents.tick "default" {
+				frequency = function.duration(1)
+			}
+		`
+		config := `
+			function "unit" {
+				value = () => testcomponents.passthrough.static.output
+			}
+
+			function "duration" {
+				value = (n) => string.format("%d%s", n, function.unit())
+			}
+		`
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, []byte(file), []byte(config), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes: []string{
+				"testcomponents.passthrough.static",
+				"testcomponents.tick.default",
+				"function.unit",
+				"function.duration",
+				"logging",
+				"tracing",
+			},
+			OutEdges: []edge{
+				{From: "testcomponents.tick.default", To: "function.duration"},
+				{From: "function.duration", To: "function.unit"},
+				{From: "function.unit", To: "testcomponents.passthrough.static"},
+			},
+		})
+	})
+
+	t.Run("Function with non-function value", func(t *testing.T) {
+		config := `
+			function "invalid" {
+				value = 5
+			}
+		`
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, nil, []byte(config), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), `the value of function "invalid" must be a function`)
+	})
+
+	t.Run("Functions have cycles", func(t *testing.T) {
+		config := `
+			function "a" {
+				value = (x) => function.b(x)
+			}
+
+			function "b" {
+				value = (x) => function.a(x)
+			}
+		`
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, nil, []byte(config), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), "cycle: ")
+	})
+
+	t.Run("Function calls itself", func(t *testing.T) {
+		config := `
+			function "a" {
+				value = (x) => x <= 0 ? 0 : function.a(x - 1)
+			}
+		`
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, nil, []byte(config), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), "self reference: function.a")
+	})
+
 	t.Run("Load with correct stability level", func(t *testing.T) {
 		l := controller.NewLoader(newLoaderOptionsWithStability(featuregate.StabilityPublicPreview))
 		diags := applyFromContent(t, l, []byte(testFile), nil, nil)
@@ -505,6 +614,65 @@ func TestScopeWithFailingComponent(t *testing.T) {
 	require.True(t, strings.Contains(diags.Error(), `unrecognized attribute name "frequenc"`))
 }
 
+func TestLoader_DeterministicDiagnostics(t *testing.T) {
+	// The nodes are evaluated concurrently, but diagnostics must be reported
+	// in dependency order and then by node ID.
+	testFile := `
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.a.output
+			unknown = true
+		}
+
+		testcomponents.passthrough "b" {
+			unknown = true
+		}
+
+		testcomponents.passthrough "a" {
+			input = "hello"
+		}
+
+		testcomponents.tick "z" {
+			unknown = true
+		}
+	`
+	newLoaderOptions := func() controller.LoaderOptions {
+		l, _ := logging.New(os.Stderr, logging.DefaultOptions)
+		return controller.LoaderOptions{
+			ComponentGlobals: controller.ComponentGlobals{
+				Logger:            l,
+				TraceProvider:     noop.NewTracerProvider(),
+				DataPath:          t.TempDir(),
+				MinStability:      featuregate.StabilityPublicPreview,
+				OnBlockNodeUpdate: func(cn controller.BlockNode) { /* no-op */ },
+				Registerer:        prometheus.NewRegistry(),
+				NewModuleController: func(opts controller.ModuleControllerOpts) controller.ModuleController {
+					return fakeModuleController{}
+				},
+			},
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, []byte(testFile), nil, nil)
+		require.Len(t, diags, 3)
+		require.Equal(t, 8, diags[0].StartPos.Line, "testcomponents.passthrough.b")
+		require.Equal(t, 16, diags[1].StartPos.Line, "testcomponents.tick.z")
+		require.Equal(t, 4, diags[2].StartPos.Line, "testcomponents.passthrough.c")
+
+		var ids []string
+		for _, c := range l.Components() {
+			ids = append(ids, c.NodeID())
+		}
+		require.Equal(t, []string{
+			"testcomponents.passthrough.a",
+			"testcomponents.passthrough.b",
+			"testcomponents.tick.z",
+			"testcomponents.passthrough.c",
+		}, ids)
+	}
+}
+
 func applyFromContent(t *testing.T, l *controller.Loader, componentBytes []byte, configBytes []byte, declareBytes []byte) diag.Diagnostics {
 	t.Helper()
 
@@ -652,3 +820,105 @@ func (fs *fakeService) Data() any {
 	}
 	return nil
 }
+
+// BenchmarkLoader_Apply measures the initial evaluation of large generated
+// graphs with a single worker and with concurrent evaluation. Nodes with a
+// delay simulate components which block while they're built, for example to
+// open files or connections.
+func BenchmarkLoader_Apply(b *testing.B) {
+	graphs := []struct {
+		name         string
+		width, depth int
+		delay        time.Duration
+	}{
+		{name: "wide", width: 2000, depth: 1},
+		{name: "layered", width: 200, depth: 10},
+		{name: "wide_delay", width: 500, depth: 1, delay: 100 * time.Microsecond},
+		{name: "layered_delay", width: 50, depth: 10, delay: 100 * time.Microsecond},
+	}
+
+	for _, graph := range graphs {
+		blocks := benchmarkGraph(b, graph.width, graph.depth, graph.delay)
+
+		for _, concurrency := range []int{1, 8} {
+			b.Run(fmt.Sprintf("%s/concurrency=%d", graph.name, concurrency), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					l := controller.NewLoader(benchmarkLoaderOptions(b, concurrency))
+					b.StartTimer()
+
+					diags := l.Apply(controller.ApplyOptions{ComponentBlocks: blocks})
+					require.NoError(b, diags.ErrorOrNil())
+				}
+			})
+		}
+	}
+}
+
+// benchmarkGraph generates depth layers of width components, where each
+// component references the component at the same position in the previous
+// layer.
+func benchmarkGraph(b *testing.B, width, depth int, delay time.Duration) []*ast.BlockStmt {
+	var sb strings.Builder
+	for layer := 0; layer < depth; layer++ {
+		for i := 0; i < width; i++ {
+			input := `"hello"`
+			if layer > 0 {
+				input = fmt.Sprintf("bench.passthrough.l%d_%d.output", layer-1, i)
+			}
+			fmt.Fprintf(&sb, "bench.passthrough \"l%d_%d\" {\n\tinput = %s\n\tdelay = %q\n}\n", layer, i, input, delay)
+		}
+	}
+
+	file, err := parser.ParseFile(b.Name(), []byte(sb.String()))
+	require.NoError(b, err)
+
+	blocks := make([]*ast.BlockStmt, 0, len(file.Body))
+	for _, stmt := range file.Body {
+		blocks = append(blocks, stmt.(*ast.BlockStmt))
+	}
+	return blocks
+}
+
+type benchmarkArgs struct {
+	Input string        `alloy:"input,attr"`
+	Delay time.Duration `alloy:"delay,attr,optional"`
+}
+
+type benchmarkExports struct {
+	Output string `alloy:"output,attr"`
+}
+
+func benchmarkLoaderOptions(b *testing.B, concurrency int) controller.LoaderOptions {
+	l, _ := logging.New(io.Discard, logging.DefaultOptions)
+
+	registry := component.NewRegistryMap(featuregate.StabilityGenerallyAvailable, false, map[string]component.Registration{
+		"bench.passthrough": {
+			Name:      "bench.passthrough",
+			Args:      benchmarkArgs{},
+			Exports:   benchmarkExports{},
+			Stability: featuregate.StabilityGenerallyAvailable,
+			Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+				a := args.(benchmarkArgs)
+				time.Sleep(a.Delay)
+				opts.OnStateChange(benchmarkExports{Output: a.Input})
+				return &testcomponents.Fake{}, nil
+			},
+		},
+	})
+
+	return controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:            l,
+			TraceProvider:     noop.NewTracerProvider(),
+			DataPath:          b.TempDir(),
+			MinStability:      featuregate.StabilityGenerallyAvailable,
+			OnBlockNodeUpdate: func(cn controller.BlockNode) { /* no-op */ },
+			NewModuleController: func(opts controller.ModuleControllerOpts) controller.ModuleController {
+				return fakeModuleController{}
+			},
+		},
+		ComponentRegistry: registry,
+		ApplyConcurrency:  concurrency,
+	}
+}
@@ -98,6 +98,7 @@ func (cm *controllerMetrics) Describe(ch chan<- *prometheus.Desc) {
 type controllerCollector struct {
 	l                      *Loader
 	runningComponentsTotal *prometheus.Desc
+	componentRestartsTotal *prometheus.Desc
 }
 
 func newControllerCollector(l *Loader, parent, id string) *controllerCollector {
@@ -109,6 +110,12 @@ func newControllerCollector(l *Loader, parent, id string) *controllerCollector {
 			[]string{"health_type"},
 			map[string]string{"controller_path": parent, "controller_id": id},
 		),
+		componentRestartsTotal: prometheus.NewDesc(
+			"alloy_component_restarts_total",
+			"Total number of times a component was restarted after exiting.",
+			[]string{"component_id"},
+			map[string]string{"controller_path": parent, "controller_id": id},
+		),
 	}
 }
 
@@ -119,7 +126,8 @@ func (cc *controllerCollector) Collect(ch chan<- prometheus.Metric) {
 		health := component.CurrentHealth().Health.String()
 		componentsByHealth[health]++
 		if builtinComponent, ok := component.(*BuiltinComponentNode); ok {
-			builtinComponent.registry.Collect(ch)
+			builtinComponent.registry.Load().Collect(ch)
+			ch <- prometheus.MustNewConstMetric(cc.componentRestartsTotal, prometheus.CounterValue, float64(builtinComponent.Restarts()), builtinComponent.NodeID())
 		}
 	}
 
@@ -136,4 +144,5 @@ func (cc *controllerCollector) Collect(ch chan<- prometheus.Metric) {
 
 func (cc *controllerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- cc.runningComponentsTotal
+	ch <- cc.componentRestartsTotal
 }
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/internal/importsource"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// LockModules loads the modules imported by the import blocks of body,
+// including the modules imported by these modules, and returns the versions
+// of the loaded modules which are recorded in the module lock file. Import
+// blocks nested in other blocks, such as declare blocks, are loaded as well.
+//
+// The import blocks of body are evaluated with modulePath as module path.
+// globals.ModuleLock selects the versions of the modules to load.
+func LockModules(body ast.Body, modulePath string, globals ComponentGlobals) ([]modlock.Entry, error) {
+	l := &moduleLocker{globals: globals}
+	defer l.stop()
+	if err := l.lockBody(body, modulePath); err != nil {
+		return nil, err
+	}
+	return l.entries, nil
+}
+
+type moduleLocker struct {
+	globals ComponentGlobals
+	nodes   []*ImportConfigNode
+	entries []modlock.Entry
+}
+
+func (l *moduleLocker) lockBody(body ast.Body, modulePath string) error {
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+
+		switch name := block.GetBlockName(); name {
+		case importsource.BlockImportFile, importsource.BlockImportString, importsource.BlockImportHTTP, importsource.BlockImportGit, importsource.BlockImportOCI:
+			if err := l.lockImport(block, name, modulePath); err != nil {
+				return err
+			}
+		default:
+			if err := l.lockBody(block.Body, modulePath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *moduleLocker) lockImport(block *ast.BlockStmt, name string, modulePath string) error {
+	if err := checkFeatureStability(name, l.globals.MinStability); err != nil {
+		return err
+	}
+
+	// Import blocks of different declare blocks may have the same label, so
+	// every import block gets its own data path.
+	globals := l.globals
+	globals.DataPath = filepath.Join(l.globals.DataPath, strconv.Itoa(len(l.nodes)))
+
+	cn := NewImportConfigNode(block, globals, importsource.GetSourceType(name))
+	err := cn.Evaluate(vm.NewScope(map[string]any{
+		importsource.ModulePath: modulePath,
+	}))
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", cn.NodeID(), err)
+	}
+	l.nodes = append(l.nodes, cn)
+	return l.collect(cn)
+}
+
+// collect records the version of the module loaded by cn and of the modules
+// it imports.
+func (l *moduleLocker) collect(cn *ImportConfigNode) error {
+	if health := cn.CurrentHealth(); health.Health == component.HealthTypeUnhealthy {
+		return fmt.Errorf("loading %s: %s", cn.NodeID(), health.Message)
+	}
+	if entry, ok := cn.LockEntry(); ok {
+		l.entries = append(l.entries, entry)
+	}
+
+	for _, child := range cn.ImportConfigNodesChildren() {
+		if err := l.collect(child); err != nil {
+			return err
+		}
+	}
+	// The declare blocks of the module may import other modules.
+	for _, body := range cn.ImportedDeclares() {
+		if err := l.lockBody(body, cn.source.ModulePath()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stop releases the resources held by the sources of the loaded modules, such
+// as file watchers, since the sources are never run.
+func (l *moduleLocker) stop() {
+	for _, cn := range l.nodes {
+		closeSources(cn)
+	}
+}
+
+func closeSources(cn *ImportConfigNode) {
+	if closer, ok := cn.source.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			level.Warn(cn.logger).Log("msg", "failed to close import source", "err", err)
+		}
+	}
+	for _, child := range cn.ImportConfigNodesChildren() {
+		closeSources(child)
+	}
+}
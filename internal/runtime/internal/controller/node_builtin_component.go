@@ -8,8 +8,11 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime/debug"
+	"runtime/pprof"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kit/log"
@@ -20,6 +23,9 @@ import (
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+	"github.com/grafana/alloy/internal/runtime/profiling"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/vm"
@@ -73,17 +79,20 @@ type ModuleControllerOpts struct {
 // ComponentGlobals are used by BuiltinComponentNodes to build managed components. All
 // BuiltinComponentNodes should use the same ComponentGlobals.
 type ComponentGlobals struct {
-	Logger               *logging.Logger                                  // Logger shared between all managed components.
-	TraceProvider        trace.TracerProvider                             // Tracer shared between all managed components.
-	DataPath             string                                           // Shared directory where component data may be stored
-	MinStability         featuregate.Stability                            // Minimum allowed stability level for features
-	OnBlockNodeUpdate    func(cn BlockNode)                               // Informs controller that we need to reevaluate
-	OnExportsChange      func(exports map[string]any)                     // Invoked when the managed component updated its exports
-	Registerer           prometheus.Registerer                            // Registerer for serving Alloy and component metrics
-	ControllerID         string                                           // ID of controller.
-	NewModuleController  func(opts ModuleControllerOpts) ModuleController // Func to generate a module controller.
-	GetServiceData       func(name string) (interface{}, error)           // Get data for a service.
-	EnableCommunityComps bool                                             // Enables the use of community components.
+	Logger                *logging.Logger                                  // Logger shared between all managed components.
+	TraceProvider         trace.TracerProvider                             // Tracer shared between all managed components.
+	DataPath              string                                           // Shared directory where component data may be stored
+	MinStability          featuregate.Stability                            // Minimum allowed stability level for features
+	OnBlockNodeUpdate     func(cn BlockNode)                               // Informs controller that we need to reevaluate
+	OnBlockNodeReevaluate func(cn BlockNode)                               // Informs controller that the node itself must be evaluated again
+	OnExportsChange       func(exports map[string]any)                     // Invoked when the managed component updated its exports
+	Registerer            prometheus.Registerer                            // Registerer for serving Alloy and component metrics
+	ControllerID          string                                           // ID of controller.
+	NewModuleController   func(opts ModuleControllerOpts) ModuleController // Func to generate a module controller.
+	GetServiceData        func(name string) (interface{}, error)           // Get data for a service.
+	EnableCommunityComps  bool                                             // Enables the use of community components.
+	ModuleLock            func() *modlock.Lock                             // Returns the lock pinning the versions of imported modules, may be nil.
+	RestartPolicy         *RestartPolicy                                   // Restart policy of components, may be nil.
 }
 
 // BuiltinComponentNode is a controller node which manages a builtin component.
@@ -99,9 +108,12 @@ type BuiltinComponentNode struct {
 	nodeID            string // Cached from id.String() to avoid allocating new strings every time NodeID is called.
 	reg               component.Registration
 	managedOpts       component.Options
-	registry          *prometheus.Registry
+	registry          atomic.Pointer[prometheus.Registry] // Replaced when the managed component is rebuilt
+	restartPolicy     *RestartPolicy
+	restarts          atomic.Int64 // Number of times the managed component was restarted
 	exportsType       reflect.Type
 	moduleController  ModuleController
+	pprofLabels       pprof.LabelSet     // Labels applied to the goroutines of the managed component
 	OnBlockNodeUpdate func(cn BlockNode) // Informs controller that we need to reevaluate
 
 	mut     sync.RWMutex
@@ -160,6 +172,8 @@ func NewBuiltinComponentNode(globals ComponentGlobals, reg component.Registratio
 		reg:               reg,
 		exportsType:       getExportsType(reg),
 		moduleController:  globals.NewModuleController(ModuleControllerOpts{Id: globalID}),
+		restartPolicy:     globals.RestartPolicy,
+		pprofLabels:       profiling.ComponentLabels(component.ID{ModuleID: globals.ControllerID, LocalID: nodeID}),
 		OnBlockNodeUpdate: globals.OnBlockNodeUpdate,
 
 		block: b,
@@ -180,16 +194,12 @@ func NewBuiltinComponentNode(globals ComponentGlobals, reg component.Registratio
 }
 
 func getManagedOptions(globals ComponentGlobals, cn *BuiltinComponentNode) component.Options {
-	cn.registry = prometheus.NewRegistry()
 	parent, id := splitPath(cn.globalID)
 	return component.Options{
-		ID:     cn.globalID,
-		Logger: log.With(globals.Logger, "component_path", parent, "component_id", id),
-		Registerer: prometheus.WrapRegistererWith(prometheus.Labels{
-			"component_path": parent,
-			"component_id":   id,
-		}, cn.registry),
-		Tracer: tracing.WrapTracer(globals.TraceProvider, cn.globalID),
+		ID:         cn.globalID,
+		Logger:     log.With(globals.Logger, "component_path", parent, "component_id", id),
+		Registerer: cn.newRegisterer(),
+		Tracer:     tracing.WrapTracer(globals.TraceProvider, cn.globalID),
 
 		DataPath: filepath.Join(globals.DataPath, cn.globalID),
 
@@ -204,6 +214,19 @@ func getManagedOptions(globals ComponentGlobals, cn *BuiltinComponentNode) compo
 	}
 }
 
+// newRegisterer replaces the registry of the managed component with an empty
+// one and returns a Registerer for it.
+func (cn *BuiltinComponentNode) newRegisterer() prometheus.Registerer {
+	registry := prometheus.NewRegistry()
+	cn.registry.Store(registry)
+
+	parent, id := splitPath(cn.globalID)
+	return prometheus.WrapRegistererWith(prometheus.Labels{
+		"component_path": parent,
+		"component_id":   id,
+	}, registry)
+}
+
 func getExportsType(reg component.Registration) reflect.Type {
 	if reg.Exports != nil {
 		return reflect.TypeOf(reg.Exports)
@@ -287,7 +310,7 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 
 	if cn.managed == nil {
 		// We haven't built the managed component successfully yet.
-		managed, err := cn.reg.Build(cn.managedOpts, argsCopyValue)
+		managed, err := cn.build(cn.managedOpts, argsCopyValue)
 		if err != nil {
 			return fmt.Errorf("building component: %w", err)
 		}
@@ -305,7 +328,7 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 	}
 
 	// Update the existing managed component
-	if err := cn.managed.Update(argsCopyValue); err != nil {
+	if err := cn.update(argsCopyValue); err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
 
@@ -317,8 +340,13 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 // canceled. Evaluate must have been called at least once without returning an
 // error before calling Run.
 //
+// If the managed component exits while ctx isn't canceled, it is rebuilt and
+// restarted according to the restart policy. Panics in the goroutine running
+// the managed component are recovered and handled like errors.
+//
 // Run will immediately return ErrUnevaluated if Evaluate has never been called
-// successfully. Otherwise, Run will return nil.
+// successfully. Otherwise, Run will return the error the managed component
+// last exited with.
 func (cn *BuiltinComponentNode) Run(ctx context.Context) error {
 	cn.mut.RLock()
 	managed := cn.managed
@@ -328,16 +356,131 @@ func (cn *BuiltinComponentNode) Run(ctx context.Context) error {
 		return ErrUnevaluated
 	}
 
-	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
+	// consecutive counts the restarts since the managed component last ran for
+	// longer than the maximum backoff.
+	var consecutive int
+
+	for {
+		cn.setRunHealth(component.HealthTypeHealthy, "started component")
 
+		started := time.Now()
+		err := cn.runManaged(ctx, managed)
+		if ctx.Err() != nil {
+			cn.setExitedHealth(err)
+			return err
+		}
+
+		policy := cn.restartPolicy.Get()
+		if policy == nil || !policy.ShouldRestart(err) {
+			cn.setExitedHealth(err)
+			return err
+		}
+		if time.Since(started) > policy.MaxBackoff {
+			consecutive = 0
+		}
+		if policy.MaxRestarts > 0 && consecutive >= policy.MaxRestarts {
+			cn.setRunHealth(component.HealthTypeExited, fmt.Sprintf("gave up restarting component after %d restarts: %s", consecutive, exitReason(err)))
+			return err
+		}
+
+		// Keep restarting until a rebuilt component runs or the policy gives up.
+		for {
+			backoff := policy.Backoff(consecutive)
+			cn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("%s, restarting in %s", exitReason(err), backoff))
+			level.Warn(cn.managedOpts.Logger).Log("msg", "restarting component", "backoff", backoff, "err", err)
+
+			select {
+			case <-ctx.Done():
+				cn.setExitedHealth(err)
+				return err
+			case <-time.After(backoff):
+			}
+
+			consecutive++
+			cn.restarts.Add(1)
+
+			managed, err = cn.rebuild()
+			if err == nil {
+				break
+			}
+			err = fmt.Errorf("building component: %w", err)
+			if policy.MaxRestarts > 0 && consecutive >= policy.MaxRestarts {
+				cn.setRunHealth(component.HealthTypeExited, fmt.Sprintf("gave up restarting component after %d restarts: %s", consecutive, exitReason(err)))
+				return err
+			}
+		}
+	}
+}
+
+// runManaged runs managed until it exits, recovering from panics.
+func (cn *BuiltinComponentNode) runManaged(ctx context.Context, managed component.Component) (err error) {
+	defer cn.recoverPanic(&err)
+
+	// Goroutines started by the managed component inherit the labels, so
+	// profiles can be attributed to the component.
+	pprof.Do(ctx, cn.pprofLabels, func(ctx context.Context) {
+		err = managed.Run(ctx)
+	})
+	return err
+}
+
+// build builds the managed component, recovering from panics.
+func (cn *BuiltinComponentNode) build(opts component.Options, args component.Arguments) (managed component.Component, err error) {
+	defer cn.recoverPanic(&err)
+	return cn.reg.Build(opts, args)
+}
+
+// update updates the managed component, recovering from panics.
+func (cn *BuiltinComponentNode) update(args component.Arguments) (err error) {
+	defer cn.recoverPanic(&err)
+	return cn.managed.Update(args)
+}
+
+// rebuild replaces the managed component with a new one built from the
+// current arguments. The new component gets an empty registry, since the
+// metrics of the previous component are still registered in the old one.
+func (cn *BuiltinComponentNode) rebuild() (component.Component, error) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	opts := cn.managedOpts
+	opts.Registerer = cn.newRegisterer()
+
+	managed, err := cn.build(opts, cn.args)
+	if err != nil {
+		return nil, err
+	}
+	cn.managed = managed
+	return managed, nil
+}
+
+// recoverPanic recovers from a panic of the managed component and stores it
+// in err. It must be deferred.
+func (cn *BuiltinComponentNode) recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		level.Error(cn.managedOpts.Logger).Log("msg", "component panicked", "panic", r, "stack", string(debug.Stack()))
+		*err = fmt.Errorf("component panicked: %v", r)
+	}
+}
+
+// setExitedHealth sets the health of the managed component after it exited
+// with err.
+func (cn *BuiltinComponentNode) setExitedHealth(err error) {
 	// Note: logging of this error is handled by the scheduler.
+	cn.setRunHealth(component.HealthTypeExited, exitReason(err))
+}
+
+func exitReason(err error) string {
 	if err != nil {
-		cn.setRunHealth(component.HealthTypeExited, fmt.Sprintf("component shut down with error: %s", err))
-	} else {
-		cn.setRunHealth(component.HealthTypeExited, "component shut down cleanly")
+		return fmt.Sprintf("component shut down with error: %s", err)
 	}
-	return err
+	return "component shut down cleanly"
+}
+
+// Restarts returns the number of times the managed component was restarted
+// after exiting.
+func (cn *BuiltinComponentNode) Restarts() int {
+	return int(cn.restarts.Load())
 }
 
 // ErrUnevaluated is returned if BuiltinComponentNode.Run is called before a managed
@@ -406,6 +549,9 @@ func (cn *BuiltinComponentNode) setExports(e component.Exports) {
 //  2. Health from the last call to Evaluate().
 //  3. Health reported from the component.
 func (cn *BuiltinComponentNode) CurrentHealth() component.Health {
+	// The managed component is replaced when it's restarted.
+	managed := cn.Component()
+
 	cn.healthMut.RLock()
 	defer cn.healthMut.RUnlock()
 
@@ -414,7 +560,7 @@ func (cn *BuiltinComponentNode) CurrentHealth() component.Health {
 		evalHealth = cn.evalHealth
 	)
 
-	if hc, ok := cn.managed.(component.HealthComponent); ok {
+	if hc, ok := managed.(component.HealthComponent); ok {
 		componentHealth := hc.CurrentHealth()
 		return component.LeastHealthy(runHealth, evalHealth, componentHealth)
 	}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/importoci"
+	"github.com/grafana/alloy/internal/nodeconf/restartpolicy"
 	"github.com/grafana/alloy/internal/runtime/internal/importsource"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
@@ -13,13 +16,17 @@ import (
 const (
 	argumentBlockID = "argument"
 	exportBlockID   = "export"
+	functionBlockID = "function"
 	loggingBlockID  = "logging"
 	tracingBlockID  = "tracing"
 )
 
 // Add config blocks that are not GA. Config blocks that are not specified here are considered GA.
 var configBlocksUnstable = map[string]featuregate.Stability{
-	foreach.Name: foreach.StabilityLevel,
+	foreach.Name:       foreach.StabilityLevel,
+	conditional.Name:   conditional.StabilityLevel,
+	importoci.Name:     importoci.StabilityLevel,
+	restartpolicy.Name: restartpolicy.StabilityLevel,
 }
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -42,14 +49,29 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals, customReg *Cu
 		return NewArgumentConfigNode(block, globals), nil
 	case exportBlockID:
 		return NewExportConfigNode(block, globals), nil
+	case functionBlockID:
+		if block.Label == "" {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  "function block must have a label",
+				StartPos: ast.StartPos(block).Position(),
+				EndPos:   ast.EndPos(block).Position(),
+			})
+			return nil, diags
+		}
+		return NewFunctionConfigNode(block), nil
 	case loggingBlockID:
 		return NewLoggingConfigNode(block, globals), nil
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals), nil
-	case importsource.BlockImportFile, importsource.BlockImportString, importsource.BlockImportHTTP, importsource.BlockImportGit:
+	case restartpolicy.Name:
+		return NewRestartPolicyConfigNode(block, globals), nil
+	case importsource.BlockImportFile, importsource.BlockImportString, importsource.BlockImportHTTP, importsource.BlockImportGit, importsource.BlockImportOCI:
 		return NewImportConfigNode(block, globals, importsource.GetSourceType(block.GetBlockName())), nil
 	case foreach.Name:
 		return NewForeachConfigNode(block, globals, customReg), nil
+	case conditional.Name:
+		return NewIfConfigNode(block, globals, customReg), nil
 	default:
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -73,24 +95,30 @@ func checkFeatureStability(blockName string, minStability featuregate.Stability)
 // This is helpful when validating node conditions specific to config node
 // types.
 type ConfigNodeMap struct {
-	logging     *LoggingConfigNode
-	tracing     *TracingConfigNode
-	argumentMap map[string]*ArgumentConfigNode
-	exportMap   map[string]*ExportConfigNode
-	importMap   map[string]*ImportConfigNode
-	foreachMap  map[string]*ForeachConfigNode
+	logging       *LoggingConfigNode
+	tracing       *TracingConfigNode
+	restartPolicy *RestartPolicyConfigNode
+	argumentMap   map[string]*ArgumentConfigNode
+	exportMap     map[string]*ExportConfigNode
+	functionMap   map[string]*FunctionConfigNode
+	importMap     map[string]*ImportConfigNode
+	foreachMap    map[string]*ForeachConfigNode
+	ifMap         map[string]*IfConfigNode
 }
 
 // NewConfigNodeMap will create an initial ConfigNodeMap. Append must be called
 // to populate NewConfigNodeMap.
 func NewConfigNodeMap() *ConfigNodeMap {
 	return &ConfigNodeMap{
-		logging:     nil,
-		tracing:     nil,
-		argumentMap: map[string]*ArgumentConfigNode{},
-		exportMap:   map[string]*ExportConfigNode{},
-		importMap:   map[string]*ImportConfigNode{},
-		foreachMap:  map[string]*ForeachConfigNode{},
+		logging:       nil,
+		tracing:       nil,
+		restartPolicy: nil,
+		argumentMap:   map[string]*ArgumentConfigNode{},
+		exportMap:     map[string]*ExportConfigNode{},
+		functionMap:   map[string]*FunctionConfigNode{},
+		importMap:     map[string]*ImportConfigNode{},
+		foreachMap:    map[string]*ForeachConfigNode{},
+		ifMap:         map[string]*IfConfigNode{},
 	}
 }
 
@@ -104,14 +132,20 @@ func (nodeMap *ConfigNodeMap) Append(configNode BlockNode) diag.Diagnostics {
 		nodeMap.argumentMap[n.Label()] = n
 	case *ExportConfigNode:
 		nodeMap.exportMap[n.Label()] = n
+	case *FunctionConfigNode:
+		nodeMap.functionMap[n.Label()] = n
 	case *LoggingConfigNode:
 		nodeMap.logging = n
 	case *TracingConfigNode:
 		nodeMap.tracing = n
+	case *RestartPolicyConfigNode:
+		nodeMap.restartPolicy = n
 	case *ImportConfigNode:
 		nodeMap.importMap[n.Label()] = n
 	case *ForeachConfigNode:
 		nodeMap.foreachMap[n.Label()] = n
+	case *IfConfigNode:
+		nodeMap.ifMap[n.Label()] = n
 	default:
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -160,6 +194,15 @@ func (nodeMap *ConfigNodeMap) ValidateModuleConstraints(isInModule bool) diag.Di
 				EndPos:   ast.EndPos(nodeMap.tracing.Block()).Position(),
 			})
 		}
+
+		if nodeMap.restartPolicy != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  "restart_policy block not allowed inside a module",
+				StartPos: ast.StartPos(nodeMap.restartPolicy.Block()).Position(),
+				EndPos:   ast.EndPos(nodeMap.restartPolicy.Block()).Position(),
+			})
+		}
 		return diags
 	}
 
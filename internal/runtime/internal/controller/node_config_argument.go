@@ -1,14 +1,30 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 	"sync"
 
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/otelcol"
 	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/typecheck"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
+// argumentTypes are the named types which can be used in the type of an
+// argument, in addition to the types of the Alloy syntax.
+var argumentTypes = map[string]reflect.Type{
+	"loki.LogsReceiver":          reflect.TypeOf((*loki.LogsReceiver)(nil)).Elem(),
+	"otelcol.Consumer":           reflect.TypeOf((*otelcol.Consumer)(nil)).Elem(),
+	"prometheus.MetricsReceiver": reflect.TypeOf((*storage.Appendable)(nil)).Elem(),
+}
+
 type ArgumentConfigNode struct {
 	label         string
 	nodeID        string
@@ -19,6 +35,8 @@ type ArgumentConfigNode struct {
 	eval         *vm.Evaluator
 	defaultValue any
 	optional     bool
+	argType      *typecheck.Type // nil if the argument accepts any value.
+	validations  []argumentValidation
 }
 
 var _ BlockNode = (*ArgumentConfigNode)(nil)
@@ -36,10 +54,99 @@ func NewArgumentConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *Argu
 	}
 }
 
+// argumentValueError is returned when the value given to an argument by the
+// caller of the module is missing or invalid.
+type argumentValueError struct {
+	err error
+}
+
+func (e argumentValueError) Error() string { return e.err.Error() }
+func (e argumentValueError) Unwrap() error { return e.err }
+
 type argumentBlock struct {
-	Optional bool   `alloy:"optional,attr,optional"`
-	Default  any    `alloy:"default,attr,optional"`
-	Comment  string `alloy:"comment,attr,optional"`
+	Optional   bool                 `alloy:"optional,attr,optional"`
+	Default    any                  `alloy:"default,attr,optional"`
+	Comment    string               `alloy:"comment,attr,optional"`
+	Type       string               `alloy:"type,attr,optional"`
+	Validation []argumentValidation `alloy:"validation,block,optional"`
+}
+
+// argumentValidation is a rule which the value of an argument must follow.
+type argumentValidation struct {
+	Regex        string   `alloy:"regex,attr,optional"`
+	Min          *float64 `alloy:"min,attr,optional"`
+	Max          *float64 `alloy:"max,attr,optional"`
+	NonEmpty     bool     `alloy:"non_empty,attr,optional"`
+	ErrorMessage string   `alloy:"error_message,attr,optional"`
+
+	regex *regexp.Regexp
+}
+
+// validate returns an error if value doesn't follow the rule. null values
+// only break the non_empty rule.
+func (v *argumentValidation) validate(value any) error {
+	err := v.check(value)
+	if err != nil && v.ErrorMessage != "" {
+		return errors.New(v.ErrorMessage)
+	}
+	return err
+}
+
+func (v *argumentValidation) check(value any) error {
+	if value == nil {
+		if v.NonEmpty {
+			return fmt.Errorf("value must not be empty")
+		}
+		return nil
+	}
+
+	if v.NonEmpty {
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			if rv.Len() == 0 {
+				return fmt.Errorf("value must not be empty")
+			}
+		}
+	}
+
+	if v.regex != nil {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("value must be a string to match regex %q", v.Regex)
+		}
+		if !v.regex.MatchString(s) {
+			return fmt.Errorf("value %q doesn't match regex %q", s, v.Regex)
+		}
+	}
+
+	if v.Min != nil || v.Max != nil {
+		n, ok := toFloat(value)
+		if !ok {
+			return fmt.Errorf("value must be a number to be checked against a range")
+		}
+		if v.Min != nil && n < *v.Min {
+			return fmt.Errorf("value %v is less than the minimum %v", value, *v.Min)
+		}
+		if v.Max != nil && n > *v.Max {
+			return fmt.Errorf("value %v is greater than the maximum %v", value, *v.Max)
+		}
+	}
+	return nil
+}
+
+func toFloat(value any) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed config block
@@ -57,12 +164,53 @@ func (cn *ArgumentConfigNode) Evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("decoding configuration: %w", err)
 	}
 
+	var argType *typecheck.Type
+	if argument.Type != "" {
+		var err error
+		if argType, err = typecheck.ParseType(argument.Type, argumentTypes); err != nil {
+			return fmt.Errorf("invalid type: %w", err)
+		}
+	}
+	for i := range argument.Validation {
+		v := &argument.Validation[i]
+		if v.Regex == "" {
+			continue
+		}
+		// The regex must match the whole value.
+		re, err := regexp.Compile("^(?:" + v.Regex + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid validation regex %q: %w", v.Regex, err)
+		}
+		v.regex = re
+	}
+
 	cn.defaultValue = argument.Default
 	cn.optional = argument.Optional
+	cn.argType = argType
+	cn.validations = argument.Validation
 
 	return nil
 }
 
+// Validate returns an error if value doesn't match the type of the argument
+// or doesn't follow its validation rules.
+func (cn *ArgumentConfigNode) Validate(value any) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	if cn.argType != nil {
+		if err := cn.argType.Check(value); err != nil {
+			return err
+		}
+	}
+	for i := range cn.validations {
+		if err := cn.validations[i].validate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (cn *ArgumentConfigNode) Optional() bool {
 	cn.mut.RLock()
 	defer cn.mut.RUnlock()
@@ -6,7 +6,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash/fnv"
+	"maps"
 	"path"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +20,7 @@ import (
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
 	"github.com/grafana/alloy/internal/runner"
+	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/vm"
@@ -27,6 +30,10 @@ import (
 // Each pipeline is managed by a custom component.
 // The custom component has access to the root scope (it can access exports and modules outside of the foreach template).
 // The collection may contain any item. Each child has one item from the collection associated to him and that can be accessed via the defined var argument.
+// The collection may also be an object, in which case each child gets an object with the key and the value of one entry.
+// Children are identified by the key of their item when the collection is an object or when a key expression is set,
+// so that changed items update their child instead of recreating it. Otherwise children are identified by a fingerprint of their item.
+// The exports of the children are exposed to the rest of the config through the exports of the foreach node.
 // Nesting foreach blocks is allowed.
 type ForeachConfigNode struct {
 	id               ComponentID
@@ -47,6 +54,12 @@ type ForeachConfigNode struct {
 	forEachChildrenUpdateChan chan struct{} // used to trigger an update of the running children
 	forEachChildrenRunning    bool
 
+	onBlockNodeUpdate func(cn BlockNode) // informs the controller that the exports changed
+
+	exportsMut   sync.RWMutex
+	childExports map[string]map[string]any // custom component ID -> exports of the child
+	instanceKeys map[string]string         // custom component ID -> key of the child in the exports
+
 	mut   sync.RWMutex
 	block *ast.BlockStmt
 	args  foreach.Arguments
@@ -86,6 +99,9 @@ func NewForeachConfigNode(block *ast.BlockStmt, globals ComponentGlobals, custom
 		forEachChildrenUpdateChan: make(chan struct{}, 1),
 		customComponents:          make(map[string]CustomComponent, 0),
 		customComponentHashCounts: make(map[string]int, 0),
+		onBlockNodeUpdate:         globals.OnBlockNodeUpdate,
+		childExports:              make(map[string]map[string]any),
+		instanceKeys:              make(map[string]string),
 	}
 }
 
@@ -115,10 +131,16 @@ func (fn *ForeachConfigNode) ComponentName() string {
 	return fn.componentName
 }
 
-// Exports returns nil as `foreach` doesn't have the ability to export values.
-// This is something we could implement in the future if there is a need for it.
+// Exports returns the exports of every child, keyed by the key of its
+// collection item. Children without a key are keyed by their ID.
 func (fn *ForeachConfigNode) Exports() component.Exports {
-	return nil
+	fn.exportsMut.RLock()
+	defer fn.exportsMut.RUnlock()
+	instances := make(map[string]map[string]any, len(fn.instanceKeys))
+	for id, key := range fn.instanceKeys {
+		instances[key] = fn.childExports[id]
+	}
+	return foreach.Exports{Instances: instances}
 }
 func (fn *ForeachConfigNode) ID() ComponentID {
 	return fn.id
@@ -141,13 +163,22 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 	fn.mut.Lock()
 	defer fn.mut.Unlock()
 
-	// Split the template block from the rest of the body because it should not be evaluated.
+	// Split the template block and the key attribute from the rest of the body because they should not be evaluated here.
 	var argsBody ast.Body
 	var template *ast.BlockStmt
+	var keyAttr *ast.AttributeStmt
 	for _, stmt := range fn.block.Body {
-		if blockStmt, ok := stmt.(*ast.BlockStmt); ok && blockStmt.GetBlockName() == foreach.TypeTemplate {
-			template = blockStmt
-			continue
+		switch stmt := stmt.(type) {
+		case *ast.BlockStmt:
+			if stmt.GetBlockName() == foreach.TypeTemplate {
+				template = stmt
+				continue
+			}
+		case *ast.AttributeStmt:
+			if stmt.Name.Name == foreach.AttrKey {
+				keyAttr = stmt
+				continue
+			}
 		}
 		argsBody = append(argsBody, stmt)
 	}
@@ -163,6 +194,15 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("decoding configuration: %w", err)
 	}
 
+	if keyAttr != nil && args.Id != "" {
+		return fmt.Errorf("the %q and %q arguments cannot be used together", "id", foreach.AttrKey)
+	}
+
+	items, err := collectionItems(args.Collection)
+	if err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+
 	// By default don't show debug metrics.
 	if args.EnableMetrics {
 		// If metrics should be enabled, just use the regular registry.
@@ -181,6 +221,7 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 		// a frequent runtime toggle, the overhead of recreating components is acceptable.
 		fn.moduleController = fn.moduleControllerFactory(fn.moduleControllerOpts)
 		fn.customComponents = make(map[string]CustomComponent)
+		fn.resetChildExports()
 		err := fn.runner.ApplyTasks(context.Background(), []*forEachChild{}) // stops all running children
 		if err != nil {
 			return fmt.Errorf("error stopping foreach children: %w", err)
@@ -191,46 +232,70 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 
 	// Loop through the items to create the custom components.
 	// On re-evaluation new components are added and existing ones are updated.
-	newCustomComponentIds := make(map[string]bool, len(args.Collection))
+	newCustomComponentIds := make(map[string]bool, len(items))
+	instanceKeys := make(map[string]string, len(items))
 	fn.customComponentHashCounts = make(map[string]int)
-	for i := 0; i < len(args.Collection); i++ {
-		// Using default value for id as whole collection object
-		id := args.Collection[i]
-
-		// Extract Id from collection if exists
-		if args.Id != "" {
-			if m, ok := args.Collection[i].(map[string]any); ok {
-				if val, exists := m[args.Id]; exists {
-					// Use the field's value for fingerprinting
-					id = val
-				} else {
-					level.Warn(fn.logger).Log("msg", "specified id not found in collection item", "id", args.Id)
-				}
+	for i, item := range items {
+		// Expose the current scope + the collection item that correspond to the child.
+		vars := deepCopyMap(scope.Variables)
+		vars[args.Var] = item.value
+
+		if keyAttr != nil {
+			var key any
+			if err := vm.New(keyAttr.Value).Evaluate(vm.NewScope(vars), &key); err != nil {
+				return fmt.Errorf("evaluating the key of the collection item %d: %w", i, err)
+			}
+			if key == nil {
+				return fmt.Errorf("the key of the collection item %d is null", i)
 			}
+			item.key = key
 		}
 
-		// We must create an ID from the collection entries to avoid recreating all components on every updates.
-		// We track the hash counts because the collection might contain duplicates ([1, 1, 1] would result in the same ids
-		// so we handle it by adding the count at the end -> [11, 12, 13]
-		customComponentID := fmt.Sprintf("foreach_%s", objectFingerprint(id))
-		count := fn.customComponentHashCounts[customComponentID] // count = 0 if the key is not found
-		fn.customComponentHashCounts[customComponentID] = count + 1
-		customComponentID += fmt.Sprintf("_%d", count+1)
+		var customComponentID, instanceKey string
+		if item.key != nil {
+			// Keyed items keep the same child as long as their key doesn't change, whatever their position in the collection.
+			customComponentID = keyedCustomComponentID(item.key)
+			instanceKey = fmt.Sprintf("%v", item.key)
+			if newCustomComponentIds[customComponentID] {
+				return fmt.Errorf("the key %q is not unique in the collection", instanceKey)
+			}
+		} else {
+			// Using default value for id as whole collection object
+			id := item.value
+
+			// Extract Id from collection if exists
+			if args.Id != "" {
+				if m, ok := item.value.(map[string]any); ok {
+					if val, exists := m[args.Id]; exists {
+						// Use the field's value for fingerprinting
+						id = val
+					} else {
+						level.Warn(fn.logger).Log("msg", "specified id not found in collection item", "id", args.Id)
+					}
+				}
+			}
+
+			// We must create an ID from the collection entries to avoid recreating all components on every updates.
+			// We track the hash counts because the collection might contain duplicates ([1, 1, 1] would result in the same ids
+			// so we handle it by adding the count at the end -> [11, 12, 13]
+			customComponentID = fmt.Sprintf("foreach_%s", objectFingerprint(id))
+			count := fn.customComponentHashCounts[customComponentID] // count = 0 if the key is not found
+			fn.customComponentHashCounts[customComponentID] = count + 1
+			customComponentID += fmt.Sprintf("_%d", count+1)
+			instanceKey = customComponentID
+		}
 
 		cc, err := fn.getOrCreateCustomComponent(customComponentID)
 		if err != nil {
 			return err
 		}
 
-		// Expose the current scope + the collection item that correspond to the child.
-		vars := deepCopyMap(scope.Variables)
-		vars[args.Var] = args.Collection[i]
-
 		customComponentRegistry := NewCustomComponentRegistry(fn.customReg, vm.NewScope(vars))
 		if err := cc.LoadBody(template.Body, map[string]any{}, customComponentRegistry); err != nil {
 			return fmt.Errorf("updating custom component in foreach: %w", err)
 		}
 		newCustomComponentIds[customComponentID] = true
+		instanceKeys[customComponentID] = instanceKey
 	}
 
 	// Delete the custom components that are no longer in the foreach.
@@ -240,6 +305,7 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 			delete(fn.customComponents, id)
 		}
 	}
+	fn.setInstanceKeys(instanceKeys)
 
 	// Trigger to stop previous children from running and to start running the new ones.
 	if fn.forEachChildrenRunning {
@@ -251,6 +317,37 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 	return nil
 }
 
+// collectionItem is an item of the foreach collection.
+type collectionItem struct {
+	value any // value exposed to the template via the var argument
+	key   any // identity of the child, nil when the child is identified by a fingerprint of its value
+}
+
+// collectionItems returns the items of an array or of an object collection.
+// The entries of an object are sorted by key and are identified by their key.
+func collectionItems(collection any) ([]collectionItem, error) {
+	switch c := collection.(type) {
+	case []any:
+		items := make([]collectionItem, 0, len(c))
+		for _, v := range c {
+			items = append(items, collectionItem{value: v})
+		}
+		return items, nil
+	case map[string]any:
+		keys := slices.Sorted(maps.Keys(c))
+		items := make([]collectionItem, 0, len(c))
+		for _, k := range keys {
+			items = append(items, collectionItem{
+				value: map[string]any{"key": k, "value": c[k]},
+				key:   k,
+			})
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("collection %#v should be array or object, got %T", collection, collection)
+	}
+}
+
 // Assumes that a lock is held,
 // so that fn.moduleController doesn't change while the function is running.
 func (fn *ForeachConfigNode) getOrCreateCustomComponent(customComponentID string) (CustomComponent, error) {
@@ -259,7 +356,9 @@ func (fn *ForeachConfigNode) getOrCreateCustomComponent(customComponentID string
 		return cc, nil
 	}
 
-	newCC, err := fn.moduleController.NewCustomComponent(customComponentID, func(exports map[string]any) {})
+	newCC, err := fn.moduleController.NewCustomComponent(customComponentID, func(exports map[string]any) {
+		fn.setChildExports(customComponentID, exports)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("creating custom component: %w", err)
 	}
@@ -267,6 +366,38 @@ func (fn *ForeachConfigNode) getOrCreateCustomComponent(customComponentID string
 	return newCC, nil
 }
 
+// setChildExports is called whenever a child updates its exports.
+func (fn *ForeachConfigNode) setChildExports(customComponentID string, exports map[string]any) {
+	fn.exportsMut.Lock()
+	changed := !equality.DeepEqual(fn.childExports[customComponentID], exports)
+	fn.childExports[customComponentID] = exports
+	fn.exportsMut.Unlock()
+
+	if changed {
+		// Inform the controller that we have new exports.
+		fn.onBlockNodeUpdate(fn)
+	}
+}
+
+// setInstanceKeys sets the instance key of every child and forgets the
+// exports of the children which were removed.
+func (fn *ForeachConfigNode) setInstanceKeys(instanceKeys map[string]string) {
+	fn.exportsMut.Lock()
+	defer fn.exportsMut.Unlock()
+	fn.instanceKeys = instanceKeys
+	for id := range fn.childExports {
+		if _, exist := instanceKeys[id]; !exist {
+			delete(fn.childExports, id)
+		}
+	}
+}
+
+func (fn *ForeachConfigNode) resetChildExports() {
+	fn.exportsMut.Lock()
+	defer fn.exportsMut.Unlock()
+	fn.childExports = make(map[string]map[string]any)
+}
+
 func (fn *ForeachConfigNode) UpdateBlock(b *ast.BlockStmt) {
 	fn.mut.Lock()
 	defer fn.mut.Unlock()
@@ -420,6 +551,14 @@ func computeHash(s string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// keyedCustomComponentID returns the ID of the child of the collection item
+// with the given key. The ID is derived from a hash of the typed key, so that
+// keys which only differ in punctuation, such as target addresses, get
+// distinct children. The key itself is only kept in instanceKeys.
+func keyedCustomComponentID(key any) string {
+	return "foreach_" + computeHash(fmt.Sprintf("%T:%v", key, key))
+}
+
 func objectFingerprint(id any) string {
 	// TODO: Test what happens if there is a "true" string and a true bool in the collection.
 	switch v := id.(type) {
@@ -15,6 +15,7 @@ import (
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/foreach"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/parser"
@@ -284,7 +285,7 @@ func TestCollectionNonArrayValue(t *testing.T) {
 		}
 	}`
 	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
-	require.ErrorContains(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))), `"aaa" should be array, got string`)
+	require.ErrorContains(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))), `collection "aaa" should be array or object, got string`)
 }
 
 func TestModuleControllerUpdate(t *testing.T) {
@@ -305,6 +306,148 @@ func TestModuleControllerUpdate(t *testing.T) {
 	require.ElementsMatch(t, customComponentIds, []string{"foreach_1_1", "foreach_2_1", "foreach_3_1"})
 }
 
+func TestCreateCustomComponentsCollectionMap(t *testing.T) {
+	config := `foreach "default" {
+		collection = {"b" = 2, "a.1" = 1}
+		var = "entry"
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	customComponentIds := foreachConfigNode.moduleController.(*ModuleControllerMock).CustomComponents
+	require.ElementsMatch(t, []string{keyedCustomComponentID("a.1"), keyedCustomComponentID("b")}, customComponentIds)
+
+	// Changing the value of an entry updates its child instead of recreating it.
+	newConfig := `foreach "default" {
+		collection = {"b" = 3, "c" = 1}
+		var = "entry"
+		template {
+		}
+	}`
+	foreachConfigNode.moduleController.(*ModuleControllerMock).Reset()
+	foreachConfigNode.UpdateBlock(getBlockFromConfig(t, newConfig))
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	customComponentIds = foreachConfigNode.moduleController.(*ModuleControllerMock).CustomComponents
+	require.Equal(t, []string{keyedCustomComponentID("c")}, customComponentIds)
+
+	keys := make([]string, 0, len(foreachConfigNode.customComponents))
+	for key := range foreachConfigNode.customComponents {
+		keys = append(keys, key)
+	}
+	require.ElementsMatch(t, keys, []string{keyedCustomComponentID("b"), keyedCustomComponentID("c")})
+}
+
+func TestCreateCustomComponentsWithKey(t *testing.T) {
+	config := `foreach "default" {
+		collection = [{"name" = "a", "port" = 80}, {"name" = "b", "port" = 81}]
+		var = "item"
+		key = item.name
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	customComponentIds := foreachConfigNode.moduleController.(*ModuleControllerMock).CustomComponents
+	require.ElementsMatch(t, []string{keyedCustomComponentID("a"), keyedCustomComponentID("b")}, customComponentIds)
+
+	// Reordering and editing items doesn't recreate the children.
+	newConfig := `foreach "default" {
+		collection = [{"name" = "b", "port" = 82}, {"name" = "a", "port" = 80}]
+		var = "item"
+		key = item.name
+		template {
+		}
+	}`
+	foreachConfigNode.moduleController.(*ModuleControllerMock).Reset()
+	foreachConfigNode.UpdateBlock(getBlockFromConfig(t, newConfig))
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	require.Empty(t, foreachConfigNode.moduleController.(*ModuleControllerMock).CustomComponents)
+	require.Len(t, foreachConfigNode.customComponents, 2)
+}
+
+func TestCreateCustomComponentsDuplicatedKeys(t *testing.T) {
+	config := `foreach "default" {
+		collection = [{"name" = "a"}, {"name" = "a"}]
+		var = "item"
+		key = item.name
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.ErrorContains(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))), `the key "a" is not unique in the collection`)
+}
+
+func TestCreateCustomComponentsKeysDifferingInPunctuation(t *testing.T) {
+	config := `foreach "default" {
+		collection = ["a-b", "a_b", "10.0.0.1:80", "10_0_0_1_80"]
+		var = "item"
+		key = item
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	require.Len(t, foreachConfigNode.customComponents, 4)
+
+	keys := make([]string, 0, len(foreachConfigNode.instanceKeys))
+	for _, key := range foreachConfigNode.instanceKeys {
+		keys = append(keys, key)
+	}
+	require.ElementsMatch(t, []string{"a-b", "a_b", "10.0.0.1:80", "10_0_0_1_80"}, keys)
+}
+
+func TestKeyAndIdConflict(t *testing.T) {
+	config := `foreach "default" {
+		collection = [{"name" = "a"}]
+		var = "item"
+		id = "name"
+		key = item.name
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.ErrorContains(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))), `the "id" and "key" arguments cannot be used together`)
+}
+
+func TestForeachExports(t *testing.T) {
+	config := `foreach "default" {
+		collection = {"a" = 1, "b" = 2}
+		var = "entry"
+		template {
+		}
+	}`
+	var updates atomic.Int32
+	globals := getComponentGlobals(t)
+	globals.OnBlockNodeUpdate = func(cn BlockNode) { updates.Inc() }
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), globals, nil)
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+
+	exportFuncs := foreachConfigNode.moduleController.(*ModuleControllerMock).ExportFuncs
+	exportFuncs[keyedCustomComponentID("a")](map[string]any{"output": 1})
+	exportFuncs[keyedCustomComponentID("b")](map[string]any{"output": 2})
+	// Unchanged exports don't trigger an update.
+	exportFuncs[keyedCustomComponentID("b")](map[string]any{"output": 2})
+	require.Equal(t, int32(2), updates.Load())
+	require.Equal(t, foreach.Exports{Instances: map[string]map[string]any{
+		"a": {"output": 1},
+		"b": {"output": 2},
+	}}, foreachConfigNode.Exports())
+
+	// The exports of removed children are dropped.
+	newConfig := `foreach "default" {
+		collection = {"a" = 1}
+		var = "entry"
+		template {
+		}
+	}`
+	foreachConfigNode.UpdateBlock(getBlockFromConfig(t, newConfig))
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	require.Equal(t, foreach.Exports{Instances: map[string]map[string]any{
+		"a": {"output": 1},
+	}}, foreachConfigNode.Exports())
+}
+
 func getBlockFromConfig(t *testing.T, config string) *ast.BlockStmt {
 	file, err := parser.ParseFile("", []byte(config))
 	require.NoError(t, err)
@@ -328,11 +471,13 @@ func getComponentGlobals(t *testing.T) ComponentGlobals {
 
 type ModuleControllerMock struct {
 	CustomComponents []string
+	ExportFuncs      map[string]component.ExportFunc
 }
 
 func NewModuleControllerMock() ModuleController {
 	return &ModuleControllerMock{
 		CustomComponents: make([]string, 0),
+		ExportFuncs:      make(map[string]component.ExportFunc),
 	}
 }
 
@@ -346,6 +491,7 @@ func (m *ModuleControllerMock) ModuleIDs() []string {
 
 func (m *ModuleControllerMock) NewCustomComponent(id string, export component.ExportFunc) (CustomComponent, error) {
 	m.CustomComponents = append(m.CustomComponents, id)
+	m.ExportFuncs[id] = export
 	return &CustomComponentMock{}, nil
 }
 
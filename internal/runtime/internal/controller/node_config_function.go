@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/grafana/alloy/internal/runtime/internal/dag"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// FunctionConfigNode represents a function block in the DAG. Functions are
+// exposed to other expressions of the module as function.LABEL.
+type FunctionConfigNode struct {
+	label         string
+	nodeID        string
+	componentName string
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current Alloy blocks to derive config from
+	eval  *vm.Evaluator
+	value any
+}
+
+var _ BlockNode = (*FunctionConfigNode)(nil)
+
+// NewFunctionConfigNode creates a new FunctionConfigNode from an initial ast.BlockStmt.
+// The underlying config isn't applied until Evaluate is called.
+func NewFunctionConfigNode(block *ast.BlockStmt) *FunctionConfigNode {
+	return &FunctionConfigNode{
+		label:         block.Label,
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}
+}
+
+type functionBlock struct {
+	Value   any    `alloy:"value,attr"`
+	Comment string `alloy:"comment,attr,optional"`
+}
+
+// Evaluate implements BlockNode and updates the function value by
+// re-evaluating its Alloy block with the provided scope.
+//
+// Evaluate will return an error if the Alloy block cannot be evaluated or if
+// its value is not a function.
+func (cn *FunctionConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	var function functionBlock
+	if err := cn.eval.Evaluate(scope, &function); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+	if function.Value == nil || reflect.TypeOf(function.Value).Kind() != reflect.Func {
+		return fmt.Errorf("the value of function %q must be a function, for example (x) => x", cn.label)
+	}
+	cn.value = function.Value
+	return nil
+}
+
+func (cn *FunctionConfigNode) Label() string { return cn.label }
+
+// Value returns the function value.
+func (cn *FunctionConfigNode) Value() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.value
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *FunctionConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *FunctionConfigNode) NodeID() string { return cn.nodeID }
+
+// UpdateBlock updates the Alloy block used to construct the function.
+// The new block isn't used until the next time Evaluate is invoked.
+//
+// UpdateBlock will panic if the block does not match the component ID of the
+// FunctionConfigNode.
+func (cn *FunctionConfigNode) UpdateBlock(b *ast.BlockStmt) {
+	if !BlockComponentID(b).Equals(strings.Split(cn.nodeID, ".")) {
+		panic("UpdateBlock called with an Alloy block with a different ID")
+	}
+
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+	cn.block = b
+	cn.eval = vm.New(b.Body)
+}
+
+// evaluateFunctions evaluates function blocks which can only reference each
+// other, such as the function blocks of an imported module. It returns the
+// evaluated functions by label.
+//
+// An error is returned if a function references an unknown function, if the
+// functions reference each other in a cycle or if a function fails to
+// evaluate.
+func evaluateFunctions(blocks map[string]*ast.BlockStmt) (map[string]any, error) {
+	var g dag.Graph
+	for _, block := range blocks {
+		g.Add(NewFunctionConfigNode(block))
+	}
+
+	for _, n := range g.Nodes() {
+		fn := n.(*FunctionConfigNode)
+		for _, t := range expressionsFromBody(fn.Block().Body) {
+			if t[0].Name != functionBlockID || len(t) < 2 {
+				continue
+			}
+			target := g.GetByID(functionBlockID + "." + t[1].Name)
+			if target == nil {
+				return nil, fmt.Errorf("function %q references unknown function %q", fn.Label(), t[1].Name)
+			}
+			g.AddEdge(dag.Edge{From: fn, To: target})
+		}
+	}
+
+	if err := dag.Validate(&g); err != nil {
+		return nil, errors.Join(err.(*multierror.Error).Errors...)
+	}
+
+	// Functions are looked up when they are called, so the scope can be filled
+	// while the functions are evaluated.
+	functions := make(map[string]any, len(blocks))
+	scope := vm.NewScope(map[string]any{functionBlockID: functions})
+
+	err := dag.WalkTopological(&g, g.Leaves(), func(n dag.Node) error {
+		fn := n.(*FunctionConfigNode)
+		if err := fn.Evaluate(scope); err != nil {
+			return fmt.Errorf("function %q: %w", fn.Label(), err)
+		}
+		functions[fn.Label()] = fn.Value()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
+
+// unavailableFunctions returns a function for each of the function blocks
+// which fails with err when called. It is used to report why functions which
+// failed to evaluate can't be called instead of reporting them as missing.
+func unavailableFunctions(blocks map[string]*ast.BlockStmt, err error) map[string]any {
+	functions := make(map[string]any, len(blocks))
+	for label := range blocks {
+		functions[label] = func(...any) (any, error) {
+			return nil, fmt.Errorf("function %q is unavailable: %w", label, err)
+		}
+	}
+	return functions
+}
@@ -0,0 +1,378 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
+	"github.com/grafana/alloy/internal/runner"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+const (
+	// ifBranchThen is the ID of the custom component running the blocks of
+	// the if body.
+	ifBranchThen = "then"
+	// ifBranchElse is the ID of the custom component running the blocks of
+	// the else block.
+	ifBranchElse = "else"
+)
+
+// The IfConfigNode runs the pipeline defined in its body when its condition argument is true,
+// and the pipeline defined in its optional else block when the condition is false.
+// The running pipeline is managed by a custom component.
+// Like for foreach, the custom component has access to the root scope.
+// When the value of the condition changes, the components of the previous branch are stopped
+// and the components of the other branch are created.
+// Nesting if blocks is allowed.
+type IfConfigNode struct {
+	id               ComponentID
+	nodeID           string
+	label            string
+	componentName    string
+	moduleController ModuleController
+
+	logger log.Logger
+
+	// customReg is the customComponentRegistry of the current loader.
+	// We pass it so that the branches have access to modules.
+	customReg *CustomComponentRegistry
+
+	branch        *ifBranch // branch selected by the last evaluation, nil if none
+	runningBranch *ifBranch // branch passed to the runner by the last update
+
+	branchUpdateChan chan struct{} // used to trigger an update of the running branch
+	branchRunning    bool
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt
+	args  conditional.Arguments
+
+	moduleControllerFactory func(opts ModuleControllerOpts) ModuleController
+	moduleControllerOpts    ModuleControllerOpts
+
+	healthMut  sync.RWMutex
+	evalHealth component.Health // Health of the last evaluate
+	runHealth  component.Health // Health of running the component
+
+	dataFlowEdgeMut  sync.RWMutex
+	dataFlowEdgeRefs []string
+
+	runner *runner.Runner[*ifBranch]
+}
+
+var _ ComponentNode = (*IfConfigNode)(nil)
+
+func NewIfConfigNode(block *ast.BlockStmt, globals ComponentGlobals, customReg *CustomComponentRegistry) *IfConfigNode {
+	nodeID := BlockComponentID(block).String()
+	globalID := nodeID
+	if globals.ControllerID != "" {
+		globalID = path.Join(globals.ControllerID, nodeID)
+	}
+
+	return &IfConfigNode{
+		nodeID:                  nodeID,
+		label:                   block.Label,
+		block:                   block,
+		componentName:           block.GetBlockName(),
+		id:                      BlockComponentID(block),
+		logger:                  log.With(globals.Logger, "component_path", globals.ControllerID, "component_id", nodeID),
+		moduleControllerFactory: globals.NewModuleController,
+		moduleControllerOpts:    ModuleControllerOpts{Id: globalID},
+		customReg:               customReg,
+		branchUpdateChan:        make(chan struct{}, 1),
+	}
+}
+
+func (fn *IfConfigNode) Label() string { return fn.label }
+
+func (fn *IfConfigNode) NodeID() string { return fn.nodeID }
+
+func (fn *IfConfigNode) Block() *ast.BlockStmt {
+	fn.mut.RLock()
+	defer fn.mut.RUnlock()
+	return fn.block
+}
+
+func (fn *IfConfigNode) Arguments() component.Arguments {
+	fn.mut.RLock()
+	defer fn.mut.RUnlock()
+	return fn.args
+}
+
+func (fn *IfConfigNode) ModuleIDs() []string {
+	fn.mut.RLock()
+	defer fn.mut.RUnlock()
+	if fn.moduleController == nil {
+		return nil
+	}
+	return fn.moduleController.ModuleIDs()
+}
+
+func (fn *IfConfigNode) ComponentName() string {
+	return fn.componentName
+}
+
+// Exports returns nil as `if` doesn't have the ability to export values.
+func (fn *IfConfigNode) Exports() component.Exports {
+	return nil
+}
+
+func (fn *IfConfigNode) ID() ComponentID {
+	return fn.id
+}
+
+func (fn *IfConfigNode) Evaluate(evalScope *vm.Scope) error {
+	err := fn.evaluate(evalScope)
+
+	switch err {
+	case nil:
+		fn.setEvalHealth(component.HealthTypeHealthy, "if evaluated")
+	default:
+		msg := fmt.Sprintf("if evaluation failed: %s", err)
+		fn.setEvalHealth(component.HealthTypeUnhealthy, msg)
+	}
+	return err
+}
+
+func (fn *IfConfigNode) evaluate(scope *vm.Scope) error {
+	fn.mut.Lock()
+	defer fn.mut.Unlock()
+
+	// Split the arguments from the blocks of both branches because the blocks should not be evaluated here.
+	var (
+		argsBody  ast.Body
+		thenBody  ast.Body
+		elseBlock *ast.BlockStmt
+	)
+	for _, stmt := range fn.block.Body {
+		blockStmt, ok := stmt.(*ast.BlockStmt)
+		switch {
+		case !ok:
+			argsBody = append(argsBody, stmt)
+		case blockStmt.GetBlockName() == conditional.TypeElse:
+			if elseBlock != nil {
+				return fmt.Errorf("only one else block is allowed in the if block")
+			}
+			elseBlock = blockStmt
+		default:
+			thenBody = append(thenBody, stmt)
+		}
+	}
+
+	eval := vm.New(argsBody)
+
+	var args conditional.Arguments
+	if err := eval.Evaluate(scope, &args); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+	fn.args = args
+
+	if fn.moduleController == nil {
+		fn.moduleController = fn.moduleControllerFactory(fn.moduleControllerOpts)
+	}
+
+	branchID, body := ifBranchThen, thenBody
+	if !args.Condition {
+		branchID, body = ifBranchElse, nil
+		if elseBlock != nil {
+			body = elseBlock.Body
+		}
+	}
+
+	switch {
+	case !args.Condition && elseBlock == nil:
+		// Nothing runs when the condition is false and there is no else block.
+		fn.branch = nil
+	case fn.branch == nil || fn.branch.id != branchID:
+		// The condition changed: the components of the other branch are created from scratch.
+		cc, err := fn.moduleController.NewCustomComponent(branchID, func(exports map[string]any) {})
+		if err != nil {
+			return fmt.Errorf("creating custom component: %w", err)
+		}
+		fn.branch = &ifBranch{id: branchID, cc: cc}
+	}
+
+	if fn.branch != nil {
+		// Expose the current scope to the branch.
+		vars := deepCopyMap(scope.Variables)
+		customComponentRegistry := NewCustomComponentRegistry(fn.customReg, vm.NewScope(vars))
+		if err := fn.branch.cc.LoadBody(body, map[string]any{}, customComponentRegistry); err != nil {
+			return fmt.Errorf("updating custom component in if: %w", err)
+		}
+	}
+
+	// Trigger to stop the previous branch from running and to start running the new one.
+	if fn.branchRunning {
+		select {
+		case fn.branchUpdateChan <- struct{}{}: // queued trigger
+		default: // trigger already queued; no-op
+		}
+	}
+	return nil
+}
+
+func (fn *IfConfigNode) UpdateBlock(b *ast.BlockStmt) {
+	fn.mut.Lock()
+	defer fn.mut.Unlock()
+	fn.block = b
+}
+
+func (fn *IfConfigNode) Run(ctx context.Context) error {
+	newCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fn.runner = runner.New(func(branch *ifBranch) runner.Worker {
+		return &ifBranchRunner{
+			branch:       branch,
+			logger:       log.With(fn.logger, "if_path", fn.nodeID, "branch", branch.id),
+			healthUpdate: fn.setRunHealth,
+		}
+	})
+	defer fn.runner.Stop()
+
+	updateTasks := func() error {
+		fn.mut.Lock()
+		defer fn.mut.Unlock()
+		fn.branchRunning = true
+
+		// A branch which was selected again since the last update reuses the module ID of its previous
+		// custom component. The previous one must be stopped before the new one starts so that the
+		// module ID is not registered twice.
+		if fn.runningBranch != nil && fn.runningBranch != fn.branch {
+			if err := fn.runner.ApplyTasks(newCtx, []*ifBranch{}); err != nil {
+				return err
+			}
+		}
+		fn.runningBranch = fn.branch
+
+		var tasks []*ifBranch
+		if fn.branch != nil {
+			tasks = append(tasks, fn.branch)
+		}
+		return fn.runner.ApplyTasks(newCtx, tasks)
+	}
+
+	fn.setRunHealth(component.HealthTypeHealthy, "started if")
+
+	err := updateTasks()
+	if err != nil {
+		return fmt.Errorf("running if branch failed: %w", err)
+	}
+
+	fn.run(ctx, updateTasks)
+	fn.setRunHealth(component.HealthTypeExited, "if node shut down cleanly")
+
+	return nil
+}
+
+func (fn *IfConfigNode) run(ctx context.Context, updateTasks func() error) {
+	for {
+		select {
+		case <-fn.branchUpdateChan:
+			err := updateTasks()
+			if err != nil {
+				level.Error(fn.logger).Log("msg", "error encountered while updating if branch", "err", err)
+				fn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("error encountered while updating if branch: %s", err))
+				// the error is not fatal, the node can still run in unhealthy mode
+			} else {
+				fn.setRunHealth(component.HealthTypeHealthy, "if branch updated successfully")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CurrentHealth returns the current health of the IfConfigNode.
+//
+// The health of an IfConfigNode is determined by combining:
+//
+//  1. Health from the call to Run().
+//  2. Health from the last call to Evaluate().
+func (fn *IfConfigNode) CurrentHealth() component.Health {
+	fn.healthMut.RLock()
+	defer fn.healthMut.RUnlock()
+	return component.LeastHealthy(fn.runHealth, fn.evalHealth)
+}
+
+func (fn *IfConfigNode) setEvalHealth(t component.HealthType, msg string) {
+	fn.healthMut.Lock()
+	defer fn.healthMut.Unlock()
+
+	fn.evalHealth = component.Health{
+		Health:     t,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
+
+func (fn *IfConfigNode) setRunHealth(t component.HealthType, msg string) {
+	fn.healthMut.Lock()
+	defer fn.healthMut.Unlock()
+
+	fn.runHealth = component.Health{
+		Health:     t,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
+
+func (fn *IfConfigNode) AddDataFlowEdgeTo(nodeID string) {
+	fn.dataFlowEdgeMut.Lock()
+	defer fn.dataFlowEdgeMut.Unlock()
+	fn.dataFlowEdgeRefs = append(fn.dataFlowEdgeRefs, nodeID)
+}
+
+func (fn *IfConfigNode) GetDataFlowEdgesTo() []string {
+	fn.dataFlowEdgeMut.RLock()
+	defer fn.dataFlowEdgeMut.RUnlock()
+	return fn.dataFlowEdgeRefs
+}
+
+func (fn *IfConfigNode) ResetDataFlowEdgeTo() {
+	fn.dataFlowEdgeMut.Lock()
+	defer fn.dataFlowEdgeMut.Unlock()
+	fn.dataFlowEdgeRefs = []string{}
+}
+
+type ifBranchRunner struct {
+	branch       *ifBranch
+	logger       log.Logger
+	healthUpdate func(t component.HealthType, msg string)
+}
+
+// ifBranch is the custom component running the blocks of a branch. A new
+// ifBranch is created every time a branch is selected, so tasks are compared
+// by identity rather than by ID.
+type ifBranch struct {
+	id string
+	cc CustomComponent
+}
+
+func (br *ifBranchRunner) Run(ctx context.Context) {
+	err := br.branch.cc.Run(ctx)
+	if err != nil {
+		level.Error(br.logger).Log("msg", "if branch stopped running", "err", err)
+		br.healthUpdate(component.HealthTypeUnhealthy, fmt.Sprintf("if branch stopped running: %s", err))
+	}
+}
+
+func (b *ifBranch) Hash() uint64 {
+	fnvHash := fnv.New64a()
+	fnvHash.Write([]byte(b.id))
+	return fnvHash.Sum64()
+}
+
+func (b *ifBranch) Equals(other runner.Task) bool {
+	return b == other.(*ifBranch)
+}
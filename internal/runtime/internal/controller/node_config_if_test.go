@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+func TestIfCreatesBranch(t *testing.T) {
+	tt := []struct {
+		name           string
+		config         string
+		expectBranches []string
+	}{
+		{
+			name: "true",
+			config: `if "default" {
+				condition = true
+			}`,
+			expectBranches: []string{"then"},
+		},
+		{
+			name: "false with else",
+			config: `if "default" {
+				condition = false
+				else {
+				}
+			}`,
+			expectBranches: []string{"else"},
+		},
+		{
+			name: "false without else",
+			config: `if "default" {
+				condition = false
+			}`,
+			expectBranches: []string{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ifConfigNode := NewIfConfigNode(getBlockFromConfig(t, tc.config), getComponentGlobals(t), nil)
+			require.NoError(t, ifConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+			customComponentIds := ifConfigNode.moduleController.(*ModuleControllerMock).CustomComponents
+			require.Equal(t, tc.expectBranches, customComponentIds)
+		})
+	}
+}
+
+func TestIfConditionFromScope(t *testing.T) {
+	config := `if "default" {
+		condition = env == "prod"
+		else {
+		}
+	}`
+	ifConfigNode := NewIfConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, ifConfigNode.Evaluate(vm.NewScope(map[string]interface{}{"env": "dev"})))
+	require.Equal(t, "else", ifConfigNode.branch.id)
+
+	require.NoError(t, ifConfigNode.Evaluate(vm.NewScope(map[string]interface{}{"env": "prod"})))
+	require.Equal(t, "then", ifConfigNode.branch.id)
+	customComponentIds := ifConfigNode.moduleController.(*ModuleControllerMock).CustomComponents
+	require.Equal(t, []string{"else", "then"}, customComponentIds)
+}
+
+func TestIfReevaluateKeepsBranch(t *testing.T) {
+	config := `if "default" {
+		condition = true
+	}`
+	ifConfigNode := NewIfConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, ifConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	branch := ifConfigNode.branch
+
+	// Re-evaluating with the same condition must not recreate the branch.
+	require.NoError(t, ifConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	require.Same(t, branch, ifConfigNode.branch)
+	customComponentIds := ifConfigNode.moduleController.(*ModuleControllerMock).CustomComponents
+	require.Equal(t, []string{"then"}, customComponentIds)
+}
+
+func TestIfInvalidConfig(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "missing condition",
+			config: `if "default" {
+			}`,
+			expectError: `missing required attribute "condition"`,
+		},
+		{
+			name: "non bool condition",
+			config: `if "default" {
+				condition = "yes"
+			}`,
+			expectError: `"yes" should be bool, got string`,
+		},
+		{
+			name: "multiple else",
+			config: `if "default" {
+				condition = true
+				else {
+				}
+				else {
+				}
+			}`,
+			expectError: "only one else block is allowed in the if block",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ifConfigNode := NewIfConfigNode(getBlockFromConfig(t, tc.config), getComponentGlobals(t), nil)
+			require.ErrorContains(t, ifConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))), tc.expectError)
+		})
+	}
+}
+
+func TestRunIfAfterConditionChange(t *testing.T) {
+	config := `if "default" {
+		condition = enabled
+		else {
+		}
+	}`
+	ifConfigNode := NewIfConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	evaluate := func(enabled bool) *CustomComponentMock {
+		require.NoError(t, ifConfigNode.Evaluate(vm.NewScope(map[string]interface{}{"enabled": enabled})))
+		return ifConfigNode.branch.cc.(*CustomComponentMock)
+	}
+
+	thenBranch := evaluate(true)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	runErr := make(chan error, 1)
+	go func() { runErr <- ifConfigNode.Run(ctx) }()
+
+	require.Eventually(t, thenBranch.IsRunning.Load, 1*time.Second, 5*time.Millisecond)
+
+	// Switching to the else branch stops the then branch.
+	elseBranch := evaluate(false)
+	require.Eventually(t, func() bool {
+		return elseBranch.IsRunning.Load() && !thenBranch.IsRunning.Load()
+	}, 1*time.Second, 5*time.Millisecond)
+
+	// Switching back creates a new then branch.
+	newThenBranch := evaluate(true)
+	require.NotSame(t, thenBranch, newThenBranch)
+	require.Eventually(t, func() bool {
+		return newThenBranch.IsRunning.Load() && !elseBranch.IsRunning.Load()
+	}, 1*time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-runErr)
+	require.False(t, newThenBranch.IsRunning.Load())
+}
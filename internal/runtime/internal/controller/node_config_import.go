@@ -20,14 +20,15 @@ import (
 	"github.com/grafana/alloy/internal/runner"
 	"github.com/grafana/alloy/internal/runtime/internal/importsource"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/parser"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
-// ImportConfigNode imports declare and import blocks via a managed import source.
-// The imported declare are stored in importedDeclares.
+// ImportConfigNode imports declare, function and import blocks via a managed import source.
+// The imported declare are stored in importedDeclares and the evaluated functions in importedFunctions.
 // For every imported import block, the ImportConfigNode will create ImportConfigNode children.
 // The children are evaluated and ran by the parent.
 // When an ImportConfigNode receives new content from its source, it updates its importedDeclares and recreates its children.
@@ -53,6 +54,7 @@ type ImportConfigNode struct {
 	importConfigNodesChildren map[string]*ImportConfigNode
 	importChildrenRunning     bool
 	importedDeclares          map[string]ast.Body
+	importedFunctions         map[string]any
 
 	// NOTE: To avoid deadlocks, whenever we need both locks we must always first lock the mut, then healthMut.
 	healthMut     sync.RWMutex
@@ -87,7 +89,7 @@ func NewImportConfigNode(block *ast.BlockStmt, globals ComponentGlobals, sourceT
 	}
 	managedOpts := getImportManagedOptions(globals, cn)
 	cn.logger = managedOpts.Logger
-	cn.source = importsource.NewImportSource(sourceType, managedOpts, vm.New(block.Body), cn.onContentUpdate)
+	cn.source = importsource.NewImportSource(sourceType, managedOpts, vm.New(block.Body), globals.ModuleLock, cn.onContentUpdate)
 	return cn
 }
 
@@ -212,8 +214,11 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 		cn.importedContent[k] = v
 	}
 	cn.importedDeclares = make(map[string]ast.Body)
+	cn.importedFunctions = nil
 	cn.importConfigNodesChildren = make(map[string]*ImportConfigNode)
 
+	functionBlocks := make(map[string]*ast.BlockStmt)
+
 	for f, ic := range importedContent {
 		parsedImportedContent, err := parser.ParseFile(cn.label, []byte(ic))
 		if err != nil {
@@ -222,8 +227,8 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 			return
 		}
 
-		// populate importedDeclares and importConfigNodesChildren
-		err = cn.processImportedContent(parsedImportedContent)
+		// populate importedDeclares, functionBlocks and importConfigNodesChildren
+		err = cn.processImportedContent(parsedImportedContent, functionBlocks)
 		if err != nil {
 			level.Error(cn.logger).Log("msg", "failed to process imported content", "file", f, "err", err)
 			cn.setContentHealth(component.HealthTypeUnhealthy, fmt.Sprintf("imported content from %q is invalid: %s", f, err))
@@ -231,8 +236,26 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 		}
 	}
 
+	// Functions and custom components share the namespace of the import.
+	for name := range functionBlocks {
+		if _, ok := cn.importedDeclares[name]; ok {
+			level.Error(cn.logger).Log("msg", "function block has the same name as a declare block", "name", name)
+			cn.setContentHealth(component.HealthTypeUnhealthy, fmt.Sprintf("imported function %q has the same name as an imported declare block", name))
+			return
+		}
+	}
+
+	importedFunctions, err := evaluateFunctions(functionBlocks)
+	if err != nil {
+		level.Error(cn.logger).Log("msg", "failed to evaluate imported functions", "err", err)
+		cn.setContentHealth(component.HealthTypeUnhealthy, fmt.Sprintf("imported functions failed to evaluate: %s", err))
+		cn.importedFunctions = unavailableFunctions(functionBlocks, err)
+		return
+	}
+	cn.importedFunctions = importedFunctions
+
 	// evaluate the importConfigNodesChildren that have been created
-	err := cn.evaluateChildren()
+	err = cn.evaluateChildren()
 	if err != nil {
 		level.Error(cn.logger).Log("msg", "failed to evaluate nested import", "err", err)
 		cn.setContentHealth(component.HealthTypeUnhealthy, fmt.Sprintf("nested import block failed to evaluate: %s", err))
@@ -251,25 +274,34 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 	cn.OnBlockNodeUpdate(cn)
 }
 
-// processImportedContent processes declare and import blocks of the provided ast content.
-func (cn *ImportConfigNode) processImportedContent(content *ast.File) error {
+// processImportedContent processes declare, function and import blocks of the provided ast content.
+// Function blocks are collected into functionBlocks so they can be evaluated together.
+func (cn *ImportConfigNode) processImportedContent(content *ast.File, functionBlocks map[string]*ast.BlockStmt) error {
 	for _, stmt := range content.Body {
 		blockStmt, ok := stmt.(*ast.BlockStmt)
 		if !ok {
-			return fmt.Errorf("only declare and import blocks are allowed in a module")
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module")
 		}
 
 		componentName := strings.Join(blockStmt.Name, ".")
 		switch componentName {
 		case declareType:
 			cn.processDeclareBlock(blockStmt)
-		case importsource.BlockImportFile, importsource.BlockImportString, importsource.BlockImportHTTP, importsource.BlockImportGit:
+		case functionBlockID:
+			if blockStmt.Label == "" {
+				return fmt.Errorf("function block must have a label")
+			}
+			if _, ok := functionBlocks[blockStmt.Label]; ok {
+				return fmt.Errorf("function block redefined %s", blockStmt.Label)
+			}
+			functionBlocks[blockStmt.Label] = blockStmt
+		case importsource.BlockImportFile, importsource.BlockImportString, importsource.BlockImportHTTP, importsource.BlockImportGit, importsource.BlockImportOCI:
 			err := cn.processImportBlock(blockStmt, componentName)
 			if err != nil {
 				return err
 			}
 		default:
-			return fmt.Errorf("only declare and import blocks are allowed in a module, got %s", componentName)
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module, got %s", componentName)
 		}
 	}
 	return nil
@@ -286,6 +318,9 @@ func (cn *ImportConfigNode) processDeclareBlock(stmt *ast.BlockStmt) {
 
 // processDeclareBlock creates an ImportConfigNode child from the provided import block.
 func (cn *ImportConfigNode) processImportBlock(stmt *ast.BlockStmt, fullName string) error {
+	if err := checkFeatureStability(fullName, cn.globals.MinStability); err != nil {
+		return err
+	}
 	sourceType := importsource.GetSourceType(fullName)
 	if _, ok := cn.importConfigNodesChildren[stmt.Label]; ok {
 		return fmt.Errorf("import block redefined %s", stmt.Label)
@@ -434,6 +469,13 @@ func (cn *ImportConfigNode) ImportedDeclares() map[string]ast.Body {
 	return cn.importedDeclares
 }
 
+// ImportedFunctions returns all functions that it imported.
+func (cn *ImportConfigNode) ImportedFunctions() map[string]any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.importedFunctions
+}
+
 // Scope returns the scope associated with the import source.
 func (cn *ImportConfigNode) Scope() *vm.Scope {
 	return vm.NewScope(map[string]interface{}{
@@ -441,6 +483,17 @@ func (cn *ImportConfigNode) Scope() *vm.Scope {
 	})
 }
 
+// LockEntry returns the version of the module loaded by the source. It
+// returns false if the source isn't recorded in the module lock file or if no
+// module is loaded.
+func (cn *ImportConfigNode) LockEntry() (modlock.Entry, bool) {
+	locked, ok := cn.source.(importsource.LockedSource)
+	if !ok {
+		return modlock.Entry{}, false
+	}
+	return locked.LockEntry()
+}
+
 // ImportConfigNodesChildren returns the ImportConfigNodesChildren of this ImportConfigNode.
 func (cn *ImportConfigNode) ImportConfigNodesChildren() map[string]*ImportConfigNode {
 	cn.mut.Lock()
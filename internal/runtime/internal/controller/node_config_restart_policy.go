@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/grafana/alloy/internal/nodeconf/restartpolicy"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// RestartPolicy holds the restart policy of components. It is set by the
+// restart_policy block of the root controller and shared with the
+// controllers of its modules.
+type RestartPolicy struct {
+	args atomic.Pointer[restartpolicy.Arguments]
+}
+
+// NewRestartPolicy returns a RestartPolicy which doesn't restart components
+// until it is set.
+func NewRestartPolicy() *RestartPolicy {
+	return &RestartPolicy{}
+}
+
+// Get returns the current policy, or nil if components must not be
+// restarted. Get may be called on a nil RestartPolicy.
+func (p *RestartPolicy) Get() *restartpolicy.Arguments {
+	if p == nil {
+		return nil
+	}
+	return p.args.Load()
+}
+
+func (p *RestartPolicy) set(args *restartpolicy.Arguments) {
+	if p != nil {
+		p.args.Store(args)
+	}
+}
+
+var _ BlockNode = (*RestartPolicyConfigNode)(nil)
+
+// RestartPolicyConfigNode is a controller node which sets the restart policy
+// of components from a restart_policy block.
+type RestartPolicyConfigNode struct {
+	nodeID        string
+	componentName string
+	policy        *RestartPolicy
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current Alloy blocks to derive config from
+	eval  *vm.Evaluator
+}
+
+// NewRestartPolicyConfigNode creates a new RestartPolicyConfigNode from an
+// initial ast.BlockStmt. The policy isn't applied until Evaluate is called.
+func NewRestartPolicyConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *RestartPolicyConfigNode {
+	return &RestartPolicyConfigNode{
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+		policy:        globals.RestartPolicy,
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}
+}
+
+// Evaluate implements BlockNode and updates the restart policy by
+// re-evaluating its Alloy block with the provided scope.
+//
+// Evaluate will return an error if the Alloy block cannot be evaluated or if
+// decoding to arguments fails.
+func (cn *RestartPolicyConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	var args restartpolicy.Arguments
+	if err := cn.eval.Evaluate(scope, &args); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+	cn.policy.set(&args)
+	return nil
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *RestartPolicyConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *RestartPolicyConfigNode) NodeID() string { return cn.nodeID }
+
+// UpdateBlock updates the Alloy block used to construct arguments.
+// The new block isn't used until the next time Evaluate is invoked.
+//
+// UpdateBlock will panic if the block does not match the component ID of the
+// RestartPolicyConfigNode.
+func (cn *RestartPolicyConfigNode) UpdateBlock(b *ast.BlockStmt) {
+	if !BlockComponentID(b).Equals(strings.Split(cn.nodeID, ".")) {
+		panic("UpdateBlock called with an Alloy block with a different ID")
+	}
+
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+	cn.block = b
+	cn.eval = vm.New(b.Body)
+}
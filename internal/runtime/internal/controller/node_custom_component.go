@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
@@ -208,11 +210,31 @@ func (cn *CustomComponentNode) evaluate(evalScope *vm.Scope) error {
 
 	// Reload the custom component with new config
 	if err := cn.managed.LoadBody(template, args, customComponentRegistry); err != nil {
-		return fmt.Errorf("updating custom component: %w", err)
+		return fmt.Errorf("updating custom component: %w", cn.withBlockPosition(err))
 	}
 	return nil
 }
 
+// withBlockPosition reports the diagnostics of err which have no position,
+// such as the errors in the values given to the arguments of the custom
+// component, on the block of the custom component.
+func (cn *CustomComponentNode) withBlockPosition(err error) error {
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		return err
+	}
+
+	res := make(diag.Diagnostics, len(diags))
+	copy(res, diags)
+	for i := range res {
+		if !res[i].StartPos.Valid() {
+			res[i].StartPos = ast.StartPos(cn.block).Position()
+			res[i].EndPos = ast.EndPos(cn.block).Position()
+		}
+	}
+	return res
+}
+
 func (cn *CustomComponentNode) Run(ctx context.Context) error {
 	cn.mut.RLock()
 	managed := cn.managed
@@ -19,6 +19,9 @@ type Queue struct {
 type QueuedNode struct {
 	Node            BlockNode
 	LastUpdatedTime time.Time
+	// Reevaluate is set when Node itself must be evaluated again, instead of
+	// the nodes which depend on it.
+	Reevaluate bool
 }
 
 // NewQueue returns a new queue.
@@ -17,20 +17,24 @@ const argumentLabel = "argument"
 // The exports are stored directly in the scope which is used to evaluate Alloy expressions.
 type valueCache struct {
 	mut                sync.RWMutex
-	componentIds       map[string]ComponentID // NodeID -> ComponentID
-	moduleExports      map[string]any         // Export label -> Export value
-	moduleArguments    map[string]any         // Argument label -> Map with the key "value" that points to the Argument value
-	moduleChangedIndex int                    // Everytime a change occurs this is incremented
-	scope              *vm.Scope              // scope provides additional context for the nodes in the module
+	componentIds       map[string]ComponentID    // NodeID -> ComponentID
+	moduleExports      map[string]any            // Export label -> Export value
+	moduleArguments    map[string]any            // Argument label -> Map with the key "value" that points to the Argument value
+	functions          map[string]any            // Function label -> Function value
+	importedFunctions  map[string]map[string]any // Import label -> Function name -> Function value
+	moduleChangedIndex int                       // Everytime a change occurs this is incremented
+	scope              *vm.Scope                 // scope provides additional context for the nodes in the module
 }
 
 // newValueCache creates a new ValueCache.
 func newValueCache() *valueCache {
 	return &valueCache{
-		componentIds:    make(map[string]ComponentID, 0),
-		moduleExports:   make(map[string]any),
-		moduleArguments: make(map[string]any),
-		scope:           vm.NewScope(make(map[string]any)),
+		componentIds:      make(map[string]ComponentID, 0),
+		moduleExports:     make(map[string]any),
+		moduleArguments:   make(map[string]any),
+		functions:         make(map[string]any),
+		importedFunctions: make(map[string]map[string]any),
+		scope:             vm.NewScope(make(map[string]any)),
 	}
 }
 
@@ -87,6 +91,41 @@ func (vc *valueCache) CacheModuleArgument(key string, value any) {
 	vc.moduleArguments[key] = keyMap
 }
 
+// CacheFunction caches the value of a function block using its label.
+func (vc *valueCache) CacheFunction(label string, value any) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	if value == nil {
+		delete(vc.functions, label)
+		return
+	}
+	vc.functions[label] = value
+}
+
+// CacheImportedFunctions caches the functions imported by the import block
+// with the given label.
+func (vc *valueCache) CacheImportedFunctions(label string, functions map[string]any) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	if len(functions) == 0 {
+		delete(vc.importedFunctions, label)
+		return
+	}
+	vc.importedFunctions[label] = functions
+}
+
+// ClearFunctions removes all the cached functions. Functions are cached again
+// when their nodes are evaluated.
+func (vc *valueCache) ClearFunctions() {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	vc.functions = make(map[string]any)
+	vc.importedFunctions = make(map[string]map[string]any)
+}
+
 // CacheModuleExportValue saves the value to the map
 func (vc *valueCache) CacheModuleExportValue(name string, value any) {
 	vc.mut.Lock()
@@ -215,6 +254,24 @@ func (vc *valueCache) GetContext() *vm.Scope {
 		vars[argumentLabel] = deepCopyMap(vc.moduleArguments)
 	}
 
+	// Add local functions if there are any.
+	if len(vc.functions) > 0 {
+		vars[functionBlockID] = deepCopyMap(vc.functions)
+	}
+
+	// Imported functions share the namespace of the import with the custom
+	// components declared by the import.
+	for label, functions := range vc.importedFunctions {
+		namespace, ok := vars[label].(map[string]any)
+		if !ok {
+			namespace = make(map[string]any, len(functions))
+			vars[label] = namespace
+		}
+		for name, fn := range functions {
+			namespace[name] = fn
+		}
+	}
+
 	return vm.NewScope(vars)
 }
 
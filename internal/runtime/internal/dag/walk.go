@@ -1,5 +1,11 @@
 package dag
 
+import (
+	"sync"
+
+	"github.com/grafana/alloy/internal/runtime/internal/worker"
+)
+
 // WalkFunc is a function that gets invoked when walking a Graph. Walking will
 // stop if WalkFunc returns a non-nil error.
 type WalkFunc func(n Node) error
@@ -105,3 +111,83 @@ func WalkTopological(g *Graph, start []Node, fn WalkFunc) error {
 
 	return nil
 }
+
+// WalkTopologicalParallel is like WalkTopological, but invokes fn
+// concurrently for nodes which don't depend on each other. A node is
+// submitted to pool once all of its outgoing edges have been visited.
+// WalkTopologicalParallel blocks until fn has returned for every visited
+// node.
+//
+// If fn returns an error, no further nodes are submitted and the first error
+// is returned. If pool can't accept a node, fn is invoked for it on the
+// calling goroutine instead.
+func WalkTopologicalParallel(g *Graph, start []Node, pool worker.Pool, fn WalkFunc) error {
+	var (
+		wg  sync.WaitGroup
+		mut sync.Mutex
+
+		visited       = make(nodeSet)
+		remainingDeps = make(map[Node]int)
+		firstErr      error
+	)
+
+	var submit func(n Node)
+
+	visit := func(n Node) {
+		defer wg.Done()
+		err := fn(n)
+
+		mut.Lock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if firstErr != nil {
+			mut.Unlock()
+			return
+		}
+
+		// Queue the nodes depending on n once all of their dependencies have
+		// been visited.
+		var ready []Node
+		for dependant := range g.inEdges[n] {
+			if _, ok := remainingDeps[dependant]; !ok {
+				remainingDeps[dependant] = len(g.outEdges[dependant])
+			}
+			remainingDeps[dependant]--
+
+			if remainingDeps[dependant] == 0 && !visited.Has(dependant) {
+				visited.Add(dependant)
+				ready = append(ready, dependant)
+			}
+		}
+		mut.Unlock()
+
+		for _, dependant := range ready {
+			submit(dependant)
+		}
+	}
+
+	submit = func(n Node) {
+		wg.Add(1)
+		if err := pool.SubmitWithKey(n.NodeID(), func() { visit(n) }); err != nil {
+			visit(n)
+		}
+	}
+
+	mut.Lock()
+	var ready []Node
+	for _, n := range start {
+		if !visited.Has(n) {
+			visited.Add(n)
+			ready = append(ready, n)
+		}
+	}
+	mut.Unlock()
+
+	for _, n := range ready {
+		submit(n)
+	}
+
+	wg.Wait()
+	return firstErr
+}
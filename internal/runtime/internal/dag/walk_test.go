@@ -0,0 +1,105 @@
+package dag
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/runtime/internal/worker"
+)
+
+func TestWalkTopologicalParallel(t *testing.T) {
+	// d depends on b and c, which both depend on a. e is independent.
+	var g Graph
+	var (
+		nodeA = stringNode("a")
+		nodeB = stringNode("b")
+		nodeC = stringNode("c")
+		nodeD = stringNode("d")
+		nodeE = stringNode("e")
+	)
+	for _, n := range []Node{nodeA, nodeB, nodeC, nodeD, nodeE} {
+		g.Add(n)
+	}
+	g.AddEdge(Edge{nodeB, nodeA})
+	g.AddEdge(Edge{nodeC, nodeA})
+	g.AddEdge(Edge{nodeD, nodeB})
+	g.AddEdge(Edge{nodeD, nodeC})
+
+	pool := worker.NewFixedWorkerPool(4, 10)
+	defer pool.Stop()
+
+	var (
+		mut        sync.Mutex
+		visited    []Node
+		outOfOrder []Node
+	)
+	err := WalkTopologicalParallel(&g, g.Leaves(), pool, func(n Node) error {
+		mut.Lock()
+		defer mut.Unlock()
+
+		for _, dep := range g.Dependencies(n) {
+			if !slices.Contains(visited, dep) {
+				outOfOrder = append(outOfOrder, n)
+			}
+		}
+		visited = append(visited, n)
+		return nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []Node{nodeA, nodeB, nodeC, nodeD, nodeE}, visited)
+	require.Empty(t, outOfOrder, "nodes must be visited after their dependencies")
+}
+
+func TestWalkTopologicalParallel_Error(t *testing.T) {
+	var g Graph
+	var (
+		nodeA = stringNode("a")
+		nodeB = stringNode("b")
+	)
+	g.Add(nodeA)
+	g.Add(nodeB)
+	g.AddEdge(Edge{nodeB, nodeA})
+
+	pool := worker.NewFixedWorkerPool(2, 10)
+	defer pool.Stop()
+
+	var (
+		errFailed = errors.New("failed")
+		visited   []Node
+	)
+	err := WalkTopologicalParallel(&g, g.Leaves(), pool, func(n Node) error {
+		visited = append(visited, n)
+		return errFailed
+	})
+	require.ErrorIs(t, err, errFailed)
+	require.Equal(t, []Node{nodeA}, visited, "dependants of a failed node must not be visited")
+}
+
+func TestWalkTopologicalParallel_FullPool(t *testing.T) {
+	var g Graph
+	for _, id := range []string{"a", "b", "c", "d"} {
+		g.Add(stringNode(id))
+	}
+
+	// A pool which can't queue all the nodes evaluates the rest on the
+	// calling goroutine.
+	pool := worker.NewFixedWorkerPool(1, 1)
+	defer pool.Stop()
+
+	var (
+		mut   sync.Mutex
+		count int
+	)
+	err := WalkTopologicalParallel(&g, g.Leaves(), pool, func(Node) error {
+		mut.Lock()
+		defer mut.Unlock()
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+}
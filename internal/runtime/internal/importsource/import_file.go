@@ -252,6 +252,19 @@ func collectFilesFromDir(path string) ([]string, error) {
 	return files, nil
 }
 
+// Close stops watching the file. It's used to release the detector of a
+// source which is evaluated but never run.
+func (im *ImportFile) Close() error {
+	im.mut.Lock()
+	defer im.mut.Unlock()
+	if im.detector == nil {
+		return nil
+	}
+	err := im.detector.Close()
+	im.detector = nil
+	return err
+}
+
 // Update the evaluator.
 func (im *ImportFile) SetEval(eval *vm.Evaluator) {
 	im.eval = eval
@@ -2,6 +2,8 @@ package importsource
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -14,6 +16,7 @@ import (
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
 	"github.com/grafana/alloy/internal/vcs"
 	"github.com/grafana/alloy/syntax"
 	"github.com/grafana/alloy/syntax/vm"
@@ -29,7 +32,10 @@ type ImportGit struct {
 	repo            *vcs.GitRepo
 	repoOpts        vcs.GitRepoOptions
 	args            GitArguments
+	revision        string // revision set in the block, args.Revision may be a locked commit
 	repoPath        string
+	cache           *moduleCache
+	moduleLock      func() *modlock.Lock
 	onContentChange func(map[string]string)
 
 	argsChanged chan struct{}
@@ -40,6 +46,7 @@ type ImportGit struct {
 
 var (
 	_ ImportSource              = (*ImportGit)(nil)
+	_ LockedSource              = (*ImportGit)(nil)
 	_ component.Component       = (*ImportGit)(nil)
 	_ component.HealthComponent = (*ImportGit)(nil)
 )
@@ -48,6 +55,7 @@ type GitArguments struct {
 	Repository    string            `alloy:"repository,attr"`
 	Revision      string            `alloy:"revision,attr,optional"`
 	Path          string            `alloy:"path,attr"`
+	Commit        string            `alloy:"commit,attr,optional"`
 	PullFrequency time.Duration     `alloy:"pull_frequency,attr,optional"`
 	GitAuthConfig vcs.GitAuthConfig `alloy:",squash"`
 }
@@ -69,6 +77,12 @@ func (args *GitArguments) Validate() error {
 		return fmt.Errorf("revision cannot be a special git reference such as HEAD, FETCH_HEAD, ORIG_HEAD, MERGE_HEAD, or CHERRY_PICK_HEAD")
 	}
 
+	if args.Commit != "" {
+		if b, err := hex.DecodeString(args.Commit); err != nil || len(b) != sha1.Size {
+			return fmt.Errorf("commit must be a full commit SHA, got %q", args.Commit)
+		}
+	}
+
 	return nil
 }
 
@@ -77,11 +91,13 @@ func (args *GitArguments) SetToDefault() {
 	*args = DefaultGitArguments
 }
 
-func NewImportGit(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportGit {
+func NewImportGit(managedOpts component.Options, eval *vm.Evaluator, moduleLock func() *modlock.Lock, onContentChange func(map[string]string)) *ImportGit {
 	return &ImportGit{
 		opts:            managedOpts,
 		log:             managedOpts.Logger,
 		eval:            eval,
+		cache:           newModuleCache(managedOpts),
+		moduleLock:      moduleLock,
 		argsChanged:     make(chan struct{}, 1),
 		onContentChange: onContentChange,
 	}
@@ -92,6 +108,8 @@ func (im *ImportGit) Evaluate(scope *vm.Scope) error {
 	if err := im.eval.Evaluate(scope, &arguments); err != nil {
 		return fmt.Errorf("decoding configuration: %w", err)
 	}
+	revision := arguments.Revision
+	arguments = lockGitArguments(currentLock(im.moduleLock), arguments)
 
 	if equality.DeepEqual(im.args, arguments) {
 		return nil
@@ -100,9 +118,26 @@ func (im *ImportGit) Evaluate(scope *vm.Scope) error {
 	if err := im.Update(arguments); err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
+
+	im.mut.Lock()
+	im.revision = revision
+	im.mut.Unlock()
 	return nil
 }
 
+// lockGitArguments returns args with the revision replaced by the commit
+// recorded in lock for the repository and revision, if any. A commit set in
+// the block takes precedence over the lock.
+func lockGitArguments(lock *modlock.Lock, args GitArguments) GitArguments {
+	entry, ok := lock.Git(args.Repository, args.Revision)
+	if !ok || args.Commit != "" {
+		return args
+	}
+	args.Revision = entry.Commit
+	args.Commit = entry.Commit
+	return args
+}
+
 func (im *ImportGit) Run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -152,7 +187,7 @@ func (im *ImportGit) updateTicker(pullFrequency time.Duration, ticker *time.Tick
 
 func (im *ImportGit) tickPollFile(ctx context.Context) {
 	im.mut.Lock()
-	err := im.pollFile(ctx, im.args)
+	err := im.fetch(ctx, im.args)
 	pullFrequency := im.args.PullFrequency
 	im.mut.Unlock()
 
@@ -168,11 +203,7 @@ func (im *ImportGit) updateHealth(err error) {
 	defer im.healthMut.Unlock()
 
 	if err != nil {
-		im.health = component.Health{
-			Health:     component.HealthTypeUnhealthy,
-			Message:    err.Error(),
-			UpdateTime: time.Now(),
-		}
+		im.health = im.cache.FetchErrorHealth(err)
 	} else {
 		im.health = component.Health{
 			Health:     component.HealthTypeHealthy,
@@ -183,13 +214,12 @@ func (im *ImportGit) updateHealth(err error) {
 }
 
 // Update implements component.Component.
-// Only acknowledge the error from Update if it's not a
-// vcs.UpdateFailedError; vcs.UpdateFailedError means that the Git repo
-// exists, but we were unable to update it. It makes sense to retry on the next poll and it may succeed.
-func (im *ImportGit) Update(args component.Arguments) (err error) {
-	defer func() {
-		im.updateHealth(err)
-	}()
+// When the module can't be fetched, the module fetched by a previous run with
+// the same arguments is loaded from the cache instead. Otherwise, the error is
+// only acknowledged if it's not a vcs.UpdateFailedError for a repository which
+// is already loaded; it makes sense to retry on the next poll and it may
+// succeed.
+func (im *ImportGit) Update(args component.Arguments) error {
 	im.mut.Lock()
 	defer im.mut.Unlock()
 
@@ -200,38 +230,21 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 	// the two different repositories.
 	im.repoPath = filepath.Join(im.opts.DataPath, "repo")
 
-	repoOpts := vcs.GitRepoOptions{
-		Repository: newArgs.Repository,
-		Revision:   newArgs.Revision,
-		Auth:       newArgs.GitAuthConfig,
-	}
-
-	// Create or update the repo field.
-	// Failure to update repository makes the module loader temporarily use cached contents on disk
-	if im.repo == nil || !equality.DeepEqual(repoOpts, im.repoOpts) {
-		r, err := vcs.NewGitRepo(context.Background(), im.repoPath, repoOpts)
-		if err != nil {
-			if errors.As(err, &vcs.UpdateFailedError{}) {
-				level.Error(im.log).Log("msg", "failed to update repository", "err", err)
-				im.updateHealth(err)
-			} else {
-				return err
-			}
-		}
-		im.repo = r
-		im.repoOpts = repoOpts
-	}
-
-	if err := im.pollFile(context.Background(), newArgs); err != nil {
-		if errors.As(err, &vcs.UpdateFailedError{}) {
-			level.Error(im.log).Log("msg", "failed to poll file from repository", "err", err)
-			// We don't update the health here because it will be updated via the defer call.
-			// This is not very good because if we reassign the err before exiting the function it will not update the health correctly.
-			// TODO improve the error  health handling.
-		} else {
-			return err
+	fetchErr := im.fetch(context.Background(), newArgs)
+	if fetchErr != nil {
+		content, cacheErr := im.cache.Load(gitModuleFingerprint(newArgs))
+		switch {
+		case cacheErr == nil:
+			level.Error(im.log).Log("msg", "failed to fetch module from repository, using the cached module", "err", fetchErr)
+			im.onContentChange(content)
+		case errors.As(fetchErr, &vcs.UpdateFailedError{}) && im.repo != nil:
+			level.Error(im.log).Log("msg", "failed to poll file from repository", "err", fetchErr)
+		default:
+			im.updateHealth(fetchErr)
+			return fetchErr
 		}
 	}
+	im.updateHealth(fetchErr)
 
 	// Schedule an update for handling the changed arguments.
 	select {
@@ -243,30 +256,72 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 	return nil
 }
 
-// pollFile fetches the latest content from the repository and updates the
-// controller. pollFile must only be called with im.mut held.
-func (im *ImportGit) pollFile(ctx context.Context, args GitArguments) error {
-	// Make sure our repo is up-to-date.
-	if err := im.repo.Update(ctx); err != nil {
+// fetch updates the repository, cloning it if needed, and updates the
+// controller with the module. fetch must only be called with im.mut held.
+func (im *ImportGit) fetch(ctx context.Context, args GitArguments) error {
+	repoOpts := vcs.GitRepoOptions{
+		Repository: args.Repository,
+		Revision:   args.Revision,
+		Auth:       args.GitAuthConfig,
+	}
+
+	// Create or update the repo field.
+	if im.repo == nil || !equality.DeepEqual(repoOpts, im.repoOpts) {
+		// Drop the previous repository so that the next poll retries to create
+		// the repository if this one fails.
+		im.repo = nil
+		r, err := vcs.NewGitRepo(ctx, im.repoPath, repoOpts)
+		if err != nil {
+			return err
+		}
+		im.repo = r
+		im.repoOpts = repoOpts
+	} else if err := im.repo.Update(ctx); err != nil {
 		return err
 	}
 
+	return im.pollFile(args)
+}
+
+// pollFile reads the module from the repository and updates the controller.
+// pollFile must only be called with im.mut held.
+func (im *ImportGit) pollFile(args GitArguments) error {
+	if args.Commit != "" {
+		rev, err := im.repo.CurrentRevision()
+		if err != nil {
+			return err
+		}
+		if rev != args.Commit {
+			return fmt.Errorf("revision %q is at commit %s, which doesn't match the pinned commit %s", args.Revision, rev, args.Commit)
+		}
+	}
+
 	info, err := im.repo.Stat(args.Path)
 	if err != nil {
 		return err
 	}
 
+	var content map[string]string
 	if info.IsDir() {
-		return im.handleDirectory(args.Path)
+		content, err = im.handleDirectory(args.Path)
+	} else {
+		content, err = im.handleFile(args.Path)
+	}
+	if err != nil {
+		return err
 	}
 
-	return im.handleFile(args.Path)
+	if err := im.cache.Store(gitModuleFingerprint(args), content); err != nil {
+		level.Warn(im.log).Log("msg", "failed to cache module", "err", err)
+	}
+	im.onContentChange(content)
+	return nil
 }
 
-func (im *ImportGit) handleDirectory(path string) error {
+func (im *ImportGit) handleDirectory(path string) (map[string]string, error) {
 	filesInfo, err := im.repo.ReadDir(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	content := make(map[string]string)
@@ -276,21 +331,45 @@ func (im *ImportGit) handleDirectory(path string) error {
 		}
 		bb, err := im.repo.ReadFile(filepath.Join(path, fi.Name()))
 		if err != nil {
-			return err
+			return nil, err
 		}
 		content[fi.Name()] = string(bb)
 	}
-	im.onContentChange(content)
-	return nil
+	return content, nil
 }
 
-func (im *ImportGit) handleFile(path string) error {
+func (im *ImportGit) handleFile(path string) (map[string]string, error) {
 	bb, err := im.repo.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	im.onContentChange(map[string]string{path: string(bb)})
-	return nil
+	return map[string]string{path: string(bb)}, nil
+}
+
+// gitModuleFingerprint returns the fingerprint of the arguments selecting the
+// module in the repository.
+func gitModuleFingerprint(args GitArguments) string {
+	return moduleFingerprint(args.Repository, args.Revision, args.Path, args.Commit)
+}
+
+// LockEntry implements LockedSource.
+func (im *ImportGit) LockEntry() (modlock.Entry, bool) {
+	im.mut.RLock()
+	defer im.mut.RUnlock()
+
+	if im.repo == nil {
+		return modlock.Entry{}, false
+	}
+	commit, err := im.repo.CurrentRevision()
+	if err != nil {
+		return modlock.Entry{}, false
+	}
+	return modlock.Entry{
+		Source:     modlock.SourceGit,
+		Repository: im.args.Repository,
+		Revision:   im.revision,
+		Commit:     commit,
+	}, true
 }
 
 // CurrentHealth implements component.HealthComponent.
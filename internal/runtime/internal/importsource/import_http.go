@@ -2,37 +2,67 @@ package importsource
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/grafana/alloy/internal/component"
 	common_config "github.com/grafana/alloy/internal/component/common/config"
 	remote_http "github.com/grafana/alloy/internal/component/remote/http"
 	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+	"github.com/grafana/alloy/syntax"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
 // ImportHTTP imports a module from a HTTP server via the remote.http component.
+// The last module received is cached on disk so that the module can be loaded
+// when the server is unreachable.
 type ImportHTTP struct {
+	// mut protects the managed component and its arguments. It's held while
+	// the component polls in New and Update, so it must never be acquired
+	// while handling content.
+	mut               sync.Mutex
 	managedRemoteHTTP *remote_http.Component
 	arguments         HTTPArguments
 	managedOpts       component.Options
 	eval              *vm.Evaluator
+	cache             *moduleCache
+	moduleLock        func() *modlock.Lock
+	onContentChange   func(map[string]string)
+
+	contentMut  sync.Mutex
+	pin         string // SHA-256 digest the content must have, if any
+	fingerprint string // fingerprint of the arguments the content is fetched with
+	modulePath  string
+	digest      string // SHA-256 digest of the loaded content
+	contentErr  error  // error of the last content rejected
+	health      component.Health
 }
 
-var _ ImportSource = (*ImportHTTP)(nil)
+var (
+	_ ImportSource = (*ImportHTTP)(nil)
+	_ LockedSource = (*ImportHTTP)(nil)
+)
 
-func NewImportHTTP(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportHTTP {
+func NewImportHTTP(managedOpts component.Options, eval *vm.Evaluator, moduleLock func() *modlock.Lock, onContentChange func(map[string]string)) *ImportHTTP {
+	im := &ImportHTTP{
+		eval:            eval,
+		cache:           newModuleCache(managedOpts),
+		moduleLock:      moduleLock,
+		onContentChange: onContentChange,
+	}
 	opts := managedOpts
 	opts.OnStateChange = func(e component.Exports) {
-		onContentChange(map[string]string{opts.ID: e.(remote_http.Exports).Content.Value})
-	}
-	return &ImportHTTP{
-		managedOpts: opts,
-		eval:        eval,
+		im.handleContent(e.(remote_http.Exports).Content.Value)
 	}
+	im.managedOpts = opts
+	return im
 }
 
 // HTTPArguments holds values which are used to configure the remote.http component.
@@ -40,6 +70,7 @@ type HTTPArguments struct {
 	URL           string        `alloy:"url,attr"`
 	PollFrequency time.Duration `alloy:"poll_frequency,attr,optional"`
 	PollTimeout   time.Duration `alloy:"poll_timeout,attr,optional"`
+	SHA256        string        `alloy:"sha256,attr,optional"`
 
 	Method  string            `alloy:"method,attr,optional"`
 	Headers map[string]string `alloy:"headers,attr,optional"`
@@ -56,32 +87,60 @@ var DefaultHTTPArguments = HTTPArguments{
 	Method:        http.MethodGet,
 }
 
+var (
+	_ syntax.Validator = (*HTTPArguments)(nil)
+	_ syntax.Defaulter = (*HTTPArguments)(nil)
+)
+
 // SetToDefault implements syntax.Defaulter.
 func (args *HTTPArguments) SetToDefault() {
 	*args = DefaultHTTPArguments
 }
 
+// Validate implements syntax.Validator.
+func (args *HTTPArguments) Validate() error {
+	if args.SHA256 != "" {
+		if b, err := hex.DecodeString(args.SHA256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("sha256 must be a hex encoded SHA-256 digest, got %q", args.SHA256)
+		}
+	}
+	return nil
+}
+
+func (args HTTPArguments) remoteHTTPArguments() remote_http.Arguments {
+	return remote_http.Arguments{
+		URL:           args.URL,
+		PollFrequency: args.PollFrequency,
+		PollTimeout:   args.PollTimeout,
+		Method:        args.Method,
+		Headers:       args.Headers,
+		Body:          args.Body,
+		Client:        args.Client,
+	}
+}
+
+// httpModuleFingerprint returns the fingerprint of the arguments selecting the
+// module.
+func httpModuleFingerprint(args HTTPArguments) string {
+	return moduleFingerprint(args.URL, args.Method, args.Body, args.SHA256)
+}
+
 func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	var arguments HTTPArguments
 	if err := im.eval.Evaluate(scope, &arguments); err != nil {
 		return fmt.Errorf("decoding configuration: %w", err)
 	}
-	remoteHttpArguments := remote_http.Arguments{
-		URL:           arguments.URL,
-		PollFrequency: arguments.PollFrequency,
-		PollTimeout:   arguments.PollTimeout,
-		Method:        arguments.Method,
-		Headers:       arguments.Headers,
-		Body:          arguments.Body,
-		Client:        arguments.Client,
-	}
+	arguments = lockHTTPArguments(currentLock(im.moduleLock), arguments)
+
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
 	if im.managedRemoteHTTP == nil {
-		var err error
-		im.managedRemoteHTTP, err = remote_http.New(im.managedOpts, remoteHttpArguments)
-		if err != nil {
-			return fmt.Errorf("creating http component: %w", err)
-		}
 		im.arguments = arguments
+		if err := im.createComponent(); err != nil {
+			return im.loadCache(fmt.Errorf("creating http component: %w", err))
+		}
+		return im.checkContent()
 	}
 
 	if equality.DeepEqual(im.arguments, arguments) {
@@ -89,19 +148,174 @@ func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	}
 
 	// Update the existing managed component
-	if err := im.managedRemoteHTTP.Update(remoteHttpArguments); err != nil {
-		return fmt.Errorf("updating component: %w", err)
-	}
 	im.arguments = arguments
+	im.setContentArguments(arguments)
+	if err := im.managedRemoteHTTP.Update(arguments.remoteHTTPArguments()); err != nil {
+		return im.loadCache(fmt.Errorf("updating component: %w", err))
+	}
+	return im.checkContent()
+}
+
+// lockHTTPArguments returns args pinned to the digest recorded in lock for
+// the URL, if any. A digest set in the block takes precedence over the lock.
+func lockHTTPArguments(lock *modlock.Lock, args HTTPArguments) HTTPArguments {
+	if entry, ok := lock.HTTP(args.URL); ok && args.SHA256 == "" {
+		args.SHA256 = entry.SHA256
+	}
+	return args
+}
+
+// createComponent creates the managed component, which polls the module. It
+// must only be called with im.mut held.
+func (im *ImportHTTP) createComponent() error {
+	im.setContentArguments(im.arguments)
+	c, err := remote_http.New(im.managedOpts, im.arguments.remoteHTTPArguments())
+	if err != nil {
+		return err
+	}
+	im.managedRemoteHTTP = c
+	return nil
+}
+
+// checkContent returns an error if the content received for the arguments
+// was rejected and the cached module can't be used instead. It must only be
+// called with im.mut held.
+func (im *ImportHTTP) checkContent() error {
+	im.contentMut.Lock()
+	err := im.contentErr
+	im.contentMut.Unlock()
+
+	if err != nil {
+		return im.loadCache(err)
+	}
+	return nil
+}
+
+// loadCache loads the module cached for the arguments because fetching it
+// failed with err. err is returned if there is no such module. It must only be
+// called with im.mut held.
+func (im *ImportHTTP) loadCache(err error) error {
+	content, cacheErr := im.cache.Load(httpModuleFingerprint(im.arguments))
+	if cacheErr != nil {
+		return err
+	}
+	level.Error(im.managedOpts.Logger).Log("msg", "failed to fetch module, using the cached module", "err", err)
+	im.setHealth(im.cache.FetchErrorHealth(err))
+	im.onContentChange(map[string]string{im.managedOpts.ID: content[im.managedOpts.ID]})
 	return nil
 }
 
+func (im *ImportHTTP) setContentArguments(args HTTPArguments) {
+	im.contentMut.Lock()
+	defer im.contentMut.Unlock()
+	im.pin = args.SHA256
+	im.fingerprint = httpModuleFingerprint(args)
+	im.modulePath, _ = path.Split(args.URL)
+	im.digest = ""
+	im.contentErr = nil
+}
+
+func (im *ImportHTTP) setHealth(health component.Health) {
+	im.contentMut.Lock()
+	defer im.contentMut.Unlock()
+	im.health = health
+}
+
+// handleContent is called by the managed component when the content of the
+// module changes. Content which doesn't match the pinned digest is rejected.
+func (im *ImportHTTP) handleContent(content string) {
+	im.contentMut.Lock()
+	pin, fingerprint := im.pin, im.fingerprint
+	im.contentMut.Unlock()
+
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+	if pin != "" && digest != pin {
+		err := fmt.Errorf("the SHA-256 digest %s of the module doesn't match the pinned digest %s", digest, pin)
+		level.Error(im.managedOpts.Logger).Log("msg", "rejected module", "err", err)
+
+		im.contentMut.Lock()
+		im.contentErr = err
+		im.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+		im.contentMut.Unlock()
+		return
+	}
+
+	im.contentMut.Lock()
+	im.digest = digest
+	im.contentErr = nil
+	im.health = component.Health{}
+	im.contentMut.Unlock()
+
+	if err := im.cache.Store(fingerprint, map[string]string{im.managedOpts.ID: content}); err != nil {
+		level.Warn(im.managedOpts.Logger).Log("msg", "failed to cache module", "err", err)
+	}
+	im.onContentChange(map[string]string{im.managedOpts.ID: content})
+}
+
 func (im *ImportHTTP) Run(ctx context.Context) error {
-	return im.managedRemoteHTTP.Run(ctx)
+	// The managed component doesn't exist if the module was loaded from the
+	// cache when the source was evaluated. Retry creating it until it
+	// succeeds.
+	for {
+		im.mut.Lock()
+		c, pollFrequency := im.managedRemoteHTTP, im.arguments.PollFrequency
+		im.mut.Unlock()
+		if c != nil {
+			return c.Run(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollFrequency):
+		}
+
+		im.mut.Lock()
+		if im.managedRemoteHTTP == nil {
+			if err := im.createComponent(); err != nil {
+				level.Error(im.managedOpts.Logger).Log("msg", "failed to fetch module", "err", err)
+				im.setHealth(im.cache.FetchErrorHealth(err))
+			}
+		}
+		im.mut.Unlock()
+	}
+}
+
+// LockEntry implements LockedSource.
+func (im *ImportHTTP) LockEntry() (modlock.Entry, bool) {
+	im.mut.Lock()
+	url := im.arguments.URL
+	im.mut.Unlock()
+
+	im.contentMut.Lock()
+	defer im.contentMut.Unlock()
+	if im.digest == "" {
+		return modlock.Entry{}, false
+	}
+	return modlock.Entry{Source: modlock.SourceHTTP, URL: url, SHA256: im.digest}, true
 }
 
 func (im *ImportHTTP) CurrentHealth() component.Health {
-	return im.managedRemoteHTTP.CurrentHealth()
+	im.contentMut.Lock()
+	health := im.health
+	im.contentMut.Unlock()
+	// The health of the content takes precedence over the health of polling.
+	if health.Health != component.HealthTypeUnknown {
+		return health
+	}
+
+	im.mut.Lock()
+	c := im.managedRemoteHTTP
+	im.mut.Unlock()
+	if c == nil {
+		return component.Health{}
+	}
+	return c.CurrentHealth()
 }
 
 // Update the evaluator.
@@ -110,6 +324,9 @@ func (im *ImportHTTP) SetEval(eval *vm.Evaluator) {
 }
 
 func (im *ImportHTTP) ModulePath() string {
-	dir, _ := path.Split(im.arguments.URL)
-	return dir
+	// The module path is read when the content changes, which may happen
+	// while im.mut is held.
+	im.contentMut.Lock()
+	defer im.contentMut.Unlock()
+	return im.modulePath
 }
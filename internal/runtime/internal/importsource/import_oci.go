@@ -0,0 +1,492 @@
+package importsource
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/importoci"
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// ImportOCI imports a module from a bundle stored in an OCI registry.
+// The last pulled bundle is cached on disk so that the module can be loaded
+// when the registry is unreachable.
+type ImportOCI struct {
+	opts            component.Options
+	log             log.Logger
+	eval            *vm.Evaluator
+	mut             sync.RWMutex
+	args            importoci.Arguments
+	reference       string // reference set in the block, args.Reference may be pinned by the lock
+	ref             oci.Reference
+	client          *oci.Client
+	publicKey       crypto.PublicKey
+	digest          digest.Digest // digest of the manifest of the loaded bundle
+	cache           *moduleCache  // only tracks whether the bundle is served from the cache
+	moduleLock      func() *modlock.Lock
+	onContentChange func(map[string]string)
+
+	argsChanged chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ ImportSource              = (*ImportOCI)(nil)
+	_ LockedSource              = (*ImportOCI)(nil)
+	_ component.Component       = (*ImportOCI)(nil)
+	_ component.HealthComponent = (*ImportOCI)(nil)
+)
+
+// ociCacheIndex describes the bundle stored in the cache.
+type ociCacheIndex struct {
+	Reference string        `json:"reference"`
+	Digest    digest.Digest `json:"digest"`
+	// PublicKey is the fingerprint of the key which verified the bundle, empty
+	// if the signature wasn't verified.
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+func NewImportOCI(managedOpts component.Options, eval *vm.Evaluator, moduleLock func() *modlock.Lock, onContentChange func(map[string]string)) *ImportOCI {
+	return &ImportOCI{
+		opts:            managedOpts,
+		log:             managedOpts.Logger,
+		eval:            eval,
+		cache:           newModuleCache(managedOpts),
+		moduleLock:      moduleLock,
+		argsChanged:     make(chan struct{}, 1),
+		onContentChange: onContentChange,
+	}
+}
+
+func (im *ImportOCI) Evaluate(scope *vm.Scope) error {
+	var arguments importoci.Arguments
+	if err := im.eval.Evaluate(scope, &arguments); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+	reference := arguments.Reference
+	arguments = lockOCIArguments(currentLock(im.moduleLock), arguments)
+
+	if equality.DeepEqual(im.args, arguments) {
+		return nil
+	}
+
+	if err := im.Update(arguments); err != nil {
+		return fmt.Errorf("updating component: %w", err)
+	}
+
+	im.mut.Lock()
+	im.reference = reference
+	im.mut.Unlock()
+	return nil
+}
+
+// lockOCIArguments returns args with the reference pinned to the digest
+// recorded in lock for the reference, if any. A digest set in the block takes
+// precedence over the lock.
+func lockOCIArguments(lock *modlock.Lock, args importoci.Arguments) importoci.Arguments {
+	entry, ok := lock.OCI(args.Reference)
+	if !ok || args.Digest != "" {
+		return args
+	}
+	ref, err := oci.ParseReference(args.Reference)
+	if err != nil || ref.Digest != "" {
+		return args
+	}
+	ref.Tag = ""
+	ref.Digest = digest.Digest(entry.Digest)
+	args.Reference = ref.String()
+	return args
+}
+
+func (im *ImportOCI) Run(ctx context.Context) error {
+	var (
+		ticker  *time.Ticker
+		tickerC <-chan time.Time
+	)
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-im.argsChanged:
+			im.mut.RLock()
+			pollFrequency := im.args.PollFrequency
+			im.mut.RUnlock()
+			ticker, tickerC = im.updateTicker(pollFrequency, ticker)
+
+		case <-tickerC:
+			im.tickPoll(ctx)
+		}
+	}
+}
+
+func (im *ImportOCI) updateTicker(pollFrequency time.Duration, ticker *time.Ticker) (*time.Ticker, <-chan time.Time) {
+	if pollFrequency <= 0 {
+		if ticker != nil {
+			ticker.Stop()
+		}
+		return nil, nil
+	}
+
+	if ticker == nil {
+		ticker = time.NewTicker(pollFrequency)
+	} else {
+		ticker.Reset(pollFrequency)
+	}
+	return ticker, ticker.C
+}
+
+func (im *ImportOCI) tickPoll(ctx context.Context) {
+	im.mut.Lock()
+	err := im.pull(ctx)
+	reference := im.args.Reference
+	im.mut.Unlock()
+
+	im.updateHealth(err)
+	if err != nil {
+		level.Error(im.log).Log("msg", "failed to pull module", "reference", reference, "err", err)
+	}
+}
+
+// Update implements component.Component.
+// When the bundle can't be pulled, the bundle cached by a previous run is
+// loaded instead if it matches the arguments. In that case Update succeeds
+// but the source is reported unhealthy until a pull succeeds.
+func (im *ImportOCI) Update(args component.Arguments) error {
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	newArgs := args.(importoci.Arguments)
+
+	ref, err := oci.ParseReference(newArgs.Reference)
+	if err != nil {
+		return err
+	}
+	var publicKey crypto.PublicKey
+	if newArgs.PublicKey != "" {
+		if publicKey, err = oci.ParsePublicKey(newArgs.PublicKey); err != nil {
+			return err
+		}
+	}
+
+	opts := oci.Options{Insecure: newArgs.Insecure}
+	if newArgs.BasicAuth != nil {
+		opts.Username = newArgs.BasicAuth.Username
+		opts.Password = string(newArgs.BasicAuth.Password)
+	}
+
+	im.args = newArgs
+	im.ref = ref
+	im.client = oci.NewClient(opts)
+	im.publicKey = publicKey
+	// Load the bundle again since the arguments which select its content or
+	// verify it may have changed.
+	im.digest = ""
+
+	pullErr := im.pull(context.Background())
+	if pullErr != nil {
+		if cacheErr := im.loadCache(); cacheErr != nil {
+			im.updateHealth(pullErr)
+			return fmt.Errorf("pulling module: %w (the cached module can't be used: %s)", pullErr, cacheErr)
+		}
+		level.Error(im.log).Log("msg", "failed to pull module, using the cached module", "reference", newArgs.Reference, "err", pullErr)
+	}
+	im.updateHealth(pullErr)
+
+	// Schedule an update for handling the changed arguments.
+	select {
+	case im.argsChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pull loads the bundle referenced by the arguments if its digest changed
+// since the last load. pull must only be called with im.mut held.
+func (im *ImportOCI) pull(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, im.args.PollTimeout)
+	defer cancel()
+
+	// A reference pinned by digest can't point to a new bundle.
+	if im.ref.Digest != "" && im.digest == im.ref.Digest {
+		return nil
+	}
+
+	d, err := im.client.Resolve(ctx, im.ref)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", im.ref, err)
+	}
+	if im.args.Digest != "" && d.String() != im.args.Digest {
+		return fmt.Errorf("the digest %s of %s doesn't match the pinned digest %s", d, im.ref, im.args.Digest)
+	}
+	if d == im.digest {
+		return nil
+	}
+
+	manifest, rawManifest, err := im.client.FetchManifest(ctx, im.ref, d)
+	if err != nil {
+		return fmt.Errorf("fetching manifest: %w", err)
+	}
+	if im.publicKey != nil {
+		if err := im.client.VerifySignature(ctx, im.ref, d, im.publicKey); err != nil {
+			return fmt.Errorf("verifying signature: %w", err)
+		}
+	}
+	layer, err := oci.ModuleLayer(manifest)
+	if err != nil {
+		return err
+	}
+	bundle, err := im.client.FetchBlob(ctx, im.ref, layer)
+	if err != nil {
+		return fmt.Errorf("fetching bundle: %w", err)
+	}
+
+	if err := im.load(d, bundle); err != nil {
+		return err
+	}
+	im.cache.setServedFromCache(false)
+
+	// The bundle is cached only once it has been verified and loaded.
+	if err := im.writeCache(d, rawManifest, layer, bundle); err != nil {
+		level.Warn(im.log).Log("msg", "failed to cache module", "err", err)
+	}
+	return nil
+}
+
+// load extracts the bundle in the module directory and sends the selected
+// files to the controller.
+func (im *ImportOCI) load(d digest.Digest, bundle []byte) error {
+	files, err := oci.ReadBundle(bundle)
+	if err != nil {
+		return err
+	}
+	content, err := selectModuleFiles(files, im.args.Path)
+	if err != nil {
+		return err
+	}
+	if err := extractBundle(im.ModulePath(), files); err != nil {
+		return fmt.Errorf("extracting bundle: %w", err)
+	}
+
+	level.Info(im.log).Log("msg", "loaded module", "reference", im.ref, "digest", d)
+	im.digest = d
+	im.onContentChange(content)
+	return nil
+}
+
+// selectModuleFiles returns the content of the file at p, or of the .alloy
+// files in the directory at p.
+func selectModuleFiles(files map[string][]byte, p string) (map[string]string, error) {
+	p = path.Clean(strings.TrimPrefix(p, "/"))
+	if data, ok := files[p]; ok {
+		return map[string]string{p: string(data)}, nil
+	}
+
+	content := make(map[string]string)
+	for name, data := range files {
+		if path.Dir(name) == p && strings.HasSuffix(name, ".alloy") {
+			content[path.Base(name)] = string(data)
+		}
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("no .alloy file found at %q in the bundle", p)
+	}
+	return content, nil
+}
+
+// extractBundle replaces the content of dir with files.
+func extractBundle(dir string, files map[string][]byte) error {
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, files[name], 0640); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+func (im *ImportOCI) cacheDir() string {
+	return filepath.Join(im.opts.DataPath, "oci")
+}
+
+func (im *ImportOCI) blobPath(d digest.Digest) string {
+	return filepath.Join(im.cacheDir(), "blobs", d.Algorithm().String(), d.Encoded())
+}
+
+// keyFingerprint returns the fingerprint of the public key of the arguments.
+func (im *ImportOCI) keyFingerprint() string {
+	if im.args.PublicKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(im.args.PublicKey)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (im *ImportOCI) writeCache(d digest.Digest, rawManifest []byte, layer ocispec.Descriptor, bundle []byte) error {
+	blobsDir := filepath.Join(im.cacheDir(), "blobs", string(digest.Canonical))
+	if err := os.RemoveAll(blobsDir); err != nil {
+		return err
+	}
+	for _, blob := range []struct {
+		digest digest.Digest
+		data   []byte
+	}{{d, rawManifest}, {layer.Digest, bundle}} {
+		if err := os.MkdirAll(filepath.Dir(im.blobPath(blob.digest)), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(im.blobPath(blob.digest), blob.data, 0640); err != nil {
+			return err
+		}
+	}
+
+	index, err := json.Marshal(ociCacheIndex{
+		Reference: im.args.Reference,
+		Digest:    d,
+		PublicKey: im.keyFingerprint(),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(im.cacheDir(), "index.json"), index, 0640)
+}
+
+// loadCache loads the cached bundle if it was pulled with the same reference
+// and verified with the same key. The digests of the cached blobs are checked
+// again before loading them.
+func (im *ImportOCI) loadCache() error {
+	rawIndex, err := os.ReadFile(filepath.Join(im.cacheDir(), "index.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New("no module is cached")
+	} else if err != nil {
+		return err
+	}
+	var index ociCacheIndex
+	if err := json.Unmarshal(rawIndex, &index); err != nil {
+		return fmt.Errorf("decoding cache index: %w", err)
+	}
+
+	switch {
+	case index.Reference != im.args.Reference:
+		return fmt.Errorf("the cached module was pulled from %s", index.Reference)
+	case im.args.Digest != "" && index.Digest.String() != im.args.Digest:
+		return fmt.Errorf("the digest %s of the cached module doesn't match the pinned digest", index.Digest)
+	case index.PublicKey != im.keyFingerprint():
+		return errors.New("the cached module wasn't verified with the configured public key")
+	}
+
+	rawManifest, err := os.ReadFile(im.blobPath(index.Digest))
+	if err != nil {
+		return err
+	}
+	if err := oci.VerifyBlob(ocispec.Descriptor{Digest: index.Digest, Size: int64(len(rawManifest))}, rawManifest); err != nil {
+		return err
+	}
+	manifest, err := oci.ParseManifest(rawManifest)
+	if err != nil {
+		return err
+	}
+	layer, err := oci.ModuleLayer(manifest)
+	if err != nil {
+		return err
+	}
+	bundle, err := os.ReadFile(im.blobPath(layer.Digest))
+	if err != nil {
+		return err
+	}
+	if err := oci.VerifyBlob(layer, bundle); err != nil {
+		return err
+	}
+	if err := im.load(index.Digest, bundle); err != nil {
+		return err
+	}
+	im.cache.setServedFromCache(true)
+	return nil
+}
+
+// LockEntry implements LockedSource.
+func (im *ImportOCI) LockEntry() (modlock.Entry, bool) {
+	im.mut.RLock()
+	defer im.mut.RUnlock()
+
+	if im.digest == "" {
+		return modlock.Entry{}, false
+	}
+	return modlock.Entry{Source: modlock.SourceOCI, Reference: im.reference, Digest: im.digest.String()}, true
+}
+
+func (im *ImportOCI) updateHealth(err error) {
+	im.healthMut.Lock()
+	defer im.healthMut.Unlock()
+
+	if err != nil {
+		im.health = im.cache.FetchErrorHealth(err)
+	} else {
+		im.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (im *ImportOCI) CurrentHealth() component.Health {
+	im.healthMut.RLock()
+	defer im.healthMut.RUnlock()
+	return im.health
+}
+
+// Update the evaluator.
+func (im *ImportOCI) SetEval(eval *vm.Evaluator) {
+	im.eval = eval
+}
+
+// ModulePath returns the directory where the bundle is extracted, so that
+// nested imports can refer to the other files of the bundle.
+func (im *ImportOCI) ModulePath() string {
+	return filepath.Join(im.cacheDir(), "module")
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/importoci"
+	"github.com/grafana/alloy/internal/runtime/modlock"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
@@ -15,6 +17,7 @@ const (
 	String
 	Git
 	HTTP
+	OCI
 )
 
 const (
@@ -22,6 +25,7 @@ const (
 	BlockImportString = "import.string"
 	BlockImportHTTP   = "import.http"
 	BlockImportGit    = "import.git"
+	BlockImportOCI    = importoci.Name
 )
 
 const ModulePath = "module_path"
@@ -40,22 +44,43 @@ type ImportSource interface {
 	ModulePath() string
 }
 
+// LockedSource is implemented by the sources whose version is recorded in the
+// module lock file.
+type LockedSource interface {
+	// LockEntry returns the version of the loaded module. It returns false if
+	// no module is loaded.
+	LockEntry() (modlock.Entry, bool)
+}
+
 // NewImportSource creates a new ImportSource depending on the type.
-// onContentChange is used by the source when it receives new content.
-func NewImportSource(sourceType SourceType, managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) ImportSource {
+// moduleLock returns the lock which pins the versions of remote modules, it
+// may be nil. onContentChange is used by the source when it receives new
+// content.
+func NewImportSource(sourceType SourceType, managedOpts component.Options, eval *vm.Evaluator, moduleLock func() *modlock.Lock, onContentChange func(map[string]string)) ImportSource {
 	switch sourceType {
 	case File:
 		return NewImportFile(managedOpts, eval, onContentChange)
 	case String:
 		return NewImportString(eval, onContentChange)
 	case HTTP:
-		return NewImportHTTP(managedOpts, eval, onContentChange)
+		return NewImportHTTP(managedOpts, eval, moduleLock, onContentChange)
 	case Git:
-		return NewImportGit(managedOpts, eval, onContentChange)
+		return NewImportGit(managedOpts, eval, moduleLock, onContentChange)
+	case OCI:
+		return NewImportOCI(managedOpts, eval, moduleLock, onContentChange)
 	}
 	panic(fmt.Errorf("unsupported source type: %v", sourceType))
 }
 
+// currentLock returns the lock returned by moduleLock, or nil if there is no
+// lock.
+func currentLock(moduleLock func() *modlock.Lock) *modlock.Lock {
+	if moduleLock == nil {
+		return nil
+	}
+	return moduleLock()
+}
+
 // GetSourceType returns a SourceType matching a source name.
 func GetSourceType(fullName string) SourceType {
 	switch fullName {
@@ -67,6 +92,8 @@ func GetSourceType(fullName string) SourceType {
 		return HTTP
 	case BlockImportGit:
 		return Git
+	case BlockImportOCI:
+		return OCI
 	}
 	panic(fmt.Errorf("name does not map to a known source type: %v", fullName))
 }
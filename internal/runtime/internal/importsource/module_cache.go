@@ -0,0 +1,144 @@
+package importsource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+// moduleCache persists the last module content fetched by a source, so that
+// the module can be loaded when the source can't be fetched, for example when
+// Alloy starts while the remote is unreachable.
+//
+// The content is stored along with a fingerprint of the arguments which
+// selected it, so that a module fetched with other arguments isn't loaded.
+type moduleCache struct {
+	path string
+
+	mut             sync.Mutex
+	servedFromCache bool
+	gauge           prometheus.Gauge
+}
+
+type moduleCacheFile struct {
+	Fingerprint string            `json:"fingerprint"`
+	Content     map[string]string `json:"content"`
+}
+
+func newModuleCache(opts component.Options) *moduleCache {
+	return &moduleCache{
+		path:  filepath.Join(opts.DataPath, "module_cache.json"),
+		gauge: newServedFromCacheGauge(opts),
+	}
+}
+
+// newServedFromCacheGauge returns the gauge reporting whether the module of
+// an import block is loaded from the cache of a previous fetch.
+func newServedFromCacheGauge(opts component.Options) prometheus.Gauge {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "alloy_import_served_from_cache",
+		Help: "Whether the module of the import block is loaded from the on-disk cache because it couldn't be fetched.",
+	})
+	if opts.Registerer != nil {
+		if err := opts.Registerer.Register(gauge); err != nil {
+			var already prometheus.AlreadyRegisteredError
+			if errors.As(err, &already) {
+				return already.ExistingCollector.(prometheus.Gauge)
+			}
+		}
+	}
+	return gauge
+}
+
+// moduleFingerprint returns the fingerprint of the arguments selecting the
+// content of a module.
+func moduleFingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store saves content fetched with the arguments matching fingerprint and
+// marks the module as no longer served from the cache.
+func (c *moduleCache) Store(fingerprint string, content map[string]string) error {
+	c.setServedFromCache(false)
+
+	data, err := json.Marshal(moduleCacheFile{Fingerprint: fingerprint, Content: content})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0750); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that the cache is never truncated.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Load returns the cached content if it was fetched with the arguments
+// matching fingerprint, and marks the module as served from the cache.
+func (c *moduleCache) Load(fingerprint string) (map[string]string, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("no module is cached")
+	} else if err != nil {
+		return nil, err
+	}
+
+	var cached moduleCacheFile
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("decoding cached module: %w", err)
+	}
+	if cached.Fingerprint != fingerprint {
+		return nil, errors.New("the cached module was fetched with different arguments")
+	}
+
+	c.setServedFromCache(true)
+	return cached.Content, nil
+}
+
+// ServedFromCache returns whether the module was loaded from the cache and
+// not fetched since.
+func (c *moduleCache) ServedFromCache() bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.servedFromCache
+}
+
+func (c *moduleCache) setServedFromCache(served bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.servedFromCache = served
+	if served {
+		c.gauge.Set(1)
+	} else {
+		c.gauge.Set(0)
+	}
+}
+
+// FetchErrorHealth returns the health of a source whose module couldn't be
+// fetched because of err.
+func (c *moduleCache) FetchErrorHealth(err error) component.Health {
+	msg := err.Error()
+	if c.ServedFromCache() {
+		msg = fmt.Sprintf("module served from cache: %s", err)
+	}
+	return component.Health{
+		Health:     component.HealthTypeUnhealthy,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
@@ -0,0 +1,176 @@
+// Package modlock implements the lock file which records the version of every
+// module imported by a configuration, so that the same modules are loaded
+// wherever the configuration is deployed.
+package modlock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// FileName is the name of the lock file stored next to the configuration.
+	FileName = "alloy.lock"
+
+	// Version is the version of the format of the lock file.
+	Version = 1
+)
+
+// Source names of the locked modules. They match the names of the import
+// blocks.
+const (
+	SourceGit  = "import.git"
+	SourceHTTP = "import.http"
+	SourceOCI  = "import.oci"
+)
+
+// Lock holds the versions of the modules imported by a configuration.
+type Lock struct {
+	Version int     `json:"version"`
+	Modules []Entry `json:"modules"`
+}
+
+// Entry holds the version a module resolved to. The fields which are set
+// depend on Source.
+type Entry struct {
+	Source string `json:"source"`
+
+	// Repository and Revision identify an import.git module, Commit is the
+	// commit Revision resolved to.
+	Repository string `json:"repository,omitempty"`
+	Revision   string `json:"revision,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+
+	// URL identifies an import.http module, SHA256 is the digest of its
+	// content.
+	URL    string `json:"url,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Reference identifies an import.oci module, Digest is the digest of the
+	// manifest Reference resolved to.
+	Reference string `json:"reference,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+// key returns the fields identifying the module of e.
+func (e Entry) key() Entry {
+	return Entry{
+		Source:     e.Source,
+		Repository: e.Repository,
+		Revision:   e.Revision,
+		URL:        e.URL,
+		Reference:  e.Reference,
+	}
+}
+
+// DefaultPath returns the path of the lock file of the configuration at
+// configPath, which can be a file or a directory.
+func DefaultPath(configPath string) string {
+	if fi, err := os.Stat(configPath); err == nil && fi.IsDir() {
+		return filepath.Join(configPath, FileName)
+	}
+	return filepath.Join(filepath.Dir(configPath), FileName)
+}
+
+// Load reads the lock file at path. Load returns a nil Lock and no error if
+// the file doesn't exist.
+func Load(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var l Lock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("decoding lock file %q: %w", path, err)
+	}
+	if l.Version != Version {
+		return nil, fmt.Errorf("unsupported lock file version %d in %q, expected %d", l.Version, path, Version)
+	}
+	return &l, nil
+}
+
+// Write writes the lock file at path. The entries are sorted so that the
+// file only changes when the locked versions change.
+func (l *Lock) Write(path string) error {
+	l.Version = Version
+	sort.Slice(l.Modules, func(i, j int) bool {
+		a, b := l.Modules[i], l.Modules[j]
+		switch {
+		case a.Source != b.Source:
+			return a.Source < b.Source
+		case a.Repository != b.Repository:
+			return a.Repository < b.Repository
+		case a.Revision != b.Revision:
+			return a.Revision < b.Revision
+		case a.URL != b.URL:
+			return a.URL < b.URL
+		default:
+			return a.Reference < b.Reference
+		}
+	})
+	if l.Modules == nil {
+		l.Modules = []Entry{}
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	// Write to a temporary file first so that the lock file is never truncated.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Add records the version of a module, replacing the version previously
+// recorded for the module.
+func (l *Lock) Add(e Entry) {
+	for i, existing := range l.Modules {
+		if existing.key() == e.key() {
+			l.Modules[i] = e
+			return
+		}
+	}
+	l.Modules = append(l.Modules, e)
+}
+
+// Git returns the entry of the import.git module for the revision of
+// repository. Git can be called on a nil Lock.
+func (l *Lock) Git(repository, revision string) (Entry, bool) {
+	return l.find(Entry{Source: SourceGit, Repository: repository, Revision: revision})
+}
+
+// HTTP returns the entry of the import.http module at url. HTTP can be called
+// on a nil Lock.
+func (l *Lock) HTTP(url string) (Entry, bool) {
+	return l.find(Entry{Source: SourceHTTP, URL: url})
+}
+
+// OCI returns the entry of the import.oci module for reference. OCI can be
+// called on a nil Lock.
+func (l *Lock) OCI(reference string) (Entry, bool) {
+	return l.find(Entry{Source: SourceOCI, Reference: reference})
+}
+
+func (l *Lock) find(key Entry) (Entry, bool) {
+	if l == nil {
+		return Entry{}, false
+	}
+	for _, e := range l.Modules {
+		if e.key() == key {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
@@ -0,0 +1,90 @@
+package modlock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMissing(t *testing.T) {
+	l, err := Load(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, err)
+	require.Nil(t, l)
+
+	// Lookups are safe on the nil Lock of a missing lock file.
+	_, ok := l.Git("https://example.com/modules.git", "main")
+	require.False(t, ok)
+}
+
+func TestWriteLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	var l Lock
+	l.Add(Entry{Source: SourceOCI, Reference: "registry.example.com/modules:v1", Digest: "sha256:aaaa"})
+	l.Add(Entry{Source: SourceGit, Repository: "https://example.com/modules.git", Revision: "main", Commit: "1111"})
+	l.Add(Entry{Source: SourceHTTP, URL: "https://example.com/math.alloy", SHA256: "bbbb"})
+	// Adding a module again replaces its version.
+	l.Add(Entry{Source: SourceGit, Repository: "https://example.com/modules.git", Revision: "main", Commit: "2222"})
+	require.NoError(t, l.Write(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "version": 1,
+  "modules": [
+    {
+      "source": "import.git",
+      "repository": "https://example.com/modules.git",
+      "revision": "main",
+      "commit": "2222"
+    },
+    {
+      "source": "import.http",
+      "url": "https://example.com/math.alloy",
+      "sha256": "bbbb"
+    },
+    {
+      "source": "import.oci",
+      "reference": "registry.example.com/modules:v1",
+      "digest": "sha256:aaaa"
+    }
+  ]
+}
+`, string(data))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+
+	e, ok := loaded.Git("https://example.com/modules.git", "main")
+	require.True(t, ok)
+	require.Equal(t, "2222", e.Commit)
+	_, ok = loaded.Git("https://example.com/modules.git", "v1.0.0")
+	require.False(t, ok)
+
+	e, ok = loaded.HTTP("https://example.com/math.alloy")
+	require.True(t, ok)
+	require.Equal(t, "bbbb", e.SHA256)
+
+	e, ok = loaded.OCI("registry.example.com/modules:v1")
+	require.True(t, ok)
+	require.Equal(t, "sha256:aaaa", e.Digest)
+}
+
+func TestLoadUnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2, "modules": []}`), 0644))
+
+	_, err := Load(path)
+	require.ErrorContains(t, err, "unsupported lock file version 2")
+}
+
+func TestDefaultPath(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.alloy")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+
+	require.Equal(t, filepath.Join(dir, FileName), DefaultPath(dir))
+	require.Equal(t, filepath.Join(dir, FileName), DefaultPath(file))
+}
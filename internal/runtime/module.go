@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/alloy/internal/runtime/internal/worker"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/scanner"
@@ -131,10 +132,10 @@ func newModule(o *moduleOptions) *module {
 	return &module{
 		o: o,
 		f: newController(controllerOptions{
-			IsModule:          true,
-			ModuleRegistry:    o.ModuleRegistry,
-			ComponentRegistry: o.ComponentRegistry,
-			WorkerPool:        o.WorkerPool,
+			IsModule:       true,
+			ModuleRegistry: o.ModuleRegistry,
+			WorkerPool:     o.WorkerPool,
+			RestartPolicy:  o.RestartPolicy,
 			Options: Options{
 				ControllerID:         o.ID,
 				Tracer:               o.Tracer,
@@ -143,6 +144,8 @@ func newModule(o *moduleOptions) *module {
 				DataPath:             o.DataPath,
 				MinStability:         o.MinStability,
 				EnableCommunityComps: o.EnableCommunityComps,
+				ComponentRegistry:    o.ComponentRegistry,
+				ModuleLock:           o.ModuleLock,
 				OnExportsChange: func(exports map[string]any) {
 					if o.export != nil {
 						o.export(exports)
@@ -230,4 +233,11 @@ type moduleControllerOptions struct {
 
 	// EnableCommunityComps enables the use of community components.
 	EnableCommunityComps bool
+
+	// ModuleLock returns the lock pinning the versions of imported modules.
+	ModuleLock func() *modlock.Lock
+
+	// RestartPolicy is the restart policy of components, set by the root
+	// controller.
+	RestartPolicy *controller.RestartPolicy
 }
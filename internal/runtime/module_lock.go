@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/internal/controller"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+	"github.com/grafana/alloy/internal/runtime/tracing"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax/ast"
+)
+
+// LockOptions holds the options to lock the modules imported by a
+// configuration.
+type LockOptions struct {
+	// Logger to use for the import sources. A no-op logger will be used if
+	// this is nil.
+	Logger *logging.Logger
+
+	// DataPath is the directory where the modules are fetched.
+	DataPath string
+
+	// MinStability is the minimum stability level of the import blocks which
+	// can be used.
+	MinStability featuregate.Stability
+
+	// Current is the lock whose versions are loaded instead of the latest
+	// versions of the modules. The latest version of every module is loaded if
+	// Current is nil.
+	Current *modlock.Lock
+}
+
+// LockModules loads the modules imported by source, including the modules
+// imported by these modules, and returns a lock which records the version of
+// every module imported by import.git, import.http and import.oci blocks.
+//
+// configPath is the path of the configuration, which determines the module
+// path of its import blocks. The modules are loaded without running the
+// configuration, so the arguments of the import blocks can't refer to
+// components.
+func LockModules(source *Source, configPath string, opts LockOptions) (*modlock.Lock, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.NewNop()
+	}
+
+	modulePath, err := util.ExtractDirPath(configPath)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to extract directory path from configPath", "configPath", configPath, "err", err)
+	}
+	tracer, err := tracing.New(tracing.DefaultOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var body ast.Body
+	for _, block := range source.configBlocks {
+		body = append(body, block)
+	}
+	for _, block := range source.declareBlocks {
+		body = append(body, block)
+	}
+
+	entries, err := controller.LockModules(body, modulePath, controller.ComponentGlobals{
+		Logger:            logger,
+		TraceProvider:     tracer,
+		DataPath:          opts.DataPath,
+		MinStability:      opts.MinStability,
+		OnBlockNodeUpdate: func(controller.BlockNode) {},
+		GetServiceData: func(name string) (interface{}, error) {
+			return nil, fmt.Errorf("service %q does not exist", name)
+		},
+		ModuleLock: func() *modlock.Lock { return opts.Current },
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &modlock.Lock{Version: modlock.Version}
+	for _, e := range entries {
+		lock.Add(e)
+	}
+	return lock, nil
+}
@@ -0,0 +1,197 @@
+package runtime_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+	"github.com/grafana/alloy/internal/service"
+)
+
+func TestLockModulesHTTP(t *testing.T) {
+	var module atomic.Value
+	module.Store(addModule)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lib.alloy":
+			// The module imports another module relative to its own URL.
+			_, _ = w.Write([]byte(`import.http "math" {
+	url = module_path + "math.alloy"
+}`))
+		case "/math.alloy":
+			_, _ = w.Write([]byte(module.Load().(string)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// The modules imported by files and by declare blocks are locked too.
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.alloy"), []byte(`import.http "lib" {
+	url = "`+srv.URL+`/lib.alloy"
+}`), 0644))
+	config := `
+import.file "lib" {
+	filename = module_path + "/lib.alloy"
+}
+
+declare "wrapper" {
+	import.http "math" {
+		url = "` + srv.URL + `/math.alloy"
+	}
+}
+
+import.http "math" {
+	url = "` + srv.URL + `/math.alloy"
+}
+
+math.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	configPath := filepath.Join(dir, "config.alloy")
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+	source, err := alloy_runtime.ParseSource(configPath, []byte(config))
+	require.NoError(t, err)
+
+	lock, err := alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{DataPath: t.TempDir()})
+	require.NoError(t, err)
+	require.Equal(t, []modlock.Entry{
+		{Source: modlock.SourceHTTP, URL: srv.URL + "/lib.alloy", SHA256: sha256Hex(`import.http "math" {
+	url = module_path + "math.alloy"
+}`)},
+		{Source: modlock.SourceHTTP, URL: srv.URL + "/math.alloy", SHA256: sha256Hex(addModule)},
+	}, sortedEntries(t, lock))
+
+	// The locked content is loaded.
+	ctrl, err := loadWithModuleLock(t, configPath, lock)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "math.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Content which doesn't match the lock is rejected.
+	module.Store(addModuleMore)
+	_, err = loadWithModuleLock(t, configPath, lock)
+	require.ErrorContains(t, err, "doesn't match the pinned digest")
+
+	// Locking again keeps the locked versions, which are no longer served.
+	_, err = alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{DataPath: t.TempDir(), Current: lock})
+	require.ErrorContains(t, err, "doesn't match the pinned digest")
+
+	// Updating the lock records the new content.
+	lock, err = alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{DataPath: t.TempDir()})
+	require.NoError(t, err)
+	entry, ok := lock.HTTP(srv.URL + "/math.alloy")
+	require.True(t, ok)
+	require.Equal(t, sha256Hex(addModuleMore), entry.SHA256)
+}
+
+func TestLockModulesOCI(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	locked := registry.PushModule("modules", "v1", map[string]string{"math.alloy": addModule})
+
+	configPath := filepath.Join(t.TempDir(), "config.alloy")
+	config := `
+import.oci "testImport" {
+	reference = "` + registry.Host() + `/modules:v1"
+	insecure  = true
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+	source, err := alloy_runtime.ParseSource(configPath, []byte(config))
+	require.NoError(t, err)
+
+	lock, err := alloy_runtime.LockModules(source, configPath, alloy_runtime.LockOptions{
+		DataPath:     t.TempDir(),
+		MinStability: featuregate.StabilityExperimental,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []modlock.Entry{
+		{Source: modlock.SourceOCI, Reference: registry.Host() + "/modules:v1", Digest: locked.String()},
+	}, lock.Modules)
+
+	// The tag moves to a new bundle but the locked bundle is pulled.
+	registry.PushModule("modules", "v1", map[string]string{"math.alloy": addModuleMore})
+
+	ctrl, err := loadWithModuleLock(t, configPath, lock)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestLockModulesStability(t *testing.T) {
+	source, err := alloy_runtime.ParseSource(t.Name(), []byte(`import.oci "math" {
+	reference = "registry.example.com/modules:v1"
+}`))
+	require.NoError(t, err)
+
+	_, err = alloy_runtime.LockModules(source, "", alloy_runtime.LockOptions{
+		DataPath:     t.TempDir(),
+		MinStability: featuregate.StabilityGenerallyAvailable,
+	})
+	require.ErrorContains(t, err, `config block "import.oci" is at stability level "experimental"`)
+}
+
+// loadWithModuleLock loads the config at configPath with the versions of the
+// modules pinned by lock and runs the controller until the test ends.
+func loadWithModuleLock(t *testing.T, configPath string, lock *modlock.Lock) (*alloy_runtime.Runtime, error) {
+	s, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+	ctrl := alloy_runtime.New(alloy_runtime.Options{
+		Logger:       s,
+		DataPath:     t.TempDir(),
+		MinStability: featuregate.StabilityExperimental,
+		Services:     []service.Service{},
+		ModuleLock:   func() *modlock.Lock { return lock },
+	})
+	config, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	f, err := alloy_runtime.ParseSource(configPath, config)
+	require.NoError(t, err)
+	err = ctrl.LoadSource(f, nil, configPath)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(t.Context())
+	}()
+	t.Cleanup(wg.Wait)
+	return ctrl, err
+}
+
+// sortedEntries returns the entries of lock in the order of the lock file.
+func sortedEntries(t *testing.T, lock *modlock.Lock) []modlock.Entry {
+	require.NoError(t, lock.Write(filepath.Join(t.TempDir(), modlock.FileName)))
+	return lock.Modules
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
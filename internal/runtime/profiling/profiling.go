@@ -0,0 +1,42 @@
+// Package profiling defines the pprof labels which Alloy applies to the
+// goroutines of running components, so that CPU and goroutine profiles can
+// be attributed to components.
+package profiling
+
+import (
+	"runtime/pprof"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+const (
+	// LabelModuleID is the pprof label holding the ID of the module a
+	// component runs in. It is empty for components of the root module.
+	LabelModuleID = "alloy_module_id"
+
+	// LabelComponentID is the pprof label holding the ID of a component,
+	// local to its module.
+	LabelComponentID = "alloy_component_id"
+)
+
+// ComponentLabels returns the pprof labels for the component with the given
+// ID.
+func ComponentLabels(id component.ID) pprof.LabelSet {
+	return pprof.Labels(LabelModuleID, id.ModuleID, LabelComponentID, id.LocalID)
+}
+
+// ComponentFromLabels returns the ID of the component a profile sample was
+// recorded for, using the labels of the sample. ok is false if the sample
+// wasn't recorded by a component.
+func ComponentFromLabels(labels map[string][]string) (id component.ID, ok bool) {
+	localIDs := labels[LabelComponentID]
+	if len(localIDs) == 0 || localIDs[0] == "" {
+		return component.ID{}, false
+	}
+
+	id.LocalID = localIDs[0]
+	if moduleIDs := labels[LabelModuleID]; len(moduleIDs) > 0 {
+		id.ModuleID = moduleIDs[0]
+	}
+	return id, true
+}
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+	"github.com/grafana/alloy/internal/runtime/profiling"
+)
+
+func TestController_ComponentPprofLabels(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	type labels struct{ moduleID, componentID string }
+	runLabels := make(chan labels, 1)
+
+	registry := component.NewRegistryMap(
+		featuregate.StabilityGenerallyAvailable,
+		true,
+		map[string]component.Registration{
+			"labelled": {
+				Name:      "labelled",
+				Args:      struct{}{},
+				Stability: featuregate.StabilityGenerallyAvailable,
+				Build: func(component.Options, component.Arguments) (component.Component, error) {
+					return &testcomponents.Fake{
+						RunFunc: func(ctx context.Context) error {
+							moduleID, _ := pprof.Label(ctx, profiling.LabelModuleID)
+							componentID, _ := pprof.Label(ctx, profiling.LabelComponentID)
+							runLabels <- labels{moduleID, componentID}
+							<-ctx.Done()
+							return nil
+						},
+					}, nil
+				},
+			},
+		},
+	)
+
+	opts := testOptions(t)
+	opts.ComponentRegistry = registry
+	ctrl := New(opts)
+
+	f, err := ParseSource(t.Name(), []byte(`labelled "a" {}`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case l := <-runLabels:
+		require.Equal(t, labels{moduleID: "", componentID: "labelled.a"}, l)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "component did not run")
+	}
+}
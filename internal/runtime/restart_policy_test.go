@@ -0,0 +1,193 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	var flakyRuns atomic.Int64
+	registry := component.NewRegistryMap(
+		featuregate.StabilityGenerallyAvailable,
+		true,
+		map[string]component.Registration{
+			"panicking": {
+				Name:      "panicking",
+				Args:      struct{}{},
+				Stability: featuregate.StabilityGenerallyAvailable,
+				Build: func(component.Options, component.Arguments) (component.Component, error) {
+					return &testcomponents.Fake{
+						RunFunc: func(context.Context) error { panic("oops") },
+					}, nil
+				},
+			},
+			"flaky": {
+				Name:      "flaky",
+				Args:      struct{}{},
+				Stability: featuregate.StabilityGenerallyAvailable,
+				Build: func(opts component.Options, _ component.Arguments) (component.Component, error) {
+					// Registering the same metric again fails unless the rebuilt
+					// component gets a new registry.
+					opts.Registerer.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "flaky_total"}))
+					return &testcomponents.Fake{
+						RunFunc: func(ctx context.Context) error {
+							if flakyRuns.Add(1) <= 2 {
+								return errors.New("flaked")
+							}
+							<-ctx.Done()
+							return nil
+						},
+					}, nil
+				},
+			},
+		},
+	)
+
+	tt := []struct {
+		name          string
+		config        string
+		id            string
+		expectHealth  component.HealthType
+		expectMessage string
+		expectRestart int
+	}{
+		{
+			name:          "panics are recovered without a policy",
+			config:        `panicking "a" {}`,
+			id:            "panicking.a",
+			expectHealth:  component.HealthTypeExited,
+			expectMessage: "component panicked: oops",
+			expectRestart: 0,
+		},
+		{
+			name: "gives up after max restarts",
+			config: `
+				restart_policy {
+					initial_backoff = "10ms"
+					max_restarts    = 3
+				}
+				panicking "a" {}
+			`,
+			id:            "panicking.a",
+			expectHealth:  component.HealthTypeExited,
+			expectMessage: "gave up restarting component after 3 restarts",
+			expectRestart: 3,
+		},
+		{
+			name: "restarted component recovers",
+			config: `
+				restart_policy {
+					initial_backoff = "10ms"
+				}
+				flaky "a" {}
+			`,
+			id:            "flaky.a",
+			expectHealth:  component.HealthTypeHealthy,
+			expectMessage: "started component",
+			expectRestart: 2,
+		},
+		{
+			name: "never restarts",
+			config: `
+				restart_policy {
+					mode = "never"
+				}
+				panicking "a" {}
+			`,
+			id:            "panicking.a",
+			expectHealth:  component.HealthTypeExited,
+			expectMessage: "component panicked: oops",
+			expectRestart: 0,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			flakyRuns.Store(0)
+
+			reg := prometheus.NewRegistry()
+			opts := testOptions(t)
+			opts.ComponentRegistry = registry
+			opts.MinStability = featuregate.StabilityExperimental
+			opts.Reg = reg
+			ctrl := New(opts)
+
+			f, err := ParseSource(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+			require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			require.EventuallyWithT(t, func(c *assert.CollectT) {
+				info, err := ctrl.GetComponent(component.ID{LocalID: tc.id}, component.InfoOptions{GetHealth: true})
+				require.NoError(c, err)
+				require.Equal(c, tc.expectHealth, info.Health.Health)
+				require.Contains(c, info.Health.Message, tc.expectMessage)
+				require.Equal(c, tc.expectRestart, info.Restarts)
+			}, 5*time.Second, 10*time.Millisecond)
+
+			expect := fmt.Sprintf(`
+				# HELP alloy_component_restarts_total Total number of times a component was restarted after exiting.
+				# TYPE alloy_component_restarts_total counter
+				alloy_component_restarts_total{component_id=%q,controller_id="",controller_path="/"} %d
+			`, tc.id, tc.expectRestart)
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect), "alloy_component_restarts_total"))
+		})
+	}
+}
+
+func TestRestartPolicy_NotAllowedInModule(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	opts := testOptions(t)
+	opts.MinStability = featuregate.StabilityExperimental
+	ctrl := New(opts)
+
+	f, err := ParseSource(t.Name(), []byte(`
+		declare "mod" {
+			restart_policy {}
+		}
+		mod "a" {}
+	`))
+	require.NoError(t, err)
+	err = ctrl.LoadSource(f, nil, "")
+	require.ErrorContains(t, err, "restart_policy block not allowed inside a module")
+	cleanUpController(t.Context(), ctrl)
+}
+
+func TestRestartPolicy_Stability(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	ctrl := New(testOptions(t))
+
+	f, err := ParseSource(t.Name(), []byte(`restart_policy {}`))
+	require.NoError(t, err)
+	err = ctrl.LoadSource(f, nil, "")
+	require.ErrorContains(t, err, `config block "restart_policy" is at stability level "experimental"`)
+	cleanUpController(t.Context(), ctrl)
+}
@@ -0,0 +1,263 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+	"github.com/grafana/alloy/internal/util"
+)
+
+// ErrRolledBack is wrapped by the error LoadSource returns when a new source
+// was reverted to the last known-good source.
+var ErrRolledBack = errors.New("reverted to the last known-good configuration")
+
+// maxRollbackEvents is the number of most recent rollbacks which are kept.
+const maxRollbackEvents = 20
+
+// RollbackEvent describes an automatic rollback of a reload.
+type RollbackEvent struct {
+	ControllerID string    `json:"controllerID"`
+	Time         time.Time `json:"time"`
+	Reason       string    `json:"reason"`
+
+	// Components are the IDs of the components which became unhealthy after
+	// the reload.
+	Components []string `json:"components"`
+
+	// RevertError is set if the last known-good source couldn't be loaded
+	// again.
+	RevertError string `json:"revertError,omitempty"`
+}
+
+// loadedSource holds the parameters of a call to LoadSource, and the module
+// lock which was in use when it was called.
+type loadedSource struct {
+	source     *Source
+	args       map[string]any
+	configPath string
+	moduleLock *modlock.Lock
+}
+
+type rollbackState struct {
+	controllerID string
+	settlePeriod time.Duration
+	metrics      *rollbackMetrics
+
+	// ctx is canceled when the controller exits, which stops the pending
+	// health check.
+	ctx    context.Context
+	cancel context.CancelFunc
+	checks sync.WaitGroup
+
+	// loadMut serializes loads and health checks.
+	loadMut  sync.Mutex
+	lastGood *loadedSource
+	// cancelCheck cancels the health check of the last load if its settle
+	// period hasn't elapsed yet.
+	cancelCheck context.CancelFunc
+
+	eventsMut sync.RWMutex
+	events    []RollbackEvent
+}
+
+func newRollbackState(controllerID string, settlePeriod time.Duration, reg prometheus.Registerer) *rollbackState {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &rollbackState{
+		controllerID: controllerID,
+		settlePeriod: settlePeriod,
+		metrics:      newRollbackMetrics(controllerID, reg),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// stop cancels the pending health check and waits for it to return.
+func (rb *rollbackState) stop() {
+	rb.cancel()
+	rb.checks.Wait()
+}
+
+type rollbackMetrics struct {
+	rollbacks    prometheus.Counter
+	lastRollback prometheus.Gauge
+}
+
+func newRollbackMetrics(controllerID string, reg prometheus.Registerer) *rollbackMetrics {
+	m := &rollbackMetrics{
+		rollbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "alloy_config_rollbacks_total",
+			Help:        "Number of reloads which were reverted to the last known-good configuration.",
+			ConstLabels: map[string]string{"controller_id": controllerID},
+		}),
+		lastRollback: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "alloy_config_last_rollback_timestamp_seconds",
+			Help:        "Timestamp of the last reload which was reverted to the last known-good configuration.",
+			ConstLabels: map[string]string{"controller_id": controllerID},
+		}),
+	}
+
+	if reg != nil {
+		m.rollbacks = util.MustRegisterOrGet(reg, m.rollbacks).(prometheus.Counter)
+		m.lastRollback = util.MustRegisterOrGet(reg, m.lastRollback).(prometheus.Gauge)
+	}
+	return m
+}
+
+// loadSourceWithRollback loads source and reverts to the last known-good
+// source if evaluating source failed. Otherwise, it returns once source is
+// applied, and the components which were healthy before the load are checked
+// in the background once the settle period elapsed. The check is canceled by
+// a newer load or when the controller exits.
+func (f *Runtime) loadSourceWithRollback(source *Source, args map[string]any, configPath string) error {
+	rb := f.rollback
+	rb.loadMut.Lock()
+	defer rb.loadMut.Unlock()
+
+	// A load which hasn't settled yet is never committed, the last known-good
+	// source stays the one to revert to.
+	if rb.cancelCheck != nil {
+		rb.cancelCheck()
+		rb.cancelCheck = nil
+	}
+
+	next := &loadedSource{source: source, args: args, configPath: configPath}
+	if f.opts.ModuleLock != nil {
+		next.moduleLock = f.opts.ModuleLock()
+	}
+
+	prev := rb.lastGood
+	if prev == nil {
+		// There is nothing to revert to on the initial load.
+		err := f.applySource(source, args, configPath)
+		if err == nil {
+			rb.lastGood = next
+		}
+		return err
+	}
+
+	unhealthyBefore := f.unhealthyComponents()
+
+	if err := f.applySource(source, args, configPath); err != nil {
+		return f.revert(prev, fmt.Sprintf("evaluation failed: %s", err), nil, err)
+	}
+
+	ctx, cancel := context.WithCancel(rb.ctx)
+	rb.cancelCheck = cancel
+	rb.checks.Add(1)
+	go func() {
+		defer rb.checks.Done()
+		defer cancel()
+		f.checkAfterSettle(ctx, prev, next, unhealthyBefore)
+	}()
+	return nil
+}
+
+// checkAfterSettle waits for the settle period, then reverts to prev if
+// components which weren't in unhealthyBefore are unhealthy, and records next
+// as the last known-good source otherwise.
+func (f *Runtime) checkAfterSettle(ctx context.Context, prev, next *loadedSource, unhealthyBefore []string) {
+	rb := f.rollback
+
+	t := time.NewTimer(rb.settlePeriod)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-t.C:
+	}
+
+	rb.loadMut.Lock()
+	defer rb.loadMut.Unlock()
+	// A newer load may have started while waiting for the lock.
+	if ctx.Err() != nil {
+		return
+	}
+	rb.cancelCheck = nil
+
+	var regressed []string
+	for _, id := range f.unhealthyComponents() {
+		if !slices.Contains(unhealthyBefore, id) {
+			regressed = append(regressed, id)
+		}
+	}
+	if len(regressed) > 0 {
+		reason := fmt.Sprintf("components became unhealthy: %s", strings.Join(regressed, ", "))
+		_ = f.revert(prev, reason, regressed, errors.New(reason))
+		return
+	}
+
+	rb.lastGood = next
+}
+
+// revert loads the last known-good source prev and records the rollback.
+// OnRevert is called first, so that the module lock of prev is restored
+// before its import blocks are evaluated. The returned error wraps both
+// ErrRolledBack and cause.
+func (f *Runtime) revert(prev *loadedSource, reason string, components []string, cause error) error {
+	rb := f.rollback
+	level.Warn(f.log).Log("msg", "reverting to the last known-good configuration", "controller", rb.controllerID, "reason", reason)
+
+	event := RollbackEvent{
+		ControllerID: rb.controllerID,
+		Time:         time.Now(),
+		Reason:       reason,
+		Components:   components,
+	}
+	if f.opts.OnRevert != nil {
+		f.opts.OnRevert(prev.source, prev.moduleLock)
+	}
+	if err := f.applySource(prev.source, prev.args, prev.configPath); err != nil {
+		level.Error(f.log).Log("msg", "failed to load the last known-good configuration", "controller", rb.controllerID, "err", err)
+		event.RevertError = err.Error()
+	}
+
+	rb.metrics.rollbacks.Inc()
+	rb.metrics.lastRollback.Set(float64(event.Time.Unix()))
+
+	rb.eventsMut.Lock()
+	rb.events = append(rb.events, event)
+	if len(rb.events) > maxRollbackEvents {
+		rb.events = rb.events[len(rb.events)-maxRollbackEvents:]
+	}
+	rb.eventsMut.Unlock()
+
+	return fmt.Errorf("%w: %w", ErrRolledBack, cause)
+}
+
+// unhealthyComponents returns the IDs of the components which are unhealthy
+// or exited.
+func (f *Runtime) unhealthyComponents() []string {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	var res []string
+	for _, cn := range f.loader.Components() {
+		switch cn.CurrentHealth().Health {
+		case component.HealthTypeUnhealthy, component.HealthTypeExited:
+			res = append(res, cn.NodeID())
+		}
+	}
+	return res
+}
+
+// RollbackEvents returns the most recent automatic rollbacks, oldest first.
+// It returns nil if automatic rollback is disabled.
+func (f *Runtime) RollbackEvents() []RollbackEvent {
+	if f.rollback == nil {
+		return nil
+	}
+
+	f.rollback.eventsMut.RLock()
+	defer f.rollback.eventsMut.RUnlock()
+	return slices.Clone(f.rollback.events)
+}
@@ -0,0 +1,161 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+	"github.com/grafana/alloy/internal/runtime/modlock"
+)
+
+func TestController_LoadSource_Rollback(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	registry := component.NewRegistryMap(
+		featuregate.StabilityGenerallyAvailable,
+		true,
+		map[string]component.Registration{
+			"healthy": {
+				Name:      "healthy",
+				Args:      struct{}{},
+				Stability: featuregate.StabilityGenerallyAvailable,
+				Build: func(component.Options, component.Arguments) (component.Component, error) {
+					return &testcomponents.Fake{}, nil
+				},
+			},
+			"failing": {
+				Name:      "failing",
+				Args:      struct{}{},
+				Stability: featuregate.StabilityGenerallyAvailable,
+				Build: func(component.Options, component.Arguments) (component.Component, error) {
+					return &testcomponents.Fake{
+						RunFunc: func(context.Context) error { return errors.New("failed to start") },
+					}, nil
+				},
+			},
+		},
+	)
+
+	opts := testOptions(t)
+	opts.ComponentRegistry = registry
+	opts.RollbackSettlePeriod = 200 * time.Millisecond
+
+	var (
+		moduleLock atomic.Pointer[modlock.Lock]
+		revertsMut sync.Mutex
+		reverts    []loadedSource
+	)
+	opts.ModuleLock = moduleLock.Load
+	opts.OnRevert = func(source *Source, lock *modlock.Lock) {
+		revertsMut.Lock()
+		defer revertsMut.Unlock()
+		reverts = append(reverts, loadedSource{source: source, moduleLock: lock})
+	}
+	lastRevert := func() loadedSource {
+		revertsMut.Lock()
+		defer revertsMut.Unlock()
+		require.NotEmpty(t, reverts)
+		return reverts[len(reverts)-1]
+	}
+	ctrl := New(opts)
+
+	load := func(cfg string) error {
+		f, err := ParseSource(t.Name(), []byte(cfg))
+		require.NoError(t, err)
+		return ctrl.LoadSource(f, nil, "")
+	}
+	componentIDs := func() []string {
+		var ids []string
+		for _, cn := range ctrl.loader.Components() {
+			ids = append(ids, cn.NodeID())
+		}
+		return ids
+	}
+
+	require.NoError(t, load(`healthy "a" {}`))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	lastGood := func() *loadedSource {
+		ctrl.rollback.loadMut.Lock()
+		defer ctrl.rollback.loadMut.Unlock()
+		return ctrl.rollback.lastGood
+	}
+
+	t.Run("healthy reload is kept", func(t *testing.T) {
+		prev := lastGood()
+		moduleLock.Store(&modlock.Lock{Version: modlock.Version})
+		require.NoError(t, load(`
+			healthy "a" {}
+			healthy "b" {}
+		`))
+		require.ElementsMatch(t, []string{"healthy.a", "healthy.b"}, componentIDs())
+
+		// The reload becomes the last known-good source once it settled.
+		require.Eventually(t, func() bool { return lastGood() != prev }, 5*time.Second, 10*time.Millisecond)
+		require.Empty(t, ctrl.RollbackEvents())
+	})
+
+	t.Run("unhealthy components are reverted", func(t *testing.T) {
+		good, goodLock := lastGood().source, moduleLock.Load()
+
+		// The load returns before the health of the components is checked.
+		moduleLock.Store(&modlock.Lock{Version: modlock.Version})
+		require.NoError(t, load(`
+			healthy "a" {}
+			failing "c" {}
+		`))
+
+		require.Eventually(t, func() bool { return len(ctrl.RollbackEvents()) == 1 }, 5*time.Second, 10*time.Millisecond)
+		require.ElementsMatch(t, []string{"healthy.a", "healthy.b"}, componentIDs())
+
+		events := ctrl.RollbackEvents()
+		require.Equal(t, []string{"failing.c"}, events[0].Components)
+		require.Empty(t, events[0].RevertError)
+
+		// OnRevert is given the source which runs again and its lock.
+		revert := lastRevert()
+		require.Same(t, good, revert.source)
+		require.Same(t, goodLock, revert.moduleLock)
+	})
+
+	t.Run("evaluation errors are reverted", func(t *testing.T) {
+		err := load(`healthy "a" { missing = true }`)
+		require.ErrorIs(t, err, ErrRolledBack)
+		require.ElementsMatch(t, []string{"healthy.a", "healthy.b"}, componentIDs())
+		require.Len(t, ctrl.RollbackEvents(), 2)
+		require.Same(t, lastGood().source, lastRevert().source)
+	})
+
+	t.Run("newer load cancels the pending check", func(t *testing.T) {
+		require.NoError(t, load(`
+			healthy "a" {}
+			failing "c" {}
+		`))
+		require.NoError(t, load(`
+			healthy "a" {}
+			healthy "b" {}
+		`))
+
+		time.Sleep(2 * opts.RollbackSettlePeriod)
+		require.ElementsMatch(t, []string{"healthy.a", "healthy.b"}, componentIDs())
+		require.Len(t, ctrl.RollbackEvents(), 2)
+	})
+}
@@ -6,7 +6,10 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/importoci"
+	"github.com/grafana/alloy/internal/nodeconf/restartpolicy"
 	"github.com/grafana/alloy/internal/static/config/encoder"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
@@ -76,7 +79,7 @@ func sourceFromBody(body ast.Body) (*Source, error) {
 			switch fullName {
 			case "declare":
 				declares = append(declares, stmt)
-			case "logging", "tracing", "argument", "export", "import.file", "import.string", "import.http", "import.git", foreach.Name:
+			case "logging", "tracing", "argument", "export", "function", "import.file", "import.string", "import.http", "import.git", importoci.Name, foreach.Name, conditional.Name, restartpolicy.Name:
 				configs = append(configs, stmt)
 			default:
 				components = append(components, stmt)
@@ -0,0 +1,116 @@
+package runtime_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+)
+
+func TestStdlibFileRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+
+	// The file is read by a component and by a component of a custom
+	// component.
+	config := `
+	declare "reader" {
+		argument "path" {}
+
+		testcomponents.passthrough "pt" {
+			input = file.read(argument.path.value)
+			lag   = "1ms"
+		}
+
+		export "output" {
+			value = testcomponents.passthrough.pt.output
+		}
+	}
+
+	testcomponents.passthrough "read" {
+		input = file.read(` + strconv.Quote(path) + `)
+		lag   = "1ms"
+	}
+
+	reader "module" {
+		path = ` + strconv.Quote(path) + `
+	}
+
+	testcomponents.passthrough "module" {
+		input = reader.module.output
+		lag   = "1ms"
+	}
+	`
+	ctrl, cancel := runFileConfig(t, config)
+	defer cancel()
+
+	requireOutput := func(expected string) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			read := getExport[testcomponents.PassthroughExports](t, ctrl, "", "testcomponents.passthrough.read")
+			module := getExport[testcomponents.PassthroughExports](t, ctrl, "", "testcomponents.passthrough.module")
+			return read.Output == expected && module.Output == expected
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	requireOutput("first")
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	requireOutput("second")
+}
+
+func TestStdlibFileGlob(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), nil, 0644))
+
+	config := `
+	testcomponents.passthrough "glob" {
+		input = string.join(file.glob(` + strconv.Quote(filepath.Join(dir, "*.yaml")) + `), ",")
+		lag   = "1ms"
+	}
+	`
+	ctrl, cancel := runFileConfig(t, config)
+	defer cancel()
+
+	requireOutput := func(expected string) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			export := getExport[testcomponents.PassthroughExports](t, ctrl, "", "testcomponents.passthrough.glob")
+			return export.Output == expected
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	requireOutput(filepath.Join(dir, "a.yaml"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), nil, 0644))
+	requireOutput(filepath.Join(dir, "a.yaml") + "," + filepath.Join(dir, "b.yaml"))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.yaml")))
+	requireOutput(filepath.Join(dir, "b.yaml"))
+}
+
+// runFileConfig loads config and runs the controller until cancel is called.
+func runFileConfig(t *testing.T, config string) (*runtime.Runtime, func()) {
+	ctrl := runtime.New(testOptions(t))
+	f, err := runtime.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	return ctrl, func() {
+		cancel()
+		<-done
+	}
+}
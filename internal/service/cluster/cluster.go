@@ -87,6 +87,7 @@ type Options struct {
 	ClusterName            string        // Name to prevent nodes without this identifier from joining the cluster.
 	MinimumClusterSize     int           // Minimum cluster size before admitting traffic to components that use clustering.
 	MinimumSizeWaitTimeout time.Duration // Maximum duration to wait for minimum cluster size before proceeding; 0 means no timeout.
+	WaitForConfigMatch     bool          // Whether to stop admitting traffic while the configuration of the node differs from the majority of the cluster.
 
 	// Function to discover peers to join. If this function is nil or returns an
 	// empty slice, no peers will be joined.
@@ -107,6 +108,14 @@ type Service struct {
 	alloyCluster *alloyCluster
 	// notifyClusterChange is used to signal that cluster has changed, and we need to notify all the components
 	notifyClusterChange chan struct{}
+
+	// httpClient is used to fetch the configurations of the peers.
+	httpClient   *http.Client
+	driftMetrics driftMetrics
+
+	configMut   sync.RWMutex
+	configHash  string
+	driftReport DriftReport
 }
 
 // Component is a component which subscribes to clustering updates.
@@ -129,8 +138,8 @@ type ComponentBlock struct {
 }
 
 var (
-	_ service.Service            = (*Service)(nil)
-	_ httpservice.ServiceHandler = (*Service)(nil)
+	_ service.Service                 = (*Service)(nil)
+	_ httpservice.ExtraServiceHandler = (*Service)(nil)
 )
 
 // New returns a new, unstarted instance of the cluster service.
@@ -202,6 +211,8 @@ func New(opts Options) (*Service, error) {
 		node:                node,
 		randGen:             rand.New(rand.NewSource(time.Now().UnixNano())),
 		notifyClusterChange: make(chan struct{}, 1),
+		httpClient:          httpClient,
+		driftMetrics:        newDriftMetrics(opts, l),
 	}
 	s.alloyCluster = newAlloyCluster(ckitConfig.Sharder, s.triggerClusterChangeNotification, opts, l)
 
@@ -277,6 +288,22 @@ func (s *Service) ServiceHandler(_ service.Host) (base string, handler http.Hand
 	return base, handler
 }
 
+// ExtraServiceHandlers returns the handler serving the summary of the
+// configuration of the node to its peers. It's served outside of the base
+// route of ServiceHandler, which belongs to ckit. The resulting handler always
+// returns 404 when clustering is disabled.
+func (s *Service) ExtraServiceHandlers(host service.Host) map[string]http.Handler {
+	var handler http.Handler = s.configSummaryHandler(host)
+
+	if !s.opts.EnableClustering {
+		handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "clustering is disabled", http.StatusNotFound)
+		})
+	}
+
+	return map[string]http.Handler{configSummaryPath: handler}
+}
+
 // ChangeState changes the state of the service. If clustering is enabled,
 // ChangeState will block until the state change has been propagated to another
 // node; cancel the current context to stop waiting. ChangeState fails if the
@@ -324,6 +351,7 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		"advertise_addr", s.opts.AdvertiseAddress,
 		"minimum_cluster_size", s.opts.MinimumClusterSize,
 		"minimum_size_wait_timeout", s.opts.MinimumSizeWaitTimeout,
+		"wait_for_config_match", s.opts.WaitForConfigMatch,
 	)
 
 	if err := s.node.Start(peers); err != nil {
@@ -352,6 +380,14 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		}
 	}()
 
+	if s.opts.EnableClustering {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runConfigSync(ctx, host)
+		}()
+	}
+
 	if s.opts.EnableClustering && s.opts.RejoinInterval > 0 {
 		wg.Add(1)
 
@@ -51,6 +51,8 @@ type Cluster interface {
 	// - there is no minimum size requirement specified
 	// - there is a minimum size requirement and the cluster size is >= that size
 	// - there is a minimum size requirement and cluster size is too small, but the configured wait deadline has passed.
+	// When waiting for the configuration to match, the configuration of the node must also not differ from the
+	// configuration of the majority of the cluster.
 	Ready() bool
 }
 
@@ -66,6 +68,9 @@ type alloyCluster struct {
 	rwMutex       sync.RWMutex
 	deadlineTimer *time.Timer
 	clusterState  clusterState
+	// configDrifted is true when the configuration of the node differs from the configuration of the majority of the
+	// cluster.
+	configDrifted bool
 }
 
 var _ Cluster = (*alloyCluster)(nil)
@@ -109,18 +114,22 @@ func newAlloyCluster(sharder shard.Sharder, clusterChangeCallback func(), opts O
 		}
 	}
 
-	// For consistency, set cluster to always ready when clustering is disabled or no minimum size is set.
+	// When waiting for the configuration to match, traffic isn't admitted until the configuration of the node has
+	// been compared with the configurations of its peers.
+	c.configDrifted = c.opts.EnableClustering && c.opts.WaitForConfigMatch
+
+	// For consistency, set cluster state to ready when clustering is disabled or no minimum size is set.
 	if !c.opts.EnableClustering || c.opts.MinimumClusterSize == 0 {
 		c.clusterState = stateReady
-		c.clusterReadyGauge.Set(1)
+		c.updateReadyGauge()
 	} else if opts.MinimumSizeWaitTimeout != 0 {
+		c.updateReadyGauge()
 		// Start the deadline timer if the minimum size wait timeout is set
 		c.deadlineTimer = time.AfterFunc(c.opts.MinimumSizeWaitTimeout, func() {
 			c.rwMutex.Lock()
 			defer c.rwMutex.Unlock()
 			c.transitionToStateDeadlinePassed()
 		})
-		c.clusterReadyGauge.Set(0)
 	}
 	return c
 }
@@ -134,16 +143,48 @@ func (c *alloyCluster) Peers() []peer.Peer {
 }
 
 func (c *alloyCluster) Ready() bool {
-	// Lock-free path: if clustering is disabled or no minimum size is set, the cluster is always ready.
-	if !c.opts.EnableClustering || c.opts.MinimumClusterSize == 0 {
+	// Lock-free path: if clustering is disabled or there are no requirements, the cluster is always ready.
+	if !c.opts.EnableClustering || (c.opts.MinimumClusterSize == 0 && !c.opts.WaitForConfigMatch) {
 		return true
 	}
 
 	c.rwMutex.RLock()
 	defer c.rwMutex.RUnlock()
+	return c.ready()
+}
+
+// ready returns whether the cluster is ready to admit traffic. rwMutex must be locked by the caller.
+func (c *alloyCluster) ready() bool {
+	if c.opts.WaitForConfigMatch && c.configDrifted {
+		return false
+	}
 	return c.clusterState == stateReady || c.clusterState == stateDeadlinePassed
 }
 
+// updateReadyGauge sets the cluster ready gauge to the current readiness. rwMutex must be locked by the caller.
+func (c *alloyCluster) updateReadyGauge() {
+	if c.ready() {
+		c.clusterReadyGauge.Set(1)
+	} else {
+		c.clusterReadyGauge.Set(0)
+	}
+}
+
+// setConfigDrifted records whether the configuration of the node differs from the configuration of the majority of
+// the cluster. It returns true if the readiness of the cluster may have changed, in which case the components must
+// be notified.
+func (c *alloyCluster) setConfigDrifted(drifted bool) bool {
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+
+	if c.configDrifted == drifted {
+		return false
+	}
+	c.configDrifted = drifted
+	c.updateReadyGauge()
+	return c.opts.WaitForConfigMatch
+}
+
 func (c *alloyCluster) updateReadyState() {
 	c.rwMutex.Lock()
 	defer c.rwMutex.Unlock()
@@ -170,7 +211,7 @@ func (c *alloyCluster) transitionToStateReady() {
 		return
 	}
 	c.clusterState = stateReady
-	c.clusterReadyGauge.Set(1)
+	c.updateReadyGauge()
 
 	// Stop the deadline timer if it was running
 	if c.deadlineTimer != nil {
@@ -190,7 +231,7 @@ func (c *alloyCluster) transitionToStateNotReady() {
 		return
 	}
 	c.clusterState = stateNotReady
-	c.clusterReadyGauge.Set(0)
+	c.updateReadyGauge()
 
 	// Restart the deadline timer if it is configured and we just transitioned to not ready
 	if c.opts.MinimumSizeWaitTimeout != 0 {
@@ -226,7 +267,7 @@ func (c *alloyCluster) transitionToStateDeadlinePassed() {
 		return
 	}
 	c.clusterState = stateDeadlinePassed
-	c.clusterReadyGauge.Set(1)
+	c.updateReadyGauge()
 
 	level.Warn(c.log).Log(
 		"msg", "deadline passed, marking cluster as ready to admit traffic",
@@ -0,0 +1,401 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/remotecfg"
+)
+
+// configSummaryPath is the path where each node serves the summary of its
+// configuration to its peers. The summaries are fetched over HTTP as ckit
+// nodes can't gossip custom metadata.
+const configSummaryPath = "/api/v0/cluster/config"
+
+// configSyncInterval is how often the configurations of the peers are
+// fetched and compared.
+var configSyncInterval = 5 * time.Second
+
+// ConfigSummary summarizes the configuration loaded by a node.
+type ConfigSummary struct {
+	// Hash of the configuration files. It is empty until a configuration is
+	// loaded successfully.
+	Hash string `json:"hash"`
+	// Sorted IDs of the running components, including the components of
+	// modules and of remote configuration. Nodes loading the same files can
+	// still run different modules or remote configurations, so the components
+	// are compared as well as the hash. The components of the instances of
+	// foreach and if blocks are excluded, as they depend on runtime data such
+	// as discovered targets rather than on the configuration.
+	Components []string `json:"components"`
+}
+
+// key identifies the configuration summarized by s: two nodes run the same
+// configuration if they have the same key.
+func (s *ConfigSummary) key() string {
+	return s.Hash + "\n" + strings.Join(s.Components, "\n")
+}
+
+// ConfigStatus is the status of the configuration of a peer compared to the
+// rest of the cluster.
+type ConfigStatus string
+
+const (
+	// ConfigStatusInSync is used when the peer runs the configuration of the
+	// majority of the cluster.
+	ConfigStatusInSync ConfigStatus = "in_sync"
+	// ConfigStatusDrifted is used when the peer runs a configuration different
+	// from the configuration of the majority of the cluster.
+	ConfigStatusDrifted ConfigStatus = "drifted"
+	// ConfigStatusUnknown is used when the configuration of the peer couldn't
+	// be retrieved, or when no configuration is run by a majority of the
+	// cluster.
+	ConfigStatusUnknown ConfigStatus = "unknown"
+)
+
+// PeerConfig is the configuration of a peer compared to the rest of the
+// cluster.
+type PeerConfig struct {
+	Hash   string       `json:"hash,omitempty"`
+	Status ConfigStatus `json:"status"`
+	// Components run by the majority of the cluster but not by the peer.
+	MissingComponents []string `json:"missingComponents,omitempty"`
+	// Components run by the peer but not by the majority of the cluster.
+	ExtraComponents []string `json:"extraComponents,omitempty"`
+}
+
+// DriftReport compares the configurations of the peers of the cluster.
+type DriftReport struct {
+	// Hash of the configuration run by more than half of the peers whose
+	// configuration is known, or empty if there is no such configuration. Peers
+	// with this hash but other components are drifted.
+	MajorityHash string
+	// Configuration of each peer, by peer name.
+	Peers map[string]PeerConfig
+}
+
+// driftMetrics are the metrics exposing the configuration drift of the
+// cluster.
+type driftMetrics struct {
+	nodeDrift    prometheus.Gauge
+	driftedPeers prometheus.Gauge
+}
+
+func newDriftMetrics(opts Options, l log.Logger) driftMetrics {
+	m := driftMetrics{
+		nodeDrift: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cluster_node_config_drift",
+			Help: "Reports 1 when the configuration of the node differs from the configuration of the majority of the cluster, 0 otherwise.",
+			ConstLabels: prometheus.Labels{
+				"cluster_name": opts.ClusterName,
+			},
+		}),
+		driftedPeers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cluster_config_drifted_peers",
+			Help: "The number of peers whose configuration differs from the configuration of the majority of the cluster.",
+			ConstLabels: prometheus.Labels{
+				"cluster_name": opts.ClusterName,
+			},
+		}),
+	}
+
+	if opts.EnableClustering && opts.Metrics != nil {
+		for _, c := range []prometheus.Collector{m.nodeDrift, m.driftedPeers} {
+			if err := opts.Metrics.Register(c); err != nil {
+				level.Warn(l).Log("msg", "failed to register config drift metric", "err", err)
+			}
+		}
+	}
+	return m
+}
+
+// SetConfigHash sets the hash of the configuration loaded by the node, which
+// is compared with the configurations of its peers. It must be called every
+// time a configuration is loaded successfully.
+func (s *Service) SetConfigHash(hash string) {
+	s.configMut.Lock()
+	defer s.configMut.Unlock()
+	s.configHash = hash
+}
+
+// ConfigDrift returns the last comparison of the configurations of the peers
+// of the cluster. The report is empty until the configurations have been
+// compared once, and when clustering is disabled.
+func (s *Service) ConfigDrift() DriftReport {
+	s.configMut.RLock()
+	defer s.configMut.RUnlock()
+	return s.driftReport
+}
+
+// configSummary returns the summary of the configuration loaded by the node.
+func (s *Service) configSummary(host service.Host) ConfigSummary {
+	s.configMut.RLock()
+	hash := s.configHash
+	s.configMut.RUnlock()
+
+	hosts := []service.Host{host}
+	if svc, ok := host.GetService(remotecfg.ServiceName); ok {
+		data := svc.Data().(remotecfg.Data)
+		hosts = append(hosts, data.Host)
+		for _, sourceHost := range data.Sources {
+			hosts = append(hosts, sourceHost)
+		}
+	}
+
+	var ids []string
+	for _, h := range hosts {
+		if h == nil {
+			// The controller of the remote configuration isn't started yet.
+			continue
+		}
+		for _, info := range component.GetAllComponents(h, component.InfoOptions{}) {
+			if isInstanceModule(info.ID.ModuleID) {
+				continue
+			}
+			ids = append(ids, info.ID.String())
+		}
+	}
+	slices.Sort(ids)
+	return ConfigSummary{Hash: hash, Components: ids}
+}
+
+// isInstanceModule returns whether the module with the given ID is an
+// instance of a foreach or if block, or is nested in one. Their module IDs
+// start with the ID of the block, followed by the ID of the instance.
+func isInstanceModule(moduleID string) bool {
+	segments := strings.Split(moduleID, "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if strings.HasPrefix(segment, "foreach.") || strings.HasPrefix(segment, "if.") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) configSummaryHandler(host service.Host) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		bb, err := json.Marshal(s.configSummary(host))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
+
+// fetchConfigSummary fetches the summary of the configuration of the peer
+// listening on addr.
+func (s *Service) fetchConfigSummary(ctx context.Context, addr string) (*ConfigSummary, error) {
+	scheme := "http"
+	if s.opts.EnableTLS {
+		scheme = "https"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+addr+configSummaryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var summary ConfigSummary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("decoding config summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// runConfigSync compares the configurations of the peers every
+// configSyncInterval until ctx is canceled.
+func (s *Service) runConfigSync(ctx context.Context, host service.Host) {
+	t := time.NewTicker(configSyncInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.syncConfigs(ctx, host)
+		}
+	}
+}
+
+// syncConfigs fetches the configurations of the peers, compares them, and
+// updates the drift report, the metrics, and the readiness of the cluster.
+func (s *Service) syncConfigs(ctx context.Context, host service.Host) {
+	ctx, cancel := context.WithTimeout(ctx, configSyncInterval)
+	defer cancel()
+
+	var (
+		selfName  string
+		wg        sync.WaitGroup
+		mut       sync.Mutex
+		summaries = make(map[string]*ConfigSummary)
+	)
+	for _, p := range s.sharder.Peers() {
+		if p.Self {
+			selfName = p.Name
+			summary := s.configSummary(host)
+			mut.Lock()
+			summaries[p.Name] = &summary
+			mut.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summary, err := s.fetchConfigSummary(ctx, p.Addr)
+			if err != nil {
+				level.Debug(s.log).Log("msg", "failed to fetch the configuration of peer", "peer", p.Name, "err", err)
+			}
+			mut.Lock()
+			summaries[p.Name] = summary
+			mut.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	report := compareConfigs(selfName, summaries)
+
+	var drifted int
+	for _, cfg := range report.Peers {
+		if cfg.Status == ConfigStatusDrifted {
+			drifted++
+		}
+	}
+	selfDrifted := report.Peers[selfName].Status == ConfigStatusDrifted
+	s.driftMetrics.driftedPeers.Set(float64(drifted))
+	if selfDrifted {
+		s.driftMetrics.nodeDrift.Set(1)
+	} else {
+		s.driftMetrics.nodeDrift.Set(0)
+	}
+
+	s.configMut.Lock()
+	wasDrifted := s.driftReport.Peers[selfName].Status == ConfigStatusDrifted
+	s.driftReport = report
+	s.configMut.Unlock()
+
+	if selfDrifted != wasDrifted {
+		if selfDrifted {
+			level.Warn(s.log).Log(
+				"msg", "the configuration of this node differs from the configuration of the majority of the cluster",
+				"hash", report.Peers[selfName].Hash,
+				"majority_hash", report.MajorityHash,
+				"wait_for_config_match", s.opts.WaitForConfigMatch,
+			)
+		} else {
+			level.Info(s.log).Log("msg", "the configuration of this node matches the configuration of the majority of the cluster", "hash", report.MajorityHash)
+		}
+	}
+
+	if s.alloyCluster.setConfigDrifted(selfDrifted) {
+		// Components must check whether the cluster is still ready.
+		s.triggerClusterChangeNotification()
+	}
+}
+
+// compareConfigs compares the summaries of the configurations of the peers,
+// keyed by peer name. Summaries which couldn't be retrieved are nil. Peers run
+// the same configuration if both their hashes and their components match. The
+// components of the drifted peers are compared with the components of the
+// local node if it runs the configuration of the majority, or of the first
+// peer by name which runs it otherwise.
+func compareConfigs(selfName string, summaries map[string]*ConfigSummary) DriftReport {
+	var (
+		known  int
+		counts = make(map[string]int)
+	)
+	for _, summary := range summaries {
+		if summary == nil || summary.Hash == "" {
+			continue
+		}
+		known++
+		counts[summary.key()]++
+	}
+
+	var majorityKey string
+	for key, count := range counts {
+		if count*2 > known {
+			majorityKey = key
+		}
+	}
+
+	names := make([]string, 0, len(summaries))
+	for name := range summaries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var reference *ConfigSummary
+	if self := summaries[selfName]; self != nil && majorityKey != "" && self.key() == majorityKey {
+		reference = self
+	}
+	for _, name := range names {
+		if reference != nil {
+			break
+		}
+		if summary := summaries[name]; summary != nil && majorityKey != "" && summary.key() == majorityKey {
+			reference = summary
+		}
+	}
+
+	report := DriftReport{
+		Peers: make(map[string]PeerConfig, len(summaries)),
+	}
+	if reference != nil {
+		report.MajorityHash = reference.Hash
+	}
+	for name, summary := range summaries {
+		if summary == nil {
+			report.Peers[name] = PeerConfig{Status: ConfigStatusUnknown}
+			continue
+		}
+
+		cfg := PeerConfig{Hash: summary.Hash}
+		switch {
+		case summary.Hash == "" || reference == nil:
+			cfg.Status = ConfigStatusUnknown
+		case summary.key() == majorityKey:
+			cfg.Status = ConfigStatusInSync
+		default:
+			cfg.Status = ConfigStatusDrifted
+			cfg.MissingComponents = difference(reference.Components, summary.Components)
+			cfg.ExtraComponents = difference(summary.Components, reference.Components)
+		}
+		report.Peers[name] = cfg
+	}
+	return report
+}
+
+// difference returns the elements of the sorted slice a which aren't in the
+// sorted slice b.
+func difference(a, b []string) []string {
+	var res []string
+	for _, v := range a {
+		if _, found := slices.BinarySearch(b, v); !found {
+			res = append(res, v)
+		}
+	}
+	return res
+}
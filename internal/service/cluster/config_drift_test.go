@@ -0,0 +1,264 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/service"
+)
+
+func TestCompareConfigs(t *testing.T) {
+	tests := []struct {
+		name             string
+		summaries        map[string]*ConfigSummary
+		expectedMajority string
+		expected         map[string]PeerConfig
+	}{
+		{
+			name: "all in sync",
+			summaries: map[string]*ConfigSummary{
+				"self": {Hash: "a", Components: []string{"x"}},
+				"b":    {Hash: "a", Components: []string{"x"}},
+			},
+			expectedMajority: "a",
+			expected: map[string]PeerConfig{
+				"self": {Hash: "a", Status: ConfigStatusInSync},
+				"b":    {Hash: "a", Status: ConfigStatusInSync},
+			},
+		},
+		{
+			name: "drifted peer",
+			summaries: map[string]*ConfigSummary{
+				"self": {Hash: "a", Components: []string{"x", "y"}},
+				"b":    {Hash: "a", Components: []string{"x", "y"}},
+				"c":    {Hash: "c", Components: []string{"x", "z"}},
+			},
+			expectedMajority: "a",
+			expected: map[string]PeerConfig{
+				"self": {Hash: "a", Status: ConfigStatusInSync},
+				"b":    {Hash: "a", Status: ConfigStatusInSync},
+				"c":    {Hash: "c", Status: ConfigStatusDrifted, MissingComponents: []string{"y"}, ExtraComponents: []string{"z"}},
+			},
+		},
+		{
+			name: "same files with other components",
+			summaries: map[string]*ConfigSummary{
+				"self": {Hash: "a", Components: []string{"x", "y"}},
+				"b":    {Hash: "a", Components: []string{"x", "y"}},
+				"c":    {Hash: "a", Components: []string{"remotecfg/z", "x"}},
+			},
+			expectedMajority: "a",
+			expected: map[string]PeerConfig{
+				"self": {Hash: "a", Status: ConfigStatusInSync},
+				"b":    {Hash: "a", Status: ConfigStatusInSync},
+				"c":    {Hash: "a", Status: ConfigStatusDrifted, MissingComponents: []string{"y"}, ExtraComponents: []string{"remotecfg/z"}},
+			},
+		},
+		{
+			name: "no majority",
+			summaries: map[string]*ConfigSummary{
+				"self": {Hash: "a"},
+				"b":    {Hash: "b"},
+			},
+			expected: map[string]PeerConfig{
+				"self": {Hash: "a", Status: ConfigStatusUnknown},
+				"b":    {Hash: "b", Status: ConfigStatusUnknown},
+			},
+		},
+		{
+			name: "unknown peers are ignored",
+			summaries: map[string]*ConfigSummary{
+				"self": {Hash: "a"},
+				"b":    nil,
+				"c":    {},
+			},
+			expectedMajority: "a",
+			expected: map[string]PeerConfig{
+				"self": {Hash: "a", Status: ConfigStatusInSync},
+				"b":    {Status: ConfigStatusUnknown},
+				"c":    {Status: ConfigStatusUnknown},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			report := compareConfigs("self", tc.summaries)
+			require.Equal(t, tc.expectedMajority, report.MajorityHash)
+			require.Equal(t, tc.expected, report.Peers)
+		})
+	}
+}
+
+func TestSyncConfigs(t *testing.T) {
+	s, err := New(Options{
+		Log:                log.NewNopLogger(),
+		Metrics:            prometheus.NewRegistry(),
+		EnableClustering:   true,
+		WaitForConfigMatch: true,
+		NodeName:           "self",
+		AdvertiseAddress:   "127.0.0.1:12345",
+	})
+	require.NoError(t, err)
+	defer s.alloyCluster.shutdown()
+
+	peerA := newConfigPeer(t, ConfigSummary{Hash: "new", Components: []string{"x", "y"}})
+	peerB := newConfigPeer(t, ConfigSummary{Hash: "new", Components: []string{"x", "y"}})
+	sharder := &mockSharder{peers: []peer.Peer{
+		{Name: "self", Self: true},
+		{Name: "a", Addr: peerA.Listener.Addr().String()},
+		{Name: "b", Addr: peerB.Listener.Addr().String()},
+	}}
+	s.sharder = sharder
+	s.alloyCluster.sharder = sharder
+
+	// Traffic isn't admitted until the configurations are compared.
+	require.False(t, s.alloyCluster.Ready())
+
+	host := &componentsHost{ids: []string{"x"}}
+	s.SetConfigHash("old")
+	s.syncConfigs(context.Background(), host)
+
+	report := s.ConfigDrift()
+	require.Equal(t, "new", report.MajorityHash)
+	require.Equal(t, PeerConfig{Hash: "old", Status: ConfigStatusDrifted, MissingComponents: []string{"y"}}, report.Peers["self"])
+	require.Equal(t, ConfigStatusInSync, report.Peers["a"].Status)
+	require.False(t, s.alloyCluster.Ready())
+	require.Equal(t, 1.0, testutil.ToFloat64(s.driftMetrics.nodeDrift))
+	require.Equal(t, 1.0, testutil.ToFloat64(s.driftMetrics.driftedPeers))
+
+	host.ids = []string{"x", "y"}
+	s.SetConfigHash("new")
+	s.syncConfigs(context.Background(), host)
+
+	require.Equal(t, ConfigStatusInSync, s.ConfigDrift().Peers["self"].Status)
+	require.True(t, s.alloyCluster.Ready())
+	require.Len(t, s.notifyClusterChange, 1)
+	require.Equal(t, 0.0, testutil.ToFloat64(s.driftMetrics.nodeDrift))
+	require.Equal(t, 0.0, testutil.ToFloat64(s.driftMetrics.driftedPeers))
+
+	// The configuration of unreachable peers is unknown.
+	peerB.Close()
+	s.syncConfigs(context.Background(), host)
+
+	report = s.ConfigDrift()
+	require.Equal(t, ConfigStatusInSync, report.Peers["self"].Status)
+	require.Equal(t, PeerConfig{Status: ConfigStatusUnknown}, report.Peers["b"])
+	require.True(t, s.alloyCluster.Ready())
+}
+
+func TestConfigSummaryIgnoresInstances(t *testing.T) {
+	s, err := New(Options{
+		Log:              log.NewNopLogger(),
+		Metrics:          prometheus.NewRegistry(),
+		EnableClustering: true,
+		NodeName:         "self",
+		AdvertiseAddress: "127.0.0.1:12345",
+	})
+	require.NoError(t, err)
+	defer s.alloyCluster.shutdown()
+	s.SetConfigHash("hash")
+
+	// Both peers load the same files, but discovered different targets, so
+	// their foreach and if blocks run different instances.
+	a := s.configSummary(&componentsHost{
+		ids: []string{"foreach.targets", "if.enabled", "import.file.mod"},
+		modules: map[string][]string{
+			"foreach.targets":                            {"foreach.targets/foreach_1"},
+			"if.enabled":                                 {"if.enabled/then"},
+			"import.file.mod":                            {"import.file.mod"},
+			"import.file.mod/mod.default":                {"import.file.mod/mod.default"},
+			"import.file.mod/mod.default/foreach.nested": {"import.file.mod/mod.default/foreach.nested/foreach_1"},
+		},
+		components: map[string][]string{
+			"foreach.targets/foreach_1":                            {"prometheus.scrape.default"},
+			"if.enabled/then":                                      {"prometheus.scrape.default"},
+			"import.file.mod":                                      {"mod.default"},
+			"import.file.mod/mod.default":                          {"foreach.nested"},
+			"import.file.mod/mod.default/foreach.nested/foreach_1": {"prometheus.scrape.inner"},
+		},
+	})
+	b := s.configSummary(&componentsHost{
+		ids: []string{"foreach.targets", "if.enabled", "import.file.mod"},
+		modules: map[string][]string{
+			"foreach.targets":             {"foreach.targets/foreach_2", "foreach.targets/foreach_3"},
+			"import.file.mod":             {"import.file.mod"},
+			"import.file.mod/mod.default": {"import.file.mod/mod.default"},
+		},
+		components: map[string][]string{
+			"foreach.targets/foreach_2":   {"prometheus.scrape.default"},
+			"foreach.targets/foreach_3":   {"prometheus.scrape.default"},
+			"import.file.mod":             {"mod.default"},
+			"import.file.mod/mod.default": {"foreach.nested"},
+		},
+	})
+	require.Equal(t, a.key(), b.key())
+	require.Equal(t, []string{
+		"foreach.targets",
+		"if.enabled",
+		"import.file.mod",
+		"import.file.mod/mod.default",
+		"import.file.mod/mod.default/foreach.nested",
+	}, a.Components)
+}
+
+// newConfigPeer starts a server which serves summary like the cluster service
+// of a peer does.
+func newConfigPeer(t *testing.T, summary ConfigSummary) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(configSummaryPath, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(summary)
+	})
+	srv := httptest.NewServer(h2c.NewHandler(mux, &http2.Server{}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// componentsHost is a service.Host running components with the given IDs.
+type componentsHost struct {
+	ids []string
+	// modules holds the IDs of the modules run by each component, by
+	// component ID.
+	modules map[string][]string
+	// components holds the local IDs of the components of each module, by
+	// module ID.
+	components map[string][]string
+}
+
+var _ service.Host = (*componentsHost)(nil)
+
+func (h *componentsHost) GetComponent(_ component.ID, _ component.InfoOptions) (*component.Info, error) {
+	return nil, component.ErrComponentNotFound
+}
+
+func (h *componentsHost) ListComponents(moduleID string, _ component.InfoOptions) ([]*component.Info, error) {
+	ids := h.ids
+	if moduleID != "" {
+		ids = h.components[moduleID]
+	}
+	infos := make([]*component.Info, 0, len(ids))
+	for _, id := range ids {
+		info := &component.Info{ID: component.ID{ModuleID: moduleID, LocalID: id}}
+		info.ModuleIDs = h.modules[info.ID.String()]
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (h *componentsHost) GetService(_ string) (service.Service, bool) { return nil, false }
+
+func (h *componentsHost) GetServiceConsumers(_ string) []service.Consumer { return nil }
+
+func (h *componentsHost) NewController(_ string) service.Controller { return nil }
@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -22,12 +24,15 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/plan"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/internal/service/remotecfg"
 	"github.com/grafana/alloy/internal/static/server"
 	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
 	"github.com/grafana/alloy/syntax/printer"
 	"github.com/grafana/ckit/memconn"
 	_ "github.com/grafana/pyroscope-go/godeltaprof/http/pprof" // Register godeltaprof handler
@@ -43,6 +48,15 @@ import (
 // ServiceName defines the name used for the HTTP service.
 const ServiceName = "http"
 
+const (
+	// planFilename is the filename reported for candidate configs sent to
+	// /api/v0/plan.
+	planFilename = "candidate.alloy"
+
+	// maxPlanBodySize limits the size of candidate configs.
+	maxPlanBodySize = 32 << 20
+)
+
 // Options are used to configure the HTTP service. Options are constant for the
 // lifetime of the HTTP service.
 type Options struct {
@@ -58,6 +72,11 @@ type Options struct {
 	EnablePProf      bool                  // Whether pprof endpoints should be exposed.
 	MinStability     featuregate.Stability // Minimum stability level to utilize for feature gates
 	BundleContext    SupportBundleContext  // Context for delivering a support bundle
+
+	// ComponentRegistry is used to look up the arguments of components when
+	// planning a candidate configuration. If nil, the default registry is
+	// used.
+	ComponentRegistry component.Registry
 }
 
 // Arguments holds runtime settings for the HTTP service.
@@ -81,8 +100,10 @@ type Service struct {
 	// Used to enforce single-flight requests to supportHandler
 	supportBundleMut sync.Mutex
 
-	// Track the raw config for use with the support bundle
-	sources map[string]*ast.File
+	// Track the raw config for use with the support bundle and for planning
+	// candidate configs.
+	sourcesMut sync.RWMutex
+	sources    map[string]*ast.File
 
 	authenticatorMut sync.RWMutex
 	// authenticator is applied to every request made to http server
@@ -268,6 +289,11 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 			level.Info(s.log).Log("msg", "reload requested via /-/reload endpoint")
 
 			if err := s.opts.ReloadFunc(); err != nil {
+				if errors.Is(err, alloy_runtime.ErrRolledBack) {
+					level.Warn(s.log).Log("msg", "reloaded config was rolled back", "err", err.Error())
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
 				level.Error(s.log).Log("msg", "failed to reload config", "err", err.Error())
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -278,6 +304,8 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		}).Methods(http.MethodGet, http.MethodPost)
 	}
 
+	r.HandleFunc("/api/v0/plan", s.planHandler(host)).Methods(http.MethodPost)
+
 	// Wire in support bundle generator
 	r.HandleFunc("/-/support", s.generateSupportBundleHandler(host)).Methods("GET")
 
@@ -359,7 +387,9 @@ func (s *Service) generateSupportBundleHandler(host service.Host) func(rw http.R
 
 		// Ensure the sources are written using the printer as it will handle
 		// secret redaction.
+		s.sourcesMut.RLock()
 		sources := redactedSources(s.sources)
+		s.sourcesMut.RUnlock()
 
 		bundle, err := ExportSupportBundle(ctx, s.opts.BundleContext.RuntimeFlags, s.opts.HTTPListenAddr, sources, cachedConfig, s.Data().(Data).DialFunc)
 		if err != nil {
@@ -374,13 +404,69 @@ func (s *Service) generateSupportBundleHandler(host service.Host) func(rw http.R
 }
 
 // SetSources sets the sources on reload to be delivered
-// with the support bundle.
+// with the support bundle and compared against by /api/v0/plan.
 func (s *Service) SetSources(sources map[string]*ast.File) {
-	s.supportBundleMut.Lock()
-	defer s.supportBundleMut.Unlock()
+	s.sourcesMut.Lock()
+	defer s.sourcesMut.Unlock()
 	s.sources = sources
 }
 
+// planHandler computes the changes a candidate config sent in the request
+// body would make to the running config, without applying it.
+func (s *Service) planHandler(host service.Host) func(rw http.ResponseWriter, r *http.Request) {
+	reg := s.opts.ComponentRegistry
+	if reg == nil {
+		reg = component.NewDefaultRegistry(s.opts.MinStability, false)
+	}
+	reg = serviceConfigRegistry{Registry: reg, host: host}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		bb, err := io.ReadAll(io.LimitReader(r.Body, maxPlanBodySize))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f, err := parser.ParseFile(planFilename, bb)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.sourcesMut.RLock()
+		p := plan.Compute(s.sources, map[string]*ast.File{planFilename: f}, reg)
+		s.sourcesMut.RUnlock()
+
+		if r.URL.Query().Get("format") == "text" {
+			rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_ = p.WriteText(rw)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(rw).Encode(p); err != nil {
+			level.Error(s.log).Log("msg", "failed to write plan", "err", err)
+		}
+	}
+}
+
+// serviceConfigRegistry looks up the config blocks of services as if they
+// were components, so that plans find which of their arguments are sensitive.
+type serviceConfigRegistry struct {
+	component.Registry
+	host service.Host
+}
+
+func (r serviceConfigRegistry) Get(name string) (component.Registration, error) {
+	reg, err := r.Registry.Get(name)
+	if err == nil {
+		return reg, nil
+	}
+	if svc, ok := r.host.GetService(name); ok && svc.Definition().ConfigType != nil {
+		return component.Registration{Name: name, Args: svc.Definition().ConfigType}, nil
+	}
+	return reg, err
+}
+
 func getServerWriteTimeout(r *http.Request) time.Duration {
 	srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server)
 	if ok && srv.WriteTimeout != 0 {
@@ -412,22 +498,32 @@ func (s *Service) getServiceRoutes(host service.Host) []serviceRoute {
 			Base:    base,
 			Handler: handler,
 		})
+
+		if eh, ok := sh.(ExtraServiceHandler); ok {
+			for base, handler := range eh.ExtraServiceHandlers(host) {
+				routes = append(routes, serviceRoute{
+					Base:    base,
+					Handler: handler,
+				})
+			}
+		}
 	}
 
 	sort.Sort(routes)
 	return routes
 }
 
-func (s *Service) componentHandler(getHost func() (service.Host, error), pathPrefix string) http.HandlerFunc {
+func (s *Service) componentHandler(getHost func(path string) (service.Host, error), pathPrefix string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		host, err := getHost()
+		// Trim the path prefix to get our full path.
+		trimmedPath := strings.TrimPrefix(r.URL.Path, pathPrefix)
+
+		host, err := getHost(trimmedPath)
 		if host == nil || err != nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			_, _ = fmt.Fprintf(w, "failed to get host: %s\n", err)
 			return
 		}
-		// Trim the path prefix to get our full path.
-		trimmedPath := strings.TrimPrefix(r.URL.Path, pathPrefix)
 
 		// splitURLPath should only fail given an unexpected path.
 		componentID, componentPath, err := splitURLPath(host, trimmedPath)
@@ -581,6 +677,18 @@ type ServiceHandler interface {
 	ServiceHandler(host service.Host) (base string, handler http.Handler)
 }
 
+// ExtraServiceHandler is a ServiceHandler which exposes HTTP handlers under
+// more than one base route, for example when the handler returned by
+// ServiceHandler belongs to a library which owns its base route.
+type ExtraServiceHandler interface {
+	ServiceHandler
+
+	// ExtraServiceHandlers returns the HTTP handlers to register in addition to
+	// the handler returned by ServiceHandler, keyed by base route. Base routes
+	// are prioritized like the base routes of ServiceHandler.
+	ExtraServiceHandlers(host service.Host) map[string]http.Handler
+}
+
 // lazyListener is a [net.Listener] which lazily initializes the underlying
 // listener.
 type lazyListener struct {
@@ -699,19 +807,21 @@ func printFileRedacted(f *ast.File) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func remoteCfgHostProvider(host service.Host) func() (service.Host, error) {
-	return func() (service.Host, error) {
+// remoteCfgHostProvider returns the host of the source of remote
+// configuration which loaded the component at the given path.
+func remoteCfgHostProvider(host service.Host) func(path string) (service.Host, error) {
+	return func(path string) (service.Host, error) {
 		svc, ok := host.GetService(remotecfg.ServiceName)
 		if !ok {
 			// This will never happen as the service dependency is explicit.
 			return nil, fmt.Errorf("failed to get the remotecfg service")
 		}
-		return svc.Data().(remotecfg.Data).Host, nil
+		return svc.Data().(remotecfg.Data).HostFor(remotecfg.SourceName(path)), nil
 	}
 }
 
-func rootHostProvider(host service.Host) func() (service.Host, error) {
-	return func() (service.Host, error) {
+func rootHostProvider(host service.Host) func(path string) (service.Host, error) {
+	return func(string) (service.Host, error) {
 		return host, nil
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/grafana/alloy/internal/component"
@@ -14,6 +15,8 @@ import (
 	"github.com/grafana/alloy/internal/service/remotecfg"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
 	"github.com/phayes/freeport"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/config"
@@ -330,6 +333,70 @@ func TestUnhealthy(t *testing.T) {
 	})
 }
 
+func TestPlan(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+
+	env, err := newTestEnvironment(t)
+	require.NoError(t, err)
+	require.NoError(t, env.ApplyConfig(""))
+
+	running, err := parser.ParseFile("config.alloy", []byte(`
+		logging {
+			level = "info"
+		}
+
+		remotecfg {
+			basic_auth {
+				username = "user"
+				password = "oldpass"
+			}
+		}
+	`))
+	require.NoError(t, err)
+	env.svc.SetSources(map[string]*ast.File{"config.alloy": running})
+
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	util.Eventually(t, func(t require.TestingT) {
+		body := strings.NewReader(`
+			logging {
+				level = "debug"
+			}
+			remotecfg {
+				basic_auth {
+					username = "admin"
+					password = "newpass"
+				}
+			}
+			prometheus.exporter.self "default" { }
+		`)
+		resp, err := http.Post(fmt.Sprintf("http://%s/api/v0/plan?format=text", env.ListenAddr()), "text/plain", body)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `Components:
+  + prometheus.exporter.self.default
+  ~ logging
+      ~ level = "info" -> "debug"
+  ~ remotecfg
+      ~ basic_auth[0].password = (secret) -> (secret)
+      ~ basic_auth[0].username = "user" -> "admin"
+
+1 to add, 2 to change, 0 to remove.
+`, string(buf))
+	})
+
+	resp, err := http.Post(fmt.Sprintf("http://%s/api/v0/plan", env.ListenAddr()), "text/plain", strings.NewReader(`logging {`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 type testEnvironment struct {
 	svc        *Service
 	addr       string
@@ -2,6 +2,7 @@ package remotecfg
 
 import (
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"hash/fnv"
@@ -23,7 +24,6 @@ import (
 	"github.com/grafana/alloy/internal/build"
 	"github.com/grafana/alloy/internal/component/common/config"
 	"github.com/grafana/alloy/internal/featuregate"
-	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/internal/util/jitter"
@@ -55,16 +55,25 @@ var errNotModified = errors.New("config not modified since last fetch")
 // The datapath field is where the service looks for the local cache location.
 // It is defined as a hash of the Arguments field.
 type Service struct {
-	opts Options
-	args Arguments
+	opts     Options
+	args     Arguments
+	name     string // Name of the source, empty for the default source.
+	basePath string // Where the configuration of the source is cached.
 
 	ctrl service.Controller
 
 	mut                  sync.RWMutex
 	asClient             collectorv1connect.CollectorServiceClient
 	clientFactory        func(args Arguments) (collectorv1connect.CollectorServiceClient, error)
+	watchClient          watchClient
+	watchClientFactory   func(args Arguments) (watchClient, error)
+	statusClient         statusClient
+	statusClientFactory  func(args Arguments) (statusClient, error)
+	statusChan           chan struct{}
+	pendingStatus        *statusReport
 	ticker               *jitter.Ticker
 	updateTickerChan     chan struct{}
+	updateWatchChan      chan struct{}
 	pollFrequency        time.Duration
 	dataPath             string
 	lastLoadedConfigHash string
@@ -76,9 +85,29 @@ type Service struct {
 	// the configuration has changed since the last fetch
 	remoteHash string
 
+	// This is set when the API doesn't support streaming the configuration,
+	// in which case it is polled until the arguments change.
+	streamUnsupported bool
+
+	// This is set when the API doesn't support status reports, in which case
+	// they aren't sent until the arguments change.
+	statusUnsupported bool
+
 	// This is the AST file parsed from the configuration. This is used
 	// for the support bundle
 	astFile *ast.File
+
+	// loadMut serializes loading configurations, so that a configuration
+	// isn't reverted while another one is loaded.
+	loadMut         sync.Mutex
+	verificationKey ed25519.PublicKey
+	committed       *loadedConfig // Last configuration known to work.
+	probation       *probation    // Configuration on probation, if any.
+	rejectedHash    string        // Hash of the last rejected configuration.
+
+	// These are the named sources, which are started by Run.
+	sources           map[string]*Service
+	updateSourcesChan chan struct{}
 }
 
 type metrics struct {
@@ -89,6 +118,9 @@ type metrics struct {
 	lastFetchSuccessTime prometheus.Gauge
 	totalAttempts        prometheus.Counter
 	getConfigTime        prometheus.Histogram
+	streamConnected      prometheus.Gauge
+	statusReportFailures prometheus.Counter
+	reverts              prometheus.Counter
 }
 
 // ServiceName defines the name used for the remotecfg service.
@@ -113,7 +145,11 @@ type Arguments struct {
 	Name             string                   `alloy:"name,attr,optional"`
 	Attributes       map[string]string        `alloy:"attributes,attr,optional"`
 	PollFrequency    time.Duration            `alloy:"poll_frequency,attr,optional"`
+	Streaming        bool                     `alloy:"streaming,attr,optional"`
+	ProbationPeriod  time.Duration            `alloy:"probation_period,attr,optional"`
+	PublicKey        string                   `alloy:"public_key,attr,optional"`
 	HTTPClientConfig *config.HTTPClientConfig `alloy:",squash"`
+	Sources          []SourceArguments        `alloy:"source,block,optional"`
 }
 
 // GetDefaultArguments populates the default values for the Arguments struct.
@@ -137,12 +173,30 @@ func (a *Arguments) Validate() error {
 		return fmt.Errorf("poll_frequency must be at least \"10s\", got %q", a.PollFrequency)
 	}
 
+	if a.ProbationPeriod < 0 {
+		return fmt.Errorf("probation_period must not be negative, got %q", a.ProbationPeriod)
+	}
+
+	if a.PublicKey != "" {
+		if _, err := parseVerificationKey(a.PublicKey); err != nil {
+			return fmt.Errorf("invalid public_key: %w", err)
+		}
+	}
+
 	for k := range a.Attributes {
 		if strings.HasPrefix(k, reservedAttributeNamespace+namespaceDelimiter) {
 			return fmt.Errorf("%q is a reserved namespace for remotecfg attribute keys", reservedAttributeNamespace)
 		}
 	}
 
+	seen := make(map[string]struct{}, len(a.Sources))
+	for _, src := range a.Sources {
+		if _, ok := seen[src.Label]; ok {
+			return fmt.Errorf("source %q is defined more than once", src.Label)
+		}
+		seen[src.Label] = struct{}{}
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it
 	// won't run otherwise
 	if a.HTTPClientConfig != nil {
@@ -163,17 +217,28 @@ func (a *Arguments) Hash() (string, error) {
 
 // New returns a new instance of the remotecfg service.
 func New(opts Options) (*Service, error) {
-	basePath := filepath.Join(opts.StoragePath, ServiceName)
+	return newService(opts, "")
+}
+
+// newService returns the service of the source with the given name, or of the
+// default source if name is empty.
+func newService(opts Options, name string) (*Service, error) {
+	basePath := sourceBasePath(opts.StoragePath, name)
 	err := os.MkdirAll(basePath, 0750)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Service{
-		opts:             opts,
-		systemAttrs:      getSystemAttributes(),
-		updateTickerChan: make(chan struct{}, 1),
-		pollFrequency:    disablePollingFrequency,
+		opts:              opts,
+		name:              name,
+		basePath:          basePath,
+		systemAttrs:       getSystemAttributes(),
+		updateTickerChan:  make(chan struct{}, 1),
+		updateWatchChan:   make(chan struct{}, 1),
+		statusChan:        make(chan struct{}, 1),
+		updateSourcesChan: make(chan struct{}, 1),
+		pollFrequency:     disablePollingFrequency,
 		clientFactory: func(args Arguments) (collectorv1connect.CollectorServiceClient, error) {
 			httpClient, err := commonconfig.NewClientFromConfig(*args.HTTPClientConfig.Convert(), "remoteconfig")
 			if err != nil {
@@ -185,6 +250,8 @@ func New(opts Options) (*Service, error) {
 				connect.WithHTTPGet(),
 			), nil
 		},
+		watchClientFactory:  newWatchClient,
+		statusClientFactory: newStatusClient,
 	}, nil
 }
 
@@ -196,7 +263,13 @@ func getSystemAttributes() map[string]string {
 }
 
 func (s *Service) registerMetrics() {
-	prom := promauto.With(s.opts.Metrics)
+	// The metrics of every source are labeled with its name, the default
+	// source having an empty name, so that they can be registered together.
+	reg := s.opts.Metrics
+	if reg != nil {
+		reg = prometheus.WrapRegistererWith(prometheus.Labels{"source": s.name}, reg)
+	}
+	prom := promauto.With(reg)
 	mets := &metrics{
 		configHash: prom.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -241,6 +314,24 @@ func (s *Service) registerMetrics() {
 				Help: "Duration of remote configuration requests.",
 			},
 		),
+		streamConnected: prom.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "remotecfg_stream_connected",
+				Help: "Whether the remote configuration is streamed from the API",
+			},
+		),
+		statusReportFailures: prom.NewCounter(
+			prometheus.CounterOpts{
+				Name: "remotecfg_status_report_failures_total",
+				Help: "Failed attempts to report the status of the remote configuration to the API",
+			},
+		),
+		reverts: prom.NewCounter(
+			prometheus.CounterOpts{
+				Name: "remotecfg_reverts_total",
+				Help: "Remote configurations reverted to the previous one during their probation",
+			},
+		),
 	}
 	s.metrics = mets
 }
@@ -249,11 +340,19 @@ func (s *Service) registerMetrics() {
 // caller.
 // Data must only be called after Run.
 func (s *Service) Data() any {
-	if s.ctrl == nil {
-		return Data{Host: nil}
+	return Data{Host: s.host(), Sources: s.sourceHosts()}
+}
+
+// host returns the Host of the controller of the service, or nil if Run wasn't
+// called yet.
+func (s *Service) host() service.Host {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	hc, ok := s.ctrl.(interface{ GetHost() service.Host })
+	if !ok {
+		return nil
 	}
-	host := s.ctrl.(alloy_runtime.ServiceController).GetHost()
-	return Data{Host: host}
+	return hc.GetHost()
 }
 
 // Data includes information associated with the HTTP service.
@@ -261,6 +360,10 @@ type Data struct {
 	// Host exposes the Host of the isolated controller that is created by the
 	// remotecfg service.
 	Host service.Host
+
+	// Sources exposes the Hosts of the isolated controllers of the named
+	// sources, by name.
+	Sources map[string]service.Host
 }
 
 // Definition returns the definition of the remotecfg service.
@@ -278,7 +381,9 @@ var _ service.Service = (*Service)(nil)
 // Run implements [service.Service] and starts the remotecfg service. It will
 // run until the provided context is canceled or there is a fatal error.
 func (s *Service) Run(ctx context.Context, host service.Host) error {
-	s.ctrl = host.NewController(ServiceName)
+	s.mut.Lock()
+	s.ctrl = host.NewController(s.controllerID())
+	s.mut.Unlock()
 
 	s.fetch()
 	err := s.registerCollector()
@@ -286,9 +391,45 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		return err
 	}
 
-	s.ticker = jitter.NewTicker(s.pollFrequency, baseJitter)
+	s.ticker = jitter.NewTicker(s.getPollFrequency(), baseJitter)
+
+	// stopWatching stops streaming the configuration, if it was started.
+	stopWatching := func() {}
+	restartWatching := func() {
+		stopWatching()
+		stopWatching = func() {}
+		if s.streamingEnabled() {
+			stopWatching = s.startWatching(ctx)
+		}
+	}
+	// The stream is started with the current arguments, so there's no need to
+	// restart it for an earlier update.
+	select {
+	case <-s.updateWatchChan:
+	default:
+	}
+	restartWatching()
+
+	var wg sync.WaitGroup
+	startedSources := make(map[string]struct{})
+	s.runSources(ctx, host, &wg, startedSources)
+
+	reporterCtx, stopReporter := context.WithCancel(ctx)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.runStatusReporter(reporterCtx)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.runProbation(reporterCtx)
+	}()
 
 	defer func() {
+		stopWatching()
+		stopReporter()
+		wg.Wait()
 		s.ticker.Stop()
 		s.ticker = nil
 	}()
@@ -306,7 +447,12 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 				level.Error(s.opts.Logger).Log("msg", "failed to fetch remote configuration from the API", "err", err)
 			}
 		case <-s.updateTickerChan:
-			s.ticker.Reset(s.pollFrequency)
+			s.ticker.Reset(s.getPollFrequency())
+		case <-s.updateWatchChan:
+			restartWatching()
+			s.ticker.Reset(s.getPollFrequency())
+		case <-s.updateSourcesChan:
+			s.runSources(ctx, host, &wg, startedSources)
 		case <-ctx.Done():
 			return nil
 		}
@@ -316,6 +462,13 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 // Update implements [service.Service] and applies settings.
 func (s *Service) Update(newConfig any) error {
 	newArgs := newConfig.(Arguments)
+	if err := s.updateSources(newArgs.Sources); err != nil {
+		return err
+	}
+	// The sources don't affect the default source, including the location of
+	// its cache.
+	newArgs.Sources = nil
+
 	s.mut.Lock()
 
 	// We either never set the block on the first place, or recently removed
@@ -323,7 +476,13 @@ func (s *Service) Update(newConfig any) error {
 	if newArgs.URL == "" {
 		s.setPollFrequency(disablePollingFrequency)
 		s.asClient = noopClient{}
+		s.watchClient = nil
+		s.statusClient = nil
+		s.pendingStatus = nil
+		s.probation = nil
+		s.args.URL = ""
 		s.args.HTTPClientConfig = config.CloneDefaultHTTPClientConfig()
+		s.restartWatching()
 		s.mut.Unlock()
 
 		s.setLastLoadedCfgHash("")
@@ -335,11 +494,20 @@ func (s *Service) Update(newConfig any) error {
 		s.mut.Unlock()
 		return err
 	}
-	s.dataPath = filepath.Join(s.opts.StoragePath, ServiceName, hash)
+	s.dataPath = filepath.Join(s.basePath, hash)
+
+	var verificationKey ed25519.PublicKey
+	if newArgs.PublicKey != "" {
+		if verificationKey, err = parseVerificationKey(newArgs.PublicKey); err != nil {
+			s.mut.Unlock()
+			return err
+		}
+	}
+	s.verificationKey = verificationKey
 
 	s.setPollFrequency(newArgs.PollFrequency)
 	// Update the HTTP client last since it might fail.
-	if !reflect.DeepEqual(s.args.HTTPClientConfig, newArgs.HTTPClientConfig) {
+	if s.args.URL != newArgs.URL || !reflect.DeepEqual(s.args.HTTPClientConfig, newArgs.HTTPClientConfig) {
 		client, err := s.clientFactory(newArgs)
 		if err != nil {
 			s.mut.Unlock()
@@ -347,12 +515,41 @@ func (s *Service) Update(newConfig any) error {
 		}
 		s.asClient = client
 	}
+	// The stream is only restarted when the arguments change, so that
+	// reloading the configuration doesn't reconnect it.
+	argsChanged := !reflect.DeepEqual(s.args, newArgs)
+	if argsChanged {
+		s.watchClient = nil
+		if newArgs.Streaming {
+			client, err := s.watchClientFactory(newArgs)
+			if err != nil {
+				s.mut.Unlock()
+				return err
+			}
+			s.watchClient = client
+		}
+		statusClient, err := s.statusClientFactory(newArgs)
+		if err != nil {
+			s.mut.Unlock()
+			return err
+		}
+		s.statusClient = statusClient
+		// Give streaming and status reports another chance with the new
+		// arguments.
+		s.streamUnsupported = false
+		s.statusUnsupported = false
+		// A rejected configuration may be accepted with the new arguments.
+		s.rejectedHash = ""
+	}
 	// Combine the new attributes on top of the system attributes
 	s.attrs = maps.Clone(s.systemAttrs)
 	maps.Copy(s.attrs, newArgs.Attributes)
 
 	// Update the args as the last step to avoid polluting any comparisons
 	s.args = newArgs
+	if argsChanged {
+		s.restartWatching()
+	}
 	err = s.registerCollector()
 	if err != nil {
 		s.mut.Unlock()
@@ -362,11 +559,12 @@ func (s *Service) Update(newConfig any) error {
 	if s.metrics == nil {
 		s.registerMetrics()
 	}
+	ctrl := s.ctrl
 	s.mut.Unlock()
 
 	// If we've already called Run, then immediately trigger an API call with
 	// the updated Arguments, and/or fall back to the updated cache location.
-	if s.ctrl != nil && s.ctrl.Ready() {
+	if ctrl != nil && ctrl.Ready() {
 		s.fetch()
 	}
 
@@ -412,6 +610,12 @@ func (s *Service) fetchRemote() error {
 	level.Debug(s.opts.Logger).Log("msg", "fetching remote configuration")
 
 	b, err := s.getAPIConfig()
+	return s.loadRemote(b, err)
+}
+
+// loadRemote loads the configuration b received from the API, unless getting
+// it failed with err.
+func (s *Service) loadRemote(b []byte, err error) error {
 	s.metrics.totalAttempts.Add(1)
 
 	if err == nil {
@@ -437,15 +641,14 @@ func (s *Service) fetchRemote() error {
 		level.Debug(s.opts.Logger).Log("msg", "skipping over API response since it matched the last loaded one")
 		return nil
 	}
-
-	err = s.parseAndLoad(b)
-	if err != nil {
-		return err
+	if s.isRejected(newConfigHash) {
+		level.Debug(s.opts.Logger).Log("msg", "skipping over API response since it matched the last rejected one")
+		return nil
 	}
 
-	// If successful, flush to disk and keep a copy.
-	s.setCachedConfig(b)
-	return nil
+	// If successful, the configuration is flushed to disk once it's
+	// committed.
+	return s.applyRemote(b)
 }
 
 func (s *Service) fetchLocal() {
@@ -477,15 +680,21 @@ func (s *Service) getAPIConfig() ([]byte, error) {
 		return nil, err
 	}
 	s.metrics.getConfigTime.Observe(time.Since(start).Seconds())
-	if gcr.Msg.NotModified {
+	return s.handleConfigResponse(gcr.Msg)
+}
+
+// handleConfigResponse returns the configuration of a response of the API,
+// or errNotModified if it didn't change since the last response.
+func (s *Service) handleConfigResponse(msg *collectorv1.GetConfigResponse) ([]byte, error) {
+	if msg.NotModified {
 		return nil, errNotModified
 	}
-	if gcr.Msg.Hash != "" {
+	if msg.Hash != "" {
 		s.mut.Lock()
-		s.remoteHash = gcr.Msg.Hash
+		s.remoteHash = msg.Hash
 		s.mut.Unlock()
 	}
-	return []byte(gcr.Msg.GetContent()), nil
+	return []byte(msg.GetContent()), nil
 }
 
 func (s *Service) getCachedConfig() ([]byte, error) {
@@ -507,15 +716,38 @@ func (s *Service) setCachedConfig(b []byte) {
 	}
 }
 
+// parseAndLoad loads the configuration b from the cache. Configurations are
+// only cached once committed, so b isn't put on probation.
 func (s *Service) parseAndLoad(b []byte) error {
-	s.mut.RLock()
-	ctrl := s.ctrl
-	s.mut.RUnlock()
+	s.loadMut.Lock()
+	defer s.loadMut.Unlock()
 
 	if len(b) == 0 {
 		return nil
 	}
-	s.setLastLoadedCfgHash(getHash(b))
+	configHash := getHash(b)
+	err := s.verifyConfig(b)
+	if err == nil {
+		err = s.load(b, configHash)
+	}
+	s.reportStatus(s.newStatusReport(configHash, err))
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	s.committed = &loadedConfig{content: b, hash: configHash}
+	s.mut.Unlock()
+	return nil
+}
+
+// load loads the configuration b with the given hash into the controller.
+func (s *Service) load(b []byte, configHash string) error {
+	s.mut.RLock()
+	ctrl := s.ctrl
+	s.mut.RUnlock()
+
+	s.setLastLoadedCfgHash(configHash)
 	file, err := ctrl.LoadSource(b, nil, s.opts.ConfigPath)
 	if err != nil {
 		return err
@@ -554,8 +786,24 @@ func (s *Service) isEnabled() bool {
 	return s.args.URL != "" && s.asClient != nil
 }
 
+// getPollFrequency returns how often to poll the API. The API isn't polled
+// while the configuration is streamed.
+func (s *Service) getPollFrequency() time.Duration {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	if s.streamingEnabledLocked() {
+		return disablePollingFrequency
+	}
+	return s.pollFrequency
+}
+
 func (s *Service) setPollFrequency(t time.Duration) {
 	s.pollFrequency = t
+	s.resetTicker()
+}
+
+// resetTicker signals Run to reset the ticker to the current poll frequency.
+func (s *Service) resetTicker() {
 	select {
 	// If the channel is full it means there's already an update triggered
 	// or Run is not running. In both cases, we don't need to trigger another
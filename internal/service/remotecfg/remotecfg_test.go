@@ -287,6 +287,10 @@ func newTestEnvironment(t *testing.T, client *collectorClient) *testEnvironment
 	svc.clientFactory = func(_ Arguments) (collectorv1connect.CollectorServiceClient, error) {
 		return client, nil
 	}
+	// Status reports need a server which implements them.
+	svc.statusClientFactory = func(_ Arguments) (statusClient, error) {
+		return nil, nil
+	}
 	require.NoError(t, err)
 
 	return &testEnvironment{
@@ -304,6 +308,9 @@ func (env *testEnvironment) ApplyConfig(config string) error {
 	// considerably; let's artificially lower it after the initial validation
 	// has taken place.
 	args.PollFrequency /= 100
+	for i := range args.Sources {
+		args.Sources[i].Arguments.PollFrequency /= 100
+	}
 	return env.svc.Update(args)
 }
 
@@ -332,7 +339,7 @@ func (fakeHost) GetService(_ string) (service.Service, bool)     { return nil, f
 func (f fakeHost) NewController(id string) service.Controller {
 	logger, _ := logging.New(io.Discard, logging.DefaultOptions)
 	ctrl := alloy_runtime.New(alloy_runtime.Options{
-		ControllerID:    ServiceName,
+		ControllerID:    id,
 		Logger:          logger,
 		Tracer:          nil,
 		DataPath:        "",
@@ -391,4 +398,5 @@ func (sc serviceController) LoadSource(b []byte, args map[string]any, configPath
 	}
 	return source.SourceFiles()[""], sc.f.LoadSource(source, args, configPath)
 }
-func (sc serviceController) Ready() bool { return sc.f.Ready() }
+func (sc serviceController) Ready() bool           { return sc.f.Ready() }
+func (sc serviceController) GetHost() service.Host { return sc.f }
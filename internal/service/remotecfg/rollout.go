@@ -0,0 +1,226 @@
+package remotecfg
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// signaturePrefix starts the last line of a signed configuration. The rest of
+// the line is the base64 encoded ed25519 signature of the bytes preceding it.
+// As the line is a comment, signed configurations load without verification.
+const signaturePrefix = "// alloy-signature: "
+
+// probationCheckInterval is how often the health of the components is checked
+// while a configuration is on probation.
+var probationCheckInterval = time.Second
+
+// loadedConfig is a configuration loaded from the API or the cache.
+type loadedConfig struct {
+	content []byte
+	hash    string
+}
+
+// probation tracks a configuration on probation. The previous configuration
+// is restored if it fails before the deadline.
+type probation struct {
+	loadedConfig
+	previous *loadedConfig
+	deadline time.Time
+}
+
+// parseVerificationKey parses a PEM encoded ed25519 public key.
+func parseVerificationKey(pemKey string) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("no PEM block found in the public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T, only ed25519 keys are supported", key)
+	}
+	return edKey, nil
+}
+
+// verifyConfig checks that b ends with a signature line made with the
+// verification key. It does nothing when no key is set.
+func (s *Service) verifyConfig(b []byte) error {
+	s.mut.RLock()
+	key := s.verificationKey
+	s.mut.RUnlock()
+	if key == nil {
+		return nil
+	}
+
+	trimmed := bytes.TrimRight(b, " \t\r\n")
+	start := bytes.LastIndexByte(trimmed, '\n') + 1
+	line := string(trimmed[start:])
+	if !strings.HasPrefix(line, signaturePrefix) {
+		return errors.New("the configuration isn't signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, signaturePrefix))
+	if err != nil {
+		return fmt.Errorf("decoding the signature of the configuration: %w", err)
+	}
+	if !ed25519.Verify(key, trimmed[:start], sig) {
+		return errors.New("invalid signature of the configuration")
+	}
+	return nil
+}
+
+// isRejected returns whether the configuration with the given hash was
+// rejected, in which case it isn't loaded again until the API sends another
+// one.
+func (s *Service) isRejected(configHash string) bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.rejectedHash != "" && s.rejectedHash == configHash
+}
+
+// applyRemote loads the configuration b received from the API. When a
+// probation period is set and a previous configuration was loaded, b is put
+// on probation and the previous configuration is restored if it fails to
+// load. Otherwise, b is committed right away.
+func (s *Service) applyRemote(b []byte) error {
+	s.loadMut.Lock()
+	defer s.loadMut.Unlock()
+
+	if len(b) == 0 {
+		return nil
+	}
+	configHash := getHash(b)
+	s.mut.RLock()
+	period, previous := s.args.ProbationPeriod, s.committed
+	s.mut.RUnlock()
+	onProbation := period > 0 && previous != nil
+
+	if err := s.verifyConfig(b); err != nil {
+		s.reject(configHash, err)
+		return err
+	}
+
+	err := s.load(b, configHash)
+	switch {
+	case err != nil && onProbation:
+		s.revert(configHash, previous, fmt.Errorf("failed to load configuration: %w", err))
+		return err
+	case err != nil:
+		s.reportStatus(s.newStatusReport(configHash, err))
+		return err
+	case onProbation:
+		level.Info(s.opts.Logger).Log("msg", "remote configuration is on probation", "hash", configHash, "until", time.Now().Add(period))
+		s.mut.Lock()
+		s.probation = &probation{
+			loadedConfig: loadedConfig{content: b, hash: configHash},
+			previous:     previous,
+			deadline:     time.Now().Add(period),
+		}
+		s.mut.Unlock()
+		report := s.newStatusReport(configHash, nil)
+		report.Status = statusProbation
+		s.reportStatus(report)
+		return nil
+	default:
+		s.reportStatus(s.newStatusReport(configHash, nil))
+		s.commit(loadedConfig{content: b, hash: configHash})
+		return nil
+	}
+}
+
+// reject reports that the configuration with the given hash wasn't loaded
+// because of err.
+func (s *Service) reject(configHash string, err error) {
+	level.Error(s.opts.Logger).Log("msg", "rejected remote configuration", "hash", configHash, "err", err)
+	s.mut.Lock()
+	s.rejectedHash = configHash
+	s.mut.Unlock()
+	s.reportStatus(s.newStatusReport(configHash, err))
+}
+
+// revert restores the previous configuration in place of the configuration
+// with the given hash, which failed because of reason. loadMut must be held.
+func (s *Service) revert(configHash string, previous *loadedConfig, reason error) {
+	level.Warn(s.opts.Logger).Log("msg", "reverting to the previous remote configuration", "hash", configHash, "previous_hash", previous.hash, "reason", reason)
+	s.metrics.reverts.Inc()
+	s.mut.Lock()
+	s.rejectedHash = configHash
+	s.probation = nil
+	s.mut.Unlock()
+
+	report := s.newStatusReport(configHash, reason)
+	report.Status = statusReverted
+	report.RevertedTo = previous.hash
+	if err := s.load(previous.content, previous.hash); err != nil {
+		level.Error(s.opts.Logger).Log("msg", "failed to restore the previous remote configuration", "hash", previous.hash, "err", err)
+		report.Error = errors.Join(reason, fmt.Errorf("failed to restore the previous configuration: %w", err)).Error()
+	}
+	s.reportStatus(report)
+}
+
+// commit records cfg as the last configuration known to work, which is
+// restored if a later configuration fails its probation, and caches it.
+func (s *Service) commit(cfg loadedConfig) {
+	s.mut.Lock()
+	s.committed = &cfg
+	s.probation = nil
+	s.mut.Unlock()
+	s.setCachedConfig(cfg.content)
+}
+
+// runProbation checks the configuration on probation until ctx is canceled.
+func (s *Service) runProbation(ctx context.Context) {
+	ticker := time.NewTicker(probationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.checkProbation(now)
+		}
+	}
+}
+
+// checkProbation reverts the configuration on probation if any of its
+// components is unhealthy or exited, and commits it once its probation period
+// is over.
+func (s *Service) checkProbation(now time.Time) {
+	s.loadMut.Lock()
+	defer s.loadMut.Unlock()
+
+	s.mut.RLock()
+	p := s.probation
+	s.mut.RUnlock()
+	if p == nil {
+		return
+	}
+
+	if status := s.componentsStatus(); status != nil && len(status.Failing) > 0 {
+		ids := make([]string, 0, len(status.Failing))
+		for _, c := range status.Failing {
+			ids = append(ids, c.ID)
+		}
+		s.revert(p.hash, p.previous, fmt.Errorf("components failed during probation: %s", strings.Join(ids, ", ")))
+		return
+	}
+	if now.Before(p.deadline) {
+		return
+	}
+
+	level.Info(s.opts.Logger).Log("msg", "remote configuration passed its probation", "hash", p.hash)
+	s.commit(p.loadedConfig)
+	s.reportStatus(s.newStatusReport(p.hash, nil))
+}
@@ -0,0 +1,244 @@
+package remotecfg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/grafana/alloy/internal/component/local/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbation(t *testing.T) {
+	lowerProbationCheckInterval(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	srv := newConfigServer(t, cfg1, false)
+	env := newStreamingTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url              = "%s"
+		poll_frequency   = "10s"
+		probation_period = "500ms"
+	`, srv.URL)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	// The first configuration has nothing to revert to, so it's committed
+	// right away.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+		assert.Equal(c, cfg1, cachedConfig(env))
+	}, time.Second, 10*time.Millisecond)
+
+	srv.setConfig(cfg2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getLastLoadedCfgHash())
+		if report := srv.lastReport(); assert.NotNil(c, report) {
+			assert.Equal(c, statusProbation, report.Status)
+		}
+	}, time.Second, 10*time.Millisecond)
+	// The configuration isn't cached until it passes its probation.
+	require.Equal(t, cfg1, cachedConfig(env))
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		if report := srv.lastReport(); assert.NotNil(c, report) {
+			assert.Equal(c, statusApplied, report.Status)
+			assert.Equal(c, getHash([]byte(cfg2)), report.ConfigHash)
+		}
+		assert.Equal(c, cfg2, cachedConfig(env))
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestProbationRevert(t *testing.T) {
+	lowerProbationCheckInterval(t)
+
+	filename := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(filename, []byte("hello"), 0644))
+
+	tt := []struct {
+		name          string
+		config        string
+		afterLoad     func(t *testing.T)
+		expectMessage string
+	}{
+		{
+			name:          "configuration fails to load",
+			config:        `loki.process "updated" { forward_to = [loki.process.missing.receiver] }`,
+			expectMessage: "failed to load configuration",
+		},
+		{
+			name: "component becomes unhealthy",
+			config: fmt.Sprintf(`
+				local.file "updated" {
+					filename       = %q
+					detector       = "poll"
+					poll_frequency = "10ms"
+				}
+			`, filename),
+			afterLoad: func(t *testing.T) {
+				require.NoError(t, os.Remove(filename))
+			},
+			expectMessage: "components failed during probation: remotecfg/local.file.updated",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			cfg1 := `loki.process "default" { forward_to = [] }`
+
+			srv := newConfigServer(t, cfg1, false)
+			env := newStreamingTestEnvironment(t)
+			require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+				url              = "%s"
+				poll_frequency   = "10s"
+				probation_period = "1h"
+			`, srv.URL)))
+
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, env.Run(ctx))
+			}()
+
+			require.EventuallyWithT(t, func(c *assert.CollectT) {
+				assert.Equal(c, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+			}, time.Second, 10*time.Millisecond)
+
+			srv.setConfig(tc.config)
+			if tc.afterLoad != nil {
+				// Wait for the component to be running before breaking it, so
+				// that the configuration fails during its probation rather
+				// than while it's loaded.
+				require.EventuallyWithT(t, func(c *assert.CollectT) {
+					assert.Equal(c, getHash([]byte(tc.config)), env.svc.getLastLoadedCfgHash())
+					if status := env.svc.componentsStatus(); assert.NotNil(c, status) {
+						assert.Equal(c, 1, status.Healthy)
+					}
+				}, time.Second, 10*time.Millisecond)
+				tc.afterLoad(t)
+			}
+
+			require.EventuallyWithT(t, func(c *assert.CollectT) {
+				assert.Equal(c, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+				if report := srv.lastReport(); assert.NotNil(c, report) {
+					assert.Equal(c, statusReverted, report.Status)
+					assert.Equal(c, getHash([]byte(tc.config)), report.ConfigHash)
+					assert.Equal(c, getHash([]byte(cfg1)), report.RevertedTo)
+					assert.Contains(c, report.Error, tc.expectMessage)
+				}
+			}, 2*time.Second, 10*time.Millisecond)
+
+			// The reverted configuration isn't loaded again.
+			time.Sleep(300 * time.Millisecond)
+			require.Equal(t, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+			require.Equal(t, cfg1, cachedConfig(env))
+
+			cancel()
+			wg.Wait()
+		})
+	}
+}
+
+func TestSignedConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	sign := func(config string) string {
+		payload := config + "\n"
+		return payload + signaturePrefix + base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(payload))) + "\n"
+	}
+	cfg1 := sign(`loki.process "default" { forward_to = [] }`)
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	srv := newConfigServer(t, cfg1, false)
+	env := newStreamingTestEnvironment(t)
+	require.ErrorContains(t, env.ApplyConfig(fmt.Sprintf(`
+		url        = "%s"
+		public_key = "not a key"
+	`, srv.URL)), "invalid public_key")
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+		public_key     = %q
+	`, srv.URL, publicKeyPEM)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+		if report := srv.lastReport(); assert.NotNil(c, report) {
+			assert.Equal(c, statusApplied, report.Status)
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	for _, rejected := range []struct {
+		config        string
+		expectMessage string
+	}{
+		{config: cfg2, expectMessage: "the configuration isn't signed"},
+		{config: strings.Replace(sign(cfg2), "updated", "tampered", 1), expectMessage: "invalid signature of the configuration"},
+	} {
+		srv.setConfig(rejected.config)
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			if report := srv.lastReport(); assert.NotNil(c, report) {
+				assert.Equal(c, statusFailed, report.Status)
+				assert.Equal(c, getHash([]byte(rejected.config)), report.ConfigHash)
+				assert.Equal(c, rejected.expectMessage, report.Error)
+			}
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+	}
+
+	srv.setConfig(sign(cfg2))
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(sign(cfg2))), env.svc.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func lowerProbationCheckInterval(t *testing.T) {
+	defaultInterval := probationCheckInterval
+	probationCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { probationCheckInterval = defaultInterval })
+}
+
+func cachedConfig(env *testEnvironment) string {
+	b, _ := env.svc.getCachedConfig()
+	return string(b)
+}
@@ -0,0 +1,225 @@
+package remotecfg
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/syntax/scanner"
+)
+
+// reservedSourceNames can't be used as source names, as they conflict with
+// the pages of the UI.
+var reservedSourceNames = []string{"component"}
+
+// SourceArguments holds the settings of a named source of remote
+// configuration. Each source is loaded into its own controller, isolated from
+// the default source and the other sources.
+type SourceArguments struct {
+	Label     string    `alloy:",label"`
+	Arguments Arguments `alloy:",squash"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (a *SourceArguments) SetToDefault() {
+	a.Arguments = GetDefaultArguments()
+}
+
+// Validate implements syntax.Validator.
+func (a *SourceArguments) Validate() error {
+	// The name is used in component IDs and in the path of the cache
+	// directory, so it must be an identifier like component labels.
+	if !scanner.IsValidIdentifier(a.Label) {
+		return fmt.Errorf("source name %q is not a valid identifier", a.Label)
+	}
+	if slices.Contains(reservedSourceNames, a.Label) {
+		return fmt.Errorf("source name %q is reserved", a.Label)
+	}
+	if a.Arguments.URL == "" {
+		return fmt.Errorf("source %q must set url", a.Label)
+	}
+	if len(a.Arguments.Sources) > 0 {
+		return fmt.Errorf("source %q can't contain source blocks", a.Label)
+	}
+	return a.Arguments.Validate()
+}
+
+// SourceName returns the name of the source which loaded the component or
+// module with the given ID, or an empty string if it isn't a named source.
+func SourceName(id string) string {
+	rest, ok := strings.CutPrefix(id, ServiceName+"/")
+	if !ok {
+		return ""
+	}
+	// Source names are identifiers, while the IDs of the components of the
+	// default source contain dots.
+	name, _, _ := strings.Cut(rest, "/")
+	if strings.Contains(name, ".") {
+		return ""
+	}
+	return name
+}
+
+// HostFor returns the Host of the controller of the named source, or of the
+// default source if name is empty. It returns nil if the source doesn't exist
+// or isn't started yet.
+func (d Data) HostFor(name string) service.Host {
+	if name == "" {
+		return d.Host
+	}
+	return d.Sources[name]
+}
+
+// controllerID returns the ID of the controller the configuration is loaded
+// into.
+func (s *Service) controllerID() string {
+	if s.name == "" {
+		return ServiceName
+	}
+	return ServiceName + "/" + s.name
+}
+
+// updateSources applies the arguments of the named sources. Sources are
+// created the first time they're configured, and disabled when they're
+// removed; their components are stopped, but their controller is kept in case
+// they're configured again.
+func (s *Service) updateSources(sources []SourceArguments) error {
+	// The sources are updated without holding the lock, as updating them
+	// fetches their configuration.
+	s.mut.Lock()
+	children := make([]*Service, 0, len(sources))
+	for _, src := range sources {
+		child, ok := s.sources[src.Label]
+		if !ok {
+			var err error
+			child, err = newService(Options{
+				Logger:      log.With(s.opts.Logger, "source", src.Label),
+				StoragePath: s.opts.StoragePath,
+				ConfigPath:  s.opts.ConfigPath,
+				Metrics:     s.opts.Metrics,
+			}, src.Label)
+			if err != nil {
+				s.mut.Unlock()
+				return fmt.Errorf("creating source %q: %w", src.Label, err)
+			}
+			if s.sources == nil {
+				s.sources = make(map[string]*Service)
+			}
+			s.sources[src.Label] = child
+			s.startSources()
+		}
+		children = append(children, child)
+	}
+	var removed []*Service
+	for name, child := range s.sources {
+		if !slices.ContainsFunc(sources, func(src SourceArguments) bool { return src.Label == name }) {
+			removed = append(removed, child)
+		}
+	}
+	s.mut.Unlock()
+
+	for i, child := range children {
+		if err := child.Update(sources[i].Arguments); err != nil {
+			return fmt.Errorf("updating source %q: %w", child.name, err)
+		}
+	}
+	for _, child := range removed {
+		if !child.isEnabled() {
+			continue
+		}
+		level.Info(s.opts.Logger).Log("msg", "disabling removed remote configuration source", "source", child.name)
+		if err := child.Update(Arguments{}); err != nil {
+			return fmt.Errorf("disabling source %q: %w", child.name, err)
+		}
+		child.unload()
+	}
+	return nil
+}
+
+// startSources signals Run to start the sources which were created since it
+// last started them.
+func (s *Service) startSources() {
+	select {
+	// If the channel is full it means there's already a start triggered or
+	// Run is not running. In both cases, we don't need to trigger another
+	// start or block.
+	case s.updateSourcesChan <- struct{}{}:
+	default:
+	}
+}
+
+// runSources runs the sources which aren't running yet until ctx is canceled.
+// started tracks the sources which are already running.
+func (s *Service) runSources(ctx context.Context, host service.Host, wg *sync.WaitGroup, started map[string]struct{}) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	for name, child := range s.sources {
+		if _, ok := started[name]; ok {
+			continue
+		}
+		started[name] = struct{}{}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := child.Run(ctx, host); err != nil {
+				level.Error(s.opts.Logger).Log("msg", "remote configuration source exited with an error", "source", name, "err", err)
+			}
+		}()
+	}
+}
+
+// unload stops the components of a disabled source.
+func (s *Service) unload() {
+	s.loadMut.Lock()
+	defer s.loadMut.Unlock()
+
+	s.mut.Lock()
+	ctrl := s.ctrl
+	s.committed = nil
+	s.probation = nil
+	// The configuration must be sent again if the source is enabled again.
+	s.remoteHash = ""
+	s.mut.Unlock()
+
+	if ctrl != nil {
+		if _, err := ctrl.LoadSource([]byte{}, nil, s.opts.ConfigPath); err != nil {
+			level.Error(s.opts.Logger).Log("msg", "failed to unload the configuration of the source", "err", err)
+		}
+	}
+	s.setAstFile(nil)
+}
+
+// sourceHosts returns the Hosts of the controllers of the enabled sources.
+func (s *Service) sourceHosts() map[string]service.Host {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	hosts := make(map[string]service.Host, len(s.sources))
+	for name, child := range s.sources {
+		if !child.isEnabled() {
+			continue
+		}
+		if host := child.host(); host != nil {
+			hosts[name] = host
+		}
+	}
+	return hosts
+}
+
+// sourceBasePath returns the directory where the source with the given name
+// caches its configuration. The default source uses the directory of the
+// service.
+func sourceBasePath(storagePath, name string) string {
+	if name == "" {
+		return filepath.Join(storagePath, ServiceName)
+	}
+	return filepath.Join(storagePath, ServiceName, "sources", name)
+}
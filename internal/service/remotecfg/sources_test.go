@@ -0,0 +1,215 @@
+package remotecfg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSources(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfgDefault := `loki.process "default" { forward_to = [] }`
+	cfgTenant1 := `loki.process "tenant" { forward_to = [] }`
+	cfgTenant2 := `loki.process "tenant_updated" { forward_to = [] }`
+
+	defaultSrv := newConfigServer(t, cfgDefault, false)
+	tenantSrv := newConfigServer(t, cfgTenant1, false)
+
+	reg := prometheus.NewRegistry()
+	storagePath := t.TempDir()
+	svc, err := New(Options{
+		Logger:      util.TestLogger(t),
+		StoragePath: storagePath,
+		Metrics:     reg,
+	})
+	require.NoError(t, err)
+	env := &testEnvironment{t: t, svc: svc}
+
+	withSource := fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+
+		source "tenant" {
+			url            = "%s"
+			poll_frequency = "10s"
+		}
+	`, defaultSrv.URL, tenantSrv.URL)
+	require.NoError(t, env.ApplyConfig(withSource))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	// Each source is loaded into its own controller.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"loki.process.default"}, componentIDs(c, svc.Data().(Data).HostFor("")))
+		assert.Equal(c, []string{"loki.process.tenant"}, componentIDs(c, svc.Data().(Data).HostFor("tenant")))
+	}, time.Second, 10*time.Millisecond)
+
+	// Each source has its own cache and metrics.
+	tenant := svc.sources["tenant"]
+	require.Equal(t, filepath.Join(storagePath, ServiceName, "sources", "tenant"), filepath.Dir(tenant.dataPath))
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		b, err := os.ReadFile(tenant.dataPath)
+		assert.NoError(c, err)
+		assert.Equal(c, cfgTenant1, string(b))
+	}, time.Second, 10*time.Millisecond)
+	expect := fmt.Sprintf(`
+		# HELP remotecfg_hash Hash of the currently active remote configuration.
+		# TYPE remotecfg_hash gauge
+		remotecfg_hash{hash=%q,source=""} 1
+		remotecfg_hash{hash=%q,source="tenant"} 1
+	`, getHash([]byte(cfgDefault)), getHash([]byte(cfgTenant1)))
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect), "remotecfg_hash"))
+
+	// Sources are polled independently.
+	tenantSrv.setConfig(cfgTenant2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"loki.process.tenant_updated"}, componentIDs(c, svc.Data().(Data).HostFor("tenant")))
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, getHash([]byte(cfgDefault)), svc.getLastLoadedCfgHash())
+
+	// Removing a source stops its components.
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+	`, defaultSrv.URL)))
+	require.NotContains(t, svc.Data().(Data).Sources, "tenant")
+	require.Empty(t, componentIDs(t, tenant.host()))
+	require.Equal(t, []string{"loki.process.default"}, componentIDs(t, svc.Data().(Data).HostFor("")))
+
+	// Adding it back loads its configuration again.
+	require.NoError(t, env.ApplyConfig(withSource))
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"loki.process.tenant_updated"}, componentIDs(c, svc.Data().(Data).HostFor("tenant")))
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestSourcesValidation(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "valid",
+			config: `
+				source "platform" { url = "https://platform.example.com" }
+				source "tenant_1" { url = "https://tenant.example.com" }
+			`,
+		},
+		{
+			name: "duplicate",
+			config: `
+				source "tenant" { url = "https://tenant.example.com" }
+				source "tenant" { url = "https://other.example.com" }
+			`,
+			expectError: `source "tenant" is defined more than once`,
+		},
+		{
+			name:        "invalid name",
+			config:      `source "tenant.a" { url = "https://tenant.example.com" }`,
+			expectError: `expected block label to be a valid identifier, but got "tenant.a"`,
+		},
+		{
+			name:        "reserved name",
+			config:      `source "component" { url = "https://tenant.example.com" }`,
+			expectError: `source name "component" is reserved`,
+		},
+		{
+			name:        "missing url",
+			config:      `source "tenant" {}`,
+			expectError: `source "tenant" must set url`,
+		},
+		{
+			name: "nested",
+			config: `
+				source "tenant" {
+					url = "https://tenant.example.com"
+					source "nested" { url = "https://nested.example.com" }
+				}
+			`,
+			expectError: `source "tenant" can't contain source blocks`,
+		},
+		{
+			name: "invalid source arguments",
+			config: `
+				source "tenant" {
+					url            = "https://tenant.example.com"
+					poll_frequency = "1s"
+				}
+			`,
+			expectError: `poll_frequency must be at least "10s"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte(tc.config), &args)
+			if tc.expectError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectError)
+		})
+	}
+}
+
+func TestSourceArgumentsValidateName(t *testing.T) {
+	for _, name := range []string{"../x", "a.b", ""} {
+		args := SourceArguments{Label: name, Arguments: GetDefaultArguments()}
+		args.Arguments.URL = "https://tenant.example.com"
+		require.EqualError(t, args.Validate(), fmt.Sprintf("source name %q is not a valid identifier", name))
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	tt := map[string]string{
+		"loki.process.default":                          "",
+		"remotecfg":                                     "",
+		"remotecfg/loki.process.default":                "",
+		"remotecfg/import.file.mod/loki.process.inner":  "",
+		"remotecfg/tenant":                              "tenant",
+		"remotecfg/tenant/loki.process.default":         "tenant",
+		"remotecfg/tenant/import.file.mod/loki.process": "tenant",
+	}
+	for id, expect := range tt {
+		require.Equal(t, expect, SourceName(id), id)
+	}
+}
+
+func componentIDs(t require.TestingT, host interface {
+	ListComponents(string, component.InfoOptions) ([]*component.Info, error)
+}) []string {
+	if host == nil {
+		return nil
+	}
+	infos, err := host.ListComponents("", component.InfoOptions{})
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(infos))
+	for _, info := range infos {
+		ids = append(ids, info.ID.LocalID)
+	}
+	return ids
+}
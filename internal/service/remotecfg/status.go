@@ -0,0 +1,261 @@
+package remotecfg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/dskit/backoff"
+	commonconfig "github.com/prometheus/common/config"
+)
+
+// reportStatusProcedure is the procedure of the API which receives the status
+// of the configuration loaded by a collector. Its request is a statusReport
+// and its response an empty object, both encoded as JSON, as documented in the
+// reference of the remotecfg block. Reports aren't sent to APIs which don't
+// implement it.
+const reportStatusProcedure = "/" + alloyService + "/ReportStatus"
+
+// Status of a loaded configuration.
+const (
+	statusApplied   = "applied"
+	statusFailed    = "failed"
+	statusProbation = "probation" // Loaded, but reverted if it fails before its probation period is over.
+	statusReverted  = "reverted"  // Failed and replaced by the previous configuration.
+)
+
+// statusBackoff is the backoff between attempts to send a status report which
+// wasn't acknowledged.
+var statusBackoff = backoff.Config{
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+	MaxRetries: 0, // Retry until acknowledged.
+}
+
+// statusReport is the request of the ReportStatus procedure.
+type statusReport struct {
+	ID          string             `json:"id"`
+	Hash        string             `json:"hash"`        // Hash received from the API with the configuration.
+	ConfigHash  string             `json:"config_hash"` // Hash of the configuration, as in the remotecfg_hash metric.
+	Status      string             `json:"status"`
+	RevertedTo  string             `json:"reverted_to,omitempty"` // Hash of the configuration restored in place of a reverted one.
+	Error       string             `json:"error,omitempty"`
+	Diagnostics []statusDiagnostic `json:"diagnostics,omitempty"`
+	Components  *componentsStatus  `json:"components,omitempty"`
+	Version     string             `json:"version"`
+	Timestamp   time.Time          `json:"timestamp"`
+}
+
+// statusDiagnostic is a diagnostic reported while loading the configuration.
+type statusDiagnostic struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Position string `json:"position,omitempty"`
+}
+
+// componentsStatus summarizes the health of the components loaded from the
+// configuration.
+type componentsStatus struct {
+	Healthy   int               `json:"healthy"`
+	Unhealthy int               `json:"unhealthy"`
+	Unknown   int               `json:"unknown"`
+	Exited    int               `json:"exited"`
+	Failing   []componentStatus `json:"failing,omitempty"` // Components which are unhealthy or exited.
+}
+
+type componentStatus struct {
+	ID      string `json:"id"`
+	Health  string `json:"health"`
+	Message string `json:"message,omitempty"`
+}
+
+// statusAck is the response of the ReportStatus procedure.
+type statusAck struct{}
+
+// statusClient sends status reports to the API.
+type statusClient interface {
+	CallUnary(ctx context.Context, req *connect.Request[statusReport]) (*connect.Response[statusAck], error)
+}
+
+func newStatusClient(args Arguments) (statusClient, error) {
+	httpClient, err := commonconfig.NewClientFromConfig(*args.HTTPClientConfig.Convert(), "remoteconfig")
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewClient[statusReport, statusAck](
+		httpClient,
+		args.URL+reportStatusProcedure,
+		connect.WithCodec(jsonCodec{}),
+	), nil
+}
+
+// jsonCodec encodes the messages of procedures which aren't defined with
+// Protobuf as JSON.
+type jsonCodec struct{}
+
+var _ connect.Codec = jsonCodec{}
+
+func (jsonCodec) Name() string                      { return "json" }
+func (jsonCodec) Marshal(msg any) ([]byte, error)   { return json.Marshal(msg) }
+func (jsonCodec) Unmarshal(b []byte, msg any) error { return json.Unmarshal(b, msg) }
+
+// newStatusReport returns the status of the configuration with the given hash,
+// which failed to load with err if err isn't nil.
+func (s *Service) newStatusReport(configHash string, err error) *statusReport {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	report := &statusReport{
+		ID:         s.args.ID,
+		Hash:       s.remoteHash,
+		ConfigHash: configHash,
+		Status:     statusApplied,
+		Version:    build.Version,
+		Timestamp:  time.Now(),
+	}
+	if err != nil {
+		report.Status = statusFailed
+		report.Error = err.Error()
+		report.Diagnostics = statusDiagnostics(err)
+	}
+	return report
+}
+
+func statusDiagnostics(err error) []statusDiagnostic {
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		var d diag.Diagnostic
+		if !errors.As(err, &d) {
+			return nil
+		}
+		diags = diag.Diagnostics{d}
+	}
+
+	res := make([]statusDiagnostic, 0, len(diags))
+	for _, d := range diags {
+		sd := statusDiagnostic{
+			Severity: "error",
+			Message:  d.Message,
+		}
+		if d.Severity == diag.SeverityLevelWarn {
+			sd.Severity = "warning"
+		}
+		if d.StartPos.Filename != "" || d.StartPos.Line != 0 {
+			sd.Position = d.StartPos.String()
+		}
+		res = append(res, sd)
+	}
+	return res
+}
+
+// componentsStatus returns the health of the components of the configuration
+// loaded by the service, or nil if it isn't available.
+func (s *Service) componentsStatus() *componentsStatus {
+	s.mut.RLock()
+	ctrl := s.ctrl
+	s.mut.RUnlock()
+
+	hc, ok := ctrl.(interface{ GetHost() service.Host })
+	if !ok {
+		return nil
+	}
+
+	var status componentsStatus
+	for _, info := range component.GetAllComponents(hc.GetHost(), component.InfoOptions{GetHealth: true}) {
+		switch info.Health.Health {
+		case component.HealthTypeHealthy:
+			status.Healthy++
+			continue
+		case component.HealthTypeUnhealthy:
+			status.Unhealthy++
+		case component.HealthTypeExited:
+			status.Exited++
+		default:
+			// Components which didn't start yet have an unknown health.
+			status.Unknown++
+			continue
+		}
+		status.Failing = append(status.Failing, componentStatus{
+			ID:      info.ID.String(),
+			Health:  info.Health.Health.String(),
+			Message: info.Health.Message,
+		})
+	}
+	return &status
+}
+
+// reportStatus queues report to be sent to the API. It replaces the report
+// which is queued, if it wasn't acknowledged yet.
+func (s *Service) reportStatus(report *statusReport) {
+	s.mut.Lock()
+	if s.statusClient == nil || s.statusUnsupported {
+		s.mut.Unlock()
+		return
+	}
+	s.pendingStatus = report
+	s.mut.Unlock()
+
+	select {
+	// If the channel is full it means there's already a report queued, which
+	// the reporter picks up along with this one.
+	case s.statusChan <- struct{}{}:
+	default:
+	}
+}
+
+// runStatusReporter sends the queued status reports to the API until ctx is
+// canceled. Reports which aren't acknowledged are retried with a backoff.
+func (s *Service) runStatusReporter(ctx context.Context) {
+	bo := backoff.New(ctx, statusBackoff)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.statusChan:
+		}
+
+		for ctx.Err() == nil {
+			s.mut.RLock()
+			report, client := s.pendingStatus, s.statusClient
+			s.mut.RUnlock()
+			if report == nil || client == nil {
+				break
+			}
+
+			// The health of the components is taken when the report is sent, so
+			// that retries report the current health.
+			report.Components = s.componentsStatus()
+			_, err := client.CallUnary(ctx, connect.NewRequest(report))
+
+			switch {
+			case err == nil:
+				level.Debug(s.opts.Logger).Log("msg", "reported configuration status to the API", "status", report.Status, "hash", report.ConfigHash)
+				s.mut.Lock()
+				if s.pendingStatus == report {
+					s.pendingStatus = nil
+				}
+				s.mut.Unlock()
+				bo.Reset()
+
+			case connect.CodeOf(err) == connect.CodeUnimplemented:
+				level.Info(s.opts.Logger).Log("msg", "the API doesn't support configuration status reports", "err", err)
+				s.mut.Lock()
+				s.statusUnsupported = true
+				s.pendingStatus = nil
+				s.mut.Unlock()
+
+			default:
+				level.Warn(s.opts.Logger).Log("msg", "failed to report configuration status to the API, retrying", "err", err)
+				s.metrics.statusReportFailures.Inc()
+				bo.Wait()
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package remotecfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/grafana/alloy/internal/build"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusReport(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfgGood := `loki.process "default" { forward_to = [] }`
+	cfgBad := `
+		loki.process "default" { forward_to = [] }
+		loki.process "default" { forward_to = [] }
+	`
+
+	srv := newConfigServer(t, cfgGood, false)
+	env := newStreamingTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		id             = "collector-1"
+		poll_frequency = "10s"
+	`, srv.URL)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		report := srv.lastReport()
+		if !assert.NotNil(c, report) {
+			return
+		}
+		assert.Equal(c, "collector-1", report.ID)
+		assert.Equal(c, statusApplied, report.Status)
+		assert.Equal(c, getHash([]byte(cfgGood)), report.ConfigHash)
+		assert.Equal(c, getHash([]byte(cfgGood)), report.Hash)
+		assert.Equal(c, build.Version, report.Version)
+		assert.Empty(c, report.Error)
+		if assert.NotNil(c, report.Components) {
+			assert.Equal(c, 1, report.Components.Healthy+report.Components.Unknown)
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	srv.setConfig(cfgBad)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		report := srv.lastReport()
+		assert.Equal(c, statusFailed, report.Status)
+		assert.Equal(c, getHash([]byte(cfgBad)), report.ConfigHash)
+		assert.NotEmpty(c, report.Error)
+		if assert.NotEmpty(c, report.Diagnostics) {
+			assert.Equal(c, "error", report.Diagnostics[0].Severity)
+			assert.Contains(c, report.Diagnostics[0].Message, "loki.process.default")
+			assert.NotEmpty(c, report.Diagnostics[0].Position)
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestStatusReportRetry(t *testing.T) {
+	defaultBackoff := statusBackoff
+	statusBackoff.MinBackoff = 10 * time.Millisecond
+	statusBackoff.MaxBackoff = 20 * time.Millisecond
+	t.Cleanup(func() { statusBackoff = defaultBackoff })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg := `loki.process "default" { forward_to = [] }`
+
+	srv := newConfigServer(t, cfg, false)
+	srv.statusErr = func(call int32) error {
+		if call <= 2 {
+			return connect.NewError(connect.CodeUnavailable, errors.New("try again later"))
+		}
+		return nil
+	}
+	env := newStreamingTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+	`, srv.URL)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		report := srv.lastReport()
+		if assert.NotNil(c, report) {
+			assert.Equal(c, statusApplied, report.Status)
+		}
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(3), srv.statusCalls.Load())
+
+	cancel()
+	wg.Wait()
+}
+
+func TestStatusReportUnimplemented(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	srv := newConfigServer(t, cfg1, false)
+	srv.statusErr = func(int32) error {
+		return connect.NewError(connect.CodeUnimplemented, errors.New("not implemented"))
+	}
+	env := newStreamingTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+	`, srv.URL)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.Eventually(t, func() bool { return srv.statusCalls.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	// Reports aren't sent anymore once the API turned out not to implement
+	// them.
+	srv.setConfig(cfg2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(1), srv.statusCalls.Load())
+
+	cancel()
+	wg.Wait()
+}
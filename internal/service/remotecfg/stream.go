@@ -0,0 +1,158 @@
+package remotecfg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/dskit/backoff"
+	commonconfig "github.com/prometheus/common/config"
+)
+
+// alloyService is the service holding the procedures of the API which are
+// defined by Alloy rather than by the CollectorService of alloy-remote-config.
+// APIs opt in to each of them by implementing it.
+const alloyService = "alloy.remotecfg.v1.RemoteConfigService"
+
+// watchConfigProcedure is the server-streaming procedure of the API which
+// pushes the configuration of a collector whenever it changes. It takes the
+// collector.v1.GetConfigRequest message of GetConfig and streams
+// collector.v1.GetConfigResponse messages. APIs which don't implement it are
+// polled instead.
+const watchConfigProcedure = "/" + alloyService + "/WatchConfig"
+
+// watchBackoff is the backoff between attempts to reconnect the stream. The
+// delays are randomized, so that a fleet of collectors doesn't reconnect at
+// the same time after the API restarts.
+var watchBackoff = backoff.Config{
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+	MaxRetries: 0, // Retry forever.
+}
+
+var errStreamClosed = errors.New("the API closed the configuration stream")
+
+// watchClient streams the configuration from the API.
+type watchClient interface {
+	CallServerStream(ctx context.Context, req *connect.Request[collectorv1.GetConfigRequest]) (*connect.ServerStreamForClient[collectorv1.GetConfigResponse], error)
+}
+
+func newWatchClient(args Arguments) (watchClient, error) {
+	httpClient, err := commonconfig.NewClientFromConfig(*args.HTTPClientConfig.Convert(), "remoteconfig")
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewClient[collectorv1.GetConfigRequest, collectorv1.GetConfigResponse](
+		httpClient,
+		args.URL+watchConfigProcedure,
+	), nil
+}
+
+// streamingEnabled returns whether the configuration should be streamed from
+// the API.
+func (s *Service) streamingEnabled() bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.streamingEnabledLocked()
+}
+
+func (s *Service) streamingEnabledLocked() bool {
+	return s.args.URL != "" && s.args.Streaming && s.watchClient != nil && !s.streamUnsupported
+}
+
+// restartWatching signals Run to restart streaming the configuration with the
+// current arguments.
+func (s *Service) restartWatching() {
+	select {
+	// If the channel is full it means there's already a restart triggered or
+	// Run is not running. In both cases, we don't need to trigger another
+	// restart or block.
+	case s.updateWatchChan <- struct{}{}:
+	default:
+	}
+}
+
+// startWatching streams the configuration from the API in the background. The
+// returned function stops streaming and waits for it to exit.
+func (s *Service) startWatching(ctx context.Context) func() {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.watch(ctx)
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// watch streams the configuration from the API and reconnects when the stream
+// fails, until ctx is canceled or the API turns out not to support streaming.
+func (s *Service) watch(ctx context.Context) {
+	s.mut.RLock()
+	client := s.watchClient
+	s.mut.RUnlock()
+
+	bo := backoff.New(ctx, watchBackoff)
+	for bo.Ongoing() {
+		err := s.watchOnce(ctx, client, bo)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connect.CodeOf(err) == connect.CodeUnimplemented {
+			level.Warn(s.opts.Logger).Log("msg", "the API doesn't support streaming the configuration, falling back to polling", "err", err)
+			s.mut.Lock()
+			s.streamUnsupported = true
+			s.mut.Unlock()
+			s.resetTicker()
+			return
+		}
+
+		level.Error(s.opts.Logger).Log("msg", "failed to stream remote configuration from the API, reconnecting", "err", err)
+		bo.Wait()
+	}
+}
+
+// watchOnce connects the stream and loads the configurations it receives until
+// the stream fails. bo is reset once the stream is connected.
+func (s *Service) watchOnce(ctx context.Context, client watchClient, bo *backoff.Backoff) error {
+	s.mut.RLock()
+	req := connect.NewRequest(&collectorv1.GetConfigRequest{
+		Id:              s.args.ID,
+		LocalAttributes: s.attrs,
+		Hash:            s.remoteHash,
+	})
+	s.mut.RUnlock()
+
+	stream, err := client.CallServerStream(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	s.metrics.streamConnected.Set(1)
+	defer s.metrics.streamConnected.Set(0)
+
+	for stream.Receive() {
+		bo.Reset()
+
+		level.Debug(s.opts.Logger).Log("msg", "received remote configuration from the stream")
+		b, err := s.handleConfigResponse(stream.Msg())
+		if err := s.loadRemote(b, err); err != nil {
+			level.Error(s.opts.Logger).Log("msg", "failed to load remote configuration from the stream", "err", err)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	return errStreamClosed
+}
@@ -0,0 +1,279 @@
+package remotecfg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreaming(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	srv := newConfigServer(t, cfg1, true)
+	env := newStreamingTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+		streaming      = true
+	`, srv.URL)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+		assert.Equal(c, int32(1), srv.watchCalls.Load())
+	}, time.Second, 10*time.Millisecond)
+
+	// Push a new configuration to the stream.
+	srv.setConfig(cfg2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	// The API isn't polled while the configuration is streamed: the only
+	// GetConfig call is the one made on startup.
+	time.Sleep(300 * time.Millisecond)
+	require.Equal(t, int32(1), srv.getConfigCalls.Load())
+
+	cancel()
+	wg.Wait()
+}
+
+func TestStreamingReconnect(t *testing.T) {
+	defaultBackoff := watchBackoff
+	watchBackoff.MinBackoff = 10 * time.Millisecond
+	watchBackoff.MaxBackoff = 20 * time.Millisecond
+	t.Cleanup(func() { watchBackoff = defaultBackoff })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	srv := newConfigServer(t, cfg1, true)
+	env := newStreamingTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+		streaming      = true
+	`, srv.URL)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.Eventually(t, func() bool { return srv.watchCalls.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	// Drop the stream and update the configuration while the collector is
+	// disconnected.
+	srv.closeStreams()
+	srv.setConfig(cfg2)
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.GreaterOrEqual(c, srv.watchCalls.Load(), int32(2))
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestStreamingFallbackToPolling(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	// The server doesn't implement the streaming procedure.
+	srv := newConfigServer(t, cfg1, false)
+	env := newStreamingTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10s"
+		streaming      = true
+	`, srv.URL)))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+		assert.False(c, env.svc.streamingEnabled())
+	}, time.Second, 10*time.Millisecond)
+
+	// The updated configuration is polled.
+	srv.setConfig(cfg2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func newStreamingTestEnvironment(t *testing.T) *testEnvironment {
+	// Unlike newTestEnvironment, the clients of the service talk to a real
+	// server.
+	svc, err := New(Options{
+		Logger:      util.TestLogger(t),
+		StoragePath: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	return &testEnvironment{
+		t:   t,
+		svc: svc,
+	}
+}
+
+// configServer is an in-process remote configuration API which can push
+// configurations to streams and receives status reports.
+type configServer struct {
+	collectorv1connect.UnimplementedCollectorServiceHandler
+	*httptest.Server
+
+	getConfigCalls atomic.Int32
+	watchCalls     atomic.Int32
+	statusCalls    atomic.Int32
+
+	mut     sync.Mutex
+	config  string
+	changed chan struct{} // Closed when config changes or streams are closed.
+	closed  bool          // Whether streams are closed on the next change.
+
+	statusErr func(call int32) error // Error returned to a status report, may be nil.
+	reports   []*statusReport        // Acknowledged status reports.
+}
+
+func newConfigServer(t *testing.T, config string, streaming bool) *configServer {
+	srv := &configServer{config: config, changed: make(chan struct{})}
+
+	mux := http.NewServeMux()
+	mux.Handle(collectorv1connect.NewCollectorServiceHandler(srv))
+	mux.Handle(reportStatusProcedure, connect.NewUnaryHandler(reportStatusProcedure, srv.reportStatus, connect.WithCodec(jsonCodec{})))
+	if streaming {
+		mux.Handle(watchConfigProcedure, connect.NewServerStreamHandler(watchConfigProcedure, srv.watchConfig))
+	}
+	srv.Server = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *configServer) setConfig(config string) {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	srv.config = config
+	close(srv.changed)
+	srv.changed = make(chan struct{})
+}
+
+func (srv *configServer) closeStreams() {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	srv.closed = true
+	close(srv.changed)
+	srv.changed = make(chan struct{})
+}
+
+func (srv *configServer) current() (string, <-chan struct{}, bool) {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	closed := srv.closed
+	srv.closed = false
+	return srv.config, srv.changed, closed
+}
+
+func (srv *configServer) GetConfig(_ context.Context, req *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
+	srv.getConfigCalls.Inc()
+	srv.mut.Lock()
+	config := srv.config
+	srv.mut.Unlock()
+	return connect.NewResponse(configResponse(config, req.Msg.Hash)), nil
+}
+
+func (srv *configServer) RegisterCollector(context.Context, *connect.Request[collectorv1.RegisterCollectorRequest]) (*connect.Response[collectorv1.RegisterCollectorResponse], error) {
+	return connect.NewResponse(&collectorv1.RegisterCollectorResponse{}), nil
+}
+
+func (srv *configServer) reportStatus(_ context.Context, req *connect.Request[statusReport]) (*connect.Response[statusAck], error) {
+	call := srv.statusCalls.Inc()
+
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	if srv.statusErr != nil {
+		if err := srv.statusErr(call); err != nil {
+			return nil, err
+		}
+	}
+	srv.reports = append(srv.reports, req.Msg)
+	return connect.NewResponse(&statusAck{}), nil
+}
+
+func (srv *configServer) lastReport() *statusReport {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	if len(srv.reports) == 0 {
+		return nil
+	}
+	return srv.reports[len(srv.reports)-1]
+}
+
+func (srv *configServer) watchConfig(ctx context.Context, req *connect.Request[collectorv1.GetConfigRequest], stream *connect.ServerStream[collectorv1.GetConfigResponse]) error {
+	srv.watchCalls.Inc()
+
+	hash := req.Msg.Hash
+	for {
+		config, changed, closed := srv.current()
+		if closed {
+			return nil
+		}
+		rsp := configResponse(config, hash)
+		if !rsp.NotModified {
+			if err := stream.Send(rsp); err != nil {
+				return err
+			}
+			hash = rsp.Hash
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// configResponse returns the response to a collector which last received
+// the configuration with the given hash.
+func configResponse(config, hash string) *collectorv1.GetConfigResponse {
+	configHash := getHash([]byte(config))
+	if hash == configHash {
+		return &collectorv1.GetConfigResponse{NotModified: true}
+	}
+	return &collectorv1.GetConfigResponse{Content: config, Hash: configHash}
+}
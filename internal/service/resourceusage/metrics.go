@@ -0,0 +1,66 @@
+package resourceusage
+
+import (
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+// metrics exposes the most recent Snapshot of a Service.
+type metrics struct {
+	svc *Service
+
+	cpuUsage   *prometheus.Desc
+	goroutines *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*metrics)(nil)
+
+func newMetrics(svc *Service) *metrics {
+	labels := []string{"component_path", "component_id"}
+
+	return &metrics{
+		svc: svc,
+
+		cpuUsage: prometheus.NewDesc(
+			"alloy_component_cpu_usage_cores",
+			"Average number of CPU cores used by the component during the last sampled CPU profile.",
+			labels, nil,
+		),
+		goroutines: prometheus.NewDesc(
+			"alloy_component_goroutines",
+			"Number of goroutines run by the component in the last sample.",
+			labels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.cpuUsage
+	ch <- m.goroutines
+}
+
+// Collect implements prometheus.Collector.
+func (m *metrics) Collect(ch chan<- prometheus.Metric) {
+	snapshot := m.svc.Snapshot()
+
+	for _, u := range snapshot.Components {
+		componentPath, componentID := splitID(u.ID)
+		if snapshot.CPUProfileDuration > 0 {
+			ch <- prometheus.MustNewConstMetric(m.cpuUsage, prometheus.GaugeValue, u.CPUCores, componentPath, componentID)
+		}
+		ch <- prometheus.MustNewConstMetric(m.goroutines, prometheus.GaugeValue, float64(u.Goroutines), componentPath, componentID)
+	}
+}
+
+// splitID returns the values of the component_path and component_id labels
+// for the component, matching the labels of the other component metrics.
+func splitID(id component.ID) (string, string) {
+	parent, localID := path.Split(path.Join(id.ModuleID, id.LocalID))
+	parent, _ = strings.CutSuffix(parent, "/")
+	return "/" + parent, localID
+}
@@ -0,0 +1,166 @@
+package resourceusage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"slices"
+	"time"
+
+	"github.com/google/pprof/profile"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/profiling"
+)
+
+// Snapshot is a sample of the resource usage of components.
+type Snapshot struct {
+	// Time is when the sample was taken.
+	Time time.Time
+
+	// CPUProfileDuration is the duration of the CPU profile the CPU usage
+	// was computed from. It is zero if no CPU profile could be taken, for
+	// example because another CPU profile was running at the same time.
+	CPUProfileDuration time.Duration
+
+	// Components holds the usage of every component which was running when
+	// the sample was taken, sorted by CPU usage and goroutines, highest
+	// first.
+	Components []Usage
+}
+
+// Usage is the resource usage of a single component, including the usage of
+// all goroutines the component started.
+type Usage struct {
+	ID component.ID
+
+	// CPUCores is the average number of CPU cores the component used while
+	// the CPU profile was taken.
+	CPUCores float64
+
+	// Goroutines is the number of goroutines the component was running.
+	Goroutines int64
+}
+
+// Component returns the usage of the component with the given ID.
+func (s Snapshot) Component(id component.ID) (Usage, bool) {
+	for _, u := range s.Components {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	return Usage{}, false
+}
+
+// sample takes a CPU profile of the given duration followed by a goroutine
+// profile and stores the resulting Snapshot.
+func (s *Service) sample(ctx context.Context, cpuProfileDuration time.Duration) {
+	cpu, err := profileCPU(ctx, cpuProfileDuration)
+	if ctx.Err() != nil {
+		return
+	} else if err != nil {
+		level.Warn(s.log).Log("msg", "skipping CPU usage of components", "err", err)
+	}
+
+	goroutines, err := profileGoroutines()
+	if err != nil {
+		level.Error(s.log).Log("msg", "failed to sample goroutines of components", "err", err)
+		return
+	}
+
+	s.setSnapshot(newSnapshot(time.Now(), cpu, goroutines))
+}
+
+func profileCPU(ctx context.Context, duration time.Duration) (*profile.Profile, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, fmt.Errorf("starting CPU profile: %w", err)
+	}
+
+	timer := time.NewTimer(duration)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+	case <-timer.C:
+	}
+	pprof.StopCPUProfile()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return profile.Parse(&buf)
+}
+
+func profileGoroutines() (*profile.Profile, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+		return nil, fmt.Errorf("writing goroutine profile: %w", err)
+	}
+	return profile.Parse(&buf)
+}
+
+// newSnapshot attributes the samples of the CPU and goroutine profiles to
+// components. cpu may be nil if no CPU profile was taken.
+func newSnapshot(t time.Time, cpu, goroutines *profile.Profile) Snapshot {
+	var (
+		snapshot = Snapshot{Time: t}
+		usages   = make(map[component.ID]*Usage)
+	)
+
+	getUsage := func(s *profile.Sample) *Usage {
+		id, ok := profiling.ComponentFromLabels(s.Label)
+		if !ok {
+			return nil
+		}
+		if _, found := usages[id]; !found {
+			usages[id] = &Usage{ID: id}
+		}
+		return usages[id]
+	}
+
+	if cpuIndex := sampleIndex(cpu, "cpu"); cpuIndex >= 0 && cpu.DurationNanos > 0 {
+		snapshot.CPUProfileDuration = time.Duration(cpu.DurationNanos)
+		for _, s := range cpu.Sample {
+			if u := getUsage(s); u != nil {
+				u.CPUCores += float64(s.Value[cpuIndex]) / float64(cpu.DurationNanos)
+			}
+		}
+	}
+
+	if goroutineIndex := sampleIndex(goroutines, "goroutine"); goroutineIndex >= 0 {
+		for _, s := range goroutines.Sample {
+			if u := getUsage(s); u != nil {
+				u.Goroutines += s.Value[goroutineIndex]
+			}
+		}
+	}
+
+	for _, u := range usages {
+		snapshot.Components = append(snapshot.Components, *u)
+	}
+	slices.SortFunc(snapshot.Components, func(a, b Usage) int {
+		return cmp.Or(
+			cmp.Compare(b.CPUCores, a.CPUCores),
+			cmp.Compare(b.Goroutines, a.Goroutines),
+			cmp.Compare(a.ID.String(), b.ID.String()),
+		)
+	})
+	return snapshot
+}
+
+// sampleIndex returns the index of the sample values of the given type, or -1
+// if p is nil or has no such sample type.
+func sampleIndex(p *profile.Profile, sampleType string) int {
+	if p == nil {
+		return -1
+	}
+	for i, st := range p.SampleType {
+		if st.Type == sampleType {
+			return i
+		}
+	}
+	return -1
+}
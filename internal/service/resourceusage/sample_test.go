@@ -0,0 +1,117 @@
+package resourceusage
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/profiling"
+)
+
+func TestNewSnapshot(t *testing.T) {
+	var (
+		scrape = component.ID{LocalID: "prometheus.scrape.default"}
+		nested = component.ID{ModuleID: "import.file.mod", LocalID: "loki.process.default"}
+	)
+	labels := func(id component.ID) map[string][]string {
+		return map[string][]string{
+			profiling.LabelModuleID:    {id.ModuleID},
+			profiling.LabelComponentID: {id.LocalID},
+		}
+	}
+
+	cpu := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		DurationNanos: int64(10 * time.Second),
+		Sample: []*profile.Sample{
+			{Value: []int64{1, int64(5 * time.Second)}, Label: labels(scrape)},
+			{Value: []int64{1, int64(5 * time.Second)}, Label: labels(scrape)},
+			{Value: []int64{1, int64(2 * time.Second)}, Label: labels(nested)},
+			{Value: []int64{1, int64(20 * time.Second)}}, // Not run by a component.
+		},
+	}
+	goroutines := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "goroutine", Unit: "count"}},
+		Sample: []*profile.Sample{
+			{Value: []int64{3}, Label: labels(scrape)},
+			{Value: []int64{4}, Label: labels(nested)},
+			{Value: []int64{1}, Label: labels(nested)},
+			{Value: []int64{50}},
+		},
+	}
+
+	now := time.Now()
+	snapshot := newSnapshot(now, cpu, goroutines)
+	require.Equal(t, Snapshot{
+		Time:               now,
+		CPUProfileDuration: 10 * time.Second,
+		Components: []Usage{
+			{ID: scrape, CPUCores: 1, Goroutines: 3},
+			{ID: nested, CPUCores: 0.2, Goroutines: 5},
+		},
+	}, snapshot)
+
+	usage, found := snapshot.Component(nested)
+	require.True(t, found)
+	require.Equal(t, int64(5), usage.Goroutines)
+
+	_, found = snapshot.Component(component.ID{LocalID: "missing"})
+	require.False(t, found)
+
+	t.Run("without CPU profile", func(t *testing.T) {
+		snapshot := newSnapshot(now, nil, goroutines)
+		require.Zero(t, snapshot.CPUProfileDuration)
+		require.Equal(t, []Usage{
+			{ID: nested, Goroutines: 5},
+			{ID: scrape, Goroutines: 3},
+		}, snapshot.Components)
+	})
+}
+
+func TestProfileGoroutines(t *testing.T) {
+	id := component.ID{ModuleID: "mod", LocalID: "fake.component"}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	started := make(chan struct{})
+	go pprof.Do(ctx, profiling.ComponentLabels(id), func(ctx context.Context) {
+		// Child goroutines inherit the labels of their parent.
+		go func() { <-ctx.Done() }()
+		close(started)
+		<-ctx.Done()
+	})
+	<-started
+
+	goroutines, err := profileGoroutines()
+	require.NoError(t, err)
+
+	usage, found := newSnapshot(time.Now(), nil, goroutines).Component(id)
+	require.True(t, found)
+	require.Equal(t, int64(2), usage.Goroutines)
+}
+
+func TestSplitID(t *testing.T) {
+	tt := []struct {
+		id          component.ID
+		expectPath  string
+		expectLocal string
+	}{
+		{component.ID{LocalID: "local.id"}, "/", "local.id"},
+		{component.ID{ModuleID: "module", LocalID: "local.id"}, "/module", "local.id"},
+		{component.ID{ModuleID: "module/nested", LocalID: "local.id"}, "/module/nested", "local.id"},
+	}
+	for _, tc := range tt {
+		componentPath, localID := splitID(tc.id)
+		require.Equal(t, tc.expectPath, componentPath)
+		require.Equal(t, tc.expectLocal, localID)
+	}
+}
@@ -0,0 +1,184 @@
+// Package resourceusage implements a service which periodically samples CPU
+// and goroutine profiles of the process and attributes them to components
+// using the pprof labels applied to component goroutines.
+package resourceusage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service"
+)
+
+// ServiceName defines the name used for the resourceusage service.
+const ServiceName = "resourceusage"
+
+// Arguments holds the configuration of the resourceusage block.
+type Arguments struct {
+	Enabled            bool          `alloy:"enabled,attr,optional"`
+	Interval           time.Duration `alloy:"interval,attr,optional"`
+	CPUProfileDuration time.Duration `alloy:"cpu_profile_duration,attr,optional"`
+}
+
+// DefaultArguments holds the default arguments of the resourceusage block.
+var DefaultArguments = Arguments{
+	Enabled:            false,
+	Interval:           time.Minute,
+	CPUProfileDuration: 10 * time.Second,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	if args.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	if args.CPUProfileDuration <= 0 {
+		return fmt.Errorf("cpu_profile_duration must be greater than 0")
+	}
+	if args.CPUProfileDuration > args.Interval {
+		return fmt.Errorf("cpu_profile_duration must not be greater than interval")
+	}
+	return nil
+}
+
+// Data is the data exposed by the resourceusage service.
+type Data interface {
+	// Enabled returns whether resource usage is being sampled.
+	Enabled() bool
+
+	// Snapshot returns the most recent sample. The returned Snapshot is
+	// empty if no sample was taken yet.
+	Snapshot() Snapshot
+}
+
+// Service samples the resource usage of components.
+type Service struct {
+	log     log.Logger
+	metrics *metrics
+
+	mut      sync.RWMutex
+	args     Arguments
+	snapshot Snapshot
+
+	// updated is signaled when the arguments change, so that the sampling
+	// loop picks them up without waiting for the current interval to end.
+	updated chan struct{}
+}
+
+var (
+	_ service.Service = (*Service)(nil)
+	_ Data            = (*Service)(nil)
+)
+
+// New returns a new, unstarted resourceusage service. Metrics are registered
+// to reg when it isn't nil.
+func New(l log.Logger, reg prometheus.Registerer) *Service {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+
+	s := &Service{
+		log:     l,
+		args:    DefaultArguments,
+		updated: make(chan struct{}, 1),
+	}
+	s.metrics = newMetrics(s)
+	if reg != nil {
+		_ = reg.Register(s.metrics)
+	}
+	return s
+}
+
+// Definition implements service.Service.
+func (*Service) Definition() service.Definition {
+	return service.Definition{
+		Name:       ServiceName,
+		ConfigType: Arguments{},
+		DependsOn:  nil,
+		Stability:  featuregate.StabilityExperimental,
+	}
+}
+
+// Run implements service.Service. While enabled, it samples the resource
+// usage of components once per interval.
+func (s *Service) Run(ctx context.Context, _ service.Host) error {
+	for {
+		args := s.getArgs()
+		if args.Enabled {
+			s.sample(ctx, args.CPUProfileDuration)
+		}
+
+		timer := time.NewTimer(args.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-s.updated:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Update implements service.Service.
+func (s *Service) Update(newConfig any) error {
+	newArgs := newConfig.(Arguments)
+
+	s.mut.Lock()
+	s.args = newArgs
+	if !newArgs.Enabled {
+		s.snapshot = Snapshot{}
+	}
+	s.mut.Unlock()
+
+	select {
+	case s.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Data implements service.Service. It returns the Service itself, which
+// implements Data.
+func (s *Service) Data() any {
+	return s
+}
+
+// Enabled implements Data.
+func (s *Service) Enabled() bool {
+	return s.getArgs().Enabled
+}
+
+// Snapshot implements Data.
+func (s *Service) Snapshot() Snapshot {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.snapshot
+}
+
+func (s *Service) getArgs() Arguments {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.args
+}
+
+func (s *Service) setSnapshot(snapshot Snapshot) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// Drop the sample if sampling was disabled while it was taken.
+	if s.args.Enabled {
+		s.snapshot = snapshot
+	}
+}
@@ -0,0 +1,61 @@
+package resourceusage
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/profiling"
+)
+
+func TestService(t *testing.T) {
+	id := component.ID{LocalID: "fake.busy"}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	// Simulate a component which keeps the CPU busy.
+	go pprof.Do(ctx, profiling.ComponentLabels(id), func(ctx context.Context) {
+		for ctx.Err() == nil {
+		}
+	})
+
+	reg := prometheus.NewRegistry()
+	svc := New(nil, reg)
+	require.NoError(t, svc.Update(Arguments{
+		Enabled:            true,
+		Interval:           time.Minute,
+		CPUProfileDuration: 200 * time.Millisecond,
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, svc.Run(ctx, nil))
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.Eventually(t, func() bool {
+		usage, found := svc.Snapshot().Component(id)
+		return found && usage.CPUCores > 0 && usage.Goroutines == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	count, err := testutil.GatherAndCount(reg, "alloy_component_cpu_usage_cores", "alloy_component_goroutines")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, count, 2)
+
+	t.Run("disabling clears the sample", func(t *testing.T) {
+		require.NoError(t, svc.Update(Arguments{Enabled: false, Interval: time.Minute}))
+		require.False(t, svc.Enabled())
+		require.Empty(t, svc.Snapshot().Components)
+	})
+}
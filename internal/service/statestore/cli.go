@@ -0,0 +1,112 @@
+package statestore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultStoragePath is the default value of the --storage.path flag of the
+// run command.
+const defaultStoragePath = "data-alloy/"
+
+// InstallTools installs the commands to inspect and reset the persisted state
+// of components into cmd.
+func InstallTools(cmd *cobra.Command) {
+	var storagePath string
+	cmd.PersistentFlags().StringVar(&storagePath, "storage.path", defaultStoragePath, "Base directory where components store data")
+
+	store := func() *Store { return NewStore(filepath.Join(storagePath, DirName)) }
+	cmd.AddCommand(
+		listCmd(store),
+		showCmd(store),
+		resetCmd(store),
+	)
+}
+
+func listCmd(store func() *Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [component ID]",
+		Short: "List the persisted state of components",
+		Long: `list prints the entries persisted by every component, or by the component
+with the given ID, along with their version, size, last update time and whether
+they are corrupted.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := store()
+			components := args
+			if len(components) == 0 {
+				var err error
+				if components, err = s.Components(); err != nil {
+					return err
+				}
+			}
+			return printEntries(cmd.OutOrStdout(), s, components)
+		},
+	}
+}
+
+func showCmd(store func() *Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <component ID> <key>",
+		Short: "Print the persisted state of a component",
+		Long:  `show writes the data of an entry persisted by a component to stdout.`,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entry, err := store().Get(args[0], args[1])
+			if err != nil {
+				return fmt.Errorf("reading key %q of component %q: %w", args[1], args[0], err)
+			}
+			_, err = cmd.OutOrStdout().Write(entry.Data)
+			return err
+		},
+	}
+}
+
+func resetCmd(store func() *Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reset <component ID> [key]",
+		Short: "Delete the persisted state of a component",
+		Long: `reset deletes an entry persisted by a component, or all of its entries when
+no key is given. Alloy shouldn't be running while the state is reset.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := store()
+			if len(args) == 2 {
+				return s.Delete(args[0], args[1])
+			}
+			return s.DeleteComponent(args[0])
+		},
+	}
+}
+
+func printEntries(w io.Writer, s *Store, components []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tKEY\tVERSION\tSIZE\tUPDATED\tSTATUS")
+	for _, component := range components {
+		keys, err := s.Keys(component)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			entry, err := s.Get(component, key)
+			switch {
+			case err == nil:
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\tok\n", component, key, entry.Version, len(entry.Data), entry.UpdatedAt.Format(time.RFC3339))
+			case errors.Is(err, ErrCorrupted):
+				fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\tcorrupted\n", component, key)
+			case errors.Is(err, os.ErrPermission):
+				fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\tunreadable\n", component, key)
+			default:
+				return err
+			}
+		}
+	}
+	return tw.Flush()
+}
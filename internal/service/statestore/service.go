@@ -0,0 +1,121 @@
+// Package statestore implements a service which lets components persist
+// small blobs of state across restarts of Alloy.
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service"
+)
+
+// ServiceName defines the name used for the statestore service.
+const ServiceName = "statestore"
+
+// DirName is the name of the directory of the storage path where state is
+// persisted.
+const DirName = "state"
+
+// Data is the data exposed by the statestore service.
+type Data interface {
+	// Component returns the state of the component with the given ID.
+	// Components pass their own ID, so that the state of each component is
+	// kept apart.
+	Component(id string) ComponentState
+}
+
+// ComponentState is the persisted state of a single component.
+type ComponentState interface {
+	// Get returns the entry with the given key. ErrNotFound is returned if
+	// the entry doesn't exist, and an error wrapping ErrCorrupted if it can't
+	// be read back. Corrupted entries should be discarded or overwritten.
+	Get(key string) (Entry, error)
+
+	// Put atomically writes the entry with the given key. version is the
+	// version of the format of data.
+	Put(key string, version int, data []byte) error
+
+	// Delete deletes the entry with the given key.
+	Delete(key string) error
+}
+
+// Service persists the state of components under the storage path.
+type Service struct {
+	log   log.Logger
+	store *Store
+}
+
+var (
+	_ service.Service = (*Service)(nil)
+	_ Data            = (*Service)(nil)
+)
+
+// New returns a new, unstarted statestore service which persists state in the
+// state directory of dataPath.
+func New(l log.Logger, dataPath string) *Service {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	return &Service{
+		log:   l,
+		store: NewStore(filepath.Join(dataPath, DirName)),
+	}
+}
+
+// Definition implements service.Service.
+func (*Service) Definition() service.Definition {
+	return service.Definition{
+		Name:       ServiceName,
+		ConfigType: nil, // statestore does not accept configuration.
+		DependsOn:  nil,
+		Stability:  featuregate.StabilityExperimental,
+	}
+}
+
+// Run implements service.Service. Entries are written synchronously, so Run
+// only waits for ctx to be canceled.
+func (s *Service) Run(ctx context.Context, _ service.Host) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements service.Service. It is never called, since the service
+// has no configuration.
+func (*Service) Update(_ any) error {
+	return fmt.Errorf("statestore service does not support configuration")
+}
+
+// Data implements service.Service. It returns the service as a Data.
+func (s *Service) Data() any { return s }
+
+// Component implements Data.
+func (s *Service) Component(id string) ComponentState {
+	return &componentState{s: s, id: id}
+}
+
+type componentState struct {
+	s  *Service
+	id string
+}
+
+func (c *componentState) Get(key string) (Entry, error) {
+	entry, err := c.s.store.Get(c.id, key)
+	if errors.Is(err, ErrCorrupted) {
+		level.Warn(c.s.log).Log("msg", "persisted component state is corrupted", "component", c.id, "key", key, "err", err)
+	}
+	return entry, err
+}
+
+func (c *componentState) Put(key string, version int, data []byte) error {
+	return c.s.store.Put(c.id, key, version, data)
+}
+
+func (c *componentState) Delete(key string) error {
+	return c.s.store.Delete(c.id, key)
+}
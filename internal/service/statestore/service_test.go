@@ -0,0 +1,66 @@
+package statestore
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	dataPath := t.TempDir()
+	svc := New(nil, dataPath)
+	state := svc.Data().(Data).Component("remote.http.b")
+
+	require.NoError(t, state.Put("cache", 3, []byte("content")))
+	entry, err := state.Get("cache")
+	require.NoError(t, err)
+	require.Equal(t, 3, entry.Version)
+	require.Equal(t, []byte("content"), entry.Data)
+
+	// The state persists across instances of the service.
+	entry, err = New(nil, dataPath).Component("remote.http.b").Get("cache")
+	require.NoError(t, err)
+	require.Equal(t, []byte("content"), entry.Data)
+
+	_, err = svc.Component("remote.http.a").Get("cache")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, state.Delete("cache"))
+	_, err = state.Get("cache")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestTools(t *testing.T) {
+	dataPath := t.TempDir()
+	s := NewStore(filepath.Join(dataPath, DirName))
+	require.NoError(t, s.Put("remote.http.a", "cache", 1, []byte("a")))
+	require.NoError(t, s.Put("remote.http.b", "cache", 2, []byte("bb")))
+	require.NoError(t, s.Put("remote.http.b", "etag", 1, []byte("b")))
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := &cobra.Command{Use: "state"}
+		InstallTools(cmd)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(append(args, "--storage.path", dataPath))
+		require.NoError(t, cmd.Execute())
+		return out.String()
+	}
+
+	out := run("list")
+	require.Contains(t, out, "remote.http.a")
+	require.Regexp(t, `remote.http.b\s+cache\s+2\s+2\s+\S+\s+ok`, out)
+
+	require.Equal(t, "bb", run("show", "remote.http.b", "cache"))
+
+	run("reset", "remote.http.b", "cache")
+	require.NotRegexp(t, `remote.http.b\s+cache`, run("list"))
+	require.Contains(t, run("list", "remote.http.b"), "etag")
+
+	run("reset", "remote.http.b")
+	require.NotContains(t, run("list"), "remote.http.b")
+}
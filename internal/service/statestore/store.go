@@ -0,0 +1,305 @@
+package statestore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// formatVersion is the version of the format of state files.
+const formatVersion = 1
+
+// fileExt is the extension of state files.
+const fileExt = ".state"
+
+var (
+	// ErrNotFound is returned when an entry doesn't exist.
+	ErrNotFound = errors.New("state entry not found")
+
+	// ErrCorrupted is returned when an entry can't be read back as it was
+	// written, for example because the file was truncated.
+	ErrCorrupted = errors.New("state entry is corrupted")
+)
+
+// Entry is a state blob persisted by a component.
+type Entry struct {
+	// Version is the version of the format of Data, chosen by the component.
+	// Components use it to detect and migrate state written by older
+	// releases.
+	Version int
+
+	// Data is the state blob.
+	Data []byte
+
+	// UpdatedAt is the time the entry was last written.
+	UpdatedAt time.Time
+}
+
+// file is the content of a state file.
+type file struct {
+	Format    int       `json:"format"`
+	Component string    `json:"component"`
+	Key       string    `json:"key"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Checksum  string    `json:"checksum"`
+	Data      []byte    `json:"data"`
+}
+
+// Store persists the state of components in a directory. Each component has
+// its own subdirectory, holding one file per key.
+type Store struct {
+	dir string
+	mut sync.Mutex
+}
+
+// NewStore returns a Store which persists state in dir. dir is created when
+// the first entry is written.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory the state is persisted in.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the entry of the component with the given key. ErrNotFound is
+// returned if the entry doesn't exist, and an error wrapping ErrCorrupted if
+// it can't be read back.
+func (s *Store) Get(component, key string) (Entry, error) {
+	if err := validateEntry(component, key); err != nil {
+		return Entry{}, err
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	f, err := readFile(s.entryPath(component, key))
+	if err != nil {
+		return Entry{}, err
+	}
+	if f.Component != component || f.Key != key {
+		return Entry{}, fmt.Errorf("%w: entry belongs to key %q of component %q", ErrCorrupted, f.Key, f.Component)
+	}
+	return Entry{Version: f.Version, Data: f.Data, UpdatedAt: f.UpdatedAt}, nil
+}
+
+// Put atomically writes the entry of the component with the given key. Once
+// Put returns, the entry is either fully written or left unchanged.
+func (s *Store) Put(component, key string, version int, data []byte) error {
+	if err := validateEntry(component, key); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(file{
+		Format:    formatVersion,
+		Component: component,
+		Key:       key,
+		Version:   version,
+		UpdatedAt: time.Now().UTC(),
+		Checksum:  checksum(data),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	dir := s.componentDir(component)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(dir, filepath.Join(dir, escape(key)+fileExt), content)
+}
+
+// Delete deletes the entry of the component with the given key. Deleting an
+// entry which doesn't exist isn't an error.
+func (s *Store) Delete(component, key string) error {
+	if err := validateEntry(component, key); err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	err := os.Remove(s.entryPath(component, key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// Remove the directory of the component once it's empty.
+	_ = os.Remove(s.componentDir(component))
+	return nil
+}
+
+// DeleteComponent deletes all the entries of the component.
+func (s *Store) DeleteComponent(component string) error {
+	if err := validateName("component", component); err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return os.RemoveAll(s.componentDir(component))
+}
+
+// Components returns the IDs of the components which have persisted state,
+// sorted by ID.
+func (s *Store) Components() ([]string, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	dirs, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var components []string
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if id, err := url.PathUnescape(d.Name()); err == nil {
+			components = append(components, id)
+		}
+	}
+	slices.Sort(components)
+	return components, nil
+}
+
+// Keys returns the keys of the entries of the component, sorted by key.
+func (s *Store) Keys(component string) ([]string, error) {
+	if err := validateName("component", component); err != nil {
+		return nil, err
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	files, err := os.ReadDir(s.componentDir(component))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), fileExt)
+		if f.IsDir() || !ok {
+			continue
+		}
+		if key, err := url.PathUnescape(name); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (s *Store) componentDir(component string) string {
+	return filepath.Join(s.dir, escape(component))
+}
+
+func (s *Store) entryPath(component, key string) string {
+	return filepath.Join(s.componentDir(component), escape(key)+fileExt)
+}
+
+// escape escapes component IDs and keys, which may contain slashes, to use
+// them as file names.
+func escape(name string) string {
+	return url.PathEscape(name)
+}
+
+func validateEntry(component, key string) error {
+	if err := validateName("component", component); err != nil {
+		return err
+	}
+	return validateName("key", key)
+}
+
+// validateName rejects names which can't be used as file names once escaped.
+// escape leaves "." and ".." untouched, and they would otherwise resolve to
+// the storage directory or its parent.
+func validateName(kind, name string) error {
+	switch name {
+	case "":
+		return fmt.Errorf("state %s must not be empty", kind)
+	case ".", "..":
+		return fmt.Errorf("state %s must not be %q", kind, name)
+	}
+	return nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func readFile(path string) (file, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file{}, ErrNotFound
+	} else if err != nil {
+		return file{}, err
+	}
+
+	var f file
+	if err := json.Unmarshal(content, &f); err != nil {
+		return file{}, fmt.Errorf("%w: %s", ErrCorrupted, err)
+	}
+	if f.Format != formatVersion {
+		return file{}, fmt.Errorf("%w: unsupported format version %d", ErrCorrupted, f.Format)
+	}
+	if checksum(f.Data) != f.Checksum {
+		return file{}, fmt.Errorf("%w: checksum mismatch", ErrCorrupted)
+	}
+	return f, nil
+}
+
+// writeFileAtomic writes content to a temporary file in dir and renames it to
+// path, so that readers never observe a partially written file.
+func writeFileAtomic(dir, path string, content []byte) error {
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Sync the directory so that the rename survives a crash. This isn't
+	// supported on every platform, so errors are ignored.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package statestore
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore(t.TempDir())
+
+	_, err := s.Get("loki.source.file.logs", "positions")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Put("loki.source.file.logs", "positions", 1, []byte("first")))
+	require.NoError(t, s.Put("loki.source.file.logs", "positions", 2, []byte("second")))
+
+	entry, err := s.Get("loki.source.file.logs", "positions")
+	require.NoError(t, err)
+	require.Equal(t, 2, entry.Version)
+	require.Equal(t, []byte("second"), entry.Data)
+	require.False(t, entry.UpdatedAt.IsZero())
+
+	require.NoError(t, s.Delete("loki.source.file.logs", "positions"))
+	_, err = s.Get("loki.source.file.logs", "positions")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting a missing entry isn't an error.
+	require.NoError(t, s.Delete("loki.source.file.logs", "positions"))
+}
+
+func TestStoreNamespacing(t *testing.T) {
+	s := NewStore(t.TempDir())
+
+	// Component IDs and keys may contain slashes.
+	require.NoError(t, s.Put("module.file.a/remote.http.b", "cache/etag", 1, []byte("module")))
+	require.NoError(t, s.Put("remote.http.b", "cache/etag", 1, []byte("root")))
+
+	entry, err := s.Get("module.file.a/remote.http.b", "cache/etag")
+	require.NoError(t, err)
+	require.Equal(t, []byte("module"), entry.Data)
+
+	entry, err = s.Get("remote.http.b", "cache/etag")
+	require.NoError(t, err)
+	require.Equal(t, []byte("root"), entry.Data)
+
+	components, err := s.Components()
+	require.NoError(t, err)
+	require.Equal(t, []string{"module.file.a/remote.http.b", "remote.http.b"}, components)
+
+	keys, err := s.Keys("remote.http.b")
+	require.NoError(t, err)
+	require.Equal(t, []string{"cache/etag"}, keys)
+
+	require.NoError(t, s.DeleteComponent("remote.http.b"))
+	components, err = s.Components()
+	require.NoError(t, err)
+	require.Equal(t, []string{"module.file.a/remote.http.b"}, components)
+
+	require.ErrorContains(t, s.Put("", "key", 1, nil), "state component must not be empty")
+	require.ErrorContains(t, s.Put("remote.http.b", "", 1, nil), "state key must not be empty")
+}
+
+func TestStoreRejectsRelativeNames(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	s := NewStore(dir)
+	require.NoError(t, s.Put("remote.http.b", "cache", 1, []byte("content")))
+
+	for _, name := range []string{".", ".."} {
+		require.ErrorContains(t, s.Put(name, "key", 1, nil), "state component must not be")
+		require.ErrorContains(t, s.Put("remote.http.b", name, 1, nil), "state key must not be")
+		require.ErrorContains(t, s.Delete(name, "cache"), "state component must not be")
+		require.ErrorContains(t, s.Delete("remote.http.b", name), "state key must not be")
+		require.ErrorContains(t, s.DeleteComponent(name), "state component must not be")
+
+		_, err := s.Get(name, "cache")
+		require.ErrorContains(t, err, "state component must not be")
+		_, err = s.Get("remote.http.b", name)
+		require.ErrorContains(t, err, "state key must not be")
+		_, err = s.Keys(name)
+		require.ErrorContains(t, err, "state component must not be")
+	}
+
+	// The storage directory and the other entries are left untouched.
+	entry, err := s.Get("remote.http.b", "cache")
+	require.NoError(t, err)
+	require.Equal(t, []byte("content"), entry.Data)
+}
+
+func TestStoreCorruption(t *testing.T) {
+	s := NewStore(t.TempDir())
+	require.NoError(t, s.Put("remote.http.b", "cache", 1, []byte("content")))
+	path := s.entryPath("remote.http.b", "cache")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Truncated file.
+	require.NoError(t, os.WriteFile(path, content[:len(content)/2], 0o600))
+	_, err = s.Get("remote.http.b", "cache")
+	require.ErrorIs(t, err, ErrCorrupted)
+
+	// Data which doesn't match the checksum.
+	require.NoError(t, s.Put("remote.http.b", "cache", 1, []byte("content")))
+	f, err := readFile(path)
+	require.NoError(t, err)
+	f.Checksum = checksum([]byte("other"))
+	writeTestFile(t, path, f)
+	_, err = s.Get("remote.http.b", "cache")
+	require.ErrorIs(t, err, ErrCorrupted)
+	require.ErrorContains(t, err, "checksum mismatch")
+
+	// Entry copied from another component.
+	require.NoError(t, s.Put("remote.http.a", "cache", 1, []byte("content")))
+	require.NoError(t, os.Rename(s.entryPath("remote.http.a", "cache"), path))
+	_, err = s.Get("remote.http.b", "cache")
+	require.ErrorIs(t, err, ErrCorrupted)
+
+	// Corrupted entries can be overwritten.
+	require.NoError(t, s.Put("remote.http.b", "cache", 1, []byte("new")))
+	entry, err := s.Get("remote.http.b", "cache")
+	require.NoError(t, err)
+	require.Equal(t, []byte("new"), entry.Data)
+}
+
+func TestStoreAtomicWrite(t *testing.T) {
+	s := NewStore(t.TempDir())
+	require.NoError(t, s.Put("remote.http.b", "cache", 1, []byte("content")))
+
+	// No temporary file is left behind.
+	files, err := os.ReadDir(filepath.Dir(s.entryPath("remote.http.b", "cache")))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func writeTestFile(t *testing.T, path string, f file) {
+	t.Helper()
+	content, err := json.Marshal(f)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
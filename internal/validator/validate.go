@@ -8,10 +8,14 @@ import (
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
 	"github.com/grafana/alloy/syntax/typecheck"
+	"github.com/grafana/alloy/syntax/vm"
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/conditional"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/importoci"
+	"github.com/grafana/alloy/internal/nodeconf/restartpolicy"
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/tracing"
@@ -39,6 +43,10 @@ type validator struct {
 	minStability featuregate.Stability
 	sources      map[string][]byte
 	sm           map[string]service.Definition
+
+	// scope holds the exports of all components and is used to infer the
+	// types of references to other components.
+	scope *vm.Scope
 }
 
 func newValidator(opts Options) *validator {
@@ -67,12 +75,14 @@ func (v *validator) run(cr *componentRegistry) error {
 		}
 	}
 
+	components, services := splitComponents(s.Components(), v.sm)
+	v.scope = exportsScope(components, cr)
+
 	var diags diag.Diagnostics
 	// Need to validate declares first becuse we will register "custom" components.
 	diags.Merge(v.validateDeclares(s.Declares(), cr))
 	diags.Merge(v.validateConfigs(s.Configs(), cr))
 
-	components, services := splitComponents(s.Components(), v.sm)
 	diags.Merge(v.validateComponents(components, cr))
 	diags.Merge(v.validateServices(services))
 
@@ -134,18 +144,55 @@ func (v *validator) validateConfigs(configs []*ast.BlockStmt, cr *componentRegis
 			cr.registerCustomComponent(c)
 		}
 
-		// In config we store blocks for logging, tracing, argument, export, import.file,
-		// import.string, import.http, import.git and foreach.
-		// For now we only typecheck logging and tracing and ignore the rest.
+		// In config we store blocks for logging, tracing, restart_policy, argument, export, function,
+		// import.file, import.string, import.http, import.git, import.oci, foreach and if.
+		// For now we only typecheck logging, tracing and restart_policy and ignore the rest.
 		switch c.GetBlockName() {
+		case "function":
+			// Function blocks are called through their label.
+			if c.Label == "" {
+				name := c.GetBlockName()
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: c.NamePos.Position(),
+					EndPos:   c.NamePos.Add(len(name) - 1).Position(),
+					Message:  "function block must have a label",
+				})
+			}
 		case "logging":
 			args := &logging.Options{}
-			diags.Merge(typecheck.Block(c, args))
+			diags.Merge(typecheck.BlockWithScope(c, args, v.scope))
 		case "tracing":
 			args := &tracing.Options{}
-			diags.Merge(typecheck.Block(c, args))
+			diags.Merge(typecheck.BlockWithScope(c, args, v.scope))
+		case restartpolicy.Name:
+			name := c.GetBlockName()
+			if err := featuregate.CheckAllowed(restartpolicy.StabilityLevel, v.minStability, fmt.Sprintf("config block %q", name)); err != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: c.NamePos.Position(),
+					EndPos:   c.NamePos.Add(len(name) - 1).Position(),
+					Message:  err.Error(),
+				})
+				continue
+			}
+			args := &restartpolicy.Arguments{}
+			diags.Merge(typecheck.BlockWithScope(c, args, v.scope))
 		case foreach.Name:
 			diags.Merge(v.validateForeach(c, cr))
+		case conditional.Name:
+			diags.Merge(v.validateIf(c, cr))
+		case importoci.Name:
+			// Check required stability level.
+			name := c.GetBlockName()
+			if err := featuregate.CheckAllowed(importoci.StabilityLevel, v.minStability, fmt.Sprintf("config block %q", name)); err != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: c.NamePos.Position(),
+					EndPos:   c.NamePos.Add(len(name) - 1).Position(),
+					Message:  err.Error(),
+				})
+			}
 		}
 	}
 
@@ -191,7 +238,7 @@ func (v *validator) validateForeach(block *ast.BlockStmt, cr *componentRegistry)
 
 	// Set the body of block to all non template properties.
 	block.Body = body
-	diags.Merge(typecheck.Block(block, &foreach.Arguments{}))
+	diags.Merge(typecheck.BlockWithScope(block, &foreach.Arguments{}, v.scope))
 
 	// Foreach blocks must have a template.
 	if template == nil {
@@ -204,13 +251,77 @@ func (v *validator) validateForeach(block *ast.BlockStmt, cr *componentRegistry)
 		return diags
 	}
 
-	// We extract all blocks from template body and evaluate them as components.
+	// The exports of the template are exposed through the exports of the foreach block.
+	diags.Merge(v.validateNestedPipeline(template.Body, cr, true))
+	return diags
+}
+
+func (v *validator) validateIf(block *ast.BlockStmt, cr *componentRegistry) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	name := block.GetBlockName()
+	// Check required stability level.
+	if err := featuregate.CheckAllowed(conditional.StabilityLevel, v.minStability, fmt.Sprintf("if block %q", name)); err != nil {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: block.NamePos.Position(),
+			EndPos:   block.NamePos.Add(len(name) - 1).Position(),
+			Message:  err.Error(),
+		})
+	}
+
 	var (
-		configs    = make([]*ast.BlockStmt, 0, len(template.Body))
-		components = make([]*ast.BlockStmt, 0, len(template.Body))
+		args      ast.Body
+		then      ast.Body
+		elseBlock *ast.BlockStmt
 	)
 
-	for _, stmt := range template.Body {
+	for _, stmt := range block.Body {
+		b, ok := stmt.(*ast.BlockStmt)
+		switch {
+		case !ok:
+			args = append(args, stmt)
+		case b.GetBlockName() == conditional.TypeElse:
+			if elseBlock != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(b).Position(),
+					EndPos:   ast.EndPos(b).Position(),
+					Message:  fmt.Sprintf("only one %q block is allowed in an if block", conditional.TypeElse),
+				})
+				continue
+			}
+			elseBlock = b
+		default:
+			then = append(then, stmt)
+		}
+	}
+
+	// Only the arguments are typechecked against the if block.
+	argsBlock := *block
+	argsBlock.Body = args
+	diags.Merge(typecheck.BlockWithScope(&argsBlock, &conditional.Arguments{}, v.scope))
+
+	diags.Merge(v.validateNestedPipeline(then, cr, false))
+	if elseBlock != nil {
+		diags.Merge(v.validateNestedPipeline(elseBlock.Body, cr, false))
+	}
+	return diags
+}
+
+// validateNestedPipeline validates the blocks of a foreach template or of an
+// if branch. They run as a custom component, so they get their own component
+// registry. Export blocks are only allowed when allowExports is true.
+func (v *validator) validateNestedPipeline(body ast.Body, cr *componentRegistry, allowExports bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	// We extract all blocks from the body and evaluate them as components.
+	var (
+		configs    = make([]*ast.BlockStmt, 0, len(body))
+		components = make([]*ast.BlockStmt, 0, len(body))
+	)
+
+	for _, stmt := range body {
 		b, ok := stmt.(*ast.BlockStmt)
 		if !ok {
 			diags.Add(diag.Diagnostic{
@@ -222,8 +333,8 @@ func (v *validator) validateForeach(block *ast.BlockStmt, cr *componentRegistry)
 			continue
 		}
 
-		var validNames = [...]string{foreach.Name, "import.file", "import.string", "import.http", "import.git"}
-		if slices.Contains(validNames[:], b.GetBlockName()) {
+		var validNames = [...]string{foreach.Name, conditional.Name, "import.file", "import.string", "import.http", "import.git", importoci.Name}
+		if slices.Contains(validNames[:], b.GetBlockName()) || (allowExports && b.GetBlockName() == "export") {
 			configs = append(configs, b)
 			continue
 		}
@@ -231,13 +342,13 @@ func (v *validator) validateForeach(block *ast.BlockStmt, cr *componentRegistry)
 		components = append(components, b)
 	}
 
-	foreachCr := newComponentRegistry(cr)
+	nestedCr := newComponentRegistry(cr)
 
-	// We can reuse validateConfigs here since we know that all block in nested
-	// are foreach.
-	diags.Merge(v.validateConfigs(configs, foreachCr))
+	// We can reuse validateConfigs here since we know that all config blocks
+	// in nested pipelines are foreach, if, import or export blocks.
+	diags.Merge(v.validateConfigs(configs, nestedCr))
 	// Validate all other blocks as components.
-	diags.Merge(v.validateComponents(components, foreachCr))
+	diags.Merge(v.validateComponents(components, nestedCr))
 	return diags
 }
 
@@ -285,7 +396,7 @@ func (v *validator) validateComponents(components []*ast.BlockStmt, cr component
 		}
 
 		// 4. Perform typecheck on component.
-		diags.Merge(typecheck.Block(c, reg.CloneArguments()))
+		diags.Merge(typecheck.BlockWithScope(c, reg.CloneArguments(), v.scope))
 	}
 
 	return diags
@@ -314,12 +425,43 @@ func (v *validator) validateServices(services []*ast.BlockStmt) diag.Diagnostics
 			continue
 		}
 
-		diags.Merge(typecheck.Block(s, def.CloneConfig()))
+		diags.Merge(typecheck.BlockWithScope(s, def.CloneConfig(), v.scope))
 	}
 
 	return diags
 }
 
+// exportsScope returns a scope where the exports of every component can be
+// referenced by its ID. The values in the scope are the example exports of
+// the registered components, which are only used for their types. Custom
+// components don't have known exports and are left out of the scope.
+func exportsScope(components []*ast.BlockStmt, cr component.Registry) *vm.Scope {
+	variables := make(map[string]any)
+
+	for _, c := range components {
+		if c.Label == "" {
+			continue
+		}
+		reg, err := cr.Get(c.GetBlockName())
+		if err != nil || reg.Exports == nil {
+			continue
+		}
+
+		ns := variables
+		for _, part := range c.Name {
+			next, ok := ns[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				ns[part] = next
+			}
+			ns = next
+		}
+		ns[c.Label] = reg.Exports
+	}
+
+	return vm.NewScope(variables)
+}
+
 func splitComponents(blocks []*ast.BlockStmt, sm map[string]service.Definition) ([]*ast.BlockStmt, []*ast.BlockStmt) {
 	components := make([]*ast.BlockStmt, 0, len(blocks))
 	services := make([]*ast.BlockStmt, 0, len(sm))
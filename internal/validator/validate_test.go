@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/alloy/internal/service/labelstore"
 	"github.com/grafana/alloy/internal/service/otel"
 	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/resourceusage"
 	"github.com/grafana/alloy/internal/service/ui"
 
 	"github.com/grafana/alloy/internal/component"
@@ -66,6 +67,7 @@ func testDirectory(t *testing.T, dir string, minStability featuregate.Stability,
 						&labelstore.Service{},
 						&otel.Service{},
 						&remotecfg.Service{},
+						&resourceusage.Service{},
 						&ui.Service{},
 					),
 					MinStability: minStability,
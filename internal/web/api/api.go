@@ -7,9 +7,11 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"math/rand"
 	"net/http"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,11 +20,13 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/grafana/alloy/internal/component"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/resourceusage"
 	"github.com/prometheus/prometheus/util/httputil"
 )
 
@@ -50,27 +54,74 @@ func (a *AlloyAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	r.Handle(path.Join(urlPrefix, "/components"), httputil.CompressionHandler{Handler: listComponentsHandler(a.alloy)})
 	r.Handle(path.Join(urlPrefix, "/remotecfg/components"), httputil.CompressionHandler{Handler: listComponentsHandlerRemoteCfg(a.alloy)})
 
+	// The resources route must be registered before the component route,
+	// which would otherwise match it.
+	r.Handle(path.Join(urlPrefix, "/components/{id:.+}/resources"), httputil.CompressionHandler{Handler: getComponentResourcesHandler(a.alloy)})
 	r.Handle(path.Join(urlPrefix, "/components/{id:.+}"), httputil.CompressionHandler{Handler: getComponentHandler(a.alloy)})
 	r.Handle(path.Join(urlPrefix, "/remotecfg/components/{id:.+}"), httputil.CompressionHandler{Handler: getComponentHandlerRemoteCfg(a.alloy)})
 
+	// The routes of the named sources of remote configuration mirror the ones
+	// of the default source above.
+	r.Handle(path.Join(urlPrefix, "/remotecfg/sources"), httputil.CompressionHandler{Handler: listRemoteCfgSourcesHandler(a.alloy)})
+	r.Handle(path.Join(urlPrefix, "/remotecfg/sources/{source}/modules/{moduleID:.+}/components"), httputil.CompressionHandler{Handler: listComponentsHandlerRemoteCfg(a.alloy)})
+	r.Handle(path.Join(urlPrefix, "/remotecfg/sources/{source}/components"), httputil.CompressionHandler{Handler: listComponentsHandlerRemoteCfg(a.alloy)})
+	r.Handle(path.Join(urlPrefix, "/remotecfg/sources/{source}/components/{id:.+}"), httputil.CompressionHandler{Handler: getComponentHandlerRemoteCfg(a.alloy)})
+
 	r.Handle(path.Join(urlPrefix, "/peers"), httputil.CompressionHandler{Handler: getClusteringPeersHandler(a.alloy)})
+	r.Handle(path.Join(urlPrefix, "/rollbacks"), httputil.CompressionHandler{Handler: getRollbacksHandler(a.alloy)})
+	r.Handle(path.Join(urlPrefix, "/resources"), httputil.CompressionHandler{Handler: getResourcesHandler(a.alloy)})
 	r.Handle(path.Join(urlPrefix, "/debug/{id:.+}"), liveDebugging(a.alloy, a.CallbackManager, a.logger))
 
 	r.Handle(path.Join(urlPrefix, "/graph"), graph(a.alloy, a.CallbackManager, a.logger))
 	r.Handle(path.Join(urlPrefix, "/graph/{moduleID:.+}"), graph(a.alloy, a.CallbackManager, a.logger))
 }
 
-func getRemoteCfgHost(host service.Host) (service.Host, error) {
+// getRemoteCfgHost returns the host of the named source of remote
+// configuration, or of the default source if source is empty.
+func getRemoteCfgHost(host service.Host, source string) (service.Host, error) {
 	svc, found := host.GetService(remotecfg.ServiceName)
 	if !found {
 		return nil, fmt.Errorf("remote config service not available")
 	}
 
 	data := svc.Data().(remotecfg.Data)
-	if data.Host == nil {
+	if source != "" {
+		if _, ok := data.Sources[source]; !ok {
+			return nil, fmt.Errorf("remote config source %q not found", source)
+		}
+	}
+	remoteCfgHost := data.HostFor(source)
+	if remoteCfgHost == nil {
 		return nil, fmt.Errorf("remote config service startup in progress")
 	}
-	return data.Host, nil
+	return remoteCfgHost, nil
+}
+
+// remoteCfgSource returns the source of remote configuration a request is
+// for: either the source of the route, or the source which loaded the
+// component or module with the given ID.
+func remoteCfgSource(r *http.Request, id string) string {
+	if source := mux.Vars(r)["source"]; source != "" {
+		return source
+	}
+	return remotecfg.SourceName(id)
+}
+
+func listRemoteCfgSourcesHandler(host service.Host) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		sources := []string{}
+		if svc, found := host.GetService(remotecfg.ServiceName); found {
+			sources = slices.AppendSeq(sources, maps.Keys(svc.Data().(remotecfg.Data).Sources))
+			slices.Sort(sources)
+		}
+
+		bb, err := json.Marshal(sources)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
 }
 
 func listComponentsHandler(host service.Host) http.HandlerFunc {
@@ -81,7 +132,7 @@ func listComponentsHandler(host service.Host) http.HandlerFunc {
 
 func listComponentsHandlerRemoteCfg(host service.Host) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		remoteCfgHost, err := getRemoteCfgHost(host)
+		remoteCfgHost, err := getRemoteCfgHost(host, remoteCfgSource(r, mux.Vars(r)["moduleID"]))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -122,7 +173,7 @@ func getComponentHandler(host service.Host) http.HandlerFunc {
 
 func getComponentHandlerRemoteCfg(host service.Host) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		remoteCfgHost, err := getRemoteCfgHost(host)
+		remoteCfgHost, err := getRemoteCfgHost(host, remoteCfgSource(r, mux.Vars(r)["id"]))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -154,6 +205,22 @@ func getComponentHandlerInternal(host service.Host, w http.ResponseWriter, r *ht
 	_, _ = w.Write(bb)
 }
 
+// configDriftReporter is implemented by the cluster service, which compares
+// the configurations of the peers.
+type configDriftReporter interface {
+	ConfigDrift() cluster.DriftReport
+}
+
+// peerInfo is a peer of the cluster along with the comparison of its
+// configuration with the rest of the cluster.
+type peerInfo struct {
+	Name   string              `json:"name"`
+	Addr   string              `json:"addr"`
+	Self   bool                `json:"isSelf"`
+	State  string              `json:"state"`
+	Config *cluster.PeerConfig `json:"config,omitempty"`
+}
+
 func getClusteringPeersHandler(host service.Host) http.HandlerFunc {
 	return func(w http.ResponseWriter, _ *http.Request) {
 		// TODO(@tpaschalis) Detect if clustering is disabled and propagate to
@@ -164,7 +231,143 @@ func getClusteringPeersHandler(host service.Host) http.HandlerFunc {
 			return
 		}
 		peers := svc.Data().(cluster.Cluster).Peers()
-		bb, err := json.Marshal(peers)
+
+		var drift cluster.DriftReport
+		if dr, ok := svc.(configDriftReporter); ok {
+			drift = dr.ConfigDrift()
+		}
+		infos := make([]peerInfo, 0, len(peers))
+		for _, p := range peers {
+			info := peerInfo{
+				Name:  p.Name,
+				Addr:  p.Addr,
+				Self:  p.Self,
+				State: p.State.String(),
+			}
+			if cfg, ok := drift.Peers[p.Name]; ok {
+				info.Config = &cfg
+			}
+			infos = append(infos, info)
+		}
+		bb, err := json.Marshal(infos)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// rollbackHost is implemented by hosts which record automatic rollbacks of
+// reloads.
+type rollbackHost interface {
+	RollbackEvents() []alloy_runtime.RollbackEvent
+}
+
+func getRollbacksHandler(host service.Host) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		events := []alloy_runtime.RollbackEvent{}
+		if rh, ok := host.(rollbackHost); ok {
+			events = append(events, rh.RollbackEvents()...)
+		}
+		slices.SortStableFunc(events, func(a, b alloy_runtime.RollbackEvent) int {
+			return b.Time.Compare(a.Time)
+		})
+
+		bb, err := json.Marshal(events)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// resourcesResponse is the response of the /resources route.
+type resourcesResponse struct {
+	Enabled bool `json:"enabled"`
+
+	// SampleTime is unset if no sample was taken yet.
+	SampleTime         *time.Time          `json:"sampleTime,omitempty"`
+	CPUProfileDuration float64             `json:"cpuProfileDurationSeconds"`
+	Components         []componentResource `json:"components"`
+}
+
+// componentResource is the resource usage of a single component.
+type componentResource struct {
+	ModuleID   string  `json:"moduleID"`
+	LocalID    string  `json:"localID"`
+	CPUCores   float64 `json:"cpuCores"`
+	Goroutines int64   `json:"goroutines"`
+}
+
+func newComponentResource(u resourceusage.Usage) componentResource {
+	return componentResource{
+		ModuleID:   u.ID.ModuleID,
+		LocalID:    u.ID.LocalID,
+		CPUCores:   u.CPUCores,
+		Goroutines: u.Goroutines,
+	}
+}
+
+func getResourceUsage(host service.Host) (resourceusage.Data, error) {
+	svc, found := host.GetService(resourceusage.ServiceName)
+	if !found {
+		return nil, fmt.Errorf("resourceusage service not running")
+	}
+	return svc.Data().(resourceusage.Data), nil
+}
+
+func getResourcesHandler(host service.Host) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		data, err := getResourceUsage(host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		snapshot := data.Snapshot()
+		resp := resourcesResponse{
+			Enabled:            data.Enabled(),
+			CPUProfileDuration: snapshot.CPUProfileDuration.Seconds(),
+			Components:         make([]componentResource, 0, len(snapshot.Components)),
+		}
+		if !snapshot.Time.IsZero() {
+			resp.SampleTime = &snapshot.Time
+		}
+		for _, u := range snapshot.Components {
+			resp.Components = append(resp.Components, newComponentResource(u))
+		}
+
+		bb, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+func getComponentResourcesHandler(host service.Host) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := getResourceUsage(host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !data.Enabled() {
+			http.Error(w, "resource usage sampling is disabled", http.StatusNotFound)
+			return
+		}
+
+		requestedComponent := component.ParseID(mux.Vars(r)["id"])
+		usage, found := data.Snapshot().Component(requestedComponent)
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+
+		bb, err := json.Marshal(newComponentResource(usage))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -353,7 +556,7 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 
 func resolveServiceHost(host service.Host, id string) (service.Host, error) {
 	if strings.HasPrefix(id, "remotecfg/") {
-		remoteCfgHost, err := getRemoteCfgHost(host)
+		remoteCfgHost, err := getRemoteCfgHost(host, remotecfg.SourceName(id))
 		if err != nil {
 			return nil, err
 		}
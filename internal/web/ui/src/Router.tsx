@@ -8,6 +8,8 @@ import PageLiveDebugging from './pages/LiveDebugging';
 import PageComponentList from './pages/PageComponentList';
 import PageRemoteComponentList from './pages/PageRemoteComponentList';
 import RemoteComponentDetailPage from './pages/RemoteComponentDetailPage';
+import PageResources from './pages/Resources';
+import PageRollbacks from './pages/Rollbacks';
 
 interface Props {
   basePath: string;
@@ -21,13 +23,17 @@ const Router = ({ basePath }: Props) => {
         <Routes>
           <Route path="/" element={<PageComponentList />} />
           <Route path="/remotecfg" element={<PageRemoteComponentList />} />
+          <Route path="/remotecfg/:source" element={<PageRemoteComponentList />} />
 
           <Route path="/component/*" element={<ComponentDetailPage />} />
           <Route path="/remotecfg/component/*" element={<RemoteComponentDetailPage />} />
+          <Route path="/remotecfg/:source/component/*" element={<RemoteComponentDetailPage />} />
 
           <Route path="/graph/*" element={<Graph />} />
           <Route path="/clustering" element={<PageClusteringPeers />} />
           <Route path="/debug/*" element={<PageLiveDebugging />} />
+          <Route path="/rollbacks" element={<PageRollbacks />} />
+          <Route path="/resources" element={<PageResources />} />
         </Routes>
       </main>
     </BrowserRouter>
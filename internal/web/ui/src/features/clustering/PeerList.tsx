@@ -1,4 +1,4 @@
-import { PeerInfo } from '../clustering/types';
+import { ConfigStatus, PeerConfig, PeerInfo } from '../clustering/types';
 
 import Table from './Table';
 
@@ -8,7 +8,33 @@ interface PeerListProps {
   peers: PeerInfo[];
 }
 
-const TABLEHEADERS = ['Node Name', 'Advertised Address', 'Current State', 'Local Node'];
+const TABLEHEADERS = ['Node Name', 'Advertised Address', 'Current State', 'Local Node', 'Configuration'];
+
+const configStatusClasses = {
+  [ConfigStatus.IN_SYNC]: `${styles.configStatus} ${styles['state-ok']}`,
+  [ConfigStatus.DRIFTED]: `${styles.configStatus} ${styles['state-error']}`,
+  [ConfigStatus.UNKNOWN]: `${styles.configStatus} ${styles['state-warn']}`,
+};
+
+/**
+ * Renders the status of the configuration of a peer, along with the
+ * components which differ from the majority of the cluster.
+ */
+const PeerConfigStatus = ({ config }: { config?: PeerConfig }) => {
+  if (!config) {
+    return <span className={styles.idName}>-</span>;
+  }
+
+  return (
+    <div>
+      <span className={configStatusClasses[config.status]} title={config.hash}>
+        {config.status.replace('_', ' ')}
+      </span>
+      {config.missingComponents && <div className={styles.configDiff}>Missing: {config.missingComponents.join(', ')}</div>}
+      {config.extraComponents && <div className={styles.configDiff}>Extra: {config.extraComponents.join(', ')}</div>}
+    </div>
+  );
+};
 
 const PeerList = ({ peers }: PeerListProps) => {
   const tableStyles = { width: '130px' };
@@ -17,7 +43,7 @@ const PeerList = ({ peers }: PeerListProps) => {
    * Custom renderer for table data
    */
   const renderTableData = () => {
-    return peers.map(({ name, addr, state, isSelf }) => (
+    return peers.map(({ name, addr, state, isSelf, config }) => (
       <tr key={name} style={{ lineHeight: '2.5' }}>
         <td>
           <span className={styles.idName}>{name}</span>
@@ -31,6 +57,9 @@ const PeerList = ({ peers }: PeerListProps) => {
         <td>
           <span> {isSelf ? '✅' : ' '}</span>
         </td>
+        <td>
+          <PeerConfigStatus config={config} />
+        </td>
       </tr>
     ));
   };
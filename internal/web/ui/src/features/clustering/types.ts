@@ -6,4 +6,35 @@ export interface PeerInfo {
   state: string;
 
   isSelf: boolean;
+
+  /**
+   * Comparison of the configuration of the peer with the rest of the cluster.
+   * Omitted until the configurations of the peers have been compared.
+   */
+  config?: PeerConfig;
+}
+
+/**
+ * Status of the configuration of a peer compared to the rest of the cluster.
+ */
+export enum ConfigStatus {
+  IN_SYNC = 'in_sync',
+  DRIFTED = 'drifted',
+  UNKNOWN = 'unknown',
+}
+
+export interface PeerConfig {
+  hash?: string;
+
+  status: ConfigStatus;
+
+  /**
+   * Components run by the majority of the cluster but not by the peer.
+   */
+  missingComponents?: string[];
+
+  /**
+   * Components run by the peer but not by the majority of the cluster.
+   */
+  extraComponents?: string[];
 }
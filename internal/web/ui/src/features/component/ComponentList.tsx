@@ -1,5 +1,6 @@
 import { NavLink } from 'react-router-dom';
 
+import { remotecfgPagePath } from '../../utils/remotecfg';
 import { HealthLabel } from '../component/HealthLabel';
 import { ComponentInfo, SortOrder } from '../component/types';
 
@@ -11,6 +12,7 @@ interface ComponentListProps {
   components: ComponentInfo[];
   overrideModuleID?: string;
   useRemotecfg: boolean;
+  remotecfgSource?: string;
   handleSorting?: (sortField: string, sortOrder: SortOrder) => void;
 }
 
@@ -18,9 +20,15 @@ const TABLEHEADERS = ['Health', 'ID'];
 
 // overrideModuleID is a workaround for the remote config page because the remotecfg component has the moduleID of its controller,
 // it should not be fetched as a module.
-const ComponentList = ({ components, overrideModuleID, useRemotecfg, handleSorting }: ComponentListProps) => {
+const ComponentList = ({
+  components,
+  overrideModuleID,
+  useRemotecfg,
+  remotecfgSource = '',
+  handleSorting,
+}: ComponentListProps) => {
   const tableStyles = { width: '130px' };
-  const urlPrefix = useRemotecfg ? '/remotecfg' : '';
+  const urlPrefix = useRemotecfg ? remotecfgPagePath(remotecfgSource) : '';
   /**
    * Custom renderer for table data
    */
@@ -5,6 +5,7 @@ import { faBug, faCubes, faDiagramProject, faLink } from '@fortawesome/free-soli
 import { FontAwesomeIcon } from '@fortawesome/react-fontawesome';
 
 import { partitionBody } from '../../utils/partition';
+import { remotecfgSourceFromPath } from '../../utils/remotecfg';
 
 import ComponentBody from './ComponentBody';
 import ComponentList from './ComponentList';
@@ -30,6 +31,7 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
   const debugPartition = props.component.debugInfo && partitionBody(props.component.debugInfo, 'Debug info');
   const location = useLocation();
   const useRemotecfg = location.pathname.startsWith('/remotecfg');
+  const remotecfgSource = remotecfgSourceFromPath(location.pathname);
 
   // TODO: update this condition when foreach is supported
   const showGraph = props.component.moduleInfo && props.component.name !== 'foreach';
@@ -140,6 +142,7 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
               )}
             </h1>
             <p>{props.component.health.message}</p>
+            {!!props.component.restarts && <p>Restarted {props.component.restarts} time(s)</p>}
           </blockquote>
         )}
 
@@ -151,7 +154,7 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           <section id="dependencies">
             <h2>Dependencies</h2>
             <div className={styles.sectionContent}>
-              <ComponentList components={referencesTo} useRemotecfg={useRemotecfg} />
+              <ComponentList components={referencesTo} useRemotecfg={useRemotecfg} remotecfgSource={remotecfgSource} />
             </div>
           </section>
         )}
@@ -160,7 +163,7 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           <section id="dependants">
             <h2>Dependants</h2>
             <div className={styles.sectionContent}>
-              <ComponentList components={referencedBy} useRemotecfg={useRemotecfg} />
+              <ComponentList components={referencedBy} useRemotecfg={useRemotecfg} remotecfgSource={remotecfgSource} />
             </div>
           </section>
         )}
@@ -169,7 +172,11 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           <section id="module">
             <h2>Module components</h2>
             <div className={styles.sectionContent}>
-              <ComponentList components={props.component.moduleInfo} useRemotecfg={useRemotecfg} />
+              <ComponentList
+                components={props.component.moduleInfo}
+                useRemotecfg={useRemotecfg}
+                remotecfgSource={remotecfgSource}
+              />
             </div>
           </section>
         )}
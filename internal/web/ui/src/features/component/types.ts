@@ -33,6 +33,11 @@ export interface ComponentInfo {
    */
   health: ComponentHealth;
 
+  /**
+   * Number of times the component was restarted after exiting.
+   */
+  restarts?: number;
+
   /**
    * IDs of components which are referencing this component.
    */
@@ -28,6 +28,16 @@ function Navbar() {
             Remote Configuration
           </NavLink>
         </li>
+        <li>
+          <NavLink to="/rollbacks" className="nav-link">
+            Rollbacks
+          </NavLink>
+        </li>
+        <li>
+          <NavLink to="/resources" className="nav-link">
+            Resources
+          </NavLink>
+        </li>
         <li>
           <a href="https://grafana.com/docs/alloy/latest">Help</a>
         </li>
@@ -0,0 +1,79 @@
+import { NavLink } from 'react-router-dom';
+
+import Table from '../clustering/Table';
+
+import { ResourceReport } from './types';
+
+import styles from './ResourceList.module.css';
+
+interface ResourceListProps {
+  report: ResourceReport | undefined;
+}
+
+const TABLEHEADERS = ['Component', 'CPU (cores)', 'Goroutines'];
+
+const ResourceList = ({ report }: ResourceListProps) => {
+  const tableStyles = { width: '200px' };
+
+  if (report === undefined) {
+    return null;
+  }
+  if (!report.enabled) {
+    return (
+      <p className={styles.empty}>
+        Resource usage sampling is disabled. Enable it with the <code>resourceusage</code> block.
+      </p>
+    );
+  }
+  if (report.sampleTime === undefined) {
+    return <p className={styles.empty}>No resource usage has been sampled yet.</p>;
+  }
+
+  const cpuSampled = report.cpuProfileDurationSeconds > 0;
+
+  /**
+   * Custom renderer for table data
+   */
+  const renderTableData = () => {
+    return report.components.map(({ moduleID, localID, cpuCores, goroutines }) => {
+      const id = moduleID ? `${moduleID}/${localID}` : localID;
+      // Components of the remote configuration have their own detail page
+      // which isn't addressed by the global ID.
+      const linkable = !moduleID.startsWith('remotecfg');
+
+      return (
+        <tr key={id} style={{ lineHeight: '2.5' }}>
+          <td>
+            {linkable ? (
+              <NavLink to={'/component/' + id}>{id}</NavLink>
+            ) : (
+              <span className={styles.text}>{id}</span>
+            )}
+          </td>
+          <td>
+            <span className={styles.text}>{cpuSampled ? cpuCores.toFixed(3) : 'n/a'}</span>
+          </td>
+          <td>
+            <span className={styles.text}>{goroutines}</span>
+          </td>
+        </tr>
+      );
+    });
+  };
+
+  return (
+    <>
+      <p className={styles.summary}>
+        Sampled at {new Date(report.sampleTime).toLocaleString()}.{' '}
+        {cpuSampled
+          ? `CPU usage is averaged over a ${report.cpuProfileDurationSeconds}s CPU profile.`
+          : 'CPU usage is unavailable because another CPU profile was running.'}
+      </p>
+      <div className={styles.list}>
+        <Table tableHeaders={TABLEHEADERS} renderTableData={renderTableData} style={tableStyles} />
+      </div>
+    </>
+  );
+};
+
+export default ResourceList;
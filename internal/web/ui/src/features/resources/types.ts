@@ -0,0 +1,35 @@
+/**
+ * ResourceReport is the most recent sample of the resource usage of
+ * components.
+ */
+export interface ResourceReport {
+  /** Whether resource usage sampling is enabled by the resourceusage block. */
+  enabled: boolean;
+
+  /** Time of the sample, as an RFC 3339 string. Unset if no sample was taken yet. */
+  sampleTime?: string;
+
+  /** Duration of the CPU profile of the sample. Zero if no CPU profile could be taken. */
+  cpuProfileDurationSeconds: number;
+
+  /** Usage of the components, sorted by CPU usage, highest first. */
+  components: ComponentResources[];
+}
+
+/**
+ * ComponentResources is the resource usage of a single component, including
+ * the goroutines it started.
+ */
+export interface ComponentResources {
+  /** ID of the module the component runs in. Empty for the root module. */
+  moduleID: string;
+
+  /** ID of the component, local to its module. */
+  localID: string;
+
+  /** Average number of CPU cores used during the CPU profile. */
+  cpuCores: number;
+
+  /** Number of goroutines run by the component. */
+  goroutines: number;
+}
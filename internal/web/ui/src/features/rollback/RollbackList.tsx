@@ -0,0 +1,52 @@
+import Table from '../clustering/Table';
+
+import { RollbackEvent } from './types';
+
+import styles from './RollbackList.module.css';
+
+interface RollbackListProps {
+  events: RollbackEvent[];
+}
+
+const TABLEHEADERS = ['Time', 'Controller', 'Reason', 'Unhealthy Components', 'Revert Error'];
+
+const RollbackList = ({ events }: RollbackListProps) => {
+  const tableStyles = { width: '200px' };
+
+  /**
+   * Custom renderer for table data
+   */
+  const renderTableData = () => {
+    return events.map(({ controllerID, time, reason, components, revertError }) => (
+      <tr key={`${controllerID}/${time}`} style={{ lineHeight: '2.5' }}>
+        <td>
+          <span className={styles.text}>{new Date(time).toLocaleString()}</span>
+        </td>
+        <td>
+          <span className={styles.text}>{controllerID || 'main'}</span>
+        </td>
+        <td>
+          <span className={styles.text}>{reason}</span>
+        </td>
+        <td>
+          <span className={styles.text}>{(components ?? []).join(', ')}</span>
+        </td>
+        <td>
+          <span className={styles.text}>{revertError ?? ''}</span>
+        </td>
+      </tr>
+    ));
+  };
+
+  if (events.length === 0) {
+    return <p className={styles.empty}>No configuration reloads have been rolled back.</p>;
+  }
+
+  return (
+    <div className={styles.list}>
+      <Table tableHeaders={TABLEHEADERS} renderTableData={renderTableData} style={tableStyles} />
+    </div>
+  );
+};
+
+export default RollbackList;
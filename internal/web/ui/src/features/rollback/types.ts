@@ -0,0 +1,20 @@
+/**
+ * RollbackEvent describes a reload which was reverted to the last known-good
+ * configuration.
+ */
+export interface RollbackEvent {
+  /** ID of the controller which reverted the reload. Empty for the root controller. */
+  controllerID: string;
+
+  /** Time of the rollback, as an RFC 3339 string. */
+  time: string;
+
+  /** Why the reload was reverted. */
+  reason: string;
+
+  /** IDs of the components which became unhealthy after the reload. */
+  components: string[] | null;
+
+  /** Set if the last known-good configuration couldn't be loaded again. */
+  revertError?: string;
+}
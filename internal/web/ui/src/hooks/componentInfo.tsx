@@ -1,16 +1,20 @@
 import { useEffect, useState } from 'react';
 
 import { ComponentInfo } from '../features/component/types';
+import { remotecfgAPIPath } from '../utils/remotecfg';
 
 /**
  * useComponentInfo retrieves the list of components from the API.
  *
  * @param fromComponent The component requesting component info. Required for
  * determining the proper list of components from the context of a module.
+ * @param source The named source of remote configuration, empty for the
+ * default source.
  */
 export const useComponentInfo = (
   moduleID: string,
-  isRemotecfg: boolean
+  isRemotecfg: boolean,
+  source = ''
 ): [ComponentInfo[], React.Dispatch<React.SetStateAction<ComponentInfo[]>>] => {
   const [components, setComponents] = useState<ComponentInfo[]>([]);
 
@@ -19,8 +23,8 @@ export const useComponentInfo = (
       const worker = async () => {
         const infoPath = isRemotecfg
           ? moduleID === ''
-            ? './api/v0/web/remotecfg/components'
-            : `./api/v0/web/remotecfg/modules/${moduleID}/components`
+            ? `${remotecfgAPIPath(source)}/components`
+            : `${remotecfgAPIPath(source)}/modules/${moduleID}/components`
           : moduleID === ''
           ? './api/v0/web/components'
           : `./api/v0/web/modules/${moduleID}/components`;
@@ -35,7 +39,7 @@ export const useComponentInfo = (
 
       worker().catch(console.error);
     },
-    [moduleID, isRemotecfg]
+    [moduleID, isRemotecfg, source]
   );
 
   return [components, setComponents];
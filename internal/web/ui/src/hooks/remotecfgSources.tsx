@@ -0,0 +1,26 @@
+import { useEffect, useState } from 'react';
+
+/**
+ * useRemotecfgSources retrieves the names of the named sources of remote
+ * configuration from the API.
+ */
+export const useRemotecfgSources = (): string[] => {
+  const [sources, setSources] = useState<string[]>([]);
+
+  useEffect(function () {
+    const worker = async () => {
+      const infoPath = './api/v0/web/remotecfg/sources';
+
+      // Request is relative to the <base> tag inside of <head>.
+      const resp = await fetch(infoPath, {
+        cache: 'no-cache',
+        credentials: 'same-origin',
+      });
+      setSources(await resp.json());
+    };
+
+    worker().catch(console.error);
+  }, []);
+
+  return sources;
+};
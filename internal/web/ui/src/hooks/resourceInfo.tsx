@@ -0,0 +1,28 @@
+import { useEffect, useState } from 'react';
+
+import { ResourceReport } from '../features/resources/types';
+
+/**
+ * useResourceInfo retrieves the most recent sample of the resource usage of
+ * components from the API.
+ */
+export const useResourceInfo = (): ResourceReport | undefined => {
+  const [report, setReport] = useState<ResourceReport | undefined>(undefined);
+
+  useEffect(function () {
+    const worker = async () => {
+      const infoPath = './api/v0/web/resources';
+
+      // Request is relative to the <base> tag inside of <head>.
+      const resp = await fetch(infoPath, {
+        cache: 'no-cache',
+        credentials: 'same-origin',
+      });
+      setReport(await resp.json());
+    };
+
+    worker().catch(console.error);
+  }, []);
+
+  return report;
+};
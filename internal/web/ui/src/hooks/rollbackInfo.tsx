@@ -0,0 +1,28 @@
+import { useEffect, useState } from 'react';
+
+import { RollbackEvent } from '../features/rollback/types';
+
+/**
+ * useRollbackInfo retrieves the most recent automatic rollbacks of
+ * configuration reloads from the API, newest first.
+ */
+export const useRollbackInfo = (): RollbackEvent[] => {
+  const [events, setEvents] = useState<RollbackEvent[]>([]);
+
+  useEffect(function () {
+    const worker = async () => {
+      const infoPath = './api/v0/web/rollbacks';
+
+      // Request is relative to the <base> tag inside of <head>.
+      const resp = await fetch(infoPath, {
+        cache: 'no-cache',
+        credentials: 'same-origin',
+      });
+      setEvents(await resp.json());
+    };
+
+    worker().catch(console.error);
+  }, []);
+
+  return events;
+};
@@ -1,9 +1,12 @@
+import { NavLink, useParams } from 'react-router-dom';
 import { faCubes } from '@fortawesome/free-solid-svg-icons';
 
 import ComponentList from '../features/component/ComponentList';
 import { ComponentInfo, SortOrder } from '../features/component/types';
 import Page from '../features/layout/Page';
 import { useComponentInfo } from '../hooks/componentInfo';
+import { useRemotecfgSources } from '../hooks/remotecfgSources';
+import { remotecfgPagePath } from '../utils/remotecfg';
 
 const fieldMappings: { [key: string]: (comp: ComponentInfo) => string | undefined } = {
   Health: (comp) => comp.health?.state?.toString(),
@@ -17,7 +20,9 @@ function getSortValue(component: ComponentInfo, field: string): string | undefin
 }
 
 function PageRemoteComponentList() {
-  const [components, setComponents] = useComponentInfo('', true);
+  const { source = '' } = useParams();
+  const [components, setComponents] = useComponentInfo('', true, source);
+  const sources = useRemotecfgSources();
 
   // TODO: make this sorting logic reusable
   const handleSorting = (sortField: string, sortOrder: SortOrder): void => {
@@ -36,9 +41,34 @@ function PageRemoteComponentList() {
     setComponents(sorted);
   };
 
+  const sourceLinks = sources.length > 0 && (
+    <p>
+      Sources:{' '}
+      {['', ...sources].map((name, i) => (
+        <span key={name}>
+          {i > 0 && ', '}
+          <NavLink to={remotecfgPagePath(name)} end>
+            {name === '' ? 'default' : name}
+          </NavLink>
+        </span>
+      ))}
+    </p>
+  );
+
   return (
-    <Page name="Remote Configuration" desc="List of remote configuration pipelines" icon={faCubes}>
-      <ComponentList overrideModuleID={''} components={components} useRemotecfg={true} handleSorting={handleSorting} />
+    <Page
+      name={source === '' ? 'Remote Configuration' : `Remote Configuration: ${source}`}
+      desc="List of remote configuration pipelines"
+      icon={faCubes}
+      infoText={sourceLinks}
+    >
+      <ComponentList
+        overrideModuleID={''}
+        components={components}
+        useRemotecfg={true}
+        remotecfgSource={source}
+        handleSorting={handleSorting}
+      />
     </Page>
   );
 }
@@ -5,12 +5,13 @@ import { ComponentView } from '../features/component/ComponentView';
 import { ComponentDetail, ComponentInfo, componentInfoByID } from '../features/component/types';
 import { useComponentInfo } from '../hooks/componentInfo';
 import { parseID } from '../utils/id';
+import { remotecfgAPIPath } from '../utils/remotecfg';
 
 const RemoteComponentDetailPage: FC = () => {
-  const { '*': id } = useParams();
+  const { '*': id, source = '' } = useParams();
 
   const { moduleID } = parseID(id || '');
-  const [components] = useComponentInfo(moduleID, true);
+  const [components] = useComponentInfo(moduleID, true, source);
   const infoByID = componentInfoByID(components);
 
   const [component, setComponent] = useState<ComponentDetail | undefined>(undefined);
@@ -21,7 +22,7 @@ const RemoteComponentDetailPage: FC = () => {
         return;
       }
 
-      const fetchURL = `./api/v0/web/remotecfg/components/${id}`;
+      const fetchURL = `${remotecfgAPIPath(source)}/components/${id}`;
       const worker = async () => {
         // Request is relative to the <base> tag inside of <head>.
         const resp = await fetch(fetchURL, {
@@ -31,7 +32,7 @@ const RemoteComponentDetailPage: FC = () => {
         const data: ComponentDetail = await resp.json();
 
         for (const moduleID of data.createdModuleIDs || []) {
-          const modulesURL = `./api/v0/web/remotecfg/modules/${moduleID}/components`;
+          const modulesURL = `${remotecfgAPIPath(source)}/modules/${moduleID}/components`;
 
           const moduleComponentsResp = await fetch(modulesURL, {
             cache: 'no-cache',
@@ -47,7 +48,7 @@ const RemoteComponentDetailPage: FC = () => {
 
       worker().catch(console.error);
     },
-    [id]
+    [id, source]
   );
 
   return component ? <ComponentView component={component} info={infoByID} /> : <div></div>;
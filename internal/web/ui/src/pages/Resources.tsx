@@ -0,0 +1,17 @@
+import { faGauge } from '@fortawesome/free-solid-svg-icons';
+
+import Page from '../features/layout/Page';
+import ResourceList from '../features/resources/ResourceList';
+import { useResourceInfo } from '../hooks/resourceInfo';
+
+function PageResources() {
+  const report = useResourceInfo();
+
+  return (
+    <Page name="Resources" desc="Components using the most CPU and goroutines" icon={faGauge}>
+      <ResourceList report={report} />
+    </Page>
+  );
+}
+
+export default PageResources;
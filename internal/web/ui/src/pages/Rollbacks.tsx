@@ -0,0 +1,17 @@
+import { faRotateLeft } from '@fortawesome/free-solid-svg-icons';
+
+import Page from '../features/layout/Page';
+import RollbackList from '../features/rollback/RollbackList';
+import { useRollbackInfo } from '../hooks/rollbackInfo';
+
+function PageRollbacks() {
+  const events = useRollbackInfo();
+
+  return (
+    <Page name="Rollbacks" desc="Configuration reloads reverted to the last known-good configuration" icon={faRotateLeft}>
+      <RollbackList events={events} />
+    </Page>
+  );
+}
+
+export default PageRollbacks;
@@ -0,0 +1,24 @@
+/**
+ * remotecfgAPIPath returns the path of the API of the named source of remote
+ * configuration, or of the default source if source is empty.
+ */
+export function remotecfgAPIPath(source: string): string {
+  return source === '' ? './api/v0/web/remotecfg' : `./api/v0/web/remotecfg/sources/${source}`;
+}
+
+/**
+ * remotecfgPagePath returns the path of the pages of the named source of
+ * remote configuration, or of the default source if source is empty.
+ */
+export function remotecfgPagePath(source: string): string {
+  return source === '' ? '/remotecfg' : `/remotecfg/${source}`;
+}
+
+/**
+ * remotecfgSourceFromPath returns the source of remote configuration of a
+ * page, or an empty string for the default source.
+ */
+export function remotecfgSourceFromPath(pathname: string): string {
+  const match = pathname.match(/^\/remotecfg\/([^/]+)(\/|$)/);
+  return match && match[1] !== 'component' ? match[1] : '';
+}
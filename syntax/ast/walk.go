@@ -65,6 +65,15 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Right)
 	case *ParenExpr:
 		Walk(v, n.Inner)
+	case *ConditionalExpr:
+		Walk(v, n.Condition)
+		Walk(v, n.True)
+		Walk(v, n.False)
+	case *LambdaExpr:
+		for _, p := range n.Params {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
 	default:
 		panic(fmt.Sprintf("syntax/ast: unexpected node type %T", n))
 	}
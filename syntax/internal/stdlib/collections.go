@@ -0,0 +1,260 @@
+package stdlib
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+var mapFuncs = map[string]interface{}{
+	"keys":   mapKeys,
+	"values": mapValues,
+	"merge":  mapMerge,
+}
+
+// Inputs:
+// args[0]: []any:       array to transform
+// args[1]: func(x) any: function to apply to each element
+var arrayMap = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgs(funcValue, args, value.TypeArray, value.TypeFunction); err != nil {
+		return value.Null, err
+	}
+
+	list, fn := args[0], args[1]
+
+	res := make([]value.Value, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		val, err := fn.Call(list.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+		res[i] = val
+	}
+	return value.Array(res...), nil
+})
+
+// Inputs:
+// args[0]: []any:        array to filter
+// args[1]: func(x) bool: predicate deciding whether to keep an element
+var arrayFilter = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgs(funcValue, args, value.TypeArray, value.TypeFunction); err != nil {
+		return value.Null, err
+	}
+
+	list, fn := args[0], args[1]
+
+	res := make([]value.Value, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		keep, err := fn.Call(list.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+		if keep.Type() != value.TypeBool {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("filter function must return bool, got %s", keep.Type()),
+			}
+		}
+		if keep.Bool() {
+			res = append(res, list.Index(i))
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// Inputs:
+// args[0]: []any:            array to reduce
+// args[1]: any:              initial value of the accumulator
+// args[2]: func(acc, x) any: function combining the accumulator with an element
+var arrayReduce = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 3 {
+		return value.Null, value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("expected 3 args, got %d", len(args)),
+		}
+	}
+	if err := checkArg(funcValue, args[0], 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArg(funcValue, args[2], 2, value.TypeFunction); err != nil {
+		return value.Null, err
+	}
+
+	list, acc, fn := args[0], args[1], args[2]
+
+	for i := 0; i < list.Len(); i++ {
+		var err error
+		acc, err = fn.Call(acc, list.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+	}
+	return acc, nil
+})
+
+// arrayFlatten recursively replaces any nested arrays with their elements.
+var arrayFlatten = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgs(funcValue, args, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	var flatten func(res []value.Value, list value.Value) []value.Value
+	flatten = func(res []value.Value, list value.Value) []value.Value {
+		for i := 0; i < list.Len(); i++ {
+			if elem := list.Index(i); elem.Type() == value.TypeArray {
+				res = flatten(res, elem)
+			} else {
+				res = append(res, elem)
+			}
+		}
+		return res
+	}
+
+	return value.Array(flatten(make([]value.Value, 0, args[0].Len()), args[0])...), nil
+})
+
+// arrayDistinct removes duplicate elements from an array, keeping the first
+// occurrence of each element.
+var arrayDistinct = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgs(funcValue, args, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	list := args[0]
+
+	res := make([]value.Value, 0, list.Len())
+NextElement:
+	for i := 0; i < list.Len(); i++ {
+		elem := list.Index(i)
+		for _, seen := range res {
+			if value.ValuesEqual(seen, elem) {
+				continue NextElement
+			}
+		}
+		res = append(res, elem)
+	}
+	return value.Array(res...), nil
+})
+
+// mapKeys returns the keys of an object. Keys are sorted unless the object
+// has a consistent order.
+var mapKeys = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("expected 1 args, got %d", len(args)),
+		}
+	}
+	obj, err := objectArg(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := orderedKeys(obj)
+	res := make([]value.Value, len(keys))
+	for i, key := range keys {
+		res[i] = value.String(key)
+	}
+	return value.Array(res...), nil
+})
+
+// mapValues returns the values of an object in the same order as mapKeys
+// returns its keys.
+var mapValues = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("expected 1 args, got %d", len(args)),
+		}
+	}
+	obj, err := objectArg(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := orderedKeys(obj)
+	res := make([]value.Value, len(keys))
+	for i, key := range keys {
+		res[i], _ = obj.Key(key)
+	}
+	return value.Array(res...), nil
+})
+
+// mapMerge merges any number of objects into a new object. If a key exists in
+// multiple objects, the value from the last object is used.
+var mapMerge = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	res := make(map[string]value.Value)
+
+	for i, arg := range args {
+		obj, err := objectArg(funcValue, arg, i)
+		if err != nil {
+			return value.Null, err
+		}
+
+		for _, key := range obj.Keys() {
+			res[key], _ = obj.Key(key)
+		}
+	}
+	return value.Object(res), nil
+})
+
+// checkArgs validates that args has exactly one argument per type in types
+// and that each argument is of the corresponding type.
+func checkArgs(funcValue value.Value, args []value.Value, types ...value.Type) error {
+	if len(args) != len(types) {
+		return value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("expected %d args, got %d", len(types), len(args)),
+		}
+	}
+	for i, ty := range types {
+		if err := checkArg(funcValue, args[i], i, ty); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkArg(funcValue value.Value, arg value.Value, index int, ty value.Type) error {
+	if arg.Type() == ty {
+		return nil
+	}
+	return value.ArgError{
+		Function: funcValue,
+		Argument: arg,
+		Index:    index,
+		Inner: value.TypeError{
+			Value:    arg,
+			Expected: ty,
+		},
+	}
+}
+
+// objectArg returns arg as an object, converting capsules which can be
+// converted into objects.
+func objectArg(funcValue value.Value, arg value.Value, index int) (value.Value, error) {
+	if arg.Type() == value.TypeObject {
+		return arg, nil
+	}
+	if obj, ok := arg.TryConvertToObject(); ok {
+		return value.Object(obj), nil
+	}
+	return value.Null, value.ArgError{
+		Function: funcValue,
+		Argument: arg,
+		Index:    index,
+		Inner: value.TypeError{
+			Value:    arg,
+			Expected: value.TypeObject,
+		},
+	}
+}
+
+func orderedKeys(obj value.Value) []string {
+	keys := obj.Keys()
+	if !obj.OrderedKeys() {
+		sort.Strings(keys)
+	}
+	return keys
+}
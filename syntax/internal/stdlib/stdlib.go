@@ -62,6 +62,7 @@ var Identifiers = map[string]interface{}{
 	"encoding": encoding,
 	"string":   str,
 	"file":     file,
+	"map":      mapFuncs,
 }
 
 func init() {
@@ -71,6 +72,27 @@ func init() {
 
 var file = map[string]interface{}{
 	"path_join": filepath.Join,
+	"read":      readFile,
+	"glob":      glob,
+}
+
+func readFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func glob(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if matches == nil {
+		return []string{}, nil
+	}
+	return matches, nil
 }
 
 var encoding = map[string]interface{}{
@@ -99,6 +121,11 @@ var str = map[string]interface{}{
 var array = map[string]interface{}{
 	"concat":       concat,
 	"combine_maps": combineMaps,
+	"map":          arrayMap,
+	"filter":       arrayFilter,
+	"reduce":       arrayReduce,
+	"flatten":      arrayFlatten,
+	"distinct":     arrayDistinct,
 }
 
 var convert = map[string]interface{}{
@@ -379,9 +379,41 @@ func (n blockName) ValidAttribute() bool {
 
 // ParseExpression parses a single expression.
 //
-//	Expression = BinOpExpr
+//	Expression = ConditionalExpr
 func (p *parser) ParseExpression() ast.Expr {
-	return p.parseBinOp(1)
+	return p.parseConditionalExpr()
+}
+
+// parseConditionalExpr parses a conditional expression. If there is no
+// conditional expression in the current state, the binary expression is
+// returned instead.
+//
+//	ConditionalExpr = BinOpExpr [ "?" Expression ":" Expression ]
+//
+// Conditional expressions are right-associative, so a ? b : c ? d : e is
+// parsed as a ? b : (c ? d : e).
+func (p *parser) parseConditionalExpr() ast.Expr {
+	cond := p.parseBinOp(1)
+	if p.tok != token.QUESTION {
+		return cond
+	}
+
+	res := &ast.ConditionalExpr{Condition: cond}
+
+	res.QuestionPos, _, _ = p.expect(token.QUESTION)
+	res.True = p.ParseExpression()
+
+	if p.tok != token.COLON {
+		// Don't consume the unexpected token, which may be the terminator for
+		// the current statement.
+		p.addErrorf("expected %s, got %s", token.COLON, p.tok)
+		res.False = &ast.LiteralExpr{Kind: token.NULL, Value: "null", ValuePos: p.pos}
+		return res
+	}
+
+	res.ColonPos, _, _ = p.expect(token.COLON)
+	res.False = p.ParseExpression()
+	return res
 }
 
 // parseBinOp is the entrypoint for binary expressions. If there is no binary
@@ -569,13 +601,14 @@ func isUnaryOp(tok token.Token) bool {
 
 // parsePrimaryExpr parses a primary expression.
 //
-//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr
+//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr | LambdaExpr
 //
 //	LiteralValue = identifier | string | number | float | bool | null |
 //	               "(" Expression ")"
 //
 //	ArrayExpr  = "[" [ ExpressionList ] "]"
 //	ObjectExpr = "{" [ FieldList ] "}"
+//	LambdaExpr = "(" [ ParamList ] ")" "=>" Expression
 func (p *parser) parsePrimaryExpr() ast.Expr {
 	switch p.tok {
 	case token.IDENT:
@@ -599,8 +632,39 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 
 	case token.LPAREN:
 		lParen, _, _ := p.expect(token.LPAREN)
+
+		// A "(" may either start a parenthesized expression or the parameter
+		// list of a lambda. We don't know which one until we've seen a "," or
+		// the "=>" following the closing parenthesis.
+		if p.tok == token.RPAREN {
+			rParen, _, _ := p.expect(token.RPAREN)
+			if p.tok == token.ARROW {
+				return p.parseLambdaExpr(lParen, nil, rParen)
+			}
+
+			p.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: rParen.Position(),
+				Message:  fmt.Sprintf("expected expression, got %s", token.RPAREN),
+			})
+			return &ast.ParenExpr{
+				LParenPos: lParen,
+				Inner:     &ast.LiteralExpr{Kind: token.NULL, Value: "null", ValuePos: rParen},
+				RParenPos: rParen,
+			}
+		}
+
 		expr := p.ParseExpression()
+		if p.tok == token.COMMA {
+			params := p.parseParamList(expr)
+			rParen, _, _ := p.expect(token.RPAREN)
+			return p.parseLambdaExpr(lParen, params, rParen)
+		}
+
 		rParen, _, _ := p.expect(token.RPAREN)
+		if p.tok == token.ARROW {
+			return p.parseLambdaExpr(lParen, []*ast.Ident{p.toParam(expr)}, rParen)
+		}
 
 		return &ast.ParenExpr{
 			LParenPos: lParen,
@@ -635,6 +699,71 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 	return res
 }
 
+// parseParamList parses the remainder of a lambda parameter list, where first
+// is the already parsed first parameter and the current token is the ","
+// following it.
+//
+//	ParamList = identifier { "," identifier } [ "," ]
+func (p *parser) parseParamList(first ast.Expr) []*ast.Ident {
+	params := []*ast.Ident{p.toParam(first)}
+
+	for p.tok == token.COMMA {
+		p.next() // Consume ","
+		if p.tok == token.RPAREN {
+			break // Trailing comma
+		}
+
+		namePos, _, name := p.expect(token.IDENT)
+		params = append(params, &ast.Ident{Name: name, NamePos: namePos})
+	}
+
+	return params
+}
+
+// toParam converts an expression parsed before it was known to be part of a
+// lambda parameter list into a parameter name.
+func (p *parser) toParam(expr ast.Expr) *ast.Ident {
+	if ident, ok := expr.(*ast.IdentifierExpr); ok {
+		return ident.Ident
+	}
+
+	p.diags.Add(diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(expr).Position(),
+		EndPos:   ast.EndPos(expr).Position(),
+		Message:  "expected parameter name",
+	})
+	return &ast.Ident{NamePos: ast.StartPos(expr)}
+}
+
+// parseLambdaExpr parses the remainder of a lambda expression after its
+// parameter list.
+//
+//	LambdaExpr = "(" [ ParamList ] ")" "=>" Expression
+func (p *parser) parseLambdaExpr(lParen token.Pos, params []*ast.Ident, rParen token.Pos) ast.Expr {
+	seen := make(map[string]struct{}, len(params))
+	for _, param := range params {
+		if _, dup := seen[param.Name]; dup && param.Name != "" {
+			p.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(param).Position(),
+				EndPos:   ast.EndPos(param).Position(),
+				Message:  fmt.Sprintf("duplicate parameter name %q", param.Name),
+			})
+		}
+		seen[param.Name] = struct{}{}
+	}
+
+	res := &ast.LambdaExpr{
+		Params:    params,
+		LParenPos: lParen,
+		RParenPos: rParen,
+	}
+	res.ArrowPos, _, _ = p.expect(token.ARROW)
+	res.Body = p.ParseExpression()
+	return res
+}
+
 var statementEnd = map[token.Token]struct{}{
 	token.TERMINATOR: {},
 	token.RPAREN:     {},
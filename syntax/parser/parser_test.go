@@ -108,6 +108,20 @@ func TestParseExpressions(t *testing.T) {
 
 		"parens": `(1 + 5) * 100`,
 
+		"conditional":              `a ? 1 : 2`,
+		"conditional with binops":  `a == "b" && c ? 1 + 2 : 3 * 4`,
+		"nested conditional":       `a ? b ? 1 : 2 : c ? 3 : 4`,
+		"conditional in call":      `f(a ? 1 : 2, 3)`,
+		"conditional with objects": `a ? { x = 1 } : { x = 2 }`,
+
+		"lambda no params":        `() => 1`,
+		"lambda one param":        `(x) => x + 1`,
+		"lambda many params":      `(acc, x) => acc + x`,
+		"lambda trailing comma":   `(acc, x,) => acc + x`,
+		"lambda as argument":      `array.map(list, (x) => x * 2)`,
+		"lambda returning lambda": `(x) => (y) => x + y`,
+		"called lambda":           `((x) => x)(1)`,
+
 		"mixed expression": `(a.b.c)(1, 3 * some_list[magic_index * 2]).resulting_field`,
 	}
 
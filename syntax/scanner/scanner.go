@@ -21,36 +21,39 @@ import (
 //   line_comment  = "//" { character }
 //   block_comment = "/*" { character | newline } "*/"
 //
-//   IDENT   = letter { letter | number }
-//   NULL    = "null"
-//   BOOL    = "true" | "false"
-//   NUMBER  = digits
-//   FLOAT   = ( digits | "." digits ) [ "e" [ "+" | "-" ] digits ]
-//   STRING  = '"' { string_character | escape_sequence } '"'
-//   OR      = "||"
-//   AND     = "&&"
-//   NOT     = "!"
-//   NEQ     = "!="
-//   ASSIGN  = "="
-//   EQ      = "=="
-//   LT      = "<"
-//   LTE     = "<="
-//   GT      = ">"
-//   GTE     = ">="
-//   ADD     = "+"
-//   SUB     = "-"
-//   MUL     = "*"
-//   DIV     = "/"
-//   MOD     = "%"
-//   POW     = "^"
-//   LCURLY  = "{"
-//   RCURLY  = "}"
-//   LPAREN  = "("
-//   RPAREN  = ")"
-//   LBRACK  = "["
-//   RBRACK  = "]"
-//   COMMA   = ","
-//   DOT     = "."
+//   IDENT    = letter { letter | number }
+//   NULL     = "null"
+//   BOOL     = "true" | "false"
+//   NUMBER   = digits
+//   FLOAT    = ( digits | "." digits ) [ "e" [ "+" | "-" ] digits ]
+//   STRING   = '"' { string_character | escape_sequence } '"'
+//   OR       = "||"
+//   AND      = "&&"
+//   NOT      = "!"
+//   NEQ      = "!="
+//   ASSIGN   = "="
+//   ARROW    = "=>"
+//   EQ       = "=="
+//   LT       = "<"
+//   LTE      = "<="
+//   GT       = ">"
+//   GTE      = ">="
+//   ADD      = "+"
+//   SUB      = "-"
+//   MUL      = "*"
+//   DIV      = "/"
+//   MOD      = "%"
+//   POW      = "^"
+//   LCURLY   = "{"
+//   RCURLY   = "}"
+//   LPAREN   = "("
+//   RPAREN   = ")"
+//   LBRACK   = "["
+//   RBRACK   = "]"
+//   COMMA    = ","
+//   DOT      = "."
+//   QUESTION = "?"
+//   COLON    = ":"
 //
 // The EBNF for escape_sequence is currently undocumented; see scanEscape for
 // details. The escape sequences supported by Alloy are the same as the escape
@@ -275,8 +278,13 @@ scanAgain:
 
 		case '!': // !, !=
 			tok = s.switch2(token.NOT, token.NEQ, '=')
-		case '=': // =, ==
-			tok = s.switch2(token.ASSIGN, token.EQ, '=')
+		case '=': // =, ==, =>
+			if s.ch == '>' {
+				s.next() // consume '>'
+				tok = token.ARROW
+			} else {
+				tok = s.switch2(token.ASSIGN, token.EQ, '=')
+			}
 		case '<': // <, <=
 			tok = s.switch2(token.LT, token.LTE, '=')
 		case '>': // >, >=
@@ -338,6 +346,10 @@ scanAgain:
 		case '.':
 			// NOTE: Fractions starting with '.' are handled by outer switch
 			tok = token.DOT
+		case '?':
+			tok = token.QUESTION
+		case ':':
+			tok = token.COLON
 
 		default:
 			// s.next() reports invalid BOMs so we don't need to repeat the error.
@@ -58,6 +58,7 @@ var tokens = []tokenExample{
 	{token.LT, "<"},
 	{token.GT, ">"},
 	{token.ASSIGN, "="},
+	{token.ARROW, "=>"},
 	{token.NOT, "!"},
 
 	{token.NEQ, "!="},
@@ -69,6 +70,8 @@ var tokens = []tokenExample{
 	{token.LCURLY, "{"},
 	{token.COMMA, ","},
 	{token.DOT, "."},
+	{token.QUESTION, "?"},
+	{token.COLON, ":"},
 
 	{token.RPAREN, ")"},
 	{token.RBRACK, "]"},
@@ -34,6 +34,7 @@ const (
 	NOT // !
 
 	ASSIGN // =
+	ARROW  // =>
 
 	EQ  // ==
 	NEQ // !=
@@ -57,6 +58,9 @@ const (
 	RBRACK // ]
 	COMMA  // ,
 	DOT    // .
+
+	QUESTION // ?
+	COLON    // :
 	operatorEnd
 
 	TERMINATOR // \n
@@ -80,6 +84,7 @@ var tokenNames = [...]string{
 	NOT: "!",
 
 	ASSIGN: "=",
+	ARROW:  "=>",
 	EQ:     "==",
 	NEQ:    "!=",
 	LT:     "<",
@@ -103,6 +108,9 @@ var tokenNames = [...]string{
 	COMMA:  ",",
 	DOT:    ".",
 
+	QUESTION: "?",
+	COLON:    ":",
+
 	TERMINATOR: "TERMINATOR",
 }
 
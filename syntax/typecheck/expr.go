@@ -0,0 +1,314 @@
+package typecheck
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/internal/tagcache"
+	"github.com/grafana/alloy/syntax/internal/value"
+	"github.com/grafana/alloy/syntax/token"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+var (
+	goAny                    = reflect.TypeOf((*any)(nil)).Elem()
+	goBool                   = reflect.TypeOf(false)
+	goInt                    = reflect.TypeOf(int(0))
+	goFloat                  = reflect.TypeOf(float64(0))
+	goString                 = reflect.TypeOf("")
+	goObject                 = reflect.TypeOf(map[string]any(nil))
+	goArray                  = reflect.TypeOf([]any(nil))
+	goFunction               = reflect.TypeOf(func(...any) (any, error) { return nil, nil })
+	goUnmarshaler            = reflect.TypeOf((*value.Unmarshaler)(nil)).Elem()
+	goTextUnmarshaler        = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	goConvertibleFromCapsule = reflect.TypeOf((*value.ConvertibleFromCapsule)(nil)).Elem()
+	goConvertibleIntoCapsule = reflect.TypeOf((*value.ConvertibleIntoCapsule)(nil)).Elem()
+)
+
+// checkExpr checks that the result of expr can be decoded into a Go value of
+// type into. Arrays, objects and conditionals are checked element by element
+// so that diagnostics point at the offending expression.
+func checkExpr(scope *vm.Scope, expr ast.Expr, into reflect.Type) diag.Diagnostics {
+	into = derefType(into)
+	if into == goAny || decodesWithInterface(into) {
+		return nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return checkExpr(scope, e.Inner, into)
+
+	case *ast.ConditionalExpr:
+		var diags diag.Diagnostics
+		diags.Merge(checkExpr(scope, e.Condition, goBool))
+		diags.Merge(checkExpr(scope, e.True, into))
+		diags.Merge(checkExpr(scope, e.False, into))
+		return diags
+
+	case *ast.ArrayExpr:
+		if value.AlloyType(into) != value.TypeArray {
+			break
+		}
+		var diags diag.Diagnostics
+		for _, elem := range e.Elements {
+			diags.Merge(checkExpr(scope, elem, into.Elem()))
+		}
+		return diags
+
+	case *ast.ObjectExpr:
+		if value.AlloyType(into) != value.TypeObject {
+			break
+		}
+		var diags diag.Diagnostics
+		for _, field := range e.Fields {
+			switch into.Kind() {
+			case reflect.Map:
+				diags.Merge(checkExpr(scope, field.Value, into.Elem()))
+			case reflect.Struct:
+				if tf, ok := tagcache.Get(into).TagLookup[field.Name.Name]; ok && tf.IsAttr() {
+					diags.Merge(checkExpr(scope, field.Value, into.FieldByIndex(tf.Index).Type))
+				}
+			}
+		}
+		return diags
+	}
+
+	from := typeOf(scope, expr)
+	if assignable(from, into) {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(expr).Position(),
+		EndPos:   ast.EndPos(expr).Position(),
+		Message:  fmt.Sprintf("expected %s, got %s", describeType(into), describeType(from)),
+	}}
+}
+
+// typeOf infers the Go type of the value expr evaluates to. nil is returned
+// when the type can't be known without evaluating the expression.
+func typeOf(scope *vm.Scope, expr ast.Expr) reflect.Type {
+	switch e := expr.(type) {
+	case *ast.LiteralExpr:
+		switch e.Kind {
+		case token.NUMBER:
+			return goInt
+		case token.FLOAT:
+			return goFloat
+		case token.STRING:
+			return goString
+		case token.BOOL:
+			return goBool
+		}
+		return nil
+
+	case *ast.ArrayExpr:
+		var elem reflect.Type
+		for i, el := range e.Elements {
+			t := typeOf(scope, el)
+			if t == nil || (i > 0 && t != elem) {
+				return goArray
+			}
+			elem = t
+		}
+		if elem == nil {
+			return goArray
+		}
+		return reflect.SliceOf(elem)
+
+	case *ast.ObjectExpr:
+		return goObject
+
+	case *ast.IdentifierExpr, *ast.AccessExpr:
+		if v, ok := lookupValue(scope, e); ok {
+			if v == nil {
+				return nil
+			}
+			return reflect.TypeOf(v)
+		}
+		access, ok := e.(*ast.AccessExpr)
+		if !ok {
+			return nil
+		}
+		return fieldType(typeOf(scope, access.Value), access.Name.Name)
+
+	case *ast.IndexExpr:
+		t := derefType(typeOf(scope, e.Value))
+		if t == nil {
+			return nil
+		}
+		switch t.Kind() {
+		case reflect.Array, reflect.Slice, reflect.Map:
+			return t.Elem()
+		}
+		return nil
+
+	case *ast.UnaryExpr:
+		switch e.Kind {
+		case token.NOT:
+			return goBool
+		case token.SUB:
+			return goFloat
+		}
+		return nil
+
+	case *ast.BinaryExpr:
+		switch e.Kind {
+		case token.OR, token.AND, token.EQ, token.NEQ, token.LT, token.LTE, token.GT, token.GTE:
+			return goBool
+		case token.SUB, token.MUL, token.DIV, token.MOD, token.POW:
+			return goFloat
+		case token.ADD:
+			left, right := typeOf(scope, e.Left), typeOf(scope, e.Right)
+			if left == nil || right == nil {
+				return nil
+			}
+			switch lt, rt := value.AlloyType(left), value.AlloyType(right); {
+			case lt == value.TypeString && rt == value.TypeString:
+				return goString
+			case lt == value.TypeNumber && rt == value.TypeNumber:
+				return goFloat
+			}
+		}
+		return nil
+
+	case *ast.ParenExpr:
+		return typeOf(scope, e.Inner)
+
+	case *ast.ConditionalExpr:
+		if t := typeOf(scope, e.True); t == typeOf(scope, e.False) {
+			return t
+		}
+		return nil
+
+	case *ast.LambdaExpr:
+		return goFunction
+	}
+
+	// Function calls can return anything.
+	return nil
+}
+
+// lookupValue resolves identifiers and field accesses on objects from the
+// scope. It returns false if expr can't be fully resolved from the scope.
+func lookupValue(scope *vm.Scope, expr ast.Expr) (any, bool) {
+	switch e := expr.(type) {
+	case *ast.IdentifierExpr:
+		return scope.Lookup(e.Ident.Name)
+	case *ast.AccessExpr:
+		v, ok := lookupValue(scope, e.Value)
+		if !ok {
+			return nil, false
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		v, ok = obj[e.Name.Name]
+		return v, ok
+	}
+	return nil, false
+}
+
+// fieldType returns the type of the field name of a struct or map type.
+func fieldType(t reflect.Type, name string) reflect.Type {
+	t = derefType(t)
+	if t == nil {
+		return nil
+	}
+	switch {
+	case t.Kind() == reflect.Map && t.Key() == goString:
+		return t.Elem()
+	case t.Kind() == reflect.Struct && value.AlloyType(t) == value.TypeObject:
+		if tf, ok := tagcache.Get(t).TagLookup[name]; ok {
+			return t.FieldByIndex(tf.Index).Type
+		}
+	}
+	return nil
+}
+
+// assignable reports whether a value of type from may be decoded into a Go
+// value of type into. It mirrors the conversion rules of the decoder but
+// errs on the side of allowing the assignment when the outcome depends on
+// the value rather than its type.
+func assignable(from, into reflect.Type) bool {
+	from, into = derefType(from), derefType(into)
+	if from == nil || into == nil || from == goAny || into == goAny {
+		return true
+	}
+	if from.AssignableTo(into) || decodesWithInterface(into) || implements(from, goConvertibleIntoCapsule) {
+		return true
+	}
+
+	fromType, intoType := value.AlloyType(from), value.AlloyType(into)
+	switch {
+	case fromType == value.TypeNumber && intoType == value.TypeString,
+		fromType == value.TypeString && intoType == value.TypeNumber:
+		return true
+
+	case fromType != intoType:
+		return false
+
+	case fromType == value.TypeArray:
+		return assignable(from.Elem(), into.Elem())
+
+	case fromType == value.TypeObject:
+		if from.Kind() == reflect.Map && into.Kind() == reflect.Map {
+			return assignable(from.Elem(), into.Elem())
+		}
+		return true
+
+	case fromType == value.TypeCapsule:
+		// Capsules can be converted if the Go value stored in the capsule
+		// implements the interface of into. When from is an interface, the value
+		// might implement into even if the interfaces are unrelated, but it's
+		// most likely a misconfiguration such as passing the receiver of a
+		// logs pipeline to a metrics pipeline.
+		switch {
+		case into.Kind() == reflect.Interface:
+			return implements(from, into) || (from.Kind() == reflect.Interface && into.Implements(from))
+		case from.Kind() == reflect.Interface:
+			return implements(into, from)
+		}
+		return false
+	}
+
+	return true
+}
+
+// decodesWithInterface reports whether values decoded into t are handled by
+// one of the interfaces of the decoder, in which case the accepted types are
+// unknown.
+func decodesWithInterface(t reflect.Type) bool {
+	return implements(t, goUnmarshaler) || implements(t, goTextUnmarshaler) || implements(t, goConvertibleFromCapsule)
+}
+
+// implements reports whether t or a pointer to t implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || (t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(iface))
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func describeType(t reflect.Type) string {
+	t = derefType(t)
+	switch ty := value.AlloyType(t); ty {
+	case value.TypeCapsule:
+		return fmt.Sprintf("capsule(%q)", t.String())
+	case value.TypeArray:
+		if elem := derefType(t.Elem()); elem != goAny {
+			return fmt.Sprintf("array(%s)", describeType(elem))
+		}
+		return ty.String()
+	default:
+		return ty.String()
+	}
+}
@@ -0,0 +1,280 @@
+package typecheck
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/internal/value"
+	"github.com/grafana/alloy/syntax/parser"
+)
+
+type typeKind int
+
+const (
+	kindAny typeKind = iota
+	kindString
+	kindNumber
+	kindBool
+	kindSecret
+	kindList
+	kindMap
+	kindObject
+	kindNamed
+)
+
+var goSecret = reflect.TypeOf(alloytypes.Secret(""))
+
+// Type is a constraint on the type of Alloy values. Types are written as
+// expressions:
+//
+//   - any, string, number, bool and secret match values of the corresponding
+//     Alloy type. A secret also matches strings.
+//   - list(T) matches arrays whose elements match T.
+//   - map(T) matches objects whose fields match T.
+//   - object({NAME = T, ...}) matches objects which have every listed field,
+//     with a value matching its type. Other fields are allowed.
+//   - A named type, such as loki.LogsReceiver, matches capsule values which
+//     are of or implement the Go type it is registered with.
+//
+// Values are matched as they are: they aren't converted to the type. null
+// matches every type.
+type Type struct {
+	kind   typeKind
+	name   string           // Name of named types.
+	goType reflect.Type     // Go type of named types.
+	elem   *Type            // Element type of lists and maps.
+	fields map[string]*Type // Field types of objects.
+}
+
+// ParseType parses the type expression expr. named maps the names of the
+// named types which may be used in expr to their Go types.
+func ParseType(expr string, named map[string]reflect.Type) (*Type, error) {
+	e, err := parser.ParseExpression(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing type %q: %w", expr, err)
+	}
+	return parseType(e, named)
+}
+
+func parseType(expr ast.Expr, named map[string]reflect.Type) (*Type, error) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return parseType(e.Inner, named)
+
+	case *ast.IdentifierExpr:
+		switch e.Ident.Name {
+		case "any":
+			return &Type{kind: kindAny}, nil
+		case "string":
+			return &Type{kind: kindString}, nil
+		case "number":
+			return &Type{kind: kindNumber}, nil
+		case "bool":
+			return &Type{kind: kindBool}, nil
+		case "secret":
+			return &Type{kind: kindSecret}, nil
+		case "list":
+			return &Type{kind: kindList, elem: &Type{kind: kindAny}}, nil
+		case "map", "object":
+			return &Type{kind: kindMap, elem: &Type{kind: kindAny}}, nil
+		}
+
+	case *ast.CallExpr:
+		ident, ok := e.Value.(*ast.IdentifierExpr)
+		if !ok {
+			break
+		}
+		name := ident.Ident.Name
+		if name != "list" && name != "map" && name != "object" {
+			break
+		}
+		if len(e.Args) != 1 {
+			return nil, fmt.Errorf("%s type expects 1 argument, got %d", name, len(e.Args))
+		}
+
+		if name == "object" {
+			obj, ok := e.Args[0].(*ast.ObjectExpr)
+			if !ok {
+				return nil, fmt.Errorf("object type expects an object of field types")
+			}
+			t := &Type{kind: kindObject, fields: make(map[string]*Type, len(obj.Fields))}
+			for _, field := range obj.Fields {
+				ft, err := parseType(field.Value, named)
+				if err != nil {
+					return nil, fmt.Errorf("field %q: %w", field.Name.Name, err)
+				}
+				t.fields[field.Name.Name] = ft
+			}
+			return t, nil
+		}
+
+		elem, err := parseType(e.Args[0], named)
+		if err != nil {
+			return nil, err
+		}
+		if name == "map" {
+			return &Type{kind: kindMap, elem: elem}, nil
+		}
+		return &Type{kind: kindList, elem: elem}, nil
+	}
+
+	name, ok := typeName(expr)
+	if !ok {
+		return nil, fmt.Errorf("invalid type expression")
+	}
+	if goType, ok := named[name]; ok {
+		return &Type{kind: kindNamed, name: name, goType: goType}, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", name)
+}
+
+// typeName returns the name of the named type referred to by expr, such as
+// loki.LogsReceiver.
+func typeName(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.IdentifierExpr:
+		return e.Ident.Name, true
+	case *ast.AccessExpr:
+		if name, ok := typeName(e.Value); ok {
+			return name + "." + e.Name.Name, true
+		}
+	}
+	return "", false
+}
+
+// String returns the type expression of t.
+func (t *Type) String() string {
+	switch t.kind {
+	case kindString:
+		return "string"
+	case kindNumber:
+		return "number"
+	case kindBool:
+		return "bool"
+	case kindSecret:
+		return "secret"
+	case kindList:
+		return fmt.Sprintf("list(%s)", t.elem)
+	case kindMap:
+		return fmt.Sprintf("map(%s)", t.elem)
+	case kindObject:
+		var fields []string
+		for _, name := range sortedKeys(t.fields) {
+			fields = append(fields, fmt.Sprintf("%s = %s", name, t.fields[name]))
+		}
+		return fmt.Sprintf("object({%s})", strings.Join(fields, ", "))
+	case kindNamed:
+		return t.name
+	default:
+		return "any"
+	}
+}
+
+// Check returns an error if v doesn't match t.
+func (t *Type) Check(v any) error {
+	return t.check(value.Encode(v))
+}
+
+func (t *Type) check(val value.Value) error {
+	if val.Type() == value.TypeNull {
+		return nil
+	}
+
+	switch t.kind {
+	case kindAny:
+		return nil
+
+	case kindString:
+		if val.Type() == value.TypeString {
+			return nil
+		}
+	case kindNumber:
+		if val.Type() == value.TypeNumber {
+			return nil
+		}
+	case kindBool:
+		if val.Type() == value.TypeBool {
+			return nil
+		}
+	case kindSecret:
+		if val.Type() == value.TypeString || (val.Type() == value.TypeCapsule && val.Reflect().Type() == goSecret) {
+			return nil
+		}
+
+	case kindList:
+		if val.Type() != value.TypeArray {
+			break
+		}
+		for i := range val.Len() {
+			if err := t.elem.check(val.Index(i)); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		return nil
+
+	case kindMap:
+		obj, ok := objectFields(val)
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(obj) {
+			if err := t.elem.check(obj[key]); err != nil {
+				return fmt.Errorf("key %q: %w", key, err)
+			}
+		}
+		return nil
+
+	case kindObject:
+		obj, ok := objectFields(val)
+		if !ok {
+			break
+		}
+		for _, name := range sortedKeys(t.fields) {
+			field, ok := obj[name]
+			if !ok {
+				return fmt.Errorf("missing field %q", name)
+			}
+			if err := t.fields[name].check(field); err != nil {
+				return fmt.Errorf("field %q: %w", name, err)
+			}
+		}
+		return nil
+
+	case kindNamed:
+		if val.Type() != value.TypeCapsule {
+			break
+		}
+		rt := val.Reflect().Type()
+		if rt == t.goType || (t.goType.Kind() == reflect.Interface && rt.Implements(t.goType)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected %s, got %s", t, val.Describe())
+}
+
+// objectFields returns the fields of object values and of capsules which can
+// be converted into objects.
+func objectFields(val value.Value) (map[string]value.Value, bool) {
+	if val.Type() == value.TypeObject {
+		fields := make(map[string]value.Value, val.Len())
+		for _, key := range val.Keys() {
+			fields[key], _ = val.Key(key)
+		}
+		return fields, true
+	}
+	return val.TryConvertToObject()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
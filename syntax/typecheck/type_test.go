@@ -0,0 +1,96 @@
+package typecheck
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+type receiver interface{ Receive() }
+
+type testReceiver struct{}
+
+func (testReceiver) Receive()      {}
+func (testReceiver) AlloyCapsule() {}
+
+var namedTypes = map[string]reflect.Type{
+	"test.Receiver": reflect.TypeOf((*receiver)(nil)).Elem(),
+}
+
+func TestParseType(t *testing.T) {
+	tt := []struct {
+		expr     string
+		expected string
+		err      string
+	}{
+		{expr: "string", expected: "string"},
+		{expr: "list", expected: "list(any)"},
+		{expr: "object", expected: "map(any)"},
+		{expr: "list(map(number))", expected: "list(map(number))"},
+		{expr: "list(test.Receiver)", expected: "list(test.Receiver)"},
+		{expr: "object({name = string, labels = map(string)})", expected: "object({labels = map(string), name = string})"},
+		{expr: "strin", err: `unknown type "strin"`},
+		{expr: "loki.LogsReceiver", err: `unknown type "loki.LogsReceiver"`},
+		{expr: "list(string, number)", err: "list type expects 1 argument, got 2"},
+		{expr: "object(string)", err: "object type expects an object of field types"},
+		{expr: `object({a = "string"})`, err: `field "a": invalid type expression`},
+		{expr: "list(", err: `parsing type "list("`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.expr, func(t *testing.T) {
+			ty, err := ParseType(tc.expr, namedTypes)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ty.String())
+		})
+	}
+}
+
+func TestTypeCheck(t *testing.T) {
+	tt := []struct {
+		expr  string
+		value any
+		err   string
+	}{
+		{expr: "string", value: "foo"},
+		{expr: "string", value: nil},
+		{expr: "string", value: 1, err: "expected string, got number"},
+		{expr: "number", value: "1", err: "expected number, got string"},
+		{expr: "bool", value: true},
+		{expr: "secret", value: "foo"},
+		{expr: "secret", value: alloytypes.Secret("foo")},
+		{expr: "string", value: alloytypes.Secret("foo"), err: `expected string, got capsule("alloytypes.Secret")`},
+		{expr: "list(number)", value: []any{1, 2.5}},
+		{expr: "list(number)", value: []any{1, "2"}, err: "index 1: expected number, got string"},
+		{expr: "map(string)", value: map[string]any{"a": "b"}},
+		{expr: "map(string)", value: map[string]any{"a": false}, err: `key "a": expected string, got bool`},
+		{expr: "object({a = number})", value: map[string]any{"a": 1, "b": "other"}},
+		{expr: "object({a = number})", value: map[string]any{"b": 1}, err: `missing field "a"`},
+		{expr: "object({a = list(string)})", value: map[string]any{"a": []any{1}}, err: `field "a": index 0: expected string, got number`},
+		{expr: "list(test.Receiver)", value: []any{testReceiver{}}},
+		{expr: "test.Receiver", value: "foo", err: "expected test.Receiver, got string"},
+		{expr: "any", value: testReceiver{}},
+	}
+
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%s/%v", tc.expr, tc.value), func(t *testing.T) {
+			ty, err := ParseType(tc.expr, namedTypes)
+			require.NoError(t, err)
+
+			err = ty.Check(tc.value)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -9,23 +9,42 @@ import (
 	"github.com/grafana/alloy/syntax/diag"
 	"github.com/grafana/alloy/syntax/internal/reflectutil"
 	"github.com/grafana/alloy/syntax/internal/tagcache"
+	"github.com/grafana/alloy/syntax/vm"
 )
 
 type state struct {
+	scope      *vm.Scope
 	tags       *tagcache.TagInfo
 	seenAttrs  map[string]struct{}
 	blockCount map[string]int
 }
 
+// Block checks that b can be decoded into args. Besides checking the
+// presence of blocks and attributes, the types of attribute values are
+// inferred and checked against the Go types of args. Identifiers are only
+// resolved from the standard library.
 func Block(b *ast.BlockStmt, args any) diag.Diagnostics {
+	return BlockWithScope(b, args, nil)
+}
+
+// BlockWithScope is like Block but also resolves identifiers from scope when
+// inferring the types of attribute values. Only the types of the Go values
+// in scope are used, so scope may be filled with zero values, such as the
+// zero values of the exports of components.
+//
+// Expressions which can't be typed without being evaluated, such as
+// function calls or references to identifiers missing from scope, are not
+// reported.
+func BlockWithScope(b *ast.BlockStmt, args any, scope *vm.Scope) diag.Diagnostics {
 	rv := reflectutil.DeferencePointer(reflect.ValueOf(args))
-	return block(b, rv)
+	return block(scope, b, rv)
 }
 
-func block(b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
+func block(scope *vm.Scope, b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	s := state{
+		scope:      scope,
 		tags:       tagcache.Get(rv.Type()),
 		seenAttrs:  make(map[string]struct{}),
 		blockCount: make(map[string]int),
@@ -38,13 +57,6 @@ func block(b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 		}
 	}
 
-	// FIXME(kallep): When we start to check that correct types are set for properties we most likely need to
-	// consider these interfaces.
-	// - value.Defaulter
-	// - value.Unmarshaler
-	// - value.ConvertibleFromCapsule
-	// - value.ConvertibleIntoCapsule
-	// - encoding.TextUnmarshaler
 	for _, stmt := range b.Body {
 		switch n := stmt.(type) {
 		case *ast.BlockStmt:
@@ -119,7 +131,7 @@ func checkBlock(s *state, b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 	case reflect.Slice:
 		// NOTE: we do not need to store any values so we can always set len and cap to 1 and reuse the same slot
 		field.Set(reflect.MakeSlice(field.Type(), 1, 1))
-		return block(b, reflectutil.DeferencePointer(field.Index(0)))
+		return block(s.scope, b, reflectutil.DeferencePointer(field.Index(0)))
 	case reflect.Array:
 		if field.Len() != s.blockCount[name] {
 			return diag.Diagnostics{{
@@ -135,7 +147,7 @@ func checkBlock(s *state, b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 			}}
 		}
 
-		return block(b, reflectutil.DeferencePointer(field.Index(0)))
+		return block(s.scope, b, reflectutil.DeferencePointer(field.Index(0)))
 	default:
 		if s.blockCount[name] > 1 {
 			return diag.Diagnostics{{
@@ -145,7 +157,7 @@ func checkBlock(s *state, b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 				Message:  fmt.Sprintf("block %q may only be specified once", name),
 			}}
 		}
-		return block(b, reflectutil.DeferencePointer(field))
+		return block(s.scope, b, reflectutil.DeferencePointer(field))
 	}
 }
 
@@ -164,10 +176,10 @@ func checkEnum(s *state, b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 
 	elem := reflectutil.DeferencePointer(field.Index(0))
 
-	return block(b, reflectutil.DeferencePointer(reflectutil.GetOrAlloc(elem, tf.BlockField)))
+	return block(s.scope, b, reflectutil.DeferencePointer(reflectutil.GetOrAlloc(elem, tf.BlockField)))
 }
 
-func checkAttr(s *state, a *ast.AttributeStmt, _ reflect.Value) diag.Diagnostics {
+func checkAttr(s *state, a *ast.AttributeStmt, rv reflect.Value) diag.Diagnostics {
 	tf, ok := s.tags.TagLookup[a.Name.Name]
 	if !ok {
 		return diag.Diagnostics{{
@@ -195,5 +207,5 @@ func checkAttr(s *state, a *ast.AttributeStmt, _ reflect.Value) diag.Diagnostics
 	}
 
 	s.seenAttrs[a.Name.Name] = struct{}{}
-	return nil
+	return checkExpr(s.scope, a.Value, reflectutil.GetOrAlloc(rv, tf).Type())
 }
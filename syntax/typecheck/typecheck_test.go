@@ -2,9 +2,11 @@ package typecheck
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
 	"github.com/stretchr/testify/require"
 )
 
@@ -234,7 +236,7 @@ func TestBlock(t *testing.T) {
 					}
 	
 					enum.block2 {
-						arg3 = "test"
+						arg3 = true
 					}
 				}
 			`),
@@ -303,3 +305,151 @@ func TestBlock(t *testing.T) {
 		})
 	}
 }
+
+type Appender interface{ Append(string) }
+
+type Receiver interface{ Receive() chan string }
+
+type TypedArgs struct {
+	Name      string            `alloy:"name,attr,optional"`
+	Count     int               `alloy:"count,attr,optional"`
+	Enabled   bool              `alloy:"enabled,attr,optional"`
+	Timeout   time.Duration     `alloy:"timeout,attr,optional"`
+	Labels    map[string]string `alloy:"labels,attr,optional"`
+	Targets   []string          `alloy:"targets,attr,optional"`
+	ForwardTo []Appender        `alloy:"forward_to,attr,optional"`
+	Anything  any               `alloy:"anything,attr,optional"`
+}
+
+type appenderExports struct {
+	Receiver Appender `alloy:"receiver,attr"`
+}
+
+type receiverExports struct {
+	Receiver Receiver `alloy:"receiver,attr"`
+	Names    []string `alloy:"names,attr"`
+}
+
+func TestBlockTypes(t *testing.T) {
+	scope := vm.NewScope(map[string]any{
+		"metrics": map[string]any{
+			"default": appenderExports{},
+		},
+		"logs": map[string]any{
+			"default": receiverExports{},
+		},
+	})
+
+	tests := []struct {
+		desc        string
+		src         string
+		expectedErr string
+	}{
+		{
+			desc: "literals ok",
+			src: `
+				test {
+					name     = "test"
+					count    = 5
+					enabled  = true
+					timeout  = "5s"
+					labels   = { "a" = "b" }
+					targets  = ["a", "b"]
+					anything = [1, "a", {}]
+				}
+			`,
+		},
+		{
+			desc: "number and string conversions ok",
+			src: `
+				test {
+					name  = 5
+					count = "5"
+				}
+			`,
+		},
+		{
+			desc:        "bool into string",
+			src:         `test { name = true }`,
+			expectedErr: `1:15: expected string, got bool`,
+		},
+		{
+			desc:        "object into number",
+			src:         `test { count = {} }`,
+			expectedErr: `1:16: expected number, got object`,
+		},
+		{
+			desc:        "wrong element type",
+			src:         `test { targets = ["a", false] }`,
+			expectedErr: `1:24: expected string, got bool`,
+		},
+		{
+			desc:        "wrong object value type",
+			src:         `test { labels = { "a" = [] } }`,
+			expectedErr: `1:25: expected string, got array`,
+		},
+		{
+			desc: "expressions ok",
+			src: `
+				test {
+					name    = "a" + "b"
+					count   = (1 + 2) * 3
+					enabled = !(1 > 2) && true
+					targets = logs.default.names
+				}
+			`,
+		},
+		{
+			desc:        "wrong conditional branch",
+			src:         `test { name = 1 > 2 ? "a" : [] }`,
+			expectedErr: `1:29: expected string, got array`,
+		},
+		{
+			desc:        "wrong conditional condition",
+			src:         `test { name = "true" ? "a" : "b" }`,
+			expectedErr: `1:15: expected bool, got string`,
+		},
+		{
+			desc:        "lambda into string",
+			src:         `test { name = (x) => x }`,
+			expectedErr: `1:15: expected string, got function`,
+		},
+		{
+			desc: "matching capsule",
+			src:  `test { forward_to = [metrics.default.receiver] }`,
+		},
+		{
+			desc:        "mismatched capsule",
+			src:         `test { forward_to = [metrics.default.receiver, logs.default.receiver] }`,
+			expectedErr: `1:48: expected capsule("typecheck.Appender"), got capsule("typecheck.Receiver")`,
+		},
+		{
+			desc:        "mismatched export",
+			src:         `test { forward_to = logs.default.names }`,
+			expectedErr: `1:21: expected array(capsule("typecheck.Appender")), got array(string)`,
+		},
+		{
+			desc: "unknown types are not reported",
+			src: `
+				test {
+					name       = unknown.component.value
+					count      = string.format("%d", 1)
+					forward_to = [logs.default.missing]
+				}
+			`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			file, err := parser.ParseFile("", []byte(tt.src))
+			require.NoError(t, err)
+			diag := BlockWithScope(file.Body[0].(*ast.BlockStmt), &TypedArgs{}, scope)
+			if tt.expectedErr == "" {
+				require.Len(t, diag, 0)
+			} else {
+				require.EqualError(t, diag, tt.expectedErr)
+			}
+		})
+	}
+}
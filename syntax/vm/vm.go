@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync/atomic"
 
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
@@ -24,6 +25,12 @@ type Evaluator struct {
 	// optimizations, allowing for precomputing and storing the result of
 	// anything that is constant.
 	node ast.Node
+
+	// callDepth is the number of calls in progress to the lambdas declared by
+	// node. Every infinite recursion repeatedly calls a lambda declared by one
+	// of a finite set of Evaluators, so limiting the depth per Evaluator
+	// bounds the recursion without sharing a budget with unrelated nodes.
+	callDepth atomic.Int64
 }
 
 // New creates a new Evaluator for the given AST node. The given node must be
@@ -462,6 +469,22 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 	case *ast.ParenExpr:
 		return vm.evaluateExpr(scope, assoc, expr.Inner)
 
+	case *ast.ConditionalExpr:
+		cond, err := vm.evaluateExpr(scope, assoc, expr.Condition)
+		if err != nil {
+			return value.Null, err
+		}
+		if cond.Type() != value.TypeBool {
+			return value.Null, value.TypeError{Value: cond, Expected: value.TypeBool}
+		}
+
+		// Only the selected branch is evaluated, so the other branch may
+		// reference values which don't exist or would otherwise fail.
+		if cond.Bool() {
+			return vm.evaluateExpr(scope, assoc, expr.True)
+		}
+		return vm.evaluateExpr(scope, assoc, expr.False)
+
 	case *ast.UnaryExpr:
 		val, err := vm.evaluateExpr(scope, assoc, expr.Value)
 		if err != nil {
@@ -469,6 +492,9 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 		}
 		return evalUnaryOp(expr.Kind, val)
 
+	case *ast.LambdaExpr:
+		return vm.evaluateLambda(scope, expr), nil
+
 	case *ast.CallExpr:
 		funcVal, err := vm.evaluateExpr(scope, assoc, expr.Value)
 		if err != nil {
@@ -492,6 +518,49 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 	}
 }
 
+// maxCallDepth is the maximum number of nested calls to the lambdas declared
+// by an Evaluator. Lambdas may call themselves through their arguments, so
+// without a limit a valid expression such as ((f) => f(f))((f) => f(f)) would
+// overflow the Go stack, which can't be recovered from.
+const maxCallDepth = 1000
+
+// evaluateLambda creates a function value from a lambda expression. The
+// function captures scope, so the body of the lambda may refer to any
+// variable which was available where the lambda was declared.
+func (vm *Evaluator) evaluateLambda(scope *Scope, expr *ast.LambdaExpr) value.Value {
+	return value.Func(value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+		if len(args) != len(expr.Params) {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("expected %d args, got %d", len(expr.Params), len(args)),
+			}
+		}
+
+		defer vm.callDepth.Add(-1)
+		if vm.callDepth.Add(1) > maxCallDepth {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("maximum function call depth of %d exceeded", maxCallDepth),
+			}
+		}
+
+		vars := make(map[string]interface{}, len(args))
+		for i, param := range expr.Params {
+			vars[param.Name] = args[i]
+		}
+
+		// The lambda may be called after the evaluation which declared it has
+		// completed, so errors from the body are decorated here using their own
+		// set of associated nodes.
+		assoc := make(map[value.Value]ast.Node)
+		res, err := vm.evaluateExpr(scope.child(vars), assoc, expr.Body)
+		if err != nil {
+			return value.Null, makeDiagnostic(err, assoc)
+		}
+		return res, nil
+	}))
+}
+
 // A Scope exposes a set of variables available to use during evaluation.
 type Scope struct {
 	// Variables holds the list of available variable names that can be used when
@@ -501,6 +570,10 @@ type Scope struct {
 	// Evaluate; maps and slices will be copied by reference for performance
 	// optimizations.
 	Variables map[string]interface{}
+
+	// parent is the enclosing scope, if any. Variables in the parent are
+	// visible unless shadowed by Variables.
+	parent *Scope
 }
 
 func NewScope(variables map[string]interface{}) *Scope {
@@ -509,10 +582,19 @@ func NewScope(variables map[string]interface{}) *Scope {
 	}
 }
 
+// child returns a new Scope with the given variables which falls back to s
+// for any variable it doesn't define.
+func (s *Scope) child(variables map[string]interface{}) *Scope {
+	return &Scope{
+		Variables: variables,
+		parent:    s,
+	}
+}
+
 // Lookup looks up a named identifier from the scope and the stdlib.
 func (s *Scope) Lookup(name string) (interface{}, bool) {
-	// Check the scope first.
-	if s != nil {
+	// Check the scope and its parents first.
+	for ; s != nil; s = s.parent {
 		if val, ok := s.Variables[name]; ok {
 			return val, true
 		}
@@ -2,6 +2,8 @@ package vm_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -357,6 +359,11 @@ func TestStdlib_StringFunc(t *testing.T) {
 }
 
 func TestStdlibFileFunc(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("content of a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("content of b"), 0644))
+	scope := vm.NewScope(map[string]interface{}{"dir": dir})
+
 	tt := []struct {
 		name   string
 		input  string
@@ -364,6 +371,65 @@ func TestStdlibFileFunc(t *testing.T) {
 	}{
 		{"file.path_join", `file.path_join("this/is", "a/path")`, "this/is/a/path"},
 		{"file.path_join empty", `file.path_join()`, ""},
+		{"file.read", `file.read(file.path_join(dir, "a.txt"))`, "content of a"},
+		{"file.glob", `file.glob(file.path_join(dir, "*.txt"))`, []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}},
+		{"file.glob no match", `file.glob(file.path_join(dir, "*.yaml"))`, []string{}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlibFileRead_Missing(t *testing.T) {
+	expr, err := parser.ParseExpression(`file.read(path)`)
+	require.NoError(t, err)
+
+	scope := vm.NewScope(map[string]interface{}{"path": filepath.Join(t.TempDir(), "missing.txt")})
+	var actual string
+	require.ErrorContains(t, vm.New(expr).Evaluate(scope, &actual), "no such file or directory")
+}
+
+func TestStdlibCollectionFuncs(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"array.map", `array.map([1, 2, 3], (x) => x * 2)`, []int{2, 4, 6}},
+		{"array.map empty", `array.map([], (x) => x * 2)`, []int{}},
+		{"array.map stdlib func", `array.map(["a", "b"], string.to_upper)`, []string{"A", "B"}},
+		{
+			"array.map objects",
+			`array.map([{ "__address__" = "a:80" }], (t) => map.merge(t, { "env" = "prod" }))`,
+			[]map[string]string{{"__address__": "a:80", "env": "prod"}},
+		},
+		{"array.filter", `array.filter([1, 2, 3, 4], (x) => x % 2 == 0)`, []int{2, 4}},
+		{
+			"array.filter objects",
+			`array.filter([{ "addr" = "a" }, { "addr" = "" }], (t) => t.addr != "")`,
+			[]map[string]string{{"addr": "a"}},
+		},
+		{"array.reduce", `array.reduce([1, 2, 3, 4], 0, (acc, x) => acc + x)`, 10},
+		{"array.reduce empty", `array.reduce([], "init", (acc, x) => acc + x)`, "init"},
+		{"array.flatten", `array.flatten([1, [2, [3, [4]]], [], 5])`, []int{1, 2, 3, 4, 5}},
+		{"array.distinct", `array.distinct([1, 2, 1, 3, 2])`, []int{1, 2, 3}},
+		{"array.distinct objects", `array.distinct([{ a = 1 }, { a = 1 }, { a = 2 }])`, []map[string]int{{"a": 1}, {"a": 2}}},
+		{"map.keys", `map.keys({ b = 1, a = 2, c = 3 })`, []string{"a", "b", "c"}},
+		{"map.values", `map.values({ b = 1, a = 2, c = 3 })`, []int{2, 1, 3}},
+		{"map.merge", `map.merge({ a = 1, b = 1 }, { b = 2 }, { c = 3 })`, map[string]int{"a": 1, "b": 2, "c": 3}},
+		{"map.merge empty", `map.merge()`, map[string]int{}},
+		{"captured variable", `array.map([1, 2], (x) => array.map([10, 20], (y) => x + y))`, [][]int{{11, 21}, {12, 22}}},
+		{"shadowed variable", `array.map([1, 2], (x) => array.map([3], (x) => x))`, [][]int{{3}, {3}}},
 	}
 
 	for _, tc := range tt {
@@ -380,6 +446,38 @@ func TestStdlibFileFunc(t *testing.T) {
 	}
 }
 
+func TestStdlibCollectionFuncs_Errors(t *testing.T) {
+	tt := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{"array.map not array", `array.map("a", (x) => x)`, `"a" should be array, got string`},
+		{"array.map not function", `array.map([1], 5)`, `5 should be function, got number`},
+		{"array.map wrong arity", `array.map([1], (a, b) => a)`, `expected 2 args, got 1`},
+		{"array.map body error", `array.map([1], (x) => x.field)`, `1:23: x cannot access field "field" on value of type number`},
+		{"array.filter non-bool", `array.filter([1], (x) => x)`, `filter function must return bool, got number`},
+		{"array.reduce args", `array.reduce([1], (acc, x) => acc)`, `expected 3 args, got 2`},
+		{"unbounded recursion", `((f) => f(f))((f) => f(f))`, `maximum function call depth of 1000 exceeded`},
+		{"unbounded recursion through stdlib", `((f) => f(f))((f) => array.map([f], (g) => g(g)))`, `maximum function call depth of 1000 exceeded`},
+		{"map.keys not object", `map.keys([1])`, `[1] should be object, got array`},
+		{"map.merge not object", `map.merge({}, 1)`, `1 should be object, got number`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var v interface{}
+			err = eval.Evaluate(nil, &v)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
 func BenchmarkConcat(b *testing.B) {
 	// There's a bit of setup work to do here: we want to create a scope holding
 	// a slice of the Person type, which has a fair amount of data in it.
@@ -429,3 +527,21 @@ func BenchmarkConcat(b *testing.B) {
 		_ = eval.Evaluate(scope, &b)
 	}
 }
+
+func TestStdlibCallDepthPerEvaluator(t *testing.T) {
+	// countdown recurses n times before returning 0.
+	countdown := `((f, n) => n == 0 ? 0 : f(f, n - 1))((f, n) => n == 0 ? 0 : f(f, n - 1), %d)`
+
+	inner, err := parser.ParseExpression(fmt.Sprintf(`(n) => %s`, fmt.Sprintf(countdown, 900)))
+	require.NoError(t, err)
+	var deep interface{}
+	require.NoError(t, vm.New(inner).Evaluate(nil, &deep))
+
+	// The lambdas of each Evaluator are nested 900 times, which together
+	// exceeds the limit without exceeding it for either Evaluator.
+	outer, err := parser.ParseExpression(`((f, n) => n == 0 ? deep(0) : f(f, n - 1))((f, n) => n == 0 ? deep(0) : f(f, n - 1), 900)`)
+	require.NoError(t, err)
+	var v int
+	require.NoError(t, vm.New(outer).Evaluate(vm.NewScope(map[string]interface{}{"deep": deep}), &v))
+	require.Equal(t, 0, v)
+}
@@ -137,6 +137,15 @@ func TestVM_Evaluate(t *testing.T) {
 		{`!true`, bool(false)},
 		{`!false`, bool(true)},
 		{`-15`, int(-15)},
+
+		// Conditional
+		{`true ? 1 : 2`, int(1)},
+		{`false ? 1 : 2`, int(2)},
+		{`foobar == 42 ? "yes" : "no"`, string("yes")},
+		{`false ? 1 : true ? 2 : 3`, int(2)},
+		{`(true ? { a = 1 } : { a = 2 }).a`, int(1)},
+		{`true ? 5 : does_not_exist`, int(5)},
+		{`false ? does_not_exist : 5`, int(5)},
 	}
 
 	for _, tc := range tt {